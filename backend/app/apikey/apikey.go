@@ -0,0 +1,16 @@
+package apikey
+
+import (
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+)
+
+func InitAPIKeyRepository(db pkgDB.DatabaseTransaction) domain.APIKeyRepository {
+	return NewAPIKeyRepository(db)
+}
+func InitAPIKeyUsecase(dbTx pkgDB.DatabaseTransaction, repo domain.APIKeyRepository, audit domain.AuditUsecase) domain.APIKeyUsecase {
+	return NewAPIKeyUsecase(dbTx, repo, audit)
+}
+func InitAPIKeyHandler(usecase domain.APIKeyUsecase) domain.APIKeyHandler {
+	return NewAPIKeyHandler(usecase)
+}
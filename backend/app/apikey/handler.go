@@ -0,0 +1,107 @@
+package apikey
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	handler struct {
+		usecase domain.APIKeyUsecase
+	}
+)
+
+// ListAPIKeys implements domain.APIKeyHandler.
+func (h *handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	data, err := h.usecase.ListAPIKeys(r.Context())
+	if err != nil {
+		logrus.Errorf("error listing api keys: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: "Error listing api keys",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "API keys retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// CreateAPIKey implements domain.APIKeyHandler.
+func (h *handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	dataBuffer := domain.CreateAPIKeyRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&dataBuffer); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.CreateAPIKey(r.Context(), dataBuffer)
+	if err != nil {
+		utils.RespondError(w, "error creating api key", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusCreated,
+		Message: "API key created successfully, store it now as it will not be shown again",
+		Data:    data,
+	}, w)
+}
+
+// RevokeAPIKey implements domain.APIKeyHandler.
+func (h *handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	if err := h.usecase.RevokeAPIKey(r.Context(), id); err != nil {
+		logrus.Errorf("error revoking api key: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: "Error revoking api key",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "API key revoked successfully",
+		Data:    nil,
+	}, w)
+}
+
+var (
+	handlr *handler
+)
+
+func NewAPIKeyHandler(usecase domain.APIKeyUsecase) domain.APIKeyHandler {
+	if handlr == nil {
+		handlr = &handler{
+			usecase,
+		}
+	}
+	return handlr
+}
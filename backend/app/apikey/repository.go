@@ -0,0 +1,87 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/sirupsen/logrus"
+)
+
+// touchInterval bounds how often last_used_at is written for a busy key.
+const touchInterval = time.Minute
+
+type (
+	repository struct {
+		db pkgDB.DatabaseTransaction
+	}
+)
+
+// ListAPIKeys implements domain.APIKeyRepository.
+func (r *repository) ListAPIKeys(ctx context.Context, userId int) ([]domain.APIKey, error) {
+	data := []domain.APIKey{}
+	err := r.db.DB(ctx).Where("user_id = ?", userId).Order("id desc").Find(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetAPIKey implements domain.APIKeyRepository.
+func (r *repository) GetAPIKey(ctx context.Context, id int) (domain.APIKey, error) {
+	data := domain.APIKey{}
+	err := r.db.DB(ctx).First(&data, id).Error
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+	return data, nil
+}
+
+// FindAPIKeyByHash implements domain.APIKeyRepository.
+func (r *repository) FindAPIKeyByHash(ctx context.Context, hash string) (domain.APIKey, error) {
+	data := domain.APIKey{}
+	err := r.db.DB(ctx).Where("key_hash = ?", hash).First(&data).Error
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+	return data, nil
+}
+
+// CreateAPIKey implements domain.APIKeyRepository.
+func (r *repository) CreateAPIKey(ctx context.Context, key domain.APIKey) (domain.APIKey, error) {
+	err := r.db.DB(ctx).Create(&key).Error
+	if err != nil {
+		logrus.Error("repository.CreateAPIKey: failed to save api key")
+		return domain.APIKey{}, err
+	}
+	return key, nil
+}
+
+// RevokeAPIKey implements domain.APIKeyRepository.
+func (r *repository) RevokeAPIKey(ctx context.Context, id int) error {
+	return r.db.DB(ctx).Model(&domain.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+// TouchAPIKey implements domain.APIKeyRepository.
+func (r *repository) TouchAPIKey(ctx context.Context, id int) error {
+	now := time.Now()
+	return r.db.DB(ctx).Model(&domain.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-touchInterval)).
+		Update("last_used_at", now).Error
+}
+
+var (
+	repo *repository
+)
+
+func NewAPIKeyRepository(db pkgDB.DatabaseTransaction) domain.APIKeyRepository {
+	if repo == nil {
+		repo = &repository{
+			db,
+		}
+	}
+	return repo
+}
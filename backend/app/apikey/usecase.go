@@ -0,0 +1,168 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/Kocannn/self-dunking-ai/pkg/token"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type (
+	usecase struct {
+		dbTx  pkgDB.DatabaseTransaction
+		repo  domain.APIKeyRepository
+		audit domain.AuditUsecase
+	}
+)
+
+// ListAPIKeys implements domain.APIKeyUsecase.
+func (u *usecase) ListAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	user, err := session(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return u.repo.ListAPIKeys(ctx, user.Id)
+}
+
+// CreateAPIKey implements domain.APIKeyUsecase. Keys never carry more than
+// the owner's role allows.
+func (u *usecase) CreateAPIKey(ctx context.Context, req domain.CreateAPIKeyRequest) (domain.CreateAPIKeyResponse, error) {
+	user, err := session(ctx)
+	if err != nil {
+		return domain.CreateAPIKeyResponse{}, err
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return domain.CreateAPIKeyResponse{}, fmt.Errorf("%w: name is required", domain.ErrBadRequest)
+	}
+	if req.ExpiresInDays < 0 {
+		return domain.CreateAPIKeyResponse{}, fmt.Errorf("%w: expires_in_days must not be negative", domain.ErrBadRequest)
+	}
+
+	scopes := req.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{domain.PermIdeaReadOwn, domain.PermIdeaWriteOwn}
+	}
+	for _, scope := range scopes {
+		if !user.Can(scope) {
+			return domain.CreateAPIKeyResponse{}, fmt.Errorf("%w: scope %q is not granted to your role", domain.ErrBadRequest, scope)
+		}
+	}
+
+	secret, err := token.NewOpaque()
+	if err != nil {
+		return domain.CreateAPIKeyResponse{}, err
+	}
+	prefix := secret[:8]
+	plain := domain.APIKeyPrefix + prefix + "_" + secret[8:]
+
+	now := time.Now()
+	key := domain.APIKey{
+		UserId:    user.Id,
+		Name:      req.Name,
+		Prefix:    domain.APIKeyPrefix + prefix,
+		KeyHash:   token.Hash(plain),
+		Scopes:    scopes,
+		CreatedAt: &now,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := now.AddDate(0, 0, req.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+
+	var created domain.APIKey
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		created, err = u.repo.CreateAPIKey(txCtx, key)
+		if err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditAPIKeyCreate,
+			TargetType: "api_key",
+			TargetId:   strconv.Itoa(created.Id),
+			After:      created,
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error creating api key: %v", err)
+		return domain.CreateAPIKeyResponse{}, err
+	}
+
+	return domain.CreateAPIKeyResponse{
+		APIKey: created,
+		Key:    plain,
+	}, nil
+}
+
+// RevokeAPIKey implements domain.APIKeyUsecase.
+func (u *usecase) RevokeAPIKey(ctx context.Context, id int) error {
+	user, err := session(ctx)
+	if err != nil {
+		return err
+	}
+
+	key, err := u.repo.GetAPIKey(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrNotFound
+		}
+		return err
+	}
+	if key.UserId != user.Id && !user.Can(domain.PermUserManage) {
+		return domain.ErrNotFound
+	}
+
+	return u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		if err := u.repo.RevokeAPIKey(txCtx, id); err != nil {
+			return err
+		}
+		revoked := key
+		now := time.Now()
+		revoked.RevokedAt = &now
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditAPIKeyRevoke,
+			TargetType: "api_key",
+			TargetId:   strconv.Itoa(id),
+			Before:     key,
+			After:      revoked,
+		})
+	})
+}
+
+// session returns the caller when they signed in themselves; a leaked key
+// must not be able to mint, list or revoke the owner's other keys.
+func session(ctx context.Context) (domain.User, error) {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.User{}, domain.ErrUnauthorized
+	}
+	if user.Scopes != nil {
+		return domain.User{}, fmt.Errorf("%w: api keys cannot be managed with an api key", domain.ErrForbidden)
+	}
+	return user, nil
+}
+
+var (
+	uc *usecase
+)
+
+func NewAPIKeyUsecase(dbTx pkgDB.DatabaseTransaction, repo domain.APIKeyRepository, audit domain.AuditUsecase) domain.APIKeyUsecase {
+	if uc == nil {
+		uc = &usecase{
+			dbTx:  dbTx,
+			repo:  repo,
+			audit: audit,
+		}
+	}
+	return uc
+}
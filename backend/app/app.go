@@ -1,20 +1,60 @@
 package app
 
 import (
+	"context"
+
 	"github.com/Kocannn/self-dunking-ai/config"
 	"github.com/Kocannn/self-dunking-ai/domain"
-	"github.com/hammer-code/lms-be/pkg/jwt"
+	"github.com/Kocannn/self-dunking-ai/pkg/cache"
+	"github.com/Kocannn/self-dunking-ai/pkg/jwt"
+	"github.com/Kocannn/self-dunking-ai/pkg/mailer"
+	"github.com/Kocannn/self-dunking-ai/pkg/oidc"
+	"github.com/sirupsen/logrus"
 	"gorm.io/driver/postgres"
 
+	"github.com/Kocannn/self-dunking-ai/app/apikey"
+	"github.com/Kocannn/self-dunking-ai/app/audit"
+	"github.com/Kocannn/self-dunking-ai/app/batch"
+	"github.com/Kocannn/self-dunking-ai/app/comment"
+	"github.com/Kocannn/self-dunking-ai/app/email"
+	"github.com/Kocannn/self-dunking-ai/app/experiment"
 	"github.com/Kocannn/self-dunking-ai/app/idea"
+	"github.com/Kocannn/self-dunking-ai/app/job"
 	"github.com/Kocannn/self-dunking-ai/app/middleware"
+	"github.com/Kocannn/self-dunking-ai/app/persona"
+	"github.com/Kocannn/self-dunking-ai/app/prompt"
+	"github.com/Kocannn/self-dunking-ai/app/rubric"
+	"github.com/Kocannn/self-dunking-ai/app/search"
+	"github.com/Kocannn/self-dunking-ai/app/share"
+	"github.com/Kocannn/self-dunking-ai/app/tag"
+	"github.com/Kocannn/self-dunking-ai/app/usage"
+	"github.com/Kocannn/self-dunking-ai/app/user"
+	"github.com/Kocannn/self-dunking-ai/app/workspace"
 
 	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
 )
 
 type App struct {
-	IdeaHandler domain.IdeaHandler
-	Middleware  domain.Middleware
+	IdeaHandler       domain.IdeaHandler
+	PromptHandler     domain.PromptHandler
+	ExperimentHandler domain.ExperimentHandler
+	UserHandler       domain.UserHandler
+	APIKeyHandler     domain.APIKeyHandler
+	UsageHandler      domain.UsageHandler
+	WorkspaceHandler  domain.WorkspaceHandler
+	AuditHandler      domain.AuditHandler
+	SearchHandler     domain.SearchHandler
+	TagHandler        domain.TagHandler
+	RubricHandler     domain.RubricHandler
+	PersonaHandler    domain.PersonaHandler
+	BatchHandler      domain.BatchHandler
+	JobHandler        domain.JobHandler
+	ShareHandler      domain.ShareHandler
+	CommentHandler    domain.CommentHandler
+	Middleware        domain.Middleware
+	// Jobs runs the background queue in the worker command, and the email
+	// jobs next to the HTTP server
+	Jobs domain.JobUsecase
 }
 
 func InitApp(cfg config.Config) App {
@@ -23,21 +63,156 @@ func InitApp(cfg config.Config) App {
 			DSN: cfg.DB_POSTGRES_DSN,
 		}})
 
-	db.AutoMigrate(&domain.SubmitIdeaRequest{})
+	db.AutoMigrate(
+		&domain.User{},
+		&domain.RefreshToken{},
+		&domain.RevokedToken{},
+		&domain.OIDCState{},
+		&domain.UserToken{},
+		&domain.Email{},
+		&domain.APIKey{},
+		&domain.SubmitIdeaRequest{},
+		&domain.PromptTemplate{},
+		&domain.Evaluation{},
+		&domain.Experiment{},
+		&domain.ExperimentVariant{},
+		&domain.RateLimitBucket{},
+		&domain.UsageCounter{},
+		&domain.Workspace{},
+		&domain.WorkspaceMembership{},
+		&domain.WorkspaceInvitation{},
+		&domain.AuditLog{},
+		&domain.Tag{},
+		&domain.IdeaTag{},
+		&domain.IdeaTransition{},
+		&domain.Rubric{},
+		&domain.Persona{},
+		&domain.Comparison{},
+		&domain.BatchJob{},
+		&domain.BatchItem{},
+		&domain.Job{},
+		&domain.IdeaEmbedding{},
+		&domain.ShareLink{},
+		&domain.Comment{},
+		&domain.CommentRevision{},
+	)
 
 	jwtInstance := jwt.NewJwt(cfg.JWT_SECRET_KEY)
 
-	middleware := middleware.InitMiddleware(jwtInstance)
-
 	dbTx := pkgDB.NewDBTransaction(db)
+	auditRepo := audit.InitAuditRepository(dbTx)
+	if err := auditRepo.EnsureAppendOnly(context.Background()); err != nil {
+		logrus.Fatal("failed to protect the audit log: ", err)
+	}
+	auditUsecase := audit.InitAuditUsecase(auditRepo)
+
+	userRepo := user.InitUserRepository(dbTx)
+	apiKeyRepo := apikey.InitAPIKeyRepository(dbTx)
+
+	usageRepo := usage.InitUsageRepository(dbTx)
+	usageUsecase := usage.InitUsageUsecase(dbTx, usageRepo, userRepo)
+
+	revokedTokens := cache.New[bool]()
+
+	promptRepo := prompt.InitPromptRepository(dbTx)
+	experimentRepo := experiment.InitExperimentRepository(dbTx)
 	ideaRepo := idea.InitIdeaRepository(dbTx)
+	workspaceRepo := workspace.InitWorkspaceRepository(dbTx)
+	tagRepo := tag.InitTagRepository(dbTx)
+	rubricRepo := rubric.InitRubricRepository(dbTx)
+	personaRepo := persona.InitPersonaRepository(dbTx)
+	batchRepo := batch.InitBatchRepository(dbTx)
+	jobRepo := job.InitJobRepository(dbTx)
+	shareRepo := share.InitShareRepository(dbTx)
+	commentRepo := comment.InitCommentRepository(dbTx)
+	searchRepo := search.InitSearchRepository(dbTx)
+	if err := searchRepo.EnsureSearchIndex(context.Background()); err != nil {
+		logrus.Fatal("failed to create the search index: ", err)
+	}
+
+	var oidcProvider *oidc.Provider
+	if cfg.OIDC_ISSUER_URL != "" {
+		oidcProvider = oidc.NewProvider(oidc.Config{
+			IssuerURL:    cfg.OIDC_ISSUER_URL,
+			ClientID:     cfg.OIDC_CLIENT_ID,
+			ClientSecret: cfg.OIDC_CLIENT_SECRET,
+			RedirectURL:  cfg.OIDC_REDIRECT_URL,
+			Scopes:       cfg.OIDC_SCOPES,
+		}, nil)
+	}
 
-	ideaUsecase := idea.InitIdeaUsecase(dbTx, ideaRepo)
+	var smtpMailer *mailer.Mailer
+	if cfg.SMTP_HOST != "" {
+		smtpMailer = mailer.New(mailer.Config{
+			Host:     cfg.SMTP_HOST,
+			Port:     cfg.SMTP_PORT,
+			Username: cfg.SMTP_EMAIL,
+			Password: cfg.SMTP_PASSWORD,
+			From:     cfg.SMTP_FROM,
+		})
+	}
+
+	emailRepo := email.InitEmailRepository(dbTx)
+	emailUsecase := email.InitEmailUsecase(cfg, dbTx, emailRepo, smtpMailer, jobRepo)
+	workspaceUsecase := workspace.InitWorkspaceUsecase(cfg, dbTx, workspaceRepo, promptRepo, emailUsecase, auditUsecase)
+	middleware := middleware.InitMiddleware(jwtInstance, userRepo, apiKeyRepo, revokedTokens, usageUsecase, workspaceUsecase)
+	userUsecase := user.InitUserUsecase(cfg, dbTx, userRepo, jwtInstance, revokedTokens, oidcProvider, emailUsecase, auditUsecase)
+	apiKeyUsecase := apikey.InitAPIKeyUsecase(dbTx, apiKeyRepo, auditUsecase)
+	promptUsecase := prompt.InitPromptUsecase(dbTx, promptRepo, auditUsecase)
+	if err := promptUsecase.EnsureDefaults(context.Background()); err != nil {
+		logrus.Fatal("failed to seed default prompts: ", err)
+	}
+	experimentUsecase := experiment.InitExperimentUsecase(dbTx, experimentRepo, promptRepo, auditUsecase)
+	ideaUsecase := idea.InitIdeaUsecase(dbTx, ideaRepo, promptUsecase, experimentUsecase, usageUsecase, auditUsecase, tagRepo, rubricRepo, personaRepo)
+	tagUsecase := tag.InitTagUsecase(dbTx, tagRepo, auditUsecase)
+	rubricUsecase := rubric.InitRubricUsecase(dbTx, rubricRepo, auditUsecase)
+	personaUsecase := persona.InitPersonaUsecase(dbTx, personaRepo, auditUsecase)
+	searchUsecase := search.InitSearchUsecase(searchRepo)
+	batchUsecase := batch.InitBatchUsecase(dbTx, batchRepo, jobRepo, ideaUsecase, usageUsecase, rubricRepo, personaRepo, auditUsecase)
+	shareUsecase := share.InitShareUsecase(cfg, dbTx, shareRepo, ideaRepo, auditUsecase)
+	commentUsecase := comment.InitCommentUsecase(cfg, dbTx, commentRepo, ideaUsecase, ideaRepo, workspaceRepo, emailUsecase, auditUsecase)
+	jobUsecase := job.InitJobUsecase(dbTx, jobRepo, userRepo, workspaceUsecase, auditUsecase)
+	jobUsecase.Register(domain.JobEvaluation, ideaUsecase.RunJob)
+	jobUsecase.Register(domain.JobDebate, ideaUsecase.RunJob)
+	jobUsecase.Register(domain.JobEmbedding, ideaUsecase.RunJob)
+	jobUsecase.Register(domain.JobEmail, emailUsecase.RunJob)
+	jobUsecase.Register(domain.JobBatch, batchUsecase.RunJob)
 
+	userHandler := user.InitUserHandler(userUsecase)
+	apiKeyHandler := apikey.InitAPIKeyHandler(apiKeyUsecase)
+	promptHandler := prompt.InitPromptHandler(promptUsecase)
+	experimentHandler := experiment.InitExperimentHandler(experimentUsecase)
 	ideaHandler := idea.InitIdeaHandler(ideaUsecase)
+	usageHandler := usage.InitUsageHandler(usageUsecase)
+	workspaceHandler := workspace.InitWorkspaceHandler(workspaceUsecase)
+	auditHandler := audit.InitAuditHandler(auditUsecase)
+	searchHandler := search.InitSearchHandler(searchUsecase)
+	tagHandler := tag.InitTagHandler(tagUsecase)
+	rubricHandler := rubric.InitRubricHandler(rubricUsecase)
+	personaHandler := persona.InitPersonaHandler(personaUsecase)
+	batchHandler := batch.InitBatchHandler(batchUsecase)
+	jobHandler := job.InitJobHandler(jobUsecase)
+	shareHandler := share.InitShareHandler(shareUsecase)
+	commentHandler := comment.InitCommentHandler(commentUsecase)
 
 	return App{
-		IdeaHandler: ideaHandler,
-		Middleware:  middleware,
+		IdeaHandler:       ideaHandler,
+		PromptHandler:     promptHandler,
+		ExperimentHandler: experimentHandler,
+		UserHandler:       userHandler,
+		APIKeyHandler:     apiKeyHandler,
+		UsageHandler:      usageHandler,
+		WorkspaceHandler:  workspaceHandler,
+		AuditHandler:      auditHandler,
+		SearchHandler:     searchHandler,
+		TagHandler:        tagHandler,
+		RubricHandler:     rubricHandler,
+		PersonaHandler:    personaHandler,
+		BatchHandler:      batchHandler,
+		JobHandler:        jobHandler,
+		ShareHandler:      shareHandler,
+		CommentHandler:    commentHandler,
+		Middleware:        middleware,
+		Jobs:              jobUsecase,
 	}
 }
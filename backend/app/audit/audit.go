@@ -0,0 +1,16 @@
+package audit
+
+import (
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+)
+
+func InitAuditRepository(db pkgDB.DatabaseTransaction) domain.AuditRepository {
+	return NewAuditRepository(db)
+}
+func InitAuditUsecase(repo domain.AuditRepository) domain.AuditUsecase {
+	return NewAuditUsecase(repo)
+}
+func InitAuditHandler(usecase domain.AuditUsecase) domain.AuditHandler {
+	return NewAuditHandler(usecase)
+}
@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/utils"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	handler struct {
+		usecase domain.AuditUsecase
+	}
+)
+
+// ListAuditLogs implements domain.AuditHandler.
+func (h *handler) ListAuditLogs(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r.URL.Query())
+	if err != nil {
+		utils.RespondError(w, "error parsing audit filter", err)
+		return
+	}
+
+	data, err := h.usecase.ListAuditLogs(r.Context(), filter)
+	if err != nil {
+		utils.RespondError(w, "error listing audit logs", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Audit logs retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// ExportAuditLogs implements domain.AuditHandler.
+func (h *handler) ExportAuditLogs(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r.URL.Query())
+	if err != nil {
+		utils.RespondError(w, "error parsing audit filter", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-%s.csv"`, time.Now().UTC().Format("20060102-150405")))
+	// the status is already sent once rows stream, so a late failure can
+	// only be logged
+	if err := h.usecase.ExportAuditLogs(r.Context(), filter, w); err != nil {
+		logrus.Errorf("error exporting audit logs: %v", err)
+	}
+}
+
+// parseFilter reads actor_id, action, target_type, target_id, workspace_id,
+// request_id, from, to (RFC 3339), limit and offset.
+func parseFilter(query url.Values) (domain.AuditFilter, error) {
+	filter := domain.AuditFilter{
+		Action:     query.Get("action"),
+		TargetType: query.Get("target_type"),
+		TargetId:   query.Get("target_id"),
+		RequestId:  query.Get("request_id"),
+	}
+
+	ints := map[string]*int{
+		"actor_id":     &filter.ActorId,
+		"workspace_id": &filter.WorkspaceId,
+		"limit":        &filter.Limit,
+		"offset":       &filter.Offset,
+	}
+	for name, dst := range ints {
+		if value := query.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return domain.AuditFilter{}, fmt.Errorf("%w: Invalid %s", domain.ErrBadRequest, name)
+			}
+			*dst = n
+		}
+	}
+
+	times := map[string]**time.Time{
+		"from": &filter.From,
+		"to":   &filter.To,
+	}
+	for name, dst := range times {
+		if value := query.Get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return domain.AuditFilter{}, fmt.Errorf("%w: Invalid %s", domain.ErrBadRequest, name)
+			}
+			*dst = &t
+		}
+	}
+	return filter, nil
+}
+
+var (
+	handlr *handler
+)
+
+func NewAuditHandler(usecase domain.AuditUsecase) domain.AuditHandler {
+	if handlr == nil {
+		handlr = &handler{
+			usecase,
+		}
+	}
+	return handlr
+}
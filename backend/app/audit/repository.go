@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+)
+
+type (
+	repository struct {
+		db pkgDB.DatabaseTransaction
+	}
+)
+
+// appendOnlyStatements make Postgres itself refuse to rewrite history, so
+// not even a bug in this code base or a stray admin query can.
+var appendOnlyStatements = []string{
+	`CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS audit_logs_no_change ON audit_logs`,
+	`CREATE TRIGGER audit_logs_no_change BEFORE UPDATE OR DELETE ON audit_logs
+	FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only()`,
+	`DROP TRIGGER IF EXISTS audit_logs_no_truncate ON audit_logs`,
+	`CREATE TRIGGER audit_logs_no_truncate BEFORE TRUNCATE ON audit_logs
+	FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only()`,
+}
+
+// CreateAuditLog implements domain.AuditRepository.
+func (r *repository) CreateAuditLog(ctx context.Context, log domain.AuditLog) error {
+	return r.db.DB(ctx).Create(&log).Error
+}
+
+// ListAuditLogs implements domain.AuditRepository.
+func (r *repository) ListAuditLogs(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditLog, error) {
+	query := r.db.DB(ctx).Model(&domain.AuditLog{})
+	if filter.ActorId != 0 {
+		query = query.Where("actor_id = ?", filter.ActorId)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetId != "" {
+		query = query.Where("target_id = ?", filter.TargetId)
+	}
+	if filter.WorkspaceId != 0 {
+		query = query.Where("workspace_id = ?", filter.WorkspaceId)
+	}
+	if filter.RequestId != "" {
+		query = query.Where("request_id = ?", filter.RequestId)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	data := []domain.AuditLog{}
+	err := query.Order("id DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// EnsureAppendOnly implements domain.AuditRepository.
+func (r *repository) EnsureAppendOnly(ctx context.Context) error {
+	db := r.db.DB(ctx)
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	for _, statement := range appendOnlyStatements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	repo *repository
+)
+
+func NewAuditRepository(db pkgDB.DatabaseTransaction) domain.AuditRepository {
+	if repo == nil {
+		repo = &repository{
+			db,
+		}
+	}
+	return repo
+}
@@ -0,0 +1,218 @@
+package audit
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+	exportPage   = 500
+)
+
+// ignoredFields change on every write and would only add noise to a diff.
+var ignoredFields = map[string]bool{
+	"updated_at": true,
+}
+
+type (
+	usecase struct {
+		repo domain.AuditRepository
+	}
+)
+
+// Record implements domain.AuditUsecase.
+func (u *usecase) Record(ctx context.Context, entry domain.AuditEntry) error {
+	before, after, err := diff(entry.Before, entry.After)
+	if err != nil {
+		return fmt.Errorf("audit %s: %w", entry.Action, err)
+	}
+
+	now := time.Now()
+	log := domain.AuditLog{
+		ActorId:    entry.ActorId,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetId:   entry.TargetId,
+		RequestId:  domain.RequestIdFromContext(ctx),
+		IP:         domain.ClientIPFromContext(ctx),
+		Before:     before,
+		After:      after,
+		CreatedAt:  &now,
+	}
+	if user, ok := domain.UserFromContext(ctx); ok {
+		log.ActorId = user.Id
+		log.ActorEmail = user.Email
+	}
+	if apiKeyId, ok := ctx.Value(domain.ContextAPIKey).(int); ok {
+		log.APIKeyId = apiKeyId
+	}
+	if workspace, ok := domain.WorkspaceFromContext(ctx); ok {
+		log.WorkspaceId = workspace.Id
+	}
+	if entry.WorkspaceId != 0 {
+		log.WorkspaceId = entry.WorkspaceId
+	}
+
+	if err := u.repo.CreateAuditLog(ctx, log); err != nil {
+		logrus.Errorf("error writing audit log %s: %v", entry.Action, err)
+		return err
+	}
+	return nil
+}
+
+// ListAuditLogs implements domain.AuditUsecase.
+func (u *usecase) ListAuditLogs(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditLog, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultLimit
+	}
+	if filter.Limit > maxLimit {
+		filter.Limit = maxLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return u.repo.ListAuditLogs(ctx, filter)
+}
+
+// ExportAuditLogs implements domain.AuditUsecase. Rows are fetched page by
+// page so a large export does not have to fit in memory.
+func (u *usecase) ExportAuditLogs(ctx context.Context, filter domain.AuditFilter, w io.Writer) error {
+	out := csv.NewWriter(w)
+	header := []string{"id", "created_at", "actor_id", "actor_email", "api_key_id", "action",
+		"target_type", "target_id", "workspace_id", "request_id", "ip", "before", "after"}
+	if err := out.Write(header); err != nil {
+		return err
+	}
+
+	filter.Limit = exportPage
+	filter.Offset = 0
+	for {
+		page, err := u.repo.ListAuditLogs(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for _, log := range page {
+			if err := out.Write(csvRecord(log)); err != nil {
+				return err
+			}
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			return err
+		}
+		if len(page) < exportPage {
+			return nil
+		}
+		filter.Offset += exportPage
+	}
+}
+
+func csvRecord(log domain.AuditLog) []string {
+	createdAt := ""
+	if log.CreatedAt != nil {
+		createdAt = log.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		strconv.Itoa(log.Id),
+		createdAt,
+		strconv.Itoa(log.ActorId),
+		log.ActorEmail,
+		strconv.Itoa(log.APIKeyId),
+		log.Action,
+		log.TargetType,
+		log.TargetId,
+		strconv.Itoa(log.WorkspaceId),
+		log.RequestId,
+		log.IP,
+		jsonCell(log.Before),
+		jsonCell(log.After),
+	}
+}
+
+func jsonCell(fields map[string]interface{}) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// diff reduces before and after to the JSON fields that differ. A missing
+// side keeps the other one whole so creates and deletes show the full value.
+func diff(before, after interface{}) (map[string]interface{}, map[string]interface{}, error) {
+	b, err := fields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	a, err := fields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+	if b == nil || a == nil {
+		return b, a, nil
+	}
+
+	changedBefore := map[string]interface{}{}
+	changedAfter := map[string]interface{}{}
+	for key, value := range b {
+		if !reflect.DeepEqual(value, a[key]) {
+			changedBefore[key] = value
+			if next, ok := a[key]; ok {
+				changedAfter[key] = next
+			}
+		}
+	}
+	for key, value := range a {
+		if _, ok := b[key]; !ok {
+			changedAfter[key] = value
+		}
+	}
+	return changedBefore, changedAfter, nil
+}
+
+// fields flattens v to its JSON object, which already leaves out secrets
+// tagged json:"-".
+func fields(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	for key := range ignoredFields {
+		delete(out, key)
+	}
+	return out, nil
+}
+
+var (
+	uc *usecase
+)
+
+func NewAuditUsecase(repo domain.AuditRepository) domain.AuditUsecase {
+	if uc == nil {
+		uc = &usecase{
+			repo: repo,
+		}
+	}
+	return uc
+}
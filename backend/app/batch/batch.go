@@ -0,0 +1,16 @@
+package batch
+
+import (
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+)
+
+func InitBatchRepository(db pkgDB.DatabaseTransaction) domain.BatchRepository {
+	return NewBatchRepository(db)
+}
+func InitBatchUsecase(dbTx pkgDB.DatabaseTransaction, repo domain.BatchRepository, jobs domain.JobRepository, ideas domain.IdeaUsecase, usage domain.UsageUsecase, rubrics domain.RubricRepository, personas domain.PersonaRepository, audit domain.AuditUsecase) domain.BatchUsecase {
+	return NewBatchUsecase(dbTx, repo, jobs, ideas, usage, rubrics, personas, audit)
+}
+func InitBatchHandler(usecase domain.BatchUsecase) domain.BatchHandler {
+	return NewBatchHandler(usecase)
+}
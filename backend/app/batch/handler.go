@@ -0,0 +1,285 @@
+package batch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// maxMemory is how much of a multipart upload is kept in memory before it
+// spills to a temporary file.
+const maxMemory = 1 << 20
+
+type (
+	handler struct {
+		usecase domain.BatchUsecase
+	}
+)
+
+// CreateBatch implements domain.BatchHandler. The upload is either the
+// "file" part of a multipart form or the raw request body; format,
+// concurrency, rubric_id and persona come from the form or the query.
+func (h *handler) CreateBatch(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, domain.MaxBatchUploadBytes)
+
+	req := domain.BatchRequest{ContentType: r.Header.Get("Content-Type")}
+	var upload io.Reader = r.Body
+	// a raw body is never parsed as a form, the options are in the query
+	values := r.URL.Query()
+	if strings.HasPrefix(req.ContentType, "multipart/form-data") {
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			logrus.Errorf("error parsing upload: %v", err)
+			utils.Response(domain.HttpResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Error reading uploaded file",
+				Data:    nil,
+			}, w)
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			logrus.Errorf("error reading upload: %v", err)
+			utils.Response(domain.HttpResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Error reading uploaded file",
+				Data:    nil,
+			}, w)
+			return
+		}
+		defer file.Close()
+		upload = file
+		req.Filename = header.Filename
+		req.ContentType = header.Header.Get("Content-Type")
+		values = r.Form
+	}
+
+	req.Format = values.Get("format")
+	req.Persona = values.Get("persona")
+	ints := map[string]*int{
+		"concurrency": &req.Concurrency,
+		"rubric_id":   &req.RubricId,
+	}
+	for name, dst := range ints {
+		if value := values.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				utils.Response(domain.HttpResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Invalid " + name,
+					Data:    nil,
+				}, w)
+				return
+			}
+			*dst = n
+		}
+	}
+
+	data, err := h.usecase.CreateBatch(r.Context(), req, upload)
+	if err != nil {
+		utils.RespondError(w, "error creating batch", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusAccepted,
+		Message: "Batch job created successfully",
+		Data:    data,
+	}, w)
+}
+
+// ListBatches implements domain.BatchHandler.
+func (h *handler) ListBatches(w http.ResponseWriter, r *http.Request) {
+	data, err := h.usecase.ListBatches(r.Context())
+	if err != nil {
+		utils.RespondError(w, "error listing batches", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Batch jobs retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// GetBatch implements domain.BatchHandler.
+func (h *handler) GetBatch(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := h.usecase.GetBatch(r.Context(), id)
+	if err != nil {
+		utils.RespondError(w, "error getting batch", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Batch job retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// ListBatchItems implements domain.BatchHandler.
+func (h *handler) ListBatchItems(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := h.usecase.ListBatchItems(r.Context(), id, r.URL.Query().Get("status"))
+	if err != nil {
+		utils.RespondError(w, "error listing batch items", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Batch items retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// CancelBatch implements domain.BatchHandler.
+func (h *handler) CancelBatch(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := h.usecase.CancelBatch(r.Context(), id)
+	if err != nil {
+		utils.RespondError(w, "error cancelling batch", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Batch job cancelled successfully",
+		Data:    data,
+	}, w)
+}
+
+// StreamBatch implements domain.BatchHandler. It sends a progress event
+// whenever a row finishes and a done event once the job is finished.
+func (h *handler) StreamBatch(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Streaming is not supported",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	started := false
+	err := h.usecase.WatchBatch(r.Context(), id, func(job domain.BatchJob) error {
+		if !started {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("Connection", "keep-alive")
+			started = true
+		}
+		data, err := json.Marshal(job)
+		if err != nil {
+			return err
+		}
+		event := "progress"
+		if job.Finished() {
+			event = "done"
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	})
+	if err != nil {
+		if !started {
+			utils.RespondError(w, "error watching batch", err)
+			return
+		}
+		logrus.Errorf("error watching batch: %v", err)
+	}
+}
+
+// ExportBatch implements domain.BatchHandler.
+func (h *handler) ExportBatch(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	exportFormat := r.URL.Query().Get("format")
+	// check access and the format before anything is written
+	job, err := h.usecase.GetBatch(r.Context(), id)
+	if err != nil {
+		utils.RespondError(w, "error exporting batch", err)
+		return
+	}
+	if exportFormat == "" {
+		exportFormat = job.Format
+	}
+	if exportFormat != domain.BatchFormatCSV && exportFormat != domain.BatchFormatJSONL {
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid format",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if exportFormat == domain.BatchFormatJSONL {
+		contentType = "application/x-ndjson"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="batch-%d-results.%s"`, id, exportFormat))
+	// the status is already sent once rows stream, so a late failure can
+	// only be logged
+	if err := h.usecase.ExportBatch(r.Context(), id, exportFormat, w); err != nil {
+		logrus.Errorf("error exporting batch: %v", err)
+	}
+}
+
+func pathId(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return 0, false
+	}
+	return id, true
+}
+
+var (
+	handlr *handler
+)
+
+func NewBatchHandler(usecase domain.BatchUsecase) domain.BatchHandler {
+	if handlr == nil {
+		handlr = &handler{
+			usecase,
+		}
+	}
+	return handlr
+}
@@ -0,0 +1,158 @@
+package batch
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+)
+
+// maxLineBytes bounds a single JSONL line.
+const maxLineBytes = 1 << 20
+
+// row is the shape of a JSONL line, the same fields ideas are submitted
+// with.
+type row struct {
+	Idea string `json:"idea"`
+	domain.IdeaFields
+}
+
+// format picks the upload format from the request, the file extension or
+// the content type, in that order.
+func format(req domain.BatchRequest) (string, error) {
+	if req.Format != "" {
+		switch strings.ToLower(req.Format) {
+		case domain.BatchFormatCSV:
+			return domain.BatchFormatCSV, nil
+		case domain.BatchFormatJSONL, "ndjson":
+			return domain.BatchFormatJSONL, nil
+		}
+		return "", fmt.Errorf("%w: format must be %s or %s", domain.ErrBadRequest, domain.BatchFormatCSV, domain.BatchFormatJSONL)
+	}
+	switch strings.ToLower(filepath.Ext(req.Filename)) {
+	case ".csv":
+		return domain.BatchFormatCSV, nil
+	case ".jsonl", ".ndjson":
+		return domain.BatchFormatJSONL, nil
+	}
+	contentType := strings.ToLower(req.ContentType)
+	switch {
+	case strings.HasPrefix(contentType, "text/csv"):
+		return domain.BatchFormatCSV, nil
+	case strings.Contains(contentType, "ndjson"), strings.Contains(contentType, "jsonl"):
+		return domain.BatchFormatJSONL, nil
+	}
+	return "", fmt.Errorf("%w: cannot tell the upload format, pass format=%s or format=%s", domain.ErrBadRequest, domain.BatchFormatCSV, domain.BatchFormatJSONL)
+}
+
+// parseCSV reads a CSV upload with a header row naming the columns: idea
+// and the idea form fields, in any order and case. Unknown columns are
+// ignored.
+func parseCSV(upload io.Reader) ([]domain.BatchItem, error) {
+	reader := csv.NewReader(upload)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: the upload is empty", domain.ErrBadRequest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrBadRequest, err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		// spreadsheet exports often start with a byte order mark
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	_, hasIdea := columns["idea"]
+	_, hasProblem := columns["problem"]
+	_, hasSolution := columns["solution"]
+	if !hasIdea && !(hasProblem && hasSolution) {
+		return nil, fmt.Errorf("%w: the header needs an idea column, or problem and solution columns", domain.ErrBadRequest)
+	}
+
+	var items []domain.BatchItem
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return items, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrBadRequest, err)
+		}
+		line, _ := reader.FieldPos(0)
+		cell := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		item := domain.BatchItem{
+			Line: line,
+			Idea: cell("idea"),
+			IdeaFields: domain.IdeaFields{
+				Problem:      cell("problem"),
+				Solution:     cell("solution"),
+				Customer:     cell("customer"),
+				RevenueModel: cell("revenue_model"),
+				Competitors:  cell("competitors"),
+				Stage:        cell("stage"),
+				Constraints:  cell("constraints"),
+			},
+		}
+		if item.Idea == "" && item.IdeaFields == (domain.IdeaFields{}) {
+			continue
+		}
+		if items = append(items, item); len(items) > domain.MaxBatchRows {
+			return nil, tooManyRows()
+		}
+	}
+}
+
+// parseJSONL reads one idea per line. A line that is not valid JSON becomes
+// a failed item, so the rest of the upload is still evaluated.
+func parseJSONL(upload io.Reader) ([]domain.BatchItem, error) {
+	scanner := bufio.NewScanner(upload)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+
+	var items []domain.BatchItem
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		item := domain.BatchItem{Line: line}
+		value := row{}
+		if err := json.Unmarshal([]byte(text), &value); err != nil {
+			item.Status = domain.BatchItemFailed
+			item.Error = fmt.Sprintf("invalid JSON: %v", err)
+		} else {
+			item.Idea = value.Idea
+			item.IdeaFields = value.IdeaFields
+		}
+		if items = append(items, item); len(items) > domain.MaxBatchRows {
+			return nil, tooManyRows()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: line %d: %v", domain.ErrBadRequest, line+1, err)
+	}
+	return items, nil
+}
+
+func tooManyRows() error {
+	return fmt.Errorf("%w: an upload may hold at most %d ideas", domain.ErrBadRequest, domain.MaxBatchRows)
+}
@@ -0,0 +1,170 @@
+package batch
+
+import (
+	"context"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"gorm.io/gorm"
+)
+
+// itemBatchSize is how many rows are inserted per statement.
+const itemBatchSize = 100
+
+type (
+	repository struct {
+		db pkgDB.DatabaseTransaction
+	}
+)
+
+// CreateBatchJob implements domain.BatchRepository.
+func (r *repository) CreateBatchJob(ctx context.Context, job domain.BatchJob) (domain.BatchJob, error) {
+	job.WorkspaceId = workspaceId(ctx)
+	if err := r.db.DB(ctx).Create(&job).Error; err != nil {
+		return domain.BatchJob{}, err
+	}
+	return job, nil
+}
+
+// CreateBatchItems implements domain.BatchRepository.
+func (r *repository) CreateBatchItems(ctx context.Context, items []domain.BatchItem) error {
+	return r.db.DB(ctx).CreateInBatches(&items, itemBatchSize).Error
+}
+
+// GetBatchJob implements domain.BatchRepository.
+func (r *repository) GetBatchJob(ctx context.Context, id int) (domain.BatchJob, error) {
+	data := domain.BatchJob{}
+	if err := r.scoped(ctx).First(&data, id).Error; err != nil {
+		return domain.BatchJob{}, err
+	}
+	return data, nil
+}
+
+// ListBatchJobs implements domain.BatchRepository.
+func (r *repository) ListBatchJobs(ctx context.Context, userId int) ([]domain.BatchJob, error) {
+	data := []domain.BatchJob{}
+	query := r.scoped(ctx)
+	if userId != 0 {
+		query = query.Where("user_id = ?", userId)
+	}
+	if err := query.Order("id DESC").Find(&data).Error; err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListBatchItems implements domain.BatchRepository. Items have no workspace
+// of their own; callers load the job through GetBatchJob first.
+func (r *repository) ListBatchItems(ctx context.Context, jobId int, status string) ([]domain.BatchItem, error) {
+	data := []domain.BatchItem{}
+	query := r.db.DB(ctx).Where("job_id = ?", jobId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("line, id").Find(&data).Error; err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// UpdateBatchStatus implements domain.BatchRepository.
+func (r *repository) UpdateBatchStatus(ctx context.Context, id int, from []string, to, reason string) error {
+	now := time.Now()
+	updates := map[string]interface{}{"status": to}
+	switch to {
+	case domain.BatchRunning:
+		updates["started_at"] = now
+	case domain.BatchCompleted, domain.BatchFailed, domain.BatchCancelled:
+		updates["finished_at"] = now
+	}
+	if reason != "" {
+		updates["error"] = reason
+	}
+
+	result := r.db.DB(ctx).Model(&domain.BatchJob{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// FinishBatchItem implements domain.BatchRepository.
+func (r *repository) FinishBatchItem(ctx context.Context, item domain.BatchItem) error {
+	result := r.db.DB(ctx).Model(&domain.BatchItem{}).
+		Where("id = ? AND status IN ?", item.Id, []string{domain.BatchItemPending, domain.BatchItemCancelled}).
+		Updates(map[string]interface{}{
+			"status":     item.Status,
+			"idea_id":    item.IdeaId,
+			"score":      item.Score,
+			"output":     item.Output,
+			"error":      item.Error,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SetBatchItemIdea implements domain.BatchRepository.
+func (r *repository) SetBatchItemIdea(ctx context.Context, id, ideaId int) error {
+	return r.db.DB(ctx).Model(&domain.BatchItem{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"idea_id":    ideaId,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// CountBatchItem implements domain.BatchRepository.
+func (r *repository) CountBatchItem(ctx context.Context, jobId int, status string) error {
+	counter := "failed"
+	if status == domain.BatchItemSucceeded {
+		counter = "succeeded"
+	}
+	return r.db.DB(ctx).Model(&domain.BatchJob{}).
+		Where("id = ?", jobId).
+		Updates(map[string]interface{}{
+			"processed": gorm.Expr("processed + 1"),
+			counter:     gorm.Expr(counter + " + 1"),
+		}).Error
+}
+
+// CancelPendingBatchItems implements domain.BatchRepository.
+func (r *repository) CancelPendingBatchItems(ctx context.Context, jobId int) error {
+	return r.db.DB(ctx).Model(&domain.BatchItem{}).
+		Where("job_id = ? AND status = ?", jobId, domain.BatchItemPending).
+		Updates(map[string]interface{}{"status": domain.BatchItemCancelled, "updated_at": time.Now()}).Error
+}
+
+// scoped limits a query to the jobs of the active workspace.
+func (r *repository) scoped(ctx context.Context) *gorm.DB {
+	return r.db.DB(ctx).Where("workspace_id = ?", workspaceId(ctx))
+}
+
+func workspaceId(ctx context.Context) int {
+	workspace, _ := domain.WorkspaceFromContext(ctx)
+	return workspace.Id
+}
+
+var (
+	repo *repository
+)
+
+func NewBatchRepository(db pkgDB.DatabaseTransaction) domain.BatchRepository {
+	if repo == nil {
+		repo = &repository{
+			db,
+		}
+	}
+	return repo
+}
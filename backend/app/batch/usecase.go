@@ -0,0 +1,619 @@
+package batch
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// watchInterval is how often WatchBatch reloads a job.
+const watchInterval = time.Second
+
+var (
+	errCancelled = errors.New("cancelled by the user")
+)
+
+type (
+	usecase struct {
+		dbTx     pkgDB.DatabaseTransaction
+		repo     domain.BatchRepository
+		jobs     domain.JobRepository
+		ideas    domain.IdeaUsecase
+		usage    domain.UsageUsecase
+		rubrics  domain.RubricRepository
+		personas domain.PersonaRepository
+		audit    domain.AuditUsecase
+		// slots is shared by every job and bounds their model calls
+		slots chan struct{}
+		mu    sync.Mutex
+		// running holds the cancel functions of the batches this process runs
+		running map[int]context.CancelCauseFunc
+	}
+)
+
+// CreateBatch implements domain.BatchUsecase. Rows that cannot be read are
+// stored as failed items right away, so they show up in the results file.
+func (u *usecase) CreateBatch(ctx context.Context, req domain.BatchRequest, upload io.Reader) (domain.BatchJob, error) {
+	user, err := write(ctx)
+	if err != nil {
+		return domain.BatchJob{}, err
+	}
+	uploadFormat, err := format(req)
+	if err != nil {
+		return domain.BatchJob{}, err
+	}
+	if req.Concurrency == 0 {
+		req.Concurrency = domain.DefaultBatchConcurrency
+	}
+	if req.Concurrency < 1 || req.Concurrency > domain.MaxBatchConcurrency {
+		return domain.BatchJob{}, fmt.Errorf("%w: concurrency must be between 1 and %d", domain.ErrBadRequest, domain.MaxBatchConcurrency)
+	}
+	if err := u.checkOptions(ctx, req); err != nil {
+		return domain.BatchJob{}, err
+	}
+
+	var items []domain.BatchItem
+	if uploadFormat == domain.BatchFormatCSV {
+		items, err = parseCSV(upload)
+	} else {
+		items, err = parseJSONL(upload)
+	}
+	if err != nil {
+		return domain.BatchJob{}, err
+	}
+	if len(items) == 0 {
+		return domain.BatchJob{}, fmt.Errorf("%w: the upload holds no ideas", domain.ErrBadRequest)
+	}
+
+	now := time.Now()
+	job := domain.BatchJob{
+		UserId:      user.Id,
+		Status:      domain.BatchPending,
+		Format:      uploadFormat,
+		Filename:    req.Filename,
+		Concurrency: req.Concurrency,
+		RubricId:    req.RubricId,
+		Persona:     req.Persona,
+		Total:       len(items),
+		CreatedAt:   &now,
+	}
+	for _, item := range items {
+		if item.Status == domain.BatchItemFailed {
+			job.Processed++
+			job.Failed++
+		}
+	}
+
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		created, err := u.repo.CreateBatchJob(txCtx, job)
+		if err != nil {
+			return err
+		}
+		job = created
+		for i := range items {
+			items[i].JobId = job.Id
+			items[i].UpdatedAt = &now
+			if items[i].Status == "" {
+				items[i].Status = domain.BatchItemPending
+			}
+		}
+		if err := u.repo.CreateBatchItems(txCtx, items); err != nil {
+			return err
+		}
+		if err := u.enqueue(txCtx, job, user.Scopes); err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditBatchCreate,
+			TargetType: "batch",
+			TargetId:   strconv.Itoa(job.Id),
+			After:      job,
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error creating batch: %v", err)
+		return domain.BatchJob{}, err
+	}
+	return job, nil
+}
+
+// ListBatches implements domain.BatchUsecase. Workspace admins see every
+// job of the workspace, everybody else their own.
+func (u *usecase) ListBatches(ctx context.Context) ([]domain.BatchJob, error) {
+	user, err := read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userId := user.Id
+	if workspace, _ := domain.WorkspaceFromContext(ctx); workspace.HasMemberRole(domain.WorkspaceAdmin) {
+		userId = 0
+	}
+	data, err := u.repo.ListBatchJobs(ctx, userId)
+	if err != nil {
+		logrus.Errorf("error listing batches: %v", err)
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetBatch implements domain.BatchUsecase.
+func (u *usecase) GetBatch(ctx context.Context, id int) (domain.BatchJob, error) {
+	if _, err := read(ctx); err != nil {
+		return domain.BatchJob{}, err
+	}
+	job, err := u.repo.GetBatchJob(ctx, id)
+	if err != nil {
+		return domain.BatchJob{}, notFound(err)
+	}
+	if err := owner(ctx, job); err != nil {
+		return domain.BatchJob{}, err
+	}
+	return job, nil
+}
+
+// ListBatchItems implements domain.BatchUsecase.
+func (u *usecase) ListBatchItems(ctx context.Context, id int, status string) ([]domain.BatchItem, error) {
+	switch status {
+	case "", domain.BatchItemPending, domain.BatchItemSucceeded, domain.BatchItemFailed, domain.BatchItemCancelled:
+	default:
+		return nil, fmt.Errorf("%w: unknown item status %q", domain.ErrBadRequest, status)
+	}
+	job, err := u.GetBatch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := u.repo.ListBatchItems(ctx, job.Id, status)
+	if err != nil {
+		logrus.Errorf("error listing batch items: %v", err)
+		return nil, err
+	}
+	return data, nil
+}
+
+// CancelBatch implements domain.BatchUsecase. Rows that are being evaluated
+// still finish and keep their result; the rest are cancelled.
+func (u *usecase) CancelBatch(ctx context.Context, id int) (domain.BatchJob, error) {
+	job, err := u.GetBatch(ctx, id)
+	if err != nil {
+		return domain.BatchJob{}, err
+	}
+
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		err := u.repo.UpdateBatchStatus(txCtx, id, []string{domain.BatchPending, domain.BatchRunning}, domain.BatchCancelled, errCancelled.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: the job is already %s", domain.ErrConflict, job.Status)
+		}
+		if err != nil {
+			return err
+		}
+		if err := u.repo.CancelPendingBatchItems(txCtx, id); err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditBatchCancel,
+			TargetType: "batch",
+			TargetId:   strconv.Itoa(id),
+			Before:     map[string]interface{}{"status": job.Status},
+			After:      map[string]interface{}{"status": domain.BatchCancelled},
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error cancelling batch: %v", err)
+		return domain.BatchJob{}, err
+	}
+
+	u.stop(id, errCancelled)
+	return u.GetBatch(ctx, id)
+}
+
+// WatchBatch implements domain.BatchUsecase.
+func (u *usecase) WatchBatch(ctx context.Context, id int, send func(domain.BatchJob) error) error {
+	job, err := u.GetBatch(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := send(job); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for !job.Finished() {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		next, err := u.repo.GetBatchJob(ctx, id)
+		if err != nil {
+			return notFound(err)
+		}
+		if next.Status != job.Status || next.Processed != job.Processed {
+			if err := send(next); err != nil {
+				return err
+			}
+		}
+		job = next
+	}
+	return nil
+}
+
+// ExportBatch implements domain.BatchUsecase. The format defaults to the one
+// the job was uploaded in.
+func (u *usecase) ExportBatch(ctx context.Context, id int, exportFormat string, w io.Writer) error {
+	if exportFormat != "" && exportFormat != domain.BatchFormatCSV && exportFormat != domain.BatchFormatJSONL {
+		return fmt.Errorf("%w: format must be %s or %s", domain.ErrBadRequest, domain.BatchFormatCSV, domain.BatchFormatJSONL)
+	}
+	items, err := u.ListBatchItems(ctx, id, "")
+	if err != nil {
+		return err
+	}
+	if exportFormat == "" {
+		job, err := u.repo.GetBatchJob(ctx, id)
+		if err != nil {
+			return notFound(err)
+		}
+		exportFormat = job.Format
+	}
+
+	if exportFormat == domain.BatchFormatJSONL {
+		encoder := json.NewEncoder(w)
+		for _, item := range items {
+			if err := encoder.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	out := csv.NewWriter(w)
+	header := []string{"line", "status", "idea_id", "score", "error", "idea", "problem", "solution", "output"}
+	if err := out.Write(header); err != nil {
+		return err
+	}
+	for _, item := range items {
+		ideaId, score := "", ""
+		if item.IdeaId != 0 {
+			ideaId = strconv.Itoa(item.IdeaId)
+		}
+		if item.Score != nil {
+			score = strconv.FormatFloat(*item.Score, 'f', 2, 64)
+		}
+		record := []string{strconv.Itoa(item.Line), item.Status, ideaId, score, item.Error,
+			item.Idea, item.Problem, item.Solution, item.Output}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// RunJob implements domain.BatchUsecase. A row that was being evaluated
+// when the worker died is resumed with the idea it already created. A run
+// that hits its timeout queues a job for the rows that are left.
+func (u *usecase) RunJob(ctx context.Context, queued domain.Job) (interface{}, error) {
+	payload := domain.BatchPayload{}
+	if err := json.Unmarshal(queued.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("%w: invalid payload: %v", domain.ErrBadRequest, err)
+	}
+	job, err := u.repo.GetBatchJob(ctx, payload.BatchId)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if job.Status == domain.BatchPending {
+		err := u.repo.UpdateBatchStatus(ctx, job.Id, []string{domain.BatchPending}, domain.BatchRunning, "")
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if job, err = u.repo.GetBatchJob(ctx, job.Id); err != nil {
+			return nil, notFound(err)
+		}
+	}
+	if job.Status != domain.BatchRunning {
+		// cancelled before it started
+		return job, nil
+	}
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	u.mu.Lock()
+	u.running[job.Id] = cancel
+	u.mu.Unlock()
+	defer func() {
+		u.mu.Lock()
+		delete(u.running, job.Id)
+		u.mu.Unlock()
+	}()
+	// bookkeeping must land even after the run was cancelled
+	store := context.WithoutCancel(ctx)
+
+	items, err := u.repo.ListBatchItems(ctx, job.Id, domain.BatchItemPending)
+	if err != nil {
+		logrus.Errorf("error loading batch %d: %v", job.Id, err)
+		if queued.Attempts >= queued.MaxAttempts {
+			u.finish(store, job.Id, domain.BatchFailed, "the rows could not be loaded")
+		}
+		return nil, err
+	}
+	u.run(runCtx, job, items)
+
+	switch cause := context.Cause(runCtx); {
+	case errors.Is(cause, domain.ErrQuotaExceeded):
+		u.finish(store, job.Id, domain.BatchFailed, domain.ErrQuotaExceeded.Error())
+	case errors.Is(cause, errCancelled):
+		// CancelBatch already moved the job
+	case cause != nil:
+		if err := u.enqueue(store, job, queued.Scopes); err != nil {
+			return nil, err
+		}
+	default:
+		u.finish(store, job.Id, domain.BatchCompleted, "")
+	}
+	job, err = u.repo.GetBatchJob(store, job.Id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return job, nil
+}
+
+// enqueue queues a JobBatch that runs job with the scopes it was created
+// with.
+func (u *usecase) enqueue(ctx context.Context, job domain.BatchJob, scopes []string) error {
+	queued, err := domain.NewJob(domain.JobBatch, domain.BatchPayload{BatchId: job.Id})
+	if err != nil {
+		return err
+	}
+	queued.UserId = job.UserId
+	queued.WorkspaceId = job.WorkspaceId
+	queued.Scopes = scopes
+	_, err = u.jobs.CreateJob(ctx, queued)
+	return err
+}
+
+// stop cancels a job running in this process, if it is.
+func (u *usecase) stop(id int, cause error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if cancel, ok := u.running[id]; ok {
+		cancel(cause)
+	}
+}
+
+// run feeds items to job.Concurrency workers until they are done or ctx is.
+func (u *usecase) run(ctx context.Context, job domain.BatchJob, items []domain.BatchItem) {
+	work := make(chan domain.BatchItem)
+	var wg sync.WaitGroup
+	for i := 0; i < job.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range work {
+				u.process(ctx, job, item)
+			}
+		}()
+	}
+feed:
+	for _, item := range items {
+		select {
+		case work <- item:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+}
+
+// finish moves a running job to status and cancels what is left of it.
+func (u *usecase) finish(ctx context.Context, id int, status, reason string) {
+	if err := u.repo.UpdateBatchStatus(ctx, id, []string{domain.BatchRunning}, status, reason); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.Errorf("error finishing batch %d: %v", id, err)
+	}
+	if err := u.repo.CancelPendingBatchItems(ctx, id); err != nil {
+		logrus.Errorf("error cancelling batch %d items: %v", id, err)
+	}
+}
+
+// process submits the row as a new idea and critiques it. The job status is
+// read again first so a cancel from another server is noticed too. A row
+// that already has an idea is only critiqued.
+func (u *usecase) process(ctx context.Context, job domain.BatchJob, item domain.BatchItem) {
+	if current, err := u.repo.GetBatchJob(ctx, job.Id); err == nil && current.Status == domain.BatchCancelled {
+		u.stop(job.Id, errCancelled)
+	}
+	user, _ := domain.UserFromContext(ctx)
+	if status, err := u.usage.CheckQuota(ctx, user); err != nil {
+		logrus.Errorf("error checking quota: %v", err)
+	} else if status.Exceeded {
+		u.stop(job.Id, domain.ErrQuotaExceeded)
+	}
+
+	select {
+	case u.slots <- struct{}{}:
+		defer func() { <-u.slots }()
+	case <-ctx.Done():
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	// a started row is finished even if the job is cancelled meanwhile
+	runCtx := context.WithoutCancel(ctx)
+	item.Status = domain.BatchItemFailed
+	resumed := item.IdeaId != 0
+	var err error
+	if !resumed {
+		var ideaId int
+		err = u.dbTx.StartTransaction(runCtx, func(txCtx context.Context) error {
+			now := time.Now()
+			idea, err := u.ideas.SubmitIdeaStream(txCtx, domain.SubmitIdeaRequest{
+				UserId:     user.Id,
+				Idea:       item.Idea,
+				IdeaFields: item.IdeaFields,
+				CreatedAt:  &now,
+			})
+			if err != nil {
+				return err
+			}
+			ideaId = idea.Id
+			return u.repo.SetBatchItemIdea(txCtx, item.Id, idea.Id)
+		})
+		if err == nil {
+			item.IdeaId = ideaId
+		}
+	}
+	if err == nil {
+		item.Output, item.Score, err = u.critique(runCtx, job, item.IdeaId, resumed)
+		if err == nil {
+			item.Status = domain.BatchItemSucceeded
+		}
+	}
+	if errors.Is(err, domain.ErrQuotaExceeded) {
+		u.stop(job.Id, domain.ErrQuotaExceeded)
+	}
+	if err != nil {
+		logrus.Errorf("error evaluating batch %d line %d: %v", job.Id, item.Line, err)
+		item.Error = rowError(err)
+	}
+
+	err = u.dbTx.StartTransaction(runCtx, func(txCtx context.Context) error {
+		if err := u.repo.FinishBatchItem(txCtx, item); err != nil {
+			return err
+		}
+		return u.repo.CountBatchItem(txCtx, job.Id, item.Status)
+	})
+	if err != nil {
+		logrus.Errorf("error saving batch %d line %d: %v", job.Id, item.Line, err)
+	}
+}
+
+// critique critiques the idea of a row and returns the critique with the
+// score it gave. A resumed row whose critique was already stored reuses it,
+// so the row is not charged twice.
+func (u *usecase) critique(ctx context.Context, job domain.BatchJob, ideaId int, resumed bool) (string, *float64, error) {
+	if resumed {
+		report, err := u.ideas.GetIdeaReport(ctx, ideaId)
+		if err != nil {
+			return "", nil, err
+		}
+		if n := len(report.Critiques); n > 0 {
+			return report.Critiques[n-1].Output, report.Idea.Score, nil
+		}
+	}
+
+	messages, err := u.ideas.SubmitIdea(ctx, domain.Idea{Id: ideaId, RubricId: job.RubricId, Persona: job.Persona})
+	if err != nil {
+		return "", nil, err
+	}
+	var score *float64
+	if scored, err := u.ideas.GetIdea(ctx, ideaId); err == nil {
+		score = scored.Score
+	}
+	return messages[len(messages)-1].Content, score, nil
+}
+
+// checkOptions rejects an unknown rubric or persona before any row runs.
+func (u *usecase) checkOptions(ctx context.Context, req domain.BatchRequest) error {
+	if req.RubricId != 0 {
+		if _, err := u.rubrics.GetRubric(ctx, req.RubricId); errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: unknown rubric %d", domain.ErrBadRequest, req.RubricId)
+		} else if err != nil {
+			return err
+		}
+	}
+	if req.Persona != "" {
+		if _, ok := domain.BuiltinPersona(req.Persona); ok {
+			return nil
+		}
+		if _, err := u.personas.GetPersonaByKey(ctx, req.Persona); errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: unknown persona %q", domain.ErrBadRequest, req.Persona)
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rowError is the message reported for a failed row. Validation errors are
+// shown as they are, anything else only in the server log.
+func rowError(err error) string {
+	if errors.Is(err, domain.ErrBadRequest) || errors.Is(err, domain.ErrNotFound) || errors.Is(err, domain.ErrForbidden) ||
+		errors.Is(err, domain.ErrQuotaExceeded) {
+		return err.Error()
+	}
+	return "evaluation failed"
+}
+
+func read(ctx context.Context) (domain.User, error) {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.User{}, domain.ErrUnauthorized
+	}
+	if !user.Can(domain.PermIdeaReadOwn) {
+		return domain.User{}, domain.ErrForbidden
+	}
+	return user, nil
+}
+
+func write(ctx context.Context) (domain.User, error) {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.User{}, domain.ErrUnauthorized
+	}
+	if !user.Can(domain.PermIdeaWriteOwn) {
+		return domain.User{}, domain.ErrForbidden
+	}
+	return user, nil
+}
+
+// owner lets the creator of a job and workspace admins through.
+func owner(ctx context.Context, job domain.BatchJob) error {
+	user, _ := domain.UserFromContext(ctx)
+	workspace, _ := domain.WorkspaceFromContext(ctx)
+	if job.UserId != user.Id && !workspace.HasMemberRole(domain.WorkspaceAdmin) {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrNotFound
+	}
+	return err
+}
+
+var (
+	uc *usecase
+)
+
+func NewBatchUsecase(dbTx pkgDB.DatabaseTransaction, repo domain.BatchRepository, jobs domain.JobRepository, ideas domain.IdeaUsecase, usage domain.UsageUsecase, rubrics domain.RubricRepository, personas domain.PersonaRepository, audit domain.AuditUsecase) domain.BatchUsecase {
+	if uc == nil {
+		uc = &usecase{
+			dbTx:     dbTx,
+			repo:     repo,
+			jobs:     jobs,
+			ideas:    ideas,
+			usage:    usage,
+			rubrics:  rubrics,
+			personas: personas,
+			audit:    audit,
+			slots:    make(chan struct{}, domain.MaxBatchModelCalls),
+			running:  map[int]context.CancelCauseFunc{},
+		}
+	}
+	return uc
+}
@@ -0,0 +1,17 @@
+package comment
+
+import (
+	"github.com/Kocannn/self-dunking-ai/config"
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+)
+
+func InitCommentRepository(db pkgDB.DatabaseTransaction) domain.CommentRepository {
+	return NewCommentRepository(db)
+}
+func InitCommentUsecase(cfg config.Config, dbTx pkgDB.DatabaseTransaction, repo domain.CommentRepository, ideas domain.IdeaUsecase, ideaRepo domain.IdeaRepository, workspaces domain.WorkspaceRepository, email domain.EmailUsecase, audit domain.AuditUsecase) domain.CommentUsecase {
+	return NewCommentUsecase(cfg, dbTx, repo, ideas, ideaRepo, workspaces, email, audit)
+}
+func InitCommentHandler(usecase domain.CommentUsecase) domain.CommentHandler {
+	return NewCommentHandler(usecase)
+}
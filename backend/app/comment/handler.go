@@ -0,0 +1,178 @@
+package comment
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	handler struct {
+		usecase domain.CommentUsecase
+	}
+)
+
+// ListComments implements domain.CommentHandler. The evaluation_id query
+// parameter narrows the threads to one evaluation.
+func (h *handler) ListComments(w http.ResponseWriter, r *http.Request) {
+	ideaId, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+	filter := domain.CommentFilter{IdeaId: ideaId}
+	if value := r.URL.Query().Get("evaluation_id"); value != "" {
+		var err error
+		if filter.EvaluationId, err = strconv.Atoi(value); err != nil {
+			utils.Response(domain.HttpResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid evaluation_id",
+				Data:    nil,
+			}, w)
+			return
+		}
+	}
+
+	data, err := h.usecase.ListComments(r.Context(), filter)
+	if err != nil {
+		utils.RespondError(w, "error listing comments", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Comments retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// CreateComment implements domain.CommentHandler.
+func (h *handler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	ideaId, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+	req := domain.CreateCommentRequest{}
+	if !decode(w, r, &req) {
+		return
+	}
+
+	data, err := h.usecase.CreateComment(r.Context(), ideaId, req)
+	if err != nil {
+		utils.RespondError(w, "error creating comment", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusCreated,
+		Message: "Comment created successfully",
+		Data:    data,
+	}, w)
+}
+
+// UpdateComment implements domain.CommentHandler.
+func (h *handler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+	req := domain.UpdateCommentRequest{}
+	if !decode(w, r, &req) {
+		return
+	}
+
+	data, err := h.usecase.UpdateComment(r.Context(), id, req)
+	if err != nil {
+		utils.RespondError(w, "error updating comment", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Comment updated successfully",
+		Data:    data,
+	}, w)
+}
+
+// DeleteComment implements domain.CommentHandler.
+func (h *handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.usecase.DeleteComment(r.Context(), id); err != nil {
+		utils.RespondError(w, "error deleting comment", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Comment deleted successfully",
+		Data:    nil,
+	}, w)
+}
+
+// ListCommentHistory implements domain.CommentHandler.
+func (h *handler) ListCommentHistory(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := h.usecase.ListCommentHistory(r.Context(), id)
+	if err != nil {
+		utils.RespondError(w, "error listing comment history", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Comment history retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+func pathId(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return 0, false
+	}
+	return id, true
+}
+
+func decode(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return false
+	}
+	return true
+}
+
+var (
+	handlr *handler
+)
+
+func NewCommentHandler(usecase domain.CommentUsecase) domain.CommentHandler {
+	if handlr == nil {
+		handlr = &handler{
+			usecase,
+		}
+	}
+	return handlr
+}
@@ -0,0 +1,103 @@
+package comment
+
+import (
+	"context"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type (
+	repository struct {
+		db pkgDB.DatabaseTransaction
+	}
+)
+
+// ListComments implements domain.CommentRepository.
+func (r *repository) ListComments(ctx context.Context, filter domain.CommentFilter) ([]domain.Comment, error) {
+	data := []domain.Comment{}
+	query := r.scoped(ctx).Preload("User").Where("idea_id = ?", filter.IdeaId)
+	if filter.EvaluationId != 0 {
+		query = query.Where("evaluation_id = ?", filter.EvaluationId)
+	}
+	if err := query.Order("created_at, id").Find(&data).Error; err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetComment implements domain.CommentRepository.
+func (r *repository) GetComment(ctx context.Context, id int) (domain.Comment, error) {
+	data := domain.Comment{}
+	if err := r.scoped(ctx).Preload("User").First(&data, id).Error; err != nil {
+		return domain.Comment{}, err
+	}
+	return data, nil
+}
+
+// CreateComment implements domain.CommentRepository.
+func (r *repository) CreateComment(ctx context.Context, comment domain.Comment) (domain.Comment, error) {
+	comment.WorkspaceId = workspaceId(ctx)
+	if err := r.db.DB(ctx).Omit("User").Create(&comment).Error; err != nil {
+		logrus.Error("repository.CreateComment: failed to save comment")
+		return domain.Comment{}, err
+	}
+	return comment, nil
+}
+
+// UpdateComment implements domain.CommentRepository. It saves the body,
+// mentions and the edit and delete times.
+func (r *repository) UpdateComment(ctx context.Context, comment domain.Comment) (domain.Comment, error) {
+	err := r.scoped(ctx).Model(&comment).
+		Select("body", "mentions", "edited_at", "deleted_at", "updated_at").
+		Omit("User").
+		Updates(&comment).Error
+	if err != nil {
+		logrus.Error("repository.UpdateComment: failed to update comment")
+		return domain.Comment{}, err
+	}
+	return r.GetComment(ctx, comment.Id)
+}
+
+// CreateCommentRevision implements domain.CommentRepository.
+func (r *repository) CreateCommentRevision(ctx context.Context, revision domain.CommentRevision) error {
+	return r.db.DB(ctx).Create(&revision).Error
+}
+
+// ListCommentRevisions implements domain.CommentRepository.
+func (r *repository) ListCommentRevisions(ctx context.Context, commentId int) ([]domain.CommentRevision, error) {
+	data := []domain.CommentRevision{}
+	err := r.db.DB(ctx).
+		Where("comment_id = ?", commentId).
+		Order("created_at, id").
+		Find(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// scoped limits a query to the caller's active workspace.
+func (r *repository) scoped(ctx context.Context) *gorm.DB {
+	return r.db.DB(ctx).Where("workspace_id = ?", workspaceId(ctx))
+}
+
+func workspaceId(ctx context.Context) int {
+	workspace, _ := domain.WorkspaceFromContext(ctx)
+	return workspace.Id
+}
+
+var (
+	repo *repository
+)
+
+func NewCommentRepository(db pkgDB.DatabaseTransaction) domain.CommentRepository {
+	if repo == nil {
+		repo = &repository{
+			db,
+		}
+	}
+	return repo
+}
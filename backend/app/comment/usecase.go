@@ -0,0 +1,445 @@
+package comment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Kocannn/self-dunking-ai/config"
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/Kocannn/self-dunking-ai/pkg/mailer"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// mentionPattern finds @username, but not the domain of an email address.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@([A-Za-z0-9_][A-Za-z0-9_.-]*)`)
+
+type (
+	usecase struct {
+		cfg        config.Config
+		dbTx       pkgDB.DatabaseTransaction
+		repo       domain.CommentRepository
+		ideas      domain.IdeaUsecase
+		ideaRepo   domain.IdeaRepository
+		workspaces domain.WorkspaceRepository
+		email      domain.EmailUsecase
+		audit      domain.AuditUsecase
+	}
+)
+
+// ListComments implements domain.CommentUsecase. A deleted comment is only
+// kept when it still has replies.
+func (u *usecase) ListComments(ctx context.Context, filter domain.CommentFilter) ([]domain.Comment, error) {
+	if _, err := u.ideas.GetIdea(ctx, filter.IdeaId); err != nil {
+		return nil, err
+	}
+	comments, err := u.repo.ListComments(ctx, filter)
+	if err != nil {
+		logrus.Errorf("error listing comments: %v", err)
+		return nil, err
+	}
+
+	replies := map[int][]domain.Comment{}
+	for _, comment := range comments {
+		if comment.ParentId != 0 && comment.DeletedAt == nil {
+			replies[comment.ParentId] = append(replies[comment.ParentId], comment)
+		}
+	}
+	threads := []domain.Comment{}
+	for _, comment := range comments {
+		if comment.ParentId != 0 {
+			continue
+		}
+		comment.Replies = replies[comment.Id]
+		if comment.DeletedAt != nil && len(comment.Replies) == 0 {
+			continue
+		}
+		threads = append(threads, comment)
+	}
+	return threads, nil
+}
+
+// CreateComment implements domain.CommentUsecase. Whoever can read the idea
+// and write can discuss it.
+func (u *usecase) CreateComment(ctx context.Context, ideaId int, req domain.CreateCommentRequest) (domain.Comment, error) {
+	user, err := write(ctx)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	body, err := validBody(req.Body)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	idea, err := u.ideas.GetIdea(ctx, ideaId)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+
+	comment := domain.Comment{
+		IdeaId:       ideaId,
+		EvaluationId: req.EvaluationId,
+		UserId:       user.Id,
+		Body:         body,
+	}
+
+	if req.ParentId != 0 {
+		parent, err := u.repo.GetComment(ctx, req.ParentId)
+		if err != nil {
+			return domain.Comment{}, notFound(err)
+		}
+		if parent.IdeaId != ideaId {
+			return domain.Comment{}, fmt.Errorf("%w: the parent comment belongs to another idea", domain.ErrBadRequest)
+		}
+		if parent.DeletedAt != nil {
+			return domain.Comment{}, fmt.Errorf("%w: the parent comment was deleted", domain.ErrConflict)
+		}
+		if req.EvaluationId != 0 && req.EvaluationId != parent.EvaluationId {
+			return domain.Comment{}, fmt.Errorf("%w: a reply is about the evaluation of its thread", domain.ErrBadRequest)
+		}
+		if req.Anchor != nil {
+			return domain.Comment{}, fmt.Errorf("%w: only a new thread can be anchored", domain.ErrBadRequest)
+		}
+		// a reply to a reply joins the thread
+		comment.ParentId = parent.Id
+		if parent.ParentId != 0 {
+			comment.ParentId = parent.ParentId
+		}
+		comment.EvaluationId = parent.EvaluationId
+	}
+
+	if comment.EvaluationId != 0 {
+		evaluation, err := u.ideaRepo.GetEvaluation(ctx, comment.EvaluationId)
+		if err != nil {
+			return domain.Comment{}, notFound(err)
+		}
+		if evaluation.IdeaId != ideaId {
+			return domain.Comment{}, fmt.Errorf("%w: the evaluation belongs to another idea", domain.ErrBadRequest)
+		}
+		if req.Anchor != nil {
+			if comment.Anchor, err = anchor(evaluation.Output, *req.Anchor); err != nil {
+				return domain.Comment{}, err
+			}
+		}
+	} else if req.Anchor != nil {
+		return domain.Comment{}, fmt.Errorf("%w: an anchor needs an evaluation_id", domain.ErrBadRequest)
+	}
+
+	mentioned, err := u.mentions(ctx, body, user.Id)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	comment.Mentions = userIds(mentioned)
+
+	now := time.Now()
+	comment.CreatedAt = &now
+	comment.UpdatedAt = &now
+
+	var created domain.Comment
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		created, err = u.repo.CreateComment(txCtx, comment)
+		if err != nil {
+			return err
+		}
+		return u.notify(txCtx, user, idea, created, mentioned)
+	})
+	if err != nil {
+		logrus.Errorf("error creating comment: %v", err)
+		return domain.Comment{}, err
+	}
+	created.User = &user
+	return created, nil
+}
+
+// UpdateComment implements domain.CommentUsecase. Only the author edits a
+// comment; the previous body goes to its history and only members who were
+// not mentioned before are notified.
+func (u *usecase) UpdateComment(ctx context.Context, id int, req domain.UpdateCommentRequest) (domain.Comment, error) {
+	user, err := write(ctx)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	body, err := validBody(req.Body)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	comment, err := u.repo.GetComment(ctx, id)
+	if err != nil {
+		return domain.Comment{}, notFound(err)
+	}
+	if comment.DeletedAt != nil {
+		return domain.Comment{}, domain.ErrNotFound
+	}
+	if comment.UserId != user.Id {
+		return domain.Comment{}, domain.ErrForbidden
+	}
+	idea, err := u.ideas.GetIdea(ctx, comment.IdeaId)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	if body == comment.Body {
+		return comment, nil
+	}
+
+	mentioned, err := u.mentions(ctx, body, user.Id)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	before := map[int]bool{}
+	for _, userId := range comment.Mentions {
+		before[userId] = true
+	}
+	added := []domain.User{}
+	for _, member := range mentioned {
+		if !before[member.Id] {
+			added = append(added, member)
+		}
+	}
+
+	previous := comment.Body
+	now := time.Now()
+	comment.Body = body
+	comment.Mentions = userIds(mentioned)
+	comment.EditedAt = &now
+
+	var updated domain.Comment
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		if err := u.repo.CreateCommentRevision(txCtx, domain.CommentRevision{
+			CommentId: comment.Id,
+			Body:      previous,
+			EditorId:  user.Id,
+			CreatedAt: &now,
+		}); err != nil {
+			return err
+		}
+		var err error
+		updated, err = u.repo.UpdateComment(txCtx, comment)
+		if err != nil {
+			return err
+		}
+		return u.notify(txCtx, user, idea, updated, added)
+	})
+	if err != nil {
+		logrus.Errorf("error updating comment: %v", err)
+		return domain.Comment{}, err
+	}
+	return updated, nil
+}
+
+// DeleteComment implements domain.CommentUsecase. Authors delete their own
+// comments, managers any comment of the workspace.
+func (u *usecase) DeleteComment(ctx context.Context, id int) error {
+	user, err := write(ctx)
+	if err != nil {
+		return err
+	}
+	comment, err := u.repo.GetComment(ctx, id)
+	if err != nil {
+		return notFound(err)
+	}
+	if comment.DeletedAt != nil {
+		return domain.ErrNotFound
+	}
+	if comment.UserId != user.Id && !domain.WorkspaceManager(ctx, user) {
+		return domain.ErrForbidden
+	}
+	if _, err := u.ideas.GetIdea(ctx, comment.IdeaId); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	deleted := comment
+	deleted.User = nil
+	deleted.Body = ""
+	deleted.Mentions = nil
+	deleted.DeletedAt = &now
+
+	return u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		if err := u.repo.CreateCommentRevision(txCtx, domain.CommentRevision{
+			CommentId: comment.Id,
+			Body:      comment.Body,
+			EditorId:  user.Id,
+			CreatedAt: &now,
+		}); err != nil {
+			return err
+		}
+		if _, err := u.repo.UpdateComment(txCtx, deleted); err != nil {
+			return err
+		}
+		comment.User = nil
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditCommentDelete,
+			TargetType: "comment",
+			TargetId:   strconv.Itoa(comment.Id),
+			Before:     comment,
+			After:      deleted,
+		})
+	})
+}
+
+// ListCommentHistory implements domain.CommentUsecase. The history of a
+// deleted comment is left to its author and the managers.
+func (u *usecase) ListCommentHistory(ctx context.Context, id int) ([]domain.CommentRevision, error) {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+	comment, err := u.repo.GetComment(ctx, id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if _, err := u.ideas.GetIdea(ctx, comment.IdeaId); err != nil {
+		return nil, err
+	}
+	if comment.DeletedAt != nil && comment.UserId != user.Id && !domain.WorkspaceManager(ctx, user) {
+		return nil, domain.ErrForbidden
+	}
+	data, err := u.repo.ListCommentRevisions(ctx, id)
+	if err != nil {
+		logrus.Errorf("error listing comment history: %v", err)
+		return nil, err
+	}
+	return data, nil
+}
+
+// mentions resolves the @usernames of body to members of the active
+// workspace. Unknown names and the author are left out.
+func (u *usecase) mentions(ctx context.Context, body string, authorId int) ([]domain.User, error) {
+	names := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		names[strings.ToLower(strings.TrimRight(match[1], ".-"))] = true
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	workspace, _ := domain.WorkspaceFromContext(ctx)
+	members, err := u.workspaces.ListMembers(ctx, workspace.Id)
+	if err != nil {
+		logrus.Errorf("error listing workspace members: %v", err)
+		return nil, err
+	}
+	users := []domain.User{}
+	for _, member := range members {
+		if member.User == nil || member.UserId == authorId {
+			continue
+		}
+		if names[strings.ToLower(member.User.Username)] {
+			users = append(users, *member.User)
+		}
+	}
+	if len(users) > domain.MaxCommentMentions {
+		return nil, fmt.Errorf("%w: a comment can mention at most %d members", domain.ErrBadRequest, domain.MaxCommentMentions)
+	}
+	return users, nil
+}
+
+// notify mails each mentioned user a link to the comment, in the
+// transaction that saves it.
+func (u *usecase) notify(ctx context.Context, author domain.User, idea domain.SubmitIdeaRequest, comment domain.Comment, users []domain.User) error {
+	base := u.cfg.BASE_URL_FE
+	if base == "" {
+		base = u.cfg.BaseURL
+	}
+	link := fmt.Sprintf("%s/ideas/%d#comment-%d", strings.TrimSuffix(base, "/"), idea.Id, comment.Id)
+	for _, user := range users {
+		if err := u.email.Enqueue(ctx, user.Email, mailer.TemplateCommentMention, map[string]interface{}{
+			"Author": author.Username,
+			"Idea":   summary(idea.Idea),
+			"Body":   comment.Body,
+			"Link":   link,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", fmt.Errorf("%w: body is required", domain.ErrBadRequest)
+	}
+	if utf8.RuneCountInString(body) > domain.MaxCommentLength {
+		return "", fmt.Errorf("%w: body must be at most %d characters", domain.ErrBadRequest, domain.MaxCommentLength)
+	}
+	return body, nil
+}
+
+// anchor checks that the span lies within output, counted in characters,
+// and quotes it.
+func anchor(output string, span domain.CommentAnchor) (*domain.CommentAnchor, error) {
+	runes := []rune(output)
+	if span.Start < 0 || span.End <= span.Start || span.End > len(runes) {
+		return nil, fmt.Errorf("%w: anchor must be a span of the evaluation output, 0 to %d", domain.ErrBadRequest, len(runes))
+	}
+	return &domain.CommentAnchor{
+		Start: span.Start,
+		End:   span.End,
+		Quote: string(runes[span.Start:span.End]),
+	}, nil
+}
+
+// summary is the first line of the idea, short enough for a mail.
+func summary(text string) string {
+	text = strings.TrimSpace(strings.SplitN(strings.TrimSpace(text), "\n", 2)[0])
+	if runes := []rune(text); len(runes) > 80 {
+		return strings.TrimSpace(string(runes[:80])) + "…"
+	}
+	return text
+}
+
+func userIds(users []domain.User) []int {
+	ids := []int{}
+	for _, user := range users {
+		ids = append(ids, user.Id)
+	}
+	return ids
+}
+
+// write returns the caller if they may change data, which an API key with
+// read scopes only may not.
+func write(ctx context.Context) (domain.User, error) {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.User{}, domain.ErrUnauthorized
+	}
+	if !user.Can(domain.PermIdeaWriteOwn) {
+		return domain.User{}, domain.ErrForbidden
+	}
+	return user, nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrNotFound
+	}
+	return err
+}
+
+var (
+	uc *usecase
+)
+
+func NewCommentUsecase(cfg config.Config, dbTx pkgDB.DatabaseTransaction, repo domain.CommentRepository, ideas domain.IdeaUsecase, ideaRepo domain.IdeaRepository, workspaces domain.WorkspaceRepository, email domain.EmailUsecase, audit domain.AuditUsecase) domain.CommentUsecase {
+	if uc == nil {
+		uc = &usecase{
+			cfg:        cfg,
+			dbTx:       dbTx,
+			repo:       repo,
+			ideas:      ideas,
+			ideaRepo:   ideaRepo,
+			workspaces: workspaces,
+			email:      email,
+			audit:      audit,
+		}
+	}
+	return uc
+}
@@ -0,0 +1,15 @@
+package email
+
+import (
+	"github.com/Kocannn/self-dunking-ai/config"
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/Kocannn/self-dunking-ai/pkg/mailer"
+)
+
+func InitEmailRepository(db pkgDB.DatabaseTransaction) domain.EmailRepository {
+	return NewEmailRepository(db)
+}
+func InitEmailUsecase(cfg config.Config, dbTx pkgDB.DatabaseTransaction, repo domain.EmailRepository, mailer *mailer.Mailer, jobs domain.JobRepository) domain.EmailUsecase {
+	return NewEmailUsecase(cfg, dbTx, repo, mailer, jobs)
+}
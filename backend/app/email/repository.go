@@ -0,0 +1,73 @@
+package email
+
+import (
+	"context"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	repository struct {
+		db pkgDB.DatabaseTransaction
+	}
+)
+
+// CreateEmail implements domain.EmailRepository.
+func (r *repository) CreateEmail(ctx context.Context, email domain.Email) (domain.Email, error) {
+	err := r.db.DB(ctx).Create(&email).Error
+	if err != nil {
+		logrus.Error("repository.CreateEmail: failed to save email")
+		return domain.Email{}, err
+	}
+	return email, nil
+}
+
+// ClaimEmail implements domain.EmailRepository.
+func (r *repository) ClaimEmail(ctx context.Context, id int) (domain.Email, error) {
+	data := domain.Email{}
+	err := r.db.DB(ctx).Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("id = ? AND status = ?", id, domain.EmailPending).
+		Take(&data).Error
+	if err != nil {
+		return domain.Email{}, err
+	}
+	return data, nil
+}
+
+// MarkEmailSent implements domain.EmailRepository.
+func (r *repository) MarkEmailSent(ctx context.Context, id int) error {
+	return r.db.DB(ctx).Model(&domain.Email{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     domain.EmailSent,
+			"sent_at":    time.Now(),
+			"last_error": "",
+		}).Error
+}
+
+// MarkEmailFailed implements domain.EmailRepository.
+func (r *repository) MarkEmailFailed(ctx context.Context, id int, status string, lastError string) error {
+	return r.db.DB(ctx).Model(&domain.Email{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     status,
+			"last_error": lastError,
+		}).Error
+}
+
+var (
+	repo *repository
+)
+
+func NewEmailRepository(db pkgDB.DatabaseTransaction) domain.EmailRepository {
+	if repo == nil {
+		repo = &repository{
+			db,
+		}
+	}
+	return repo
+}
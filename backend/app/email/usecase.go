@@ -0,0 +1,140 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/config"
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/Kocannn/self-dunking-ai/pkg/mailer"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	sendTimeout = 30 * time.Second
+	// maxAttempts is how often the job queue tries to deliver a mail before
+	// the mail is marked failed
+	maxAttempts = 6
+)
+
+type (
+	usecase struct {
+		cfg  config.Config
+		dbTx pkgDB.DatabaseTransaction
+		repo domain.EmailRepository
+		// mailer is nil when SMTP is not configured; the delivery jobs then
+		// fail until they are dead and can be retried once it is
+		mailer *mailer.Mailer
+		jobs   domain.JobRepository
+	}
+)
+
+// Enqueue implements domain.EmailUsecase.
+func (u *usecase) Enqueue(ctx context.Context, to, template string, data map[string]interface{}) error {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	if _, ok := data["AppName"]; !ok {
+		data["AppName"] = u.cfg.APP_NAME
+	}
+
+	msg, err := mailer.Render(template, data)
+	if err != nil {
+		logrus.Errorf("error rendering email %s: %v", template, err)
+		return err
+	}
+
+	now := time.Now()
+	email, err := u.repo.CreateEmail(ctx, domain.Email{
+		To:        to,
+		Template:  template,
+		Subject:   msg.Subject,
+		TextBody:  msg.Text,
+		HTMLBody:  msg.HTML,
+		Status:    domain.EmailPending,
+		CreatedAt: &now,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the job queue counts the attempts and backs off between them
+	job, err := domain.NewJob(domain.JobEmail, domain.EmailPayload{EmailId: email.Id})
+	if err != nil {
+		return err
+	}
+	job.MaxAttempts = maxAttempts
+	_, err = u.jobs.CreateJob(ctx, job)
+	return err
+}
+
+// RunJob implements domain.EmailUsecase. A mail that was already sent, or
+// that another worker is sending, is left alone.
+func (u *usecase) RunJob(ctx context.Context, job domain.Job) (interface{}, error) {
+	payload := domain.EmailPayload{}
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("%w: invalid payload: %v", domain.ErrBadRequest, err)
+	}
+
+	var sendErr error
+	err := u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		email, err := u.repo.ClaimEmail(txCtx, payload.EmailId)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		sendErr = u.send(ctx, email)
+		if sendErr == nil {
+			return u.repo.MarkEmailSent(txCtx, email.Id)
+		}
+		status := domain.EmailPending
+		if job.Attempts >= job.MaxAttempts {
+			status = domain.EmailFailed
+		}
+		logrus.Errorf("error sending email %d (attempt %d of %d): %v", email.Id, job.Attempts, job.MaxAttempts, sendErr)
+		return u.repo.MarkEmailFailed(txCtx, email.Id, status, sendErr.Error())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nil, sendErr
+}
+
+func (u *usecase) send(ctx context.Context, email domain.Email) error {
+	if u.mailer == nil {
+		return errors.New("SMTP is not configured")
+	}
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	return u.mailer.Send(ctx, mailer.Message{
+		To:      email.To,
+		Subject: email.Subject,
+		Text:    email.TextBody,
+		HTML:    email.HTMLBody,
+	})
+}
+
+var (
+	uc *usecase
+)
+
+func NewEmailUsecase(cfg config.Config, dbTx pkgDB.DatabaseTransaction, repo domain.EmailRepository, mailer *mailer.Mailer, jobs domain.JobRepository) domain.EmailUsecase {
+	if uc == nil {
+		uc = &usecase{
+			cfg:    cfg,
+			dbTx:   dbTx,
+			repo:   repo,
+			mailer: mailer,
+			jobs:   jobs,
+		}
+	}
+	return uc
+}
@@ -0,0 +1,244 @@
+package email
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/config"
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/pkg/mailer"
+	"github.com/Kocannn/self-dunking-ai/pkg/mailer/mailertest"
+	"gorm.io/gorm"
+)
+
+// fakeRepo keeps the outbox in memory.
+type fakeRepo struct {
+	mu     sync.Mutex
+	emails map[int]domain.Email
+}
+
+func (r *fakeRepo) CreateEmail(ctx context.Context, email domain.Email) (domain.Email, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	email.Id = len(r.emails) + 1
+	r.emails[email.Id] = email
+	return email, nil
+}
+
+func (r *fakeRepo) ClaimEmail(ctx context.Context, id int) (domain.Email, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	email, ok := r.emails[id]
+	if !ok || email.Status != domain.EmailPending {
+		return domain.Email{}, gorm.ErrRecordNotFound
+	}
+	return email, nil
+}
+
+func (r *fakeRepo) MarkEmailSent(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	email := r.emails[id]
+	now := time.Now()
+	email.Status, email.SentAt, email.LastError = domain.EmailSent, &now, ""
+	r.emails[id] = email
+	return nil
+}
+
+func (r *fakeRepo) MarkEmailFailed(ctx context.Context, id int, status string, lastError string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	email := r.emails[id]
+	email.Status, email.LastError = status, lastError
+	r.emails[id] = email
+	return nil
+}
+
+func (r *fakeRepo) get(id int) domain.Email {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.emails[id]
+}
+
+// fakeJobs collects the queued jobs.
+type fakeJobs struct {
+	domain.JobRepository
+	jobs []domain.Job
+}
+
+func (j *fakeJobs) CreateJob(ctx context.Context, job domain.Job) (domain.Job, error) {
+	job.Id = len(j.jobs) + 1
+	j.jobs = append(j.jobs, job)
+	return job, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) DB(ctx context.Context) *gorm.DB { return nil }
+
+func (fakeTx) StartTransaction(ctx context.Context, fn func(txCtx context.Context) error) error {
+	return fn(ctx)
+}
+
+func newTestUsecase(t *testing.T) (*usecase, *fakeRepo, *fakeJobs, *mailertest.Sink) {
+	t.Helper()
+	sink, err := mailertest.NewSink()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(sink.Close)
+
+	repo := &fakeRepo{emails: map[int]domain.Email{}}
+	jobs := &fakeJobs{}
+	return &usecase{
+		cfg:    config.Config{APP_NAME: "Inverta"},
+		dbTx:   fakeTx{},
+		repo:   repo,
+		mailer: mailer.New(mailer.Config{Host: sink.Host, Port: sink.Port, From: "noreply@inverta.test"}),
+		jobs:   jobs,
+	}, repo, jobs, sink
+}
+
+func TestEnqueueRendersAndQueuesDelivery(t *testing.T) {
+	u, repo, jobs, sink := newTestUsecase(t)
+
+	err := u.Enqueue(context.Background(), "ada@example.com", mailer.TemplateResetPassword, map[string]interface{}{
+		"Username":  "ada",
+		"Link":      "https://app.example.com/reset-password?token=abc",
+		"ExpiresIn": "1 hour",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	email := repo.get(1)
+	if email.To != "ada@example.com" || email.Status != domain.EmailPending {
+		t.Errorf("stored %+v", email)
+	}
+	if !strings.Contains(email.Subject, "Inverta") || !strings.Contains(email.TextBody, "reset-password?token=abc") {
+		t.Errorf("rendered subject %q, text %q", email.Subject, email.TextBody)
+	}
+	if len(jobs.jobs) != 1 || jobs.jobs[0].Type != domain.JobEmail || jobs.jobs[0].MaxAttempts != maxAttempts {
+		t.Fatalf("queued %+v, want one email job with %d attempts", jobs.jobs, maxAttempts)
+	}
+	if sink.Attempts() != 0 {
+		t.Error("the mail was sent before its job ran")
+	}
+}
+
+func TestEnqueueUnknownTemplate(t *testing.T) {
+	u, repo, jobs, _ := newTestUsecase(t)
+
+	if err := u.Enqueue(context.Background(), "ada@example.com", "missing", nil); err == nil {
+		t.Fatal("Enqueue of an unknown template succeeded")
+	}
+	if len(repo.emails) != 0 || len(jobs.jobs) != 0 {
+		t.Error("a mail that cannot be rendered was queued")
+	}
+}
+
+func TestRunJobDelivers(t *testing.T) {
+	u, repo, jobs, sink := newTestUsecase(t)
+	if err := u.Enqueue(context.Background(), "ada@example.com", mailer.TemplateVerifyEmail, map[string]interface{}{
+		"Username": "ada", "Link": "https://app.example.com/verify-email?token=abc", "ExpiresIn": "24 hours",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	job := jobs.jobs[0]
+	job.Attempts = 1
+	if _, err := u.RunJob(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+	messages := sink.Messages()
+	if len(messages) != 1 || messages[0].To[0] != "ada@example.com" || !strings.Contains(messages[0].Data, "verify-email?token=3Dabc") {
+		t.Fatalf("sink got %+v", messages)
+	}
+	if email := repo.get(1); email.Status != domain.EmailSent || email.SentAt == nil {
+		t.Errorf("email is %s, want sent", email.Status)
+	}
+
+	// a job that runs again after its mail went out does not resend it
+	if _, err := u.RunJob(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+	if sink.Attempts() != 1 {
+		t.Errorf("%d deliveries, want the mail sent once", sink.Attempts())
+	}
+}
+
+func TestRunJobRetriesFailingRelay(t *testing.T) {
+	u, repo, jobs, sink := newTestUsecase(t)
+	if err := u.Enqueue(context.Background(), "ada@example.com", mailer.TemplateVerifyEmail, nil); err != nil {
+		t.Fatal(err)
+	}
+	sink.Reject(maxAttempts)
+
+	job := jobs.jobs[0]
+	for job.Attempts = 1; job.Attempts < job.MaxAttempts; job.Attempts++ {
+		if _, err := u.RunJob(context.Background(), job); err == nil {
+			t.Fatalf("attempt %d succeeded against a rejecting relay", job.Attempts)
+		}
+		// the job queue retries it, so the mail stays pending
+		if email := repo.get(1); email.Status != domain.EmailPending || !strings.Contains(email.LastError, "451") {
+			t.Fatalf("after attempt %d the email is %s (%q)", job.Attempts, email.Status, email.LastError)
+		}
+	}
+
+	if _, err := u.RunJob(context.Background(), job); err == nil {
+		t.Fatal("the last attempt succeeded against a rejecting relay")
+	}
+	if email := repo.get(1); email.Status != domain.EmailFailed {
+		t.Errorf("after the last attempt the email is %s, want failed", email.Status)
+	}
+	if sink.Attempts() != maxAttempts || len(sink.Messages()) != 0 {
+		t.Errorf("%d deliveries tried, %d accepted", sink.Attempts(), len(sink.Messages()))
+	}
+}
+
+func TestRunJobRecoversOnRetry(t *testing.T) {
+	u, repo, jobs, sink := newTestUsecase(t)
+	if err := u.Enqueue(context.Background(), "ada@example.com", mailer.TemplateVerifyEmail, nil); err != nil {
+		t.Fatal(err)
+	}
+	sink.Reject(1)
+
+	job := jobs.jobs[0]
+	job.Attempts = 1
+	if _, err := u.RunJob(context.Background(), job); err == nil {
+		t.Fatal("the first attempt succeeded against a rejecting relay")
+	}
+	job.Attempts = 2
+	if _, err := u.RunJob(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+	if email := repo.get(1); email.Status != domain.EmailSent || email.LastError != "" {
+		t.Errorf("email is %s (%q), want sent", email.Status, email.LastError)
+	}
+	if len(sink.Messages()) != 1 {
+		t.Errorf("sink got %d messages, want 1", len(sink.Messages()))
+	}
+}
+
+func TestRunJobWithoutSMTP(t *testing.T) {
+	u, repo, jobs, _ := newTestUsecase(t)
+	u.mailer = nil
+	if err := u.Enqueue(context.Background(), "ada@example.com", mailer.TemplateVerifyEmail, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs.jobs) != 1 {
+		t.Fatal("no job was queued without SMTP")
+	}
+
+	job := jobs.jobs[0]
+	job.Attempts = 1
+	if _, err := u.RunJob(context.Background(), job); err == nil {
+		t.Fatal("a mail was delivered without SMTP")
+	}
+	if email := repo.get(1); email.Status != domain.EmailPending {
+		t.Errorf("email is %s, want it kept pending", email.Status)
+	}
+}
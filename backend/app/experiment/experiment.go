@@ -0,0 +1,16 @@
+package experiment
+
+import (
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+)
+
+func InitExperimentRepository(db pkgDB.DatabaseTransaction) domain.ExperimentRepository {
+	return NewExperimentRepository(db)
+}
+func InitExperimentUsecase(dbTx pkgDB.DatabaseTransaction, repo domain.ExperimentRepository, promptRepo domain.PromptRepository, audit domain.AuditUsecase) domain.ExperimentUsecase {
+	return NewExperimentUsecase(dbTx, repo, promptRepo, audit)
+}
+func InitExperimentHandler(usecase domain.ExperimentUsecase) domain.ExperimentHandler {
+	return NewExperimentHandler(usecase)
+}
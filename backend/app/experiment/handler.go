@@ -0,0 +1,188 @@
+package experiment
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	handler struct {
+		usecase domain.ExperimentUsecase
+	}
+)
+
+// ListExperiments implements domain.ExperimentHandler.
+func (h *handler) ListExperiments(w http.ResponseWriter, r *http.Request) {
+	data, err := h.usecase.ListExperiments(r.Context())
+	if err != nil {
+		logrus.Errorf("error listing experiments: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error listing experiments",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Experiments retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// GetExperiment implements domain.ExperimentHandler.
+func (h *handler) GetExperiment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.GetExperiment(r.Context(), id)
+	if err != nil {
+		logrus.Errorf("error getting experiment: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: "Error retrieving experiment",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Experiment retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// CreateExperiment implements domain.ExperimentHandler.
+func (h *handler) CreateExperiment(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logrus.Errorf("error reading request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error reading request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	dataBuffer := domain.Experiment{}
+	if err := json.Unmarshal(bodyBytes, &dataBuffer); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.CreateExperiment(r.Context(), dataBuffer)
+	if err != nil {
+		utils.RespondError(w, "error creating experiment", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusCreated,
+		Message: "Experiment created successfully",
+		Data:    data,
+	}, w)
+}
+
+// UpdateExperimentStatus implements domain.ExperimentHandler.
+func (h *handler) UpdateExperimentStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	dataBuffer := domain.UpdateExperimentStatusRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&dataBuffer); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.UpdateExperimentStatus(r.Context(), id, dataBuffer.Status)
+	if err != nil {
+		utils.RespondError(w, "error updating experiment status", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Experiment updated successfully",
+		Data:    data,
+	}, w)
+}
+
+// GetExperimentMetrics implements domain.ExperimentHandler.
+func (h *handler) GetExperimentMetrics(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.GetExperimentMetrics(r.Context(), id)
+	if err != nil {
+		logrus.Errorf("error getting experiment metrics: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: "Error retrieving experiment metrics",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Experiment metrics retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+var (
+	handlr *handler
+)
+
+func NewExperimentHandler(usecase domain.ExperimentUsecase) domain.ExperimentHandler {
+	if handlr == nil {
+		handlr = &handler{
+			usecase,
+		}
+	}
+	return handlr
+}
@@ -0,0 +1,121 @@
+package experiment
+
+import (
+	"context"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	repository struct {
+		db pkgDB.DatabaseTransaction
+	}
+)
+
+// ListExperiments implements domain.ExperimentRepository.
+func (r *repository) ListExperiments(ctx context.Context) ([]domain.Experiment, error) {
+	data := []domain.Experiment{}
+	err := r.db.DB(ctx).Preload("Variants", variantOrder).Order("id desc").Find(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetExperiment implements domain.ExperimentRepository.
+func (r *repository) GetExperiment(ctx context.Context, id int) (domain.Experiment, error) {
+	data := domain.Experiment{}
+	err := r.db.DB(ctx).Preload("Variants", variantOrder).First(&data, id).Error
+	if err != nil {
+		return domain.Experiment{}, err
+	}
+	return data, nil
+}
+
+// GetRunningExperiment implements domain.ExperimentRepository.
+func (r *repository) GetRunningExperiment(ctx context.Context, promptName string) (domain.Experiment, error) {
+	data := domain.Experiment{}
+	err := r.db.DB(ctx).Preload("Variants", variantOrder).
+		Where("prompt_name = ? AND status = ?", promptName, domain.ExperimentRunning).
+		First(&data).Error
+	if err != nil {
+		return domain.Experiment{}, err
+	}
+	return data, nil
+}
+
+// LockPromptExperiments implements domain.ExperimentRepository.
+func (r *repository) LockPromptExperiments(ctx context.Context, promptName string) error {
+	var ids []int
+	return r.db.DB(ctx).Model(&domain.Experiment{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("prompt_name = ?", promptName).
+		Pluck("id", &ids).Error
+}
+
+// CreateExperiment implements domain.ExperimentRepository. Variants are
+// inserted together with the experiment.
+func (r *repository) CreateExperiment(ctx context.Context, experiment domain.Experiment) (domain.Experiment, error) {
+	err := r.db.DB(ctx).Create(&experiment).Error
+	if err != nil {
+		logrus.Error("repository.CreateExperiment: failed to save experiment")
+		return domain.Experiment{}, err
+	}
+	return experiment, nil
+}
+
+// UpdateExperimentStatus implements domain.ExperimentRepository.
+func (r *repository) UpdateExperimentStatus(ctx context.Context, id int, status string) error {
+	return r.db.DB(ctx).Model(&domain.Experiment{}).
+		Where("id = ?", id).
+		Update("status", status).Error
+}
+
+// GetExperimentMetrics implements domain.ExperimentRepository.
+func (r *repository) GetExperimentMetrics(ctx context.Context, id int) ([]domain.VariantMetrics, error) {
+	data := []domain.VariantMetrics{}
+	err := r.db.DB(ctx).Table("experiment_variants v").
+		Select(`v.id AS variant_id,
+			v.label,
+			v.prompt_version,
+			COUNT(e.id) AS evaluations,
+			COALESCE(AVG(NULLIF(e.score_originality, 0)), 0) AS avg_originality,
+			COALESCE(AVG(NULLIF(e.score_scalability, 0)), 0) AS avg_scalability,
+			COALESCE(AVG(NULLIF(e.score_feasibility, 0)), 0) AS avg_feasibility,
+			COALESCE(AVG(e.overall_score), 0) AS avg_overall,
+			COALESCE(AVG(CASE WHEN e.parse_failed THEN 1.0 ELSE 0.0 END), 0) AS parse_failure_rate,
+			COALESCE(AVG(e.latency_ms), 0) AS avg_latency_ms,
+			COUNT(e.thumbs_up) AS ratings,
+			COALESCE(AVG(CASE WHEN e.thumbs_up THEN 1.0 WHEN e.thumbs_up IS NOT NULL THEN 0.0 END), 0) AS thumbs_up_rate`).
+		Joins("LEFT JOIN evaluations e ON e.variant_id = v.id AND e.experiment_id = v.experiment_id").
+		Where("v.experiment_id = ?", id).
+		Group("v.id, v.label, v.prompt_version").
+		Order("v.id").
+		Scan(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// variantOrder loads variants in creation order, which Assign walks to
+// bucket subjects; any other order would move subjects between variants.
+func variantOrder(db *gorm.DB) *gorm.DB {
+	return db.Order("id asc")
+}
+
+var (
+	repo *repository
+)
+
+func NewExperimentRepository(db pkgDB.DatabaseTransaction) domain.ExperimentRepository {
+	if repo == nil {
+		repo = &repository{
+			db,
+		}
+	}
+	return repo
+}
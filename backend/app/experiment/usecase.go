@@ -0,0 +1,209 @@
+package experiment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type (
+	usecase struct {
+		dbTx       pkgDB.DatabaseTransaction
+		repo       domain.ExperimentRepository
+		promptRepo domain.PromptRepository
+		audit      domain.AuditUsecase
+	}
+)
+
+// ListExperiments implements domain.ExperimentUsecase.
+func (u *usecase) ListExperiments(ctx context.Context) ([]domain.Experiment, error) {
+	return u.repo.ListExperiments(ctx)
+}
+
+// GetExperiment implements domain.ExperimentUsecase.
+func (u *usecase) GetExperiment(ctx context.Context, id int) (domain.Experiment, error) {
+	data, err := u.repo.GetExperiment(ctx, id)
+	if err != nil {
+		return domain.Experiment{}, notFound(err)
+	}
+	return data, nil
+}
+
+// CreateExperiment implements domain.ExperimentUsecase.
+func (u *usecase) CreateExperiment(ctx context.Context, experiment domain.Experiment) (domain.Experiment, error) {
+	experiment.Name = strings.TrimSpace(experiment.Name)
+	if experiment.Name == "" || experiment.PromptName == "" {
+		return domain.Experiment{}, fmt.Errorf("%w: name and prompt_name are required", domain.ErrBadRequest)
+	}
+	if len(experiment.Variants) < 2 {
+		return domain.Experiment{}, fmt.Errorf("%w: an experiment needs at least two variants", domain.ErrBadRequest)
+	}
+
+	for i, variant := range experiment.Variants {
+		if variant.Weight <= 0 {
+			return domain.Experiment{}, fmt.Errorf("%w: variant weights must be positive", domain.ErrBadRequest)
+		}
+		if _, err := u.promptRepo.GetPromptVersion(ctx, experiment.PromptName, variant.PromptVersion); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.Experiment{}, fmt.Errorf("%w: %s v%d does not exist", domain.ErrBadRequest, experiment.PromptName, variant.PromptVersion)
+			}
+			return domain.Experiment{}, err
+		}
+		if variant.Label == "" {
+			experiment.Variants[i].Label = fmt.Sprintf("v%d", variant.PromptVersion)
+		}
+		experiment.Variants[i].Id = 0
+	}
+
+	now := time.Now()
+	experiment.Id = 0
+	experiment.Status = domain.ExperimentDraft
+	experiment.CreatedAt = &now
+
+	var created domain.Experiment
+	err := u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		created, err = u.repo.CreateExperiment(txCtx, experiment)
+		if err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditExperimentCreate,
+			TargetType: "experiment",
+			TargetId:   strconv.Itoa(created.Id),
+			After:      created,
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error creating experiment: %v", err)
+		return domain.Experiment{}, err
+	}
+	return created, nil
+}
+
+// UpdateExperimentStatus implements domain.ExperimentUsecase. Only one
+// experiment per prompt may run at a time; starting one locks the prompt's
+// experiments so two concurrent starts cannot both pass the check.
+func (u *usecase) UpdateExperimentStatus(ctx context.Context, id int, status string) (domain.Experiment, error) {
+	if status != domain.ExperimentRunning && status != domain.ExperimentStopped {
+		return domain.Experiment{}, fmt.Errorf("%w: status must be %q or %q", domain.ErrBadRequest, domain.ExperimentRunning, domain.ExperimentStopped)
+	}
+
+	var updated domain.Experiment
+	err := u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		data, err := u.repo.GetExperiment(txCtx, id)
+		if err != nil {
+			return notFound(err)
+		}
+
+		if status == domain.ExperimentRunning {
+			if err := u.repo.LockPromptExperiments(txCtx, data.PromptName); err != nil {
+				return err
+			}
+			running, err := u.repo.GetRunningExperiment(txCtx, data.PromptName)
+			if err == nil && running.Id != data.Id {
+				return fmt.Errorf("%w: experiment %d is already running for %q", domain.ErrConflict, running.Id, data.PromptName)
+			}
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+
+		if err := u.repo.UpdateExperimentStatus(txCtx, id, status); err != nil {
+			return err
+		}
+		before := data
+		data.Status = status
+		updated = data
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditExperimentStatusUpdate,
+			TargetType: "experiment",
+			TargetId:   strconv.Itoa(id),
+			Before:     before,
+			After:      data,
+		})
+	})
+	if err != nil {
+		return domain.Experiment{}, err
+	}
+	return updated, nil
+}
+
+// GetExperimentMetrics implements domain.ExperimentUsecase.
+func (u *usecase) GetExperimentMetrics(ctx context.Context, id int) ([]domain.VariantMetrics, error) {
+	if _, err := u.repo.GetExperiment(ctx, id); err != nil {
+		return nil, notFound(err)
+	}
+	return u.repo.GetExperimentMetrics(ctx, id)
+}
+
+// Assign implements domain.ExperimentUsecase. The bucket is derived from a
+// hash of the experiment and subject so a caller keeps seeing the same
+// variant for as long as the weights stay the same.
+func (u *usecase) Assign(ctx context.Context, promptName, subject string) (*domain.ExperimentVariant, error) {
+	experiment, err := u.repo.GetRunningExperiment(ctx, promptName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	total := 0
+	for _, variant := range experiment.Variants {
+		total += variant.Weight
+	}
+	if total == 0 {
+		return nil, nil
+	}
+
+	var bucket int
+	if subject == "" {
+		bucket = rand.Intn(total)
+	} else {
+		h := fnv.New32a()
+		fmt.Fprintf(h, "%d:%s", experiment.Id, subject)
+		bucket = int(h.Sum32() % uint32(total))
+	}
+
+	for i := range experiment.Variants {
+		bucket -= experiment.Variants[i].Weight
+		if bucket < 0 {
+			return &experiment.Variants[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrNotFound
+	}
+	return err
+}
+
+var (
+	uc *usecase
+)
+
+func NewExperimentUsecase(dbTx pkgDB.DatabaseTransaction, repo domain.ExperimentRepository, promptRepo domain.PromptRepository, audit domain.AuditUsecase) domain.ExperimentUsecase {
+	if uc == nil {
+		uc = &usecase{
+			dbTx:       dbTx,
+			repo:       repo,
+			promptRepo: promptRepo,
+			audit:      audit,
+		}
+	}
+	return uc
+}
@@ -0,0 +1,287 @@
+package idea
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/sirupsen/logrus"
+)
+
+// CompareIdeas implements domain.IdeaUsecase. Up to MaxRankedIdeas ideas are
+// ranked on every dimension by one prompt; larger sets, or the tournament
+// mode, play a round robin of pairwise matches ranked by Elo rating.
+func (u *usecase) CompareIdeas(ctx context.Context, req domain.CompareRequest) (domain.Comparison, error) {
+	mode := req.Mode
+	if mode == "" {
+		mode = domain.CompareModeRanked
+		if len(req.IdeaIds) > domain.MaxRankedIdeas {
+			mode = domain.CompareModeTournament
+		}
+	}
+	maxIdeas := domain.MaxRankedIdeas
+	switch mode {
+	case domain.CompareModeRanked:
+	case domain.CompareModeTournament:
+		maxIdeas = domain.MaxTournamentIdeas
+	default:
+		return domain.Comparison{}, fmt.Errorf("%w: mode must be %s or %s", domain.ErrBadRequest, domain.CompareModeRanked, domain.CompareModeTournament)
+	}
+	if len(req.IdeaIds) < domain.MinCompareIdeas || len(req.IdeaIds) > maxIdeas {
+		return domain.Comparison{}, fmt.Errorf("%w: a %s comparison needs %d to %d ideas", domain.ErrBadRequest, mode, domain.MinCompareIdeas, maxIdeas)
+	}
+
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.Comparison{}, domain.ErrUnauthorized
+	}
+	seen := map[int]bool{}
+	ideas := make([]domain.SubmitIdeaRequest, 0, len(req.IdeaIds))
+	for _, id := range req.IdeaIds {
+		if id <= 0 || seen[id] {
+			return domain.Comparison{}, fmt.Errorf("%w: idea ids must be distinct", domain.ErrBadRequest)
+		}
+		seen[id] = true
+		idea, err := u.repo.GetIdea(ctx, id)
+		if err != nil {
+			return domain.Comparison{}, notFound(err)
+		}
+		if err := authorize(ctx, idea.UserId, domain.PermIdeaReadOwn, domain.PermIdeaReadAny); err != nil {
+			return domain.Comparison{}, err
+		}
+		ideas = append(ideas, idea)
+	}
+	rubric, err := u.rubric(ctx, req.RubricId)
+	if err != nil {
+		return domain.Comparison{}, err
+	}
+	// a tournament plays every pair once
+	calls := 1
+	if mode == domain.CompareModeTournament {
+		calls = len(ideas) * (len(ideas) - 1) / 2
+	}
+	if err := u.checkQuota(ctx, calls); err != nil {
+		return domain.Comparison{}, err
+	}
+
+	comparison := domain.Comparison{
+		UserId:  user.Id,
+		Mode:    mode,
+		IdeaIds: req.IdeaIds,
+	}
+	if rubric != nil {
+		comparison.RubricId = rubric.Id
+	}
+	if mode == domain.CompareModeRanked {
+		err = u.rankIdeas(ctx, &comparison, ideas, rubric)
+	} else {
+		err = u.playTournament(ctx, &comparison, ideas, rubric)
+	}
+	if err != nil {
+		return domain.Comparison{}, err
+	}
+
+	now := time.Now()
+	comparison.CreatedAt = &now
+	saved, err := u.repo.CreateComparison(ctx, comparison)
+	if err != nil {
+		logrus.Errorf("error saving comparison: %v", err)
+		return domain.Comparison{}, err
+	}
+	return saved, nil
+}
+
+// GetComparison implements domain.IdeaUsecase.
+func (u *usecase) GetComparison(ctx context.Context, id int) (domain.Comparison, error) {
+	comparison, err := u.repo.GetComparison(ctx, id)
+	if err != nil {
+		return domain.Comparison{}, notFound(err)
+	}
+	if err := authorize(ctx, comparison.UserId, domain.PermIdeaReadOwn, domain.PermIdeaReadAny); err != nil {
+		return domain.Comparison{}, err
+	}
+	return comparison, nil
+}
+
+// rankIdeas asks for a ranked table per dimension in a single prompt.
+func (u *usecase) rankIdeas(ctx context.Context, comparison *domain.Comparison, ideas []domain.SubmitIdeaRequest, rubric *domain.Rubric) error {
+	candidates := toCandidates(ideas)
+	labels := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		labels[i] = candidate.Label
+	}
+
+	messages, evaluation, err := u.complete(ctx, domain.PromptCompare, 0, 0,
+		domain.PromptData{Candidates: candidates, Rubric: rubric},
+		fmt.Sprintf("Compare ideas %s.", strings.Join(labels, ", ")),
+		"I'm sorry, I couldn't compare the ideas at this time.")
+	if err != nil {
+		return err
+	}
+	output := messages[len(messages)-1].Content
+
+	dimensions := compareDimensions(rubric)
+	comparison.EvaluationId = evaluation.Id
+	comparison.Dimensions, comparison.Summary = parseRanking(output, dimensions, candidates, ideas)
+	comparison.ParseFailed = len(comparison.Dimensions) == 0
+	comparison.Standings = rankStandings(ideas, dimensions, comparison.Dimensions)
+	return nil
+}
+
+// playTournament plays every pair once and updates the Elo ratings after
+// each match.
+func (u *usecase) playTournament(ctx context.Context, comparison *domain.Comparison, ideas []domain.SubmitIdeaRequest, rubric *domain.Rubric) error {
+	standings := make([]domain.Standing, len(ideas))
+	index := map[int]int{}
+	for i, idea := range ideas {
+		standings[i] = domain.Standing{IdeaId: idea.Id, Score: domain.EloInitialRating}
+		index[idea.Id] = i
+	}
+
+	for i := range ideas {
+		for j := i + 1; j < len(ideas); j++ {
+			first, second := ideas[i], ideas[j]
+			// alternate which idea is shown first to even out position bias
+			if (i+j)%2 == 1 {
+				first, second = second, first
+			}
+			match, err := u.playMatch(ctx, first, second, rubric)
+			if err != nil {
+				return err
+			}
+			comparison.Matches = append(comparison.Matches, match)
+			updateElo(&standings[index[match.IdeaA]], &standings[index[match.IdeaB]], match.Winner)
+		}
+	}
+
+	for i := range standings {
+		standings[i].Score = math.Round(standings[i].Score*10) / 10
+	}
+	sort.SliceStable(standings, func(i, j int) bool {
+		return standings[i].Score > standings[j].Score
+	})
+	assignRanks(standings)
+	comparison.Standings = standings
+	return nil
+}
+
+func (u *usecase) playMatch(ctx context.Context, first, second domain.SubmitIdeaRequest, rubric *domain.Rubric) (domain.ComparisonMatch, error) {
+	messages, evaluation, err := u.complete(ctx, domain.PromptMatch, 0, 0,
+		domain.PromptData{Candidates: toCandidates([]domain.SubmitIdeaRequest{first, second}), Rubric: rubric},
+		"Idea A or idea B?",
+		"I'm sorry, I couldn't judge the ideas at this time.")
+	if err != nil {
+		return domain.ComparisonMatch{}, err
+	}
+
+	winner, reason := parseMatch(messages[len(messages)-1].Content)
+	match := domain.ComparisonMatch{
+		IdeaA:        first.Id,
+		IdeaB:        second.Id,
+		Reason:       reason,
+		EvaluationId: evaluation.Id,
+	}
+	switch winner {
+	case "A":
+		match.Winner = first.Id
+	case "B":
+		match.Winner = second.Id
+	}
+	return match, nil
+}
+
+// updateElo applies one result, winner being the id of a or b or 0 for a
+// draw.
+func updateElo(a, b *domain.Standing, winner int) {
+	expected := 1 / (1 + math.Pow(10, (b.Score-a.Score)/400))
+	actual := 0.5
+	switch winner {
+	case a.IdeaId:
+		actual = 1
+		a.Wins++
+		b.Losses++
+	case b.IdeaId:
+		actual = 0
+		a.Losses++
+		b.Wins++
+	default:
+		a.Draws++
+		b.Draws++
+	}
+	a.Score += domain.EloK * (actual - expected)
+	b.Score -= domain.EloK * (actual - expected)
+}
+
+// rankStandings orders the ideas by their weighted mean rank. An idea the
+// model left out of a dimension counts as ranked last there.
+func rankStandings(ideas []domain.SubmitIdeaRequest, dimensions []domain.RubricDimension, rankings []domain.DimensionRanking) []domain.Standing {
+	ranks := map[string]map[int]int{}
+	for _, ranking := range rankings {
+		ranks[ranking.Name] = map[int]int{}
+		for _, ranked := range ranking.Ranking {
+			ranks[ranking.Name][ranked.IdeaId] = ranked.Rank
+		}
+	}
+
+	standings := make([]domain.Standing, len(ideas))
+	for i, idea := range ideas {
+		var sum, weights float64
+		for _, dimension := range dimensions {
+			rank, ok := ranks[dimension.Name][idea.Id]
+			if !ok {
+				rank = len(ideas)
+			}
+			sum += dimension.Weight * float64(rank)
+			weights += dimension.Weight
+		}
+		standings[i] = domain.Standing{IdeaId: idea.Id}
+		if weights > 0 {
+			standings[i].Score = math.Round(sum/weights*100) / 100
+		}
+	}
+	sort.SliceStable(standings, func(i, j int) bool {
+		return standings[i].Score < standings[j].Score
+	})
+	assignRanks(standings)
+	return standings
+}
+
+// assignRanks numbers sorted standings, equal scores sharing a rank.
+func assignRanks(standings []domain.Standing) {
+	for i := range standings {
+		standings[i].Rank = i + 1
+		if i > 0 && standings[i].Score == standings[i-1].Score {
+			standings[i].Rank = standings[i-1].Rank
+		}
+	}
+}
+
+// compareDimensions are the rubric dimensions, or the built-in ones with
+// equal weight.
+func compareDimensions(rubric *domain.Rubric) []domain.RubricDimension {
+	if rubric != nil {
+		return rubric.Dimensions
+	}
+	dimensions := make([]domain.RubricDimension, len(domain.CompareDimensions))
+	for i, name := range domain.CompareDimensions {
+		dimensions[i] = domain.RubricDimension{Name: name, Weight: 1}
+	}
+	return dimensions
+}
+
+// toCandidates labels the ideas A, B, C, ... in the given order.
+func toCandidates(ideas []domain.SubmitIdeaRequest) []domain.Candidate {
+	candidates := make([]domain.Candidate, len(ideas))
+	for i, idea := range ideas {
+		candidates[i] = domain.Candidate{
+			Label:  string(rune('A' + i)),
+			Idea:   idea.Idea,
+			Fields: idea.IdeaFields,
+		}
+	}
+	return candidates
+}
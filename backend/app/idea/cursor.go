@@ -0,0 +1,46 @@
+package idea
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// encodeCursor points after idea in a listing sorted by sort.
+func encodeCursor(sort string, idea domain.SubmitIdeaRequest) string {
+	cursor := domain.IdeaCursor{Id: idea.Id}
+	switch sort {
+	case domain.IdeaSortScore, domain.IdeaSortScoreDesc:
+		score := -1.0
+		if idea.Score != nil {
+			score = *idea.Score
+		}
+		cursor.Value = strconv.FormatFloat(score, 'g', -1, 64)
+	default:
+		if idea.CreatedAt != nil {
+			cursor.Value = idea.CreatedAt.UTC().Format(time.RFC3339Nano)
+		}
+	}
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(value string) (domain.IdeaCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return domain.IdeaCursor{}, err
+	}
+	cursor := domain.IdeaCursor{}
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return domain.IdeaCursor{}, err
+	}
+	return cursor, nil
+}
@@ -0,0 +1,81 @@
+package idea
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+)
+
+const (
+	maxIdeaLength  = 5000
+	maxFieldLength = 2000
+)
+
+// normalizeSubmission trims the form and validates it. A structured
+// submission needs a problem and a solution; the free text idea is derived
+// from the fields when it is missing so listings keep something readable.
+func normalizeSubmission(text string, fields domain.IdeaFields) (string, domain.IdeaFields, error) {
+	text = strings.TrimSpace(text)
+	fields = domain.IdeaFields{
+		Problem:      strings.TrimSpace(fields.Problem),
+		Solution:     strings.TrimSpace(fields.Solution),
+		Customer:     strings.TrimSpace(fields.Customer),
+		RevenueModel: strings.TrimSpace(fields.RevenueModel),
+		Competitors:  strings.TrimSpace(fields.Competitors),
+		Stage:        strings.ToLower(strings.TrimSpace(fields.Stage)),
+		Constraints:  strings.TrimSpace(fields.Constraints),
+	}
+
+	if len(text) > maxIdeaLength {
+		return "", domain.IdeaFields{}, fmt.Errorf("%w: idea must be at most %d characters", domain.ErrBadRequest, maxIdeaLength)
+	}
+	if !fields.Structured() {
+		if text == "" {
+			return "", domain.IdeaFields{}, fmt.Errorf("%w: idea or problem and solution are required", domain.ErrBadRequest)
+		}
+		return text, fields, nil
+	}
+
+	if fields.Problem == "" {
+		return "", domain.IdeaFields{}, fmt.Errorf("%w: problem is required", domain.ErrBadRequest)
+	}
+	if fields.Solution == "" {
+		return "", domain.IdeaFields{}, fmt.Errorf("%w: solution is required", domain.ErrBadRequest)
+	}
+	for name, value := range map[string]string{
+		"problem":       fields.Problem,
+		"solution":      fields.Solution,
+		"customer":      fields.Customer,
+		"revenue_model": fields.RevenueModel,
+		"competitors":   fields.Competitors,
+		"constraints":   fields.Constraints,
+	} {
+		if len(value) > maxFieldLength {
+			return "", domain.IdeaFields{}, fmt.Errorf("%w: %s must be at most %d characters", domain.ErrBadRequest, name, maxFieldLength)
+		}
+	}
+	if fields.Stage != "" && !isStage(fields.Stage) {
+		return "", domain.IdeaFields{}, fmt.Errorf("%w: stage must be one of %s", domain.ErrBadRequest, strings.Join(domain.IdeaStages, ", "))
+	}
+
+	if text == "" {
+		text = summarize(fields)
+	}
+	return text, fields, nil
+}
+
+// summarize is the free text stored for a structured idea submitted without
+// one.
+func summarize(fields domain.IdeaFields) string {
+	return fmt.Sprintf("Problem: %s\nSolution: %s", fields.Problem, fields.Solution)
+}
+
+func isStage(stage string) bool {
+	for _, s := range domain.IdeaStages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
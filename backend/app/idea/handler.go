@@ -2,6 +2,8 @@ package idea
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
@@ -36,6 +38,15 @@ func (h *handler) GetIdea(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data, err := h.usecase.GetIdea(r.Context(), idInt)
+	if err != nil {
+		logrus.Errorf("error getting idea: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: "Error retrieving idea",
+			Data:    nil,
+		}, w)
+		return
+	}
 
 	utils.Response(domain.HttpResponse{
 		Code:    http.StatusOK,
@@ -72,18 +83,23 @@ func (h *handler) SubmitIdeaStream(w http.ResponseWriter, r *http.Request) {
 
 	logrus.Infof("Received idea submission: %s", dataBuffer.Idea)
 
+	user, _ := domain.UserFromContext(r.Context())
+
 	now := time.Now()
 	submitRequest := domain.SubmitIdeaRequest{
-		Idea:      dataBuffer.Idea,
-		CreatedAt: &now,
+		UserId:     user.Id,
+		Idea:       dataBuffer.Idea,
+		IdeaFields: dataBuffer.IdeaFields,
+		AutoTag:    dataBuffer.AutoTag,
+		CreatedAt:  &now,
 	}
 
 	createdIdea, err := h.usecase.SubmitIdeaStream(r.Context(), submitRequest)
 	if err != nil {
 		logrus.Errorf("error saving idea stream: %v", err)
 		utils.Response(domain.HttpResponse{
-			Code:    http.StatusInternalServerError,
-			Message: "Error saving idea",
+			Code:    utils.ErrorStatus(err),
+			Message: errorMessage(err, "Error saving idea"),
 			Data:    nil,
 		}, w)
 		return
@@ -120,11 +136,11 @@ func (h *handler) DefendIdea(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	messages, err := h.usecase.DefendIdea(r.Context(), dataBuffer.Critique)
+	messages, err := h.usecase.DefendIdea(r.Context(), dataBuffer)
 	if err != nil {
 		logrus.Errorf("error submitting idea: %v", err)
 		utils.Response(domain.HttpResponse{
-			Code:    http.StatusInternalServerError,
+			Code:    utils.ErrorStatus(err),
 			Message: "Error processing idea",
 			Data:    nil,
 		}, w)
@@ -169,11 +185,11 @@ func (h *handler) ImproveIdea(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	messages, err := h.usecase.DefendIdea(r.Context(), dataBuffer.Critique)
+	messages, err := h.usecase.ImproveIdea(r.Context(), dataBuffer)
 	if err != nil {
 		logrus.Errorf("error submitting idea: %v", err)
 		utils.Response(domain.HttpResponse{
-			Code:    http.StatusInternalServerError,
+			Code:    utils.ErrorStatus(err),
 			Message: "Error processing idea",
 			Data:    nil,
 		}, w)
@@ -218,12 +234,12 @@ func (h *handler) SubmitIdea(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	messages, err := h.usecase.SubmitIdea(r.Context(), dataBuffer.Text)
+	messages, err := h.usecase.SubmitIdea(r.Context(), dataBuffer)
 	if err != nil {
 		logrus.Errorf("error submitting idea: %v", err)
 		utils.Response(domain.HttpResponse{
-			Code:    http.StatusInternalServerError,
-			Message: "Error processing idea",
+			Code:    utils.ErrorStatus(err),
+			Message: errorMessage(err, "Error processing idea"),
 			Data:    nil,
 		}, w)
 		return
@@ -260,102 +276,622 @@ func (h *handler) StreamSubmitIdea(w http.ResponseWriter, r *http.Request) {
 	idInt, err := strconv.Atoi(id)
 	if err != nil {
 		logrus.Errorf("error parsing ID: %v", err)
-		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		utils.Error(w, "Invalid ID format", http.StatusBadRequest)
 		return
 	}
 
-	idea, err := h.usecase.GetIdea(r.Context(), idInt)
+	promptVersion, _ := strconv.Atoi(r.URL.Query().Get("prompt_version"))
+	rubricId := 0
+	if value := r.URL.Query().Get("rubric_id"); value != "" {
+		if rubricId, err = strconv.Atoi(value); err != nil || rubricId <= 0 {
+			utils.Error(w, "Invalid rubric_id", http.StatusBadRequest)
+			return
+		}
+	}
+
+	started := false
+	err = h.usecase.StreamSubmitIdea(r.Context(), idInt, promptVersion, rubricId, func(model string, messages []*domain.Message) (string, error) {
+		started = true
+		// Use streaming response
+		return ollama.StreamPrompt(w, model, messages)
+	})
 	if err != nil {
-		logrus.Errorf("error getting idea: %v", err)
-		http.Error(w, "Error retrieving idea", http.StatusInternalServerError)
+		logrus.Errorf("error streaming idea: %v", err)
+		if !started {
+			utils.Error(w, "Error retrieving idea", utils.ErrorStatus(err))
+		}
 		return
 	}
+}
 
-	promptSystem := &domain.Message{
-		Role:    "system",
-		Content: domain.PROMPT_CRITIC,
+func (h *handler) StreamDefendIdea(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logrus.Errorf("error reading request body: %v", err)
+		utils.Error(w, "Error reading request body", http.StatusBadRequest)
+		return
 	}
 
-	promptUser := &domain.Message{
-		Role:    "user",
-		Content: idea.Idea,
+	dataBuffer := domain.Idea{}
+	if err := json.Unmarshal(bodyBytes, &dataBuffer); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
 	}
 
-	messages := []*domain.Message{promptSystem, promptUser}
-
-	// Use streaming response
-	if err := ollama.StreamPrompt(w, messages); err != nil {
+	started := false
+	err = h.usecase.StreamDefendIdea(r.Context(), dataBuffer, func(model string, messages []*domain.Message) (string, error) {
+		started = true
+		// Use streaming response
+		return ollama.StreamPrompt(w, model, messages)
+	})
+	if err != nil {
 		logrus.Errorf("error streaming idea: %v", err)
+		if !started {
+			utils.Error(w, "Error processing idea", utils.ErrorStatus(err))
+		}
 		return
 	}
 }
 
-func (h *handler) StreamDefendIdea(w http.ResponseWriter, r *http.Request) {
+func (h *handler) StreamImproveIdea(w http.ResponseWriter, r *http.Request) {
 	bodyBytes, err := io.ReadAll(r.Body)
 	if err != nil {
 		logrus.Errorf("error reading request body: %v", err)
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		utils.Error(w, "Error reading request body", http.StatusBadRequest)
 		return
 	}
 
 	dataBuffer := domain.Idea{}
 	if err := json.Unmarshal(bodyBytes, &dataBuffer); err != nil {
 		logrus.Errorf("error unmarshalling request body: %v", err)
-		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		utils.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	started := false
+	err = h.usecase.StreamImproveIdea(r.Context(), dataBuffer, func(model string, messages []*domain.Message) (string, error) {
+		started = true
+		// Use streaming response
+		return ollama.StreamPrompt(w, model, messages)
+	})
+	if err != nil {
+		logrus.Errorf("error streaming idea: %v", err)
+		if !started {
+			utils.Error(w, "Error processing idea", utils.ErrorStatus(err))
+		}
+		return
+	}
+}
+
+// RateEvaluation implements domain.IdeaHandler.
+func (h *handler) RateEvaluation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
 		return
 	}
 
-	promptSystem := &domain.Message{
-		Role:    "system",
-		Content: domain.PROMPT_DEFEND,
+	dataBuffer := domain.EvaluationFeedbackRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&dataBuffer); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return
 	}
 
-	promptUser := &domain.Message{
-		Role:    "user",
-		Content: dataBuffer.Critique,
+	data, err := h.usecase.RateEvaluation(r.Context(), id, dataBuffer.ThumbsUp)
+	if err != nil {
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: "Error rating evaluation",
+			Data:    nil,
+		}, w)
+		return
 	}
 
-	messages := []*domain.Message{promptSystem, promptUser}
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Evaluation rated successfully",
+		Data:    data,
+	}, w)
+}
 
-	// Use streaming response
-	if err := ollama.StreamPrompt(w, messages); err != nil {
-		logrus.Errorf("error streaming idea: %v", err)
+// ListIdeas implements domain.IdeaHandler. Query parameters: sort, cursor,
+// limit, owner_id, status, min_score, max_score and deleted.
+func (h *handler) ListIdeas(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := domain.IdeaFilter{
+		Status:  query.Get("status"),
+		Tag:     query.Get("tag"),
+		Sort:    query.Get("sort"),
+		Deleted: query.Get("deleted") == "true",
+	}
+
+	for name, dst := range map[string]*int{"owner_id": &filter.OwnerId, "limit": &filter.Limit} {
+		if value := query.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				utils.Response(domain.HttpResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Invalid " + name,
+					Data:    nil,
+				}, w)
+				return
+			}
+			*dst = n
+		}
+	}
+	for name, dst := range map[string]**float64{"min_score": &filter.MinScore, "max_score": &filter.MaxScore} {
+		if value := query.Get(name); value != "" {
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				utils.Response(domain.HttpResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Invalid " + name,
+					Data:    nil,
+				}, w)
+				return
+			}
+			*dst = &f
+		}
+	}
+
+	data, err := h.usecase.ListIdeas(r.Context(), filter, query.Get("cursor"))
+	if err != nil {
+		logrus.Errorf("error listing ideas: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: errorMessage(err, "Error listing ideas"),
+			Data:    nil,
+		}, w)
 		return
 	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Ideas retrieved successfully",
+		Data:    data,
+	}, w)
 }
 
-func (h *handler) StreamImproveIdea(w http.ResponseWriter, r *http.Request) {
-	bodyBytes, err := io.ReadAll(r.Body)
+// UpdateIdea implements domain.IdeaHandler.
+func (h *handler) UpdateIdea(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		logrus.Errorf("error reading request body: %v", err)
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
 		return
 	}
 
-	dataBuffer := domain.Idea{}
-	if err := json.Unmarshal(bodyBytes, &dataBuffer); err != nil {
+	dataBuffer := domain.UpdateIdeaRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&dataBuffer); err != nil {
 		logrus.Errorf("error unmarshalling request body: %v", err)
-		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.UpdateIdea(r.Context(), id, dataBuffer)
+	if err != nil {
+		logrus.Errorf("error updating idea: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: errorMessage(err, "Error updating idea"),
+			Data:    nil,
+		}, w)
 		return
 	}
 
-	promptSystem := &domain.Message{
-		Role:    "system",
-		Content: domain.PROMPT_IMPROVE,
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Idea updated successfully",
+		Data:    data,
+	}, w)
+}
+
+// DeleteIdea implements domain.IdeaHandler.
+func (h *handler) DeleteIdea(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
 	}
 
-	promptUser := &domain.Message{
-		Role:    "user",
-		Content: dataBuffer.Critique,
+	if err := h.usecase.DeleteIdea(r.Context(), id); err != nil {
+		logrus.Errorf("error deleting idea: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: errorMessage(err, "Error deleting idea"),
+			Data:    nil,
+		}, w)
+		return
 	}
 
-	messages := []*domain.Message{promptSystem, promptUser}
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Idea deleted successfully",
+		Data:    nil,
+	}, w)
+}
 
-	// Use streaming response
-	if err := ollama.StreamPrompt(w, messages); err != nil {
-		logrus.Errorf("error streaming idea: %v", err)
+// RestoreIdea implements domain.IdeaHandler.
+func (h *handler) RestoreIdea(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.RestoreIdea(r.Context(), id)
+	if err != nil {
+		logrus.Errorf("error restoring idea: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: errorMessage(err, "Error restoring idea"),
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Idea restored successfully",
+		Data:    data,
+	}, w)
+}
+
+// AddIdeaTags implements domain.IdeaHandler.
+func (h *handler) AddIdeaTags(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	dataBuffer := domain.TagRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&dataBuffer); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.AddIdeaTags(r.Context(), id, dataBuffer.Tags)
+	if err != nil {
+		logrus.Errorf("error tagging idea: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: errorMessage(err, "Error tagging idea"),
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Idea tagged successfully",
+		Data:    data,
+	}, w)
+}
+
+// RemoveIdeaTag implements domain.IdeaHandler.
+func (h *handler) RemoveIdeaTag(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
+	}
+	tagId, err := strconv.Atoi(vars["tagId"])
+	if err != nil {
+		logrus.Errorf("error converting tag id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.RemoveIdeaTag(r.Context(), id, tagId)
+	if err != nil {
+		logrus.Errorf("error untagging idea: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: errorMessage(err, "Error untagging idea"),
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Tag removed successfully",
+		Data:    data,
+	}, w)
+}
+
+// TransitionIdea implements domain.IdeaHandler.
+func (h *handler) TransitionIdea(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	dataBuffer := domain.TransitionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&dataBuffer); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.TransitionIdea(r.Context(), id, dataBuffer)
+	if err != nil {
+		logrus.Errorf("error changing idea status: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: errorMessage(err, "Error changing idea status"),
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Idea status updated successfully",
+		Data:    data,
+	}, w)
+}
+
+// PanelReview implements domain.IdeaHandler.
+func (h *handler) PanelReview(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	dataBuffer := domain.PanelRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&dataBuffer); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.PanelReview(r.Context(), id, dataBuffer)
+	if err != nil {
+		logrus.Errorf("error running panel review: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: errorMessage(err, "Error running panel review"),
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Panel review completed successfully",
+		Data:    data,
+	}, w)
+}
+
+// CompareIdeas implements domain.IdeaHandler.
+func (h *handler) CompareIdeas(w http.ResponseWriter, r *http.Request) {
+	dataBuffer := domain.CompareRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&dataBuffer); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.CompareIdeas(r.Context(), dataBuffer)
+	if err != nil {
+		logrus.Errorf("error comparing ideas: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: errorMessage(err, "Error comparing ideas"),
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusCreated,
+		Message: "Ideas compared successfully",
+		Data:    data,
+	}, w)
+}
+
+// GetComparison implements domain.IdeaHandler.
+func (h *handler) GetComparison(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.GetComparison(r.Context(), id)
+	if err != nil {
+		logrus.Errorf("error getting comparison: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: errorMessage(err, "Error retrieving comparison"),
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Comparison retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// GetIdeaWorkflow implements domain.IdeaHandler.
+func (h *handler) GetIdeaWorkflow(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.GetIdeaWorkflow(r.Context(), id)
+	if err != nil {
+		logrus.Errorf("error getting idea workflow: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: errorMessage(err, "Error getting idea workflow"),
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Idea workflow retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// GetIdeaReport implements domain.IdeaHandler. The format query parameter
+// is md, the default, html, pdf or json.
+func (h *handler) GetIdeaReport(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = domain.ReportMarkdown
+	}
+	if format == domain.ReportJSON {
+		data, err := h.usecase.GetIdeaReport(r.Context(), id)
+		if err != nil {
+			logrus.Errorf("error getting idea report: %v", err)
+			utils.Response(domain.HttpResponse{
+				Code:    utils.ErrorStatus(err),
+				Message: errorMessage(err, "Error generating idea report"),
+				Data:    nil,
+			}, w)
+			return
+		}
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusOK,
+			Message: "Idea report generated successfully",
+			Data:    data,
+		}, w)
 		return
 	}
+
+	file, err := h.usecase.ExportIdeaReport(r.Context(), id, format)
+	if err != nil {
+		logrus.Errorf("error exporting idea report: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: errorMessage(err, "Error generating idea report"),
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, file.Name))
+	w.Header().Set("Content-Length", strconv.Itoa(len(file.Body)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(file.Body)
+}
+
+// errorMessage shows validation and conflict errors to the client and hides
+// the rest behind fallback.
+func errorMessage(err error, fallback string) string {
+	if errors.Is(err, domain.ErrBadRequest) || errors.Is(err, domain.ErrConflict) {
+		return err.Error()
+	}
+	if errors.Is(err, domain.ErrQuotaExceeded) {
+		return "Quota exceeded"
+	}
+	return fallback
 }
 
 var (
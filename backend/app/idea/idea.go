@@ -8,8 +8,8 @@ import (
 func InitIdeaRepository(db db.DatabaseTransaction) domain.IdeaRepository {
 	return NewIdeaRepository(db)
 }
-func InitIdeaUsecase(dbTx db.DatabaseTransaction, repo domain.IdeaRepository) domain.IdeaUsecase {
-	return NewIdeaUsecase(dbTx, repo)
+func InitIdeaUsecase(dbTx db.DatabaseTransaction, repo domain.IdeaRepository, prompt domain.PromptUsecase, experiment domain.ExperimentUsecase, usage domain.UsageUsecase, audit domain.AuditUsecase, tags domain.TagRepository, rubrics domain.RubricRepository, personas domain.PersonaRepository) domain.IdeaUsecase {
+	return NewIdeaUsecase(dbTx, repo, prompt, experiment, usage, audit, tags, rubrics, personas)
 }
 func InitIdeaHandler(usecase domain.IdeaUsecase) domain.IdeaHandler {
 	return NewIdeaHandler(usecase)
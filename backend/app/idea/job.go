@@ -0,0 +1,166 @@
+package idea
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/pkg/ollama"
+)
+
+const (
+	speakerCritic  = "critic"
+	speakerFounder = "founder"
+)
+
+// RunJob implements domain.IdeaUsecase. It runs on behalf of the user who
+// queued the job, so the usual permission and quota checks apply; every
+// model call of a job is checked and charged like one made over HTTP.
+func (u *usecase) RunJob(ctx context.Context, job domain.Job) (interface{}, error) {
+	if err := u.checkQuota(ctx, 1); err != nil {
+		return nil, err
+	}
+	switch job.Type {
+	case domain.JobEvaluation:
+		payload := domain.EvaluationPayload{}
+		if err := decodePayload(job, &payload); err != nil {
+			return nil, err
+		}
+		return u.evaluationJob(ctx, payload)
+	case domain.JobDebate:
+		payload := domain.DebatePayload{}
+		if err := decodePayload(job, &payload); err != nil {
+			return nil, err
+		}
+		return u.debateJob(ctx, payload)
+	case domain.JobEmbedding:
+		payload := domain.EmbeddingPayload{}
+		if err := decodePayload(job, &payload); err != nil {
+			return nil, err
+		}
+		return u.embeddingJob(ctx, payload)
+	}
+	return nil, fmt.Errorf("%w: ideas do not run %s jobs", domain.ErrBadRequest, job.Type)
+}
+
+// evaluationJob critiques a stored idea, which also updates its score.
+func (u *usecase) evaluationJob(ctx context.Context, payload domain.EvaluationPayload) (domain.EvaluationResult, error) {
+	messages, err := u.SubmitIdea(ctx, domain.Idea{
+		Id:            payload.IdeaId,
+		PromptVersion: payload.PromptVersion,
+		RubricId:      payload.RubricId,
+		Persona:       payload.Persona,
+	})
+	if err != nil {
+		return domain.EvaluationResult{}, err
+	}
+
+	result := domain.EvaluationResult{Critique: messages[len(messages)-1].Content}
+	if idea, err := u.repo.GetIdea(ctx, payload.IdeaId); err == nil {
+		result.Score = idea.Score
+	}
+	return result, nil
+}
+
+// debateJob alternates critiques and defenses. From the second round on the
+// critic sees the founder's last answer next to the idea.
+func (u *usecase) debateJob(ctx context.Context, payload domain.DebatePayload) (domain.DebateResult, error) {
+	idea, err := u.checkIdea(ctx, payload.IdeaId)
+	if err != nil {
+		return domain.DebateResult{}, err
+	}
+	rounds := payload.Rounds
+	if rounds == 0 {
+		rounds = 1
+	}
+
+	result := domain.DebateResult{}
+	critique, defense := "", ""
+	for round := 1; round <= rounds; round++ {
+		text := ""
+		if defense != "" {
+			text = fmt.Sprintf("%s\n\nThe founder answered the last critique:\n%s", idea.Idea, defense)
+			if len(text) > maxIdeaLength {
+				text = strings.ToValidUTF8(text[:maxIdeaLength], "")
+			}
+		}
+		messages, err := u.SubmitIdea(ctx, domain.Idea{Id: idea.Id, Text: text})
+		if err != nil {
+			return domain.DebateResult{}, err
+		}
+		critique = messages[len(messages)-1].Content
+		result.Turns = append(result.Turns, domain.DebateTurn{Round: round, Speaker: speakerCritic, Content: critique})
+
+		messages, err = u.DefendIdea(ctx, domain.Idea{Id: idea.Id, Text: idea.Idea, Critique: critique})
+		if err != nil {
+			return domain.DebateResult{}, err
+		}
+		defense = messages[len(messages)-1].Content
+		result.Turns = append(result.Turns, domain.DebateTurn{Round: round, Speaker: speakerFounder, Content: defense})
+	}
+
+	if payload.Improve {
+		messages, err := u.ImproveIdea(ctx, domain.Idea{Id: idea.Id, Text: idea.Idea, Critique: critique})
+		if err != nil {
+			return domain.DebateResult{}, err
+		}
+		result.Improvement = messages[len(messages)-1].Content
+	}
+	return result, nil
+}
+
+// embeddingJob stores the vector of the idea text and its form.
+func (u *usecase) embeddingJob(ctx context.Context, payload domain.EmbeddingPayload) (domain.EmbeddingResult, error) {
+	idea, err := u.repo.GetIdea(ctx, payload.IdeaId)
+	if err != nil {
+		return domain.EmbeddingResult{}, notFound(err)
+	}
+	if err := authorize(ctx, idea.UserId, domain.PermIdeaWriteOwn, domain.PermIdeaWriteAny); err != nil {
+		return domain.EmbeddingResult{}, err
+	}
+
+	vector, model, err := ollama.Embed(ctx, embeddingText(idea))
+	if err != nil {
+		return domain.EmbeddingResult{}, err
+	}
+	err = u.repo.SaveIdeaEmbedding(ctx, domain.IdeaEmbedding{
+		IdeaId:     idea.Id,
+		Model:      model,
+		Dimensions: len(vector),
+		Vector:     vector,
+		UpdatedAt:  time.Now(),
+	})
+	if err != nil {
+		return domain.EmbeddingResult{}, err
+	}
+	return domain.EmbeddingResult{Model: model, Dimensions: len(vector)}, nil
+}
+
+// embeddingText joins the idea and the filled in fields of its form.
+func embeddingText(idea domain.SubmitIdeaRequest) string {
+	parts := []string{idea.Idea}
+	for _, field := range []struct{ name, value string }{
+		{"Problem", idea.Problem},
+		{"Solution", idea.Solution},
+		{"Customer", idea.Customer},
+		{"Revenue model", idea.RevenueModel},
+		{"Competitors", idea.Competitors},
+		{"Stage", idea.Stage},
+		{"Constraints", idea.Constraints},
+	} {
+		if field.value != "" {
+			parts = append(parts, field.name+": "+field.value)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func decodePayload(job domain.Job, dst interface{}) error {
+	if err := json.Unmarshal(job.Payload, dst); err != nil {
+		return fmt.Errorf("%w: invalid payload: %v", domain.ErrBadRequest, err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package idea
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	maxReasonChars = 2000
+	// critiqueReason is recorded when a finished critique moves an idea on
+	critiqueReason = "critique completed"
+	// submitReason is recorded when a draft is critiqued
+	submitReason = "submitted for critique"
+)
+
+// TransitionIdea implements domain.IdeaUsecase. Authors move their ideas
+// through drafting and improving; reviews and decisions take the review
+// permission or, in a team workspace, a workspace admin. Nobody sets the
+// statuses of domain.SystemStatuses by hand.
+func (u *usecase) TransitionIdea(ctx context.Context, id int, req domain.TransitionRequest) (domain.SubmitIdeaRequest, error) {
+	if !domain.IsValidIdeaStatus(req.Status) {
+		return domain.SubmitIdeaRequest{}, fmt.Errorf("%w: unknown status %q", domain.ErrBadRequest, req.Status)
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if len([]rune(req.Reason)) > maxReasonChars {
+		return domain.SubmitIdeaRequest{}, fmt.Errorf("%w: reason must not exceed %d characters", domain.ErrBadRequest, maxReasonChars)
+	}
+	if domain.SystemStatuses[req.Status] {
+		return domain.SubmitIdeaRequest{}, fmt.Errorf("%w: an idea becomes %s when its critique completes", domain.ErrBadRequest, req.Status)
+	}
+	if req.Status == domain.IdeaRejected && req.Reason == "" {
+		return domain.SubmitIdeaRequest{}, fmt.Errorf("%w: a reason is required to reject an idea", domain.ErrBadRequest)
+	}
+
+	var moved domain.SubmitIdeaRequest
+	err := u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		idea, err := u.repo.GetIdea(txCtx, id)
+		if err != nil {
+			return notFound(err)
+		}
+		if domain.ReviewStatuses[req.Status] {
+			err = review(txCtx)
+		} else {
+			err = authorize(txCtx, idea.UserId, domain.PermIdeaWriteOwn, domain.PermIdeaWriteAny)
+		}
+		if err != nil {
+			return err
+		}
+
+		moved, err = u.transition(txCtx, idea, req.Status, req.Reason)
+		return err
+	})
+	if err != nil {
+		logrus.Errorf("error moving idea %d to %s: %v", id, req.Status, err)
+		return domain.SubmitIdeaRequest{}, err
+	}
+	if err := u.withTags(ctx, &moved); err != nil {
+		logrus.Errorf("error getting idea tags: %v", err)
+		return domain.SubmitIdeaRequest{}, err
+	}
+	return moved, nil
+}
+
+// GetIdeaWorkflow implements domain.IdeaUsecase.
+func (u *usecase) GetIdeaWorkflow(ctx context.Context, id int) (domain.IdeaWorkflow, error) {
+	idea, err := u.GetIdea(ctx, id)
+	if err != nil {
+		return domain.IdeaWorkflow{}, err
+	}
+	history, err := u.repo.ListIdeaTransitions(ctx, id)
+	if err != nil {
+		logrus.Errorf("error listing idea transitions: %v", err)
+		return domain.IdeaWorkflow{}, err
+	}
+
+	next := []string{}
+	for _, status := range domain.IdeaTransitions[idea.Status] {
+		if !domain.SystemStatuses[status] {
+			next = append(next, status)
+		}
+	}
+	return domain.IdeaWorkflow{
+		Status:  idea.Status,
+		Next:    next,
+		History: history,
+	}, nil
+}
+
+// transition moves idea to status and records the step. The update only
+// applies while the idea is still in the status it was read with, so two
+// concurrent moves cannot both succeed.
+func (u *usecase) transition(ctx context.Context, idea domain.SubmitIdeaRequest, status, reason string) (domain.SubmitIdeaRequest, error) {
+	if idea.Status == status {
+		return domain.SubmitIdeaRequest{}, fmt.Errorf("%w: idea is already %s", domain.ErrConflict, status)
+	}
+	if !domain.CanTransition(idea.Status, status) {
+		return domain.SubmitIdeaRequest{}, fmt.Errorf("%w: an idea cannot move from %s to %s", domain.ErrConflict, idea.Status, status)
+	}
+
+	if err := u.repo.UpdateIdeaStatus(ctx, idea.Id, idea.Status, status); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.SubmitIdeaRequest{}, fmt.Errorf("%w: idea changed status meanwhile", domain.ErrConflict)
+		}
+		return domain.SubmitIdeaRequest{}, err
+	}
+
+	user, _ := domain.UserFromContext(ctx)
+	now := time.Now()
+	if err := u.repo.CreateIdeaTransition(ctx, domain.IdeaTransition{
+		IdeaId:    idea.Id,
+		From:      idea.Status,
+		To:        status,
+		ActorId:   user.Id,
+		Reason:    reason,
+		CreatedAt: &now,
+	}); err != nil {
+		return domain.SubmitIdeaRequest{}, err
+	}
+
+	moved := idea
+	moved.Status = status
+	moved.UpdatedAt = &now
+	if err := u.audit.Record(ctx, domain.AuditEntry{
+		Action:     domain.AuditIdeaStatus,
+		TargetType: "idea",
+		TargetId:   strconv.Itoa(idea.Id),
+		Before:     map[string]interface{}{"status": idea.Status},
+		After:      map[string]interface{}{"status": status, "reason": reason},
+	}); err != nil {
+		return domain.SubmitIdeaRequest{}, err
+	}
+	return moved, nil
+}
+
+// markCritiqued moves an idea on once its critique is stored. A draft is
+// submitted first, since asking for a critique submits it. Like
+// saveEvaluation it only logs failures.
+func (u *usecase) markCritiqued(ctx context.Context, ideaId int) {
+	err := u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		idea, err := u.repo.GetIdea(txCtx, ideaId)
+		if err != nil {
+			return err
+		}
+		if idea.Status == domain.IdeaDraft {
+			if idea, err = u.transition(txCtx, idea, domain.IdeaSubmitted, submitReason); err != nil {
+				return err
+			}
+		}
+		if idea.Status != domain.IdeaSubmitted && idea.Status != domain.IdeaUnderReview {
+			return nil
+		}
+		_, err = u.transition(txCtx, idea, domain.IdeaCritiqued, critiqueReason)
+		return err
+	})
+	if err != nil {
+		logrus.Errorf("error marking idea %d critiqued: %v", ideaId, err)
+	}
+}
+
+// review allows reviewers, and workspace admins of a team workspace.
+func review(ctx context.Context) error {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+	if user.Can(domain.PermIdeaReview) {
+		return nil
+	}
+	if workspace, ok := domain.WorkspaceFromContext(ctx); ok && !workspace.Personal && workspace.HasMemberRole(domain.WorkspaceAdmin) {
+		return nil
+	}
+	return domain.ErrForbidden
+}
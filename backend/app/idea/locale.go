@@ -0,0 +1,35 @@
+package idea
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/pkg/i18n"
+)
+
+// detectLanguage guesses the language an idea is written in from its text
+// and form, "" when it cannot tell.
+func detectLanguage(text string, fields domain.IdeaFields) string {
+	return i18n.Detect(strings.Join([]string{
+		text, fields.Problem, fields.Solution, fields.Customer,
+		fields.RevenueModel, fields.Competitors, fields.Constraints,
+	}, "\n"))
+}
+
+// locale is the language the model should answer in: the one the caller
+// asked for, otherwise the language the idea is written in. "" leaves the
+// choice to the model.
+func locale(ctx context.Context, data domain.PromptData) string {
+	if locale := domain.LocaleFromContext(ctx); locale != "" {
+		return locale
+	}
+	if len(data.Candidates) > 0 {
+		texts := make([]string, 0, len(data.Candidates))
+		for _, candidate := range data.Candidates {
+			texts = append(texts, candidate.Idea)
+		}
+		return detectLanguage(strings.Join(texts, "\n"), domain.IdeaFields{})
+	}
+	return detectLanguage(data.Idea, data.Fields)
+}
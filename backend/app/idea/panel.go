@@ -0,0 +1,89 @@
+package idea
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/sirupsen/logrus"
+)
+
+// PanelReview implements domain.IdeaUsecase. Every persona critiques the
+// idea as a regular evaluation; the panel prompt then merges the critiques,
+// and the idea score becomes the mean of the persona scores.
+func (u *usecase) PanelReview(ctx context.Context, id int, req domain.PanelRequest) (domain.PanelReview, error) {
+	if len(req.Personas) < domain.MinPanelPersonas || len(req.Personas) > domain.MaxPanelPersonas {
+		return domain.PanelReview{}, fmt.Errorf("%w: a panel needs %d to %d personas", domain.ErrBadRequest, domain.MinPanelPersonas, domain.MaxPanelPersonas)
+	}
+
+	idea, err := u.checkIdea(ctx, id)
+	if err != nil {
+		return domain.PanelReview{}, err
+	}
+
+	seen := map[string]bool{}
+	personas := make([]*domain.Persona, 0, len(req.Personas))
+	for _, key := range req.Personas {
+		if key == "" || seen[key] {
+			return domain.PanelReview{}, fmt.Errorf("%w: personas must be distinct and not empty", domain.ErrBadRequest)
+		}
+		seen[key] = true
+		persona, err := u.persona(ctx, key)
+		if err != nil {
+			return domain.PanelReview{}, err
+		}
+		personas = append(personas, persona)
+	}
+	rubric, err := u.rubric(ctx, req.RubricId)
+	if err != nil {
+		return domain.PanelReview{}, err
+	}
+	// one critique per persona and the summary
+	if err := u.checkQuota(ctx, len(personas)+1); err != nil {
+		return domain.PanelReview{}, err
+	}
+
+	panel := domain.PanelReview{IdeaId: id}
+	var sum float64
+	scored := 0
+	for _, persona := range personas {
+		messages, evaluation, err := u.complete(ctx, domain.PromptCritic, id, 0,
+			domain.PromptData{Idea: idea.Idea, Fields: idea.IdeaFields, Rubric: rubric, Persona: persona},
+			idea.Idea,
+			"I'm sorry, I couldn't review your idea at this time.")
+		if err != nil {
+			return domain.PanelReview{}, err
+		}
+		panel.Reviews = append(panel.Reviews, domain.PersonaReview{
+			Persona:      persona.Key,
+			Name:         persona.Name,
+			EvaluationId: evaluation.Id,
+			Critique:     messages[len(messages)-1].Content,
+			OverallScore: evaluation.OverallScore,
+			Weaknesses:   evaluation.Weaknesses,
+		})
+		if evaluation.OverallScore != nil {
+			sum += *evaluation.OverallScore
+			scored++
+		}
+	}
+
+	messages, summary, err := u.complete(ctx, domain.PromptPanel, id, 0,
+		domain.PromptData{Idea: idea.Idea, Fields: idea.IdeaFields, Reviews: panel.Reviews},
+		idea.Idea,
+		"I'm sorry, I couldn't merge the reviews at this time.")
+	if err != nil {
+		return domain.PanelReview{}, err
+	}
+	panel.Summary = messages[len(messages)-1].Content
+	panel.SummaryEvaluationId = summary.Id
+
+	if scored > 0 {
+		overall := sum / float64(scored)
+		panel.OverallScore = &overall
+		if err := u.repo.UpdateIdeaScore(ctx, id, overall); err != nil {
+			logrus.Errorf("error updating idea score: %v", err)
+		}
+	}
+	return panel, nil
+}
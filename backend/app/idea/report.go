@@ -0,0 +1,270 @@
+package idea
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/pkg/markdown"
+	"github.com/Kocannn/self-dunking-ai/pkg/report"
+	"github.com/sirupsen/logrus"
+)
+
+const reportTimeFormat = "2 Jan 2006 15:04"
+
+// GetIdeaReport implements domain.IdeaUsecase. A defense is stored with
+// the critique it answers as its input, which is how the debate is put
+// back together.
+func (u *usecase) GetIdeaReport(ctx context.Context, id int) (domain.IdeaReport, error) {
+	idea, err := u.GetIdea(ctx, id)
+	if err != nil {
+		return domain.IdeaReport{}, err
+	}
+	evaluations, err := u.repo.ListEvaluations(ctx, id)
+	if err != nil {
+		logrus.Errorf("error listing idea evaluations: %v", err)
+		return domain.IdeaReport{}, err
+	}
+	transitions, err := u.repo.ListIdeaTransitions(ctx, id)
+	if err != nil {
+		logrus.Errorf("error listing idea transitions: %v", err)
+		return domain.IdeaReport{}, err
+	}
+
+	data := domain.IdeaReport{
+		Idea:         idea,
+		Critiques:    []domain.Evaluation{},
+		Debates:      []domain.DebateExchange{},
+		Improvements: []domain.Evaluation{},
+		Transitions:  transitions,
+		GeneratedAt:  time.Now(),
+	}
+	for _, evaluation := range evaluations {
+		switch evaluation.Kind {
+		case domain.PromptCritic, domain.PromptPanel:
+			data.Critiques = append(data.Critiques, evaluation)
+		case domain.PromptDefend:
+			data.Debates = append(data.Debates, domain.DebateExchange{
+				EvaluationId: evaluation.Id,
+				Critique:     strings.TrimPrefix(evaluation.Input, "Critique: "),
+				Defense:      evaluation.Output,
+				CreatedAt:    evaluation.CreatedAt,
+			})
+		case domain.PromptImprove:
+			data.Improvements = append(data.Improvements, evaluation)
+		}
+	}
+	return data, nil
+}
+
+// ExportIdeaReport implements domain.IdeaUsecase.
+func (u *usecase) ExportIdeaReport(ctx context.Context, id int, format string) (domain.ReportFile, error) {
+	if format != domain.ReportMarkdown && format != domain.ReportHTML && format != domain.ReportPDF {
+		return domain.ReportFile{}, fmt.Errorf("%w: format must be %s, %s, %s or %s", domain.ErrBadRequest,
+			domain.ReportMarkdown, domain.ReportHTML, domain.ReportPDF, domain.ReportJSON)
+	}
+	data, err := u.GetIdeaReport(ctx, id)
+	if err != nil {
+		return domain.ReportFile{}, err
+	}
+
+	doc := reportDocument(data)
+	file := domain.ReportFile{Name: fmt.Sprintf("idea-%d-report.%s", id, format)}
+	switch format {
+	case domain.ReportMarkdown:
+		file.ContentType = "text/markdown; charset=utf-8"
+		file.Body = report.Markdown(doc)
+	case domain.ReportHTML:
+		file.ContentType = "text/html; charset=utf-8"
+		file.Body, err = report.HTML(doc)
+	case domain.ReportPDF:
+		file.ContentType = "application/pdf"
+		file.Body, err = report.PDF(doc)
+	}
+	if err != nil {
+		logrus.Errorf("error rendering idea report: %v", err)
+		return domain.ReportFile{}, err
+	}
+	return file, nil
+}
+
+// reportDocument lays the report out as Markdown blocks. The model answers
+// are parsed and nested below the report's own headings, so every format
+// renders them the same way.
+func reportDocument(data domain.IdeaReport) report.Document {
+	idea := data.Idea
+	blocks := []markdown.Block{heading(2, "Overview"), table(
+		[]string{"Status", "Score", "Stage", "Tags", "Created", "Updated"},
+		[][]string{{idea.Status, formatScore(idea.Score), orDash(idea.Stage), tagNames(idea.Tags), formatTime(idea.CreatedAt), formatTime(idea.UpdatedAt)}},
+	)}
+
+	blocks = append(blocks, heading(3, "Idea"))
+	blocks = append(blocks, markdown.Parse(idea.Idea)...)
+	for _, field := range []struct{ name, value string }{
+		{"Problem", idea.Problem},
+		{"Solution", idea.Solution},
+		{"Customer", idea.Customer},
+		{"Revenue model", idea.RevenueModel},
+		{"Competitors", idea.Competitors},
+		{"Constraints", idea.Constraints},
+	} {
+		if field.value != "" {
+			blocks = append(blocks, heading(3, field.name))
+			blocks = append(blocks, markdown.Parse(field.value)...)
+		}
+	}
+
+	blocks = append(blocks, heading(2, "Scores"))
+	if len(data.Critiques) == 0 {
+		blocks = append(blocks, paragraph("The idea has not been critiqued yet."))
+	} else {
+		rows := [][]string{}
+		for _, critique := range data.Critiques {
+			rows = append(rows, []string{formatTime(critique.CreatedAt), reviewer(critique), dimensions(critique), formatScore(critique.OverallScore)})
+		}
+		blocks = append(blocks, table([]string{"Date", "Reviewer", "Dimensions", "Overall"}, rows))
+	}
+
+	blocks = append(blocks, heading(2, "Critiques"))
+	if len(data.Critiques) == 0 {
+		blocks = append(blocks, paragraph("No critiques yet."))
+	}
+	for i, critique := range data.Critiques {
+		blocks = append(blocks, heading(3, fmt.Sprintf("Critique %d · %s · %s", i+1, reviewer(critique), formatTime(critique.CreatedAt))))
+		blocks = append(blocks, markdown.ShiftHeadings(markdown.Parse(critique.Output), 4)...)
+	}
+
+	blocks = append(blocks, heading(2, "Debate"))
+	if len(data.Debates) == 0 {
+		blocks = append(blocks, paragraph("The founder has not answered a critique yet."))
+	}
+	for i, debate := range data.Debates {
+		blocks = append(blocks, heading(3, fmt.Sprintf("Round %d · %s", i+1, formatTime(debate.CreatedAt))))
+		blocks = append(blocks, heading(4, "Critique"))
+		blocks = append(blocks, markdown.Block{Kind: markdown.Quote, Blocks: markdown.ShiftHeadings(markdown.Parse(debate.Critique), 5)})
+		blocks = append(blocks, heading(4, "Defense"))
+		blocks = append(blocks, markdown.ShiftHeadings(markdown.Parse(debate.Defense), 5)...)
+	}
+
+	blocks = append(blocks, heading(2, "Improvement history"))
+	if len(data.Improvements) == 0 {
+		blocks = append(blocks, paragraph("No improvements yet."))
+	}
+	for i, improvement := range data.Improvements {
+		blocks = append(blocks, heading(3, fmt.Sprintf("Improvement %d · %s", i+1, formatTime(improvement.CreatedAt))))
+		blocks = append(blocks, markdown.ShiftHeadings(markdown.Parse(improvement.Output), 4)...)
+	}
+
+	blocks = append(blocks, heading(2, "Status history"))
+	if len(data.Transitions) == 0 {
+		blocks = append(blocks, paragraph("The idea has not changed status yet."))
+	} else {
+		rows := [][]string{}
+		for _, transition := range data.Transitions {
+			rows = append(rows, []string{formatTime(transition.CreatedAt), transition.From, transition.To, orDash(transition.Reason)})
+		}
+		blocks = append(blocks, table([]string{"Date", "From", "To", "Reason"}, rows))
+	}
+
+	return report.Document{
+		Title:       fmt.Sprintf("Idea #%d report", idea.Id),
+		Subtitle:    summary(idea.Idea),
+		Blocks:      blocks,
+		GeneratedAt: data.GeneratedAt,
+	}
+}
+
+func heading(level int, text string) markdown.Block {
+	return markdown.Block{Kind: markdown.Heading, Level: level, Spans: []markdown.Span{{Text: text}}}
+}
+
+func paragraph(text string) markdown.Block {
+	return markdown.Block{Kind: markdown.Paragraph, Spans: []markdown.Span{{Text: text, Italic: true}}}
+}
+
+// table builds a table of plain text cells.
+func table(header []string, rows [][]string) markdown.Block {
+	cells := func(texts []string) [][]markdown.Span {
+		row := [][]markdown.Span{}
+		for _, text := range texts {
+			row = append(row, []markdown.Span{{Text: text}})
+		}
+		return row
+	}
+	block := markdown.Block{Kind: markdown.Table, Header: cells(header)}
+	for _, row := range rows {
+		block.Rows = append(block.Rows, cells(row))
+	}
+	return block
+}
+
+// summary is the first line of the idea, cut to fit below the title.
+func summary(text string) string {
+	text = strings.TrimSpace(strings.SplitN(strings.TrimSpace(text), "\n", 2)[0])
+	if runes := []rune(text); len(runes) > 140 {
+		return strings.TrimSpace(string(runes[:140])) + "…"
+	}
+	return text
+}
+
+func reviewer(evaluation domain.Evaluation) string {
+	switch {
+	case evaluation.Kind == domain.PromptPanel:
+		return "Panel"
+	case evaluation.Persona != "":
+		return evaluation.Persona
+	}
+	return "Critic"
+}
+
+// dimensions lists the scores of a critique, from its rubric or else the
+// built-in dimensions.
+func dimensions(evaluation domain.Evaluation) string {
+	if evaluation.ParseFailed {
+		return "not scored"
+	}
+	parts := []string{}
+	for _, score := range evaluation.DimensionScores {
+		parts = append(parts, fmt.Sprintf("%s %d/%d", score.Name, score.Score, score.Max))
+	}
+	if len(parts) == 0 {
+		parts = []string{
+			fmt.Sprintf("Originality %d", evaluation.ScoreOriginality),
+			fmt.Sprintf("Scalability %d", evaluation.ScoreScalability),
+			fmt.Sprintf("Feasibility %d", evaluation.ScoreFeasibility),
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func tagNames(tags []domain.Tag) string {
+	names := []string{}
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return orDash(strings.Join(names, ", "))
+}
+
+func formatScore(score *float64) string {
+	if score == nil {
+		return "-"
+	}
+	return strconv.FormatFloat(*score, 'f', 1, 64)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(reportTimeFormat)
+}
+
+func orDash(text string) string {
+	if text == "" {
+		return "-"
+	}
+	return text
+}
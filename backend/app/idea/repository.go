@@ -2,10 +2,15 @@ package idea
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/Kocannn/self-dunking-ai/domain"
 	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type (
@@ -17,15 +22,147 @@ type (
 // GetIdea implements domain.IdeaRepository.
 func (r *repository) GetIdea(ctx context.Context, id int) (domain.SubmitIdeaRequest, error) {
 	data := domain.SubmitIdeaRequest{}
-	err := r.db.DB(ctx).First(&data, id).Error
+	err := r.scoped(ctx).Where("deleted_at IS NULL").First(&data, id).Error
 	if err != nil {
 		return domain.SubmitIdeaRequest{}, err
 	}
 	return data, nil
 }
 
+// GetDeletedIdea implements domain.IdeaRepository.
+func (r *repository) GetDeletedIdea(ctx context.Context, id int) (domain.SubmitIdeaRequest, error) {
+	data := domain.SubmitIdeaRequest{}
+	err := r.scoped(ctx).Where("deleted_at IS NOT NULL").First(&data, id).Error
+	if err != nil {
+		return domain.SubmitIdeaRequest{}, err
+	}
+	return data, nil
+}
+
+// ListIdeas implements domain.IdeaRepository. Pages are keyset based: the
+// cursor holds the sort key and id of the last idea already returned.
+func (r *repository) ListIdeas(ctx context.Context, filter domain.IdeaFilter) ([]domain.SubmitIdeaRequest, error) {
+	query := r.scoped(ctx).Model(&domain.SubmitIdeaRequest{})
+	if filter.Deleted {
+		query = query.Where("deleted_at IS NOT NULL")
+	} else {
+		query = query.Where("deleted_at IS NULL")
+	}
+	if filter.OwnerId != 0 {
+		query = query.Where("user_id = ?", filter.OwnerId)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Tag != "" {
+		query = query.Where("id IN (?)", r.db.DB(ctx).Table("idea_tags").
+			Select("idea_tags.idea_id").
+			Joins("JOIN tags ON tags.id = idea_tags.tag_id").
+			Where("tags.workspace_id = ? AND tags.name = ?", workspaceId(ctx), filter.Tag))
+	}
+	if filter.MinScore != nil {
+		query = query.Where("score >= ?", *filter.MinScore)
+	}
+	if filter.MaxScore != nil {
+		query = query.Where("score <= ?", *filter.MaxScore)
+	}
+
+	// unscored ideas sort below every scored one
+	key, desc := "created_at", true
+	switch filter.Sort {
+	case domain.IdeaSortCreated:
+		desc = false
+	case domain.IdeaSortScore:
+		key, desc = "COALESCE(score, -1)", false
+	case domain.IdeaSortScoreDesc:
+		key = "COALESCE(score, -1)"
+	}
+
+	if filter.After != nil {
+		value, err := cursorValue(filter.Sort, filter.After.Value)
+		if err != nil {
+			return nil, err
+		}
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		query = query.Where(fmt.Sprintf("(%s %s ?) OR (%s = ? AND id %s ?)", key, op, key, op), value, value, filter.After.Id)
+	}
+
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+	data := []domain.SubmitIdeaRequest{}
+	err := query.Order(key + " " + direction).Order("id " + direction).Limit(filter.Limit).Find(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// UpdateIdea implements domain.IdeaRepository.
+func (r *repository) UpdateIdea(ctx context.Context, idea domain.SubmitIdeaRequest) error {
+	return r.scoped(ctx).Model(&domain.SubmitIdeaRequest{}).
+		Where("id = ? AND deleted_at IS NULL", idea.Id).
+		Select("idea", "problem", "solution", "customer", "revenue_model", "competitors", "stage", "constraints", "language", "updated_at").
+		Updates(&idea).Error
+}
+
+// UpdateIdeaScore implements domain.IdeaRepository.
+func (r *repository) UpdateIdeaScore(ctx context.Context, id int, score float64) error {
+	return r.scoped(ctx).Model(&domain.SubmitIdeaRequest{}).
+		Where("id = ?", id).
+		Update("score", score).Error
+}
+
+// DeleteIdea implements domain.IdeaRepository.
+func (r *repository) DeleteIdea(ctx context.Context, id int) error {
+	return r.scoped(ctx).Model(&domain.SubmitIdeaRequest{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Update("deleted_at", time.Now()).Error
+}
+
+// RestoreIdea implements domain.IdeaRepository.
+func (r *repository) RestoreIdea(ctx context.Context, id int) error {
+	return r.scoped(ctx).Model(&domain.SubmitIdeaRequest{}).
+		Where("id = ?", id).
+		Update("deleted_at", nil).Error
+}
+
+// UpdateIdeaStatus implements domain.IdeaRepository.
+func (r *repository) UpdateIdeaStatus(ctx context.Context, id int, from, to string) error {
+	result := r.scoped(ctx).Model(&domain.SubmitIdeaRequest{}).
+		Where("id = ? AND status = ? AND deleted_at IS NULL", id, from).
+		Updates(map[string]interface{}{"status": to, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CreateIdeaTransition implements domain.IdeaRepository.
+func (r *repository) CreateIdeaTransition(ctx context.Context, transition domain.IdeaTransition) error {
+	return r.db.DB(ctx).Create(&transition).Error
+}
+
+// ListIdeaTransitions implements domain.IdeaRepository.
+func (r *repository) ListIdeaTransitions(ctx context.Context, ideaId int) ([]domain.IdeaTransition, error) {
+	data := []domain.IdeaTransition{}
+	err := r.db.DB(ctx).Where("idea_id = ?", ideaId).Order("id").Find(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
 // SubmitIdeaStream implements domain.IdeaRepository.
 func (r *repository) SubmitIdeaStream(ctx context.Context, idea domain.SubmitIdeaRequest) (domain.SubmitIdeaRequest, error) {
+	idea.WorkspaceId = workspaceId(ctx)
 	err := r.db.DB(ctx).Create(&idea).Error
 	if err != nil {
 		logrus.Error("repository.SubmitIdeaStream: failed to save idea")
@@ -34,11 +171,97 @@ func (r *repository) SubmitIdeaStream(ctx context.Context, idea domain.SubmitIde
 	return idea, nil
 }
 
+// CreateEvaluation implements domain.IdeaRepository.
+func (r *repository) CreateEvaluation(ctx context.Context, evaluation domain.Evaluation) (domain.Evaluation, error) {
+	evaluation.WorkspaceId = workspaceId(ctx)
+	err := r.db.DB(ctx).Create(&evaluation).Error
+	if err != nil {
+		logrus.Error("repository.CreateEvaluation: failed to save evaluation")
+		return domain.Evaluation{}, err
+	}
+	return evaluation, nil
+}
+
+// GetEvaluation implements domain.IdeaRepository.
+func (r *repository) GetEvaluation(ctx context.Context, id int) (domain.Evaluation, error) {
+	data := domain.Evaluation{}
+	err := r.scoped(ctx).First(&data, id).Error
+	if err != nil {
+		return domain.Evaluation{}, err
+	}
+	return data, nil
+}
+
+// ListEvaluations implements domain.IdeaRepository.
+func (r *repository) ListEvaluations(ctx context.Context, ideaId int) ([]domain.Evaluation, error) {
+	data := []domain.Evaluation{}
+	err := r.scoped(ctx).Where("idea_id = ?", ideaId).Order("created_at, id").Find(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// UpdateEvaluationFeedback implements domain.IdeaRepository.
+func (r *repository) UpdateEvaluationFeedback(ctx context.Context, id int, thumbsUp bool) error {
+	return r.scoped(ctx).Model(&domain.Evaluation{}).
+		Where("id = ?", id).
+		Update("thumbs_up", thumbsUp).Error
+}
+
+// CreateComparison implements domain.IdeaRepository.
+func (r *repository) CreateComparison(ctx context.Context, comparison domain.Comparison) (domain.Comparison, error) {
+	comparison.WorkspaceId = workspaceId(ctx)
+	if err := r.db.DB(ctx).Create(&comparison).Error; err != nil {
+		return domain.Comparison{}, err
+	}
+	return comparison, nil
+}
+
+// GetComparison implements domain.IdeaRepository.
+func (r *repository) GetComparison(ctx context.Context, id int) (domain.Comparison, error) {
+	data := domain.Comparison{}
+	if err := r.scoped(ctx).First(&data, id).Error; err != nil {
+		return domain.Comparison{}, err
+	}
+	return data, nil
+}
+
+// SaveIdeaEmbedding implements domain.IdeaRepository.
+func (r *repository) SaveIdeaEmbedding(ctx context.Context, embedding domain.IdeaEmbedding) error {
+	return r.db.DB(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "idea_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"model", "dimensions", "vector", "updated_at"}),
+	}).Create(&embedding).Error
+}
+
 // SubmitIdea implements domain.IdeaRepository.
 func (r *repository) SubmitIdea(ctx context.Context, idea string) error {
 	panic("unimplemented")
 }
 
+// scoped limits a query to the caller's active workspace. Without one in the
+// context nothing matches, so a missing middleware cannot leak other teams'
+// ideas.
+func (r *repository) scoped(ctx context.Context) *gorm.DB {
+	return r.db.DB(ctx).Where("workspace_id = ?", workspaceId(ctx))
+}
+
+func workspaceId(ctx context.Context) int {
+	workspace, _ := domain.WorkspaceFromContext(ctx)
+	return workspace.Id
+}
+
+// cursorValue turns the cursor string back into the type of the sort key.
+func cursorValue(sort, value string) (interface{}, error) {
+	switch sort {
+	case domain.IdeaSortScore, domain.IdeaSortScoreDesc:
+		return strconv.ParseFloat(value, 64)
+	default:
+		return time.Parse(time.RFC3339Nano, value)
+	}
+}
+
 var (
 	repo *repository
 )
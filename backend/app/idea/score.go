@@ -0,0 +1,199 @@
+package idea
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+)
+
+// scorePattern matches lines such as "Originality: 7/10" or
+// "**Scalability** – 6 out of 10" in the critic output.
+var scorePattern = regexp.MustCompile(`(?i)(originality|scalability|feasibility)\W{0,10}(?:score\W{0,5})?(\d{1,2})\s*(?:/|out of)?\s*(?:10)?`)
+
+type scores struct {
+	originality int
+	scalability int
+	feasibility int
+}
+
+// parseScores extracts the three 1–10 scores from a critique. ok is false
+// when any dimension is missing or out of range.
+func parseScores(output string) (scores, bool) {
+	var s scores
+	for _, match := range scorePattern.FindAllStringSubmatch(output, -1) {
+		value, err := strconv.Atoi(match[2])
+		if err != nil || value < 1 || value > 10 {
+			continue
+		}
+		// the last mention wins, the summary sits at the end of the critique
+		switch strings.ToLower(match[1]) {
+		case "originality":
+			s.originality = value
+		case "scalability":
+			s.scalability = value
+		case "feasibility":
+			s.feasibility = value
+		}
+	}
+	return s, s.originality > 0 && s.scalability > 0 && s.feasibility > 0
+}
+
+// weaknessPattern matches critique lines such as
+// "- [revenue_model] Ads will not cover the hosting costs".
+var weaknessPattern = regexp.MustCompile(`(?im)^\s*(?:[-*•]|\d+[.)])?\s*\*{0,2}\[(problem|solution|customer|revenue_model|competitors|stage|constraints|general)\]\*{0,2}\s*[:\-–]?\s*(.+?)\s*$`)
+
+// parseWeaknesses collects the field-tagged weaknesses the critic prompt asks
+// for. Critiques from older prompt versions simply yield none.
+func parseWeaknesses(output string) []domain.Weakness {
+	var weaknesses []domain.Weakness
+	for _, match := range weaknessPattern.FindAllStringSubmatch(output, -1) {
+		weaknesses = append(weaknesses, domain.Weakness{
+			Field: strings.ToLower(match[1]),
+			Text:  match[2],
+		})
+	}
+	return weaknesses
+}
+
+// mean is the overall score stored on the idea for sorting and filtering.
+func (s scores) mean() float64 {
+	return float64(s.originality+s.scalability+s.feasibility) / 3
+}
+
+// parseRubricScores extracts a score for every dimension of rubric, matched
+// by name like the built-in dimensions. ok is false when any dimension is
+// missing or outside its scale.
+func parseRubricScores(output string, rubric domain.Rubric) ([]domain.DimensionScore, bool) {
+	scores := make([]domain.DimensionScore, 0, len(rubric.Dimensions))
+	ok := true
+	for _, dimension := range rubric.Dimensions {
+		pattern := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(dimension.Name) + `\W{0,10}(?:score\W{0,5})?(\d{1,3})`)
+		score := -1
+		for _, match := range pattern.FindAllStringSubmatch(output, -1) {
+			value, err := strconv.Atoi(match[1])
+			if err != nil || value < dimension.Min || value > dimension.Max {
+				continue
+			}
+			score = value
+		}
+		if score < 0 {
+			ok = false
+			continue
+		}
+		scores = append(scores, domain.DimensionScore{
+			Name:  dimension.Name,
+			Score: score,
+			Min:   dimension.Min,
+			Max:   dimension.Max,
+		})
+	}
+	return scores, ok
+}
+
+// weightedScore combines the dimension scores into one score on the
+// domain.OverallScale. Every score counts by where it sits on its own
+// scale, so a 4 on 1 to 5 weighs the same as a 7 on 1 to 9.
+func weightedScore(rubric domain.Rubric, scores []domain.DimensionScore) float64 {
+	weights := map[string]float64{}
+	for _, dimension := range rubric.Dimensions {
+		weights[dimension.Name] = dimension.Weight
+	}
+
+	var sum, total float64
+	for _, score := range scores {
+		if score.Max <= score.Min {
+			continue
+		}
+		weight := weights[score.Name]
+		sum += weight * float64(score.Score-score.Min) / float64(score.Max-score.Min)
+		total += weight
+	}
+	if total == 0 {
+		return 0
+	}
+	return sum / total * domain.OverallScale
+}
+
+// rankingPattern matches compare lines such as
+// "[Feasibility] 1. B – It only needs an existing API" or
+// "- **[Scalability]** 2) Idea A: ...".
+var rankingPattern = regexp.MustCompile(`(?m)^\s*(?:[-*•]\s*)?\*{0,2}\[([^\]\n]+)\]\*{0,2}\s*(\d{1,2})\s*[.)]?\s*\*{0,2}(?i:idea\s+)?([A-Z])\b\*{0,2}\s*[:\-–—]?\s*(.*?)\s*$`)
+
+// parseRanking collects the per dimension ranking of the compare prompt,
+// keeping the dimensions in rubric order and the first line seen for every
+// idea. The summary is whatever follows the last ranking line, or the whole
+// output when nothing could be parsed.
+func parseRanking(output string, dimensions []domain.RubricDimension, candidates []domain.Candidate, ideas []domain.SubmitIdeaRequest) ([]domain.DimensionRanking, string) {
+	names := map[string]string{}
+	for _, dimension := range dimensions {
+		names[strings.ToLower(dimension.Name)] = dimension.Name
+	}
+	ideaIds := map[string]int{}
+	for i, candidate := range candidates {
+		ideaIds[candidate.Label] = ideas[i].Id
+	}
+
+	ranked := map[string][]domain.RankedIdea{}
+	seen := map[string]map[int]bool{}
+	end := -1
+	for _, match := range rankingPattern.FindAllStringSubmatchIndex(output, -1) {
+		name, ok := names[strings.ToLower(strings.TrimSpace(output[match[2]:match[3]]))]
+		if !ok {
+			continue
+		}
+		ideaId, ok := ideaIds[output[match[6]:match[7]]]
+		if !ok || seen[name][ideaId] {
+			continue
+		}
+		rank, err := strconv.Atoi(output[match[4]:match[5]])
+		if err != nil || rank < 1 {
+			continue
+		}
+		if seen[name] == nil {
+			seen[name] = map[int]bool{}
+		}
+		seen[name][ideaId] = true
+		ranked[name] = append(ranked[name], domain.RankedIdea{
+			IdeaId:        ideaId,
+			Rank:          rank,
+			Justification: output[match[8]:match[9]],
+		})
+		end = match[1]
+	}
+
+	var rankings []domain.DimensionRanking
+	for _, dimension := range dimensions {
+		if list, ok := ranked[dimension.Name]; ok {
+			sort.SliceStable(list, func(i, j int) bool { return list[i].Rank < list[j].Rank })
+			rankings = append(rankings, domain.DimensionRanking{Name: dimension.Name, Ranking: list})
+		}
+	}
+	if end < 0 {
+		return nil, strings.TrimSpace(output)
+	}
+	return rankings, strings.TrimSpace(output[end:])
+}
+
+// matchPattern finds the verdict of the match prompt, "Winner: A".
+var matchPattern = regexp.MustCompile(`(?im)^\W*winner\W*\s*(a|b|tie|draw)\b.*$`)
+
+// reasonPattern strips the "Reason:" label the match prompt asks for.
+var reasonPattern = regexp.MustCompile(`(?i)^\W*reason\W*\s*`)
+
+// parseMatch returns "A", "B" or "" for a draw or an unreadable verdict,
+// and the explanation without the verdict line.
+func parseMatch(output string) (string, string) {
+	loc := matchPattern.FindStringSubmatchIndex(output)
+	if loc == nil {
+		return "", strings.TrimSpace(output)
+	}
+	winner := strings.ToUpper(output[loc[2]:loc[3]])
+	if winner != "A" && winner != "B" {
+		winner = ""
+	}
+	reason := strings.TrimSpace(output[:loc[0]] + output[loc[1]:])
+	return winner, reasonPattern.ReplaceAllString(reason, "")
+}
@@ -0,0 +1,227 @@
+package idea
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/sirupsen/logrus"
+)
+
+// maxAutoTags is how many tags the model may put on a new idea.
+const maxAutoTags = 3
+
+// AddIdeaTags implements domain.IdeaUsecase. Only tags of the workspace
+// vocabulary are attached; growing it is left to TagUsecase.CreateTags, which
+// only workspace admins may call.
+func (u *usecase) AddIdeaTags(ctx context.Context, id int, names []string) (domain.SubmitIdeaRequest, error) {
+	names, err := domain.NormalizeTags(names)
+	if err != nil {
+		return domain.SubmitIdeaRequest{}, err
+	}
+
+	var tagged domain.SubmitIdeaRequest
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		idea, err := u.repo.GetIdea(txCtx, id)
+		if err != nil {
+			return notFound(err)
+		}
+		if err := authorize(txCtx, idea.UserId, domain.PermIdeaWriteOwn, domain.PermIdeaWriteAny); err != nil {
+			return err
+		}
+		if err := u.withTags(txCtx, &idea); err != nil {
+			return err
+		}
+
+		current := map[string]bool{}
+		for _, tag := range idea.Tags {
+			current[tag.Name] = true
+		}
+		added := []string{}
+		for _, name := range names {
+			if !current[name] {
+				added = append(added, name)
+			}
+		}
+		if len(added) == 0 {
+			tagged = idea
+			return nil
+		}
+		if len(idea.Tags)+len(added) > domain.MaxIdeaTags {
+			return fmt.Errorf("%w: an idea can have at most %d tags", domain.ErrBadRequest, domain.MaxIdeaTags)
+		}
+
+		vocabulary, err := u.tags.ListTags(txCtx)
+		if err != nil {
+			return err
+		}
+		ids := map[string]int{}
+		for _, tag := range vocabulary {
+			ids[tag.Name] = tag.Id
+		}
+		tagIds := make([]int, 0, len(added))
+		for _, name := range added {
+			id, ok := ids[name]
+			if !ok {
+				return fmt.Errorf("%w: unknown tag %q, a workspace admin has to add it first", domain.ErrBadRequest, name)
+			}
+			tagIds = append(tagIds, id)
+		}
+		if err := u.tags.AddIdeaTags(txCtx, id, tagIds, domain.TagSourceManual); err != nil {
+			return err
+		}
+
+		tagged = idea
+		if err := u.withTags(txCtx, &tagged); err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditIdeaTag,
+			TargetType: "idea",
+			TargetId:   strconv.Itoa(id),
+			After:      map[string]interface{}{"tags": added},
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error tagging idea: %v", err)
+		return domain.SubmitIdeaRequest{}, err
+	}
+	return tagged, nil
+}
+
+// RemoveIdeaTag implements domain.IdeaUsecase. The tag stays in the
+// workspace vocabulary.
+func (u *usecase) RemoveIdeaTag(ctx context.Context, id, tagId int) (domain.SubmitIdeaRequest, error) {
+	var untagged domain.SubmitIdeaRequest
+	err := u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		idea, err := u.repo.GetIdea(txCtx, id)
+		if err != nil {
+			return notFound(err)
+		}
+		if err := authorize(txCtx, idea.UserId, domain.PermIdeaWriteOwn, domain.PermIdeaWriteAny); err != nil {
+			return err
+		}
+		tag, err := u.tags.GetTag(txCtx, tagId)
+		if err != nil {
+			return notFound(err)
+		}
+		if err := u.tags.RemoveIdeaTag(txCtx, id, tagId); err != nil {
+			return err
+		}
+
+		untagged = idea
+		if err := u.withTags(txCtx, &untagged); err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditIdeaUntag,
+			TargetType: "idea",
+			TargetId:   strconv.Itoa(id),
+			Before:     map[string]interface{}{"tags": []string{tag.Name}},
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error untagging idea: %v", err)
+		return domain.SubmitIdeaRequest{}, err
+	}
+	return untagged, nil
+}
+
+// withTags loads the tags of idea.
+func (u *usecase) withTags(ctx context.Context, idea *domain.SubmitIdeaRequest) error {
+	tags, err := u.tags.ListIdeaTags(ctx, []int{idea.Id})
+	if err != nil {
+		return err
+	}
+	idea.Tags = tags[idea.Id]
+	return nil
+}
+
+// withPageTags loads the tags of every idea on a page with one query.
+func (u *usecase) withPageTags(ctx context.Context, ideas []domain.SubmitIdeaRequest) error {
+	ids := make([]int, 0, len(ideas))
+	for _, idea := range ideas {
+		ids = append(ids, idea.Id)
+	}
+	tags, err := u.tags.ListIdeaTags(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range ideas {
+		ideas[i].Tags = tags[ideas[i].Id]
+	}
+	return nil
+}
+
+// autoTag asks the model to choose tags for a new idea from the workspace
+// vocabulary. The idea is already saved, so failures are only logged; a
+// workspace without tags is left alone. The call is checked against and
+// charged to the quota like any other, which is why the idea routes need no
+// QuotaMiddleware: an idea without auto_tag costs nothing.
+func (u *usecase) autoTag(ctx context.Context, idea *domain.SubmitIdeaRequest) {
+	vocabulary, err := u.tags.ListTags(ctx)
+	if err != nil {
+		logrus.Errorf("error listing tags for auto-tagging: %v", err)
+		return
+	}
+	if len(vocabulary) == 0 {
+		return
+	}
+	names := make([]string, 0, len(vocabulary))
+	for _, tag := range vocabulary {
+		names = append(names, tag.Name)
+	}
+
+	messages, err := u.evaluate(ctx, domain.PromptTag, idea.Id, 0,
+		domain.PromptData{Idea: idea.Idea, Fields: idea.IdeaFields, Tags: names},
+		idea.Idea, "")
+	if errors.Is(err, domain.ErrQuotaExceeded) {
+		logrus.Infof("skipping auto-tagging of idea %d: %v", idea.Id, err)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("error auto-tagging idea %d: %v", idea.Id, err)
+		return
+	}
+
+	chosen := parseTags(messages[len(messages)-1].Content, vocabulary)
+	if len(chosen) == 0 {
+		return
+	}
+	if err := u.tags.AddIdeaTags(ctx, idea.Id, chosen, domain.TagSourceAuto); err != nil {
+		logrus.Errorf("error saving auto tags of idea %d: %v", idea.Id, err)
+		return
+	}
+	if err := u.withTags(ctx, idea); err != nil {
+		logrus.Errorf("error loading tags of idea %d: %v", idea.Id, err)
+	}
+}
+
+// parseTags picks the ids of the vocabulary tags named in a model answer,
+// one per line or comma separated. Anything outside the vocabulary is
+// ignored.
+func parseTags(output string, vocabulary []domain.Tag) []int {
+	ids := map[string]int{}
+	for _, tag := range vocabulary {
+		ids[tag.Name] = tag.Id
+	}
+
+	chosen := []int{}
+	seen := map[int]bool{}
+	for _, field := range strings.FieldsFunc(output, func(r rune) bool { return r == '\n' || r == ',' }) {
+		name := strings.ToLower(strings.Join(strings.Fields(strings.Trim(field, " \t\r-*•\"'`.")), " "))
+		id, ok := ids[name]
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		chosen = append(chosen, id)
+		if len(chosen) == maxAutoTags {
+			break
+		}
+	}
+	return chosen
+}
@@ -2,18 +2,47 @@ package idea
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/Kocannn/self-dunking-ai/domain"
 	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/Kocannn/self-dunking-ai/pkg/i18n"
 	"github.com/Kocannn/self-dunking-ai/pkg/ollama"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
 type (
 	usecase struct {
-		dbTx pkgDB.DatabaseTransaction
-		repo domain.IdeaRepository
+		dbTx       pkgDB.DatabaseTransaction
+		repo       domain.IdeaRepository
+		prompt     domain.PromptUsecase
+		experiment domain.ExperimentUsecase
+		usage      domain.UsageUsecase
+		audit      domain.AuditUsecase
+		tags       domain.TagRepository
+		rubrics    domain.RubricRepository
+		personas   domain.PersonaRepository
+	}
+
+	// promptRun describes which prompt version (and experiment variant, if
+	// any) a single evaluation was produced with.
+	promptRun struct {
+		tpl          domain.PromptTemplate
+		experimentId int
+		variantId    int
+		// model is the workspace default, "" for OLLAMA_MODEL
+		model string
+		// rubric scores a critique instead of the built-in dimensions
+		rubric *domain.Rubric
+		// persona is the key of the reviewer the critic speaks as
+		persona string
+		// locale is the language the answer was asked in
+		locale    string
+		startedAt time.Time
 	}
 )
 
@@ -22,154 +51,674 @@ func (u *usecase) GetIdea(ctx context.Context, id int) (domain.SubmitIdeaRequest
 	data, err := u.repo.GetIdea(ctx, id)
 	if err != nil {
 		logrus.Errorf("error getting idea: %v", err)
+		return domain.SubmitIdeaRequest{}, notFound(err)
+	}
+	if err := authorize(ctx, data.UserId, domain.PermIdeaReadOwn, domain.PermIdeaReadAny); err != nil {
+		return domain.SubmitIdeaRequest{}, err
+	}
+	if err := u.withTags(ctx, &data); err != nil {
+		logrus.Errorf("error getting idea tags: %v", err)
 		return domain.SubmitIdeaRequest{}, err
 	}
 	return data, nil
 }
 
+// ListIdeas implements domain.IdeaUsecase.
+func (u *usecase) ListIdeas(ctx context.Context, filter domain.IdeaFilter, cursor string) (domain.IdeaPage, error) {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.IdeaPage{}, domain.ErrUnauthorized
+	}
+	if !user.Can(domain.PermIdeaReadOwn) {
+		return domain.IdeaPage{}, domain.ErrForbidden
+	}
+
+	switch filter.Sort {
+	case "":
+		filter.Sort = domain.IdeaSortCreatedDesc
+	case domain.IdeaSortCreated, domain.IdeaSortCreatedDesc, domain.IdeaSortScore, domain.IdeaSortScoreDesc:
+	default:
+		return domain.IdeaPage{}, fmt.Errorf("%w: sort must be one of created_at, -created_at, score, -score", domain.ErrBadRequest)
+	}
+	if filter.MinScore != nil && filter.MaxScore != nil && *filter.MinScore > *filter.MaxScore {
+		return domain.IdeaPage{}, fmt.Errorf("%w: min_score must not exceed max_score", domain.ErrBadRequest)
+	}
+	if filter.Status != "" && !domain.IsValidIdeaStatus(filter.Status) {
+		return domain.IdeaPage{}, fmt.Errorf("%w: unknown status %q", domain.ErrBadRequest, filter.Status)
+	}
+	if filter.Tag != "" {
+		tags, err := domain.NormalizeTags([]string{filter.Tag})
+		if err != nil {
+			return domain.IdeaPage{}, err
+		}
+		filter.Tag = tags[0]
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	if filter.Limit > maxPageSize {
+		filter.Limit = maxPageSize
+	}
+	if cursor != "" {
+		after, err := decodeCursor(cursor)
+		if err != nil {
+			return domain.IdeaPage{}, fmt.Errorf("%w: invalid cursor", domain.ErrBadRequest)
+		}
+		filter.After = &after
+	}
+
+	// one extra row tells whether another page follows
+	limit := filter.Limit
+	filter.Limit++
+	ideas, err := u.repo.ListIdeas(ctx, filter)
+	if err != nil {
+		logrus.Errorf("error listing ideas: %v", err)
+		return domain.IdeaPage{}, err
+	}
+
+	page := domain.IdeaPage{Ideas: ideas}
+	if len(ideas) > limit {
+		page.Ideas = ideas[:limit]
+		page.NextCursor = encodeCursor(filter.Sort, page.Ideas[limit-1])
+	}
+	if err := u.withPageTags(ctx, page.Ideas); err != nil {
+		logrus.Errorf("error listing idea tags: %v", err)
+		return domain.IdeaPage{}, err
+	}
+	return page, nil
+}
+
+// UpdateIdea implements domain.IdeaUsecase. The form is validated again as a
+// whole after the patch is applied.
+func (u *usecase) UpdateIdea(ctx context.Context, id int, req domain.UpdateIdeaRequest) (domain.SubmitIdeaRequest, error) {
+	var updated domain.SubmitIdeaRequest
+	err := u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		idea, err := u.repo.GetIdea(txCtx, id)
+		if err != nil {
+			return notFound(err)
+		}
+		if err := authorize(txCtx, idea.UserId, domain.PermIdeaWriteOwn, domain.PermIdeaWriteAny); err != nil {
+			return err
+		}
+
+		text, fields := idea.Idea, idea.IdeaFields
+		// a derived summary follows the fields it was derived from
+		if fields.Structured() && text == summarize(fields) {
+			text = ""
+		}
+		patch := map[*string]*string{
+			&text:                req.Idea,
+			&fields.Problem:      req.Problem,
+			&fields.Solution:     req.Solution,
+			&fields.Customer:     req.Customer,
+			&fields.RevenueModel: req.RevenueModel,
+			&fields.Competitors:  req.Competitors,
+			&fields.Stage:        req.Stage,
+			&fields.Constraints:  req.Constraints,
+		}
+		for dst, value := range patch {
+			if value != nil {
+				*dst = *value
+			}
+		}
+		text, fields, err = normalizeSubmission(text, fields)
+		if err != nil {
+			return err
+		}
+
+		updated = idea
+		now := time.Now()
+		updated.Idea = text
+		updated.IdeaFields = fields
+		updated.Language = detectLanguage(text, fields)
+		updated.UpdatedAt = &now
+		if err := u.repo.UpdateIdea(txCtx, updated); err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditIdeaUpdate,
+			TargetType: "idea",
+			TargetId:   strconv.Itoa(id),
+			Before:     idea,
+			After:      updated,
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error updating idea: %v", err)
+		return domain.SubmitIdeaRequest{}, err
+	}
+	return updated, nil
+}
+
+// DeleteIdea implements domain.IdeaUsecase. Ideas are only soft deleted so
+// their evaluations stay intact until the idea is restored.
+func (u *usecase) DeleteIdea(ctx context.Context, id int) error {
+	return u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		idea, err := u.repo.GetIdea(txCtx, id)
+		if err != nil {
+			return notFound(err)
+		}
+		if err := authorize(txCtx, idea.UserId, domain.PermIdeaWriteOwn, domain.PermIdeaWriteAny); err != nil {
+			return err
+		}
+		if err := u.repo.DeleteIdea(txCtx, id); err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditIdeaDelete,
+			TargetType: "idea",
+			TargetId:   strconv.Itoa(id),
+			Before:     idea,
+		})
+	})
+}
+
+// RestoreIdea implements domain.IdeaUsecase.
+func (u *usecase) RestoreIdea(ctx context.Context, id int) (domain.SubmitIdeaRequest, error) {
+	var restored domain.SubmitIdeaRequest
+	err := u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		idea, err := u.repo.GetDeletedIdea(txCtx, id)
+		if err != nil {
+			return notFound(err)
+		}
+		if err := authorize(txCtx, idea.UserId, domain.PermIdeaWriteOwn, domain.PermIdeaWriteAny); err != nil {
+			return err
+		}
+		if err := u.repo.RestoreIdea(txCtx, id); err != nil {
+			return err
+		}
+		restored = idea
+		restored.DeletedAt = nil
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditIdeaRestore,
+			TargetType: "idea",
+			TargetId:   strconv.Itoa(id),
+			Before:     idea,
+			After:      restored,
+		})
+	})
+	if err != nil {
+		return domain.SubmitIdeaRequest{}, err
+	}
+	return restored, nil
+}
+
 // SubmitIdeaStream implements domain.IdeaUsecase.
 func (u *usecase) SubmitIdeaStream(ctx context.Context, idea domain.SubmitIdeaRequest) (domain.SubmitIdeaRequest, error) {
+	text, fields, err := normalizeSubmission(idea.Idea, idea.IdeaFields)
+	if err != nil {
+		return domain.SubmitIdeaRequest{}, err
+	}
+	idea.Idea = text
+	idea.IdeaFields = fields
+	idea.Language = detectLanguage(text, fields)
+
 	createdIdea, err := u.repo.SubmitIdeaStream(ctx, idea)
 	if err != nil {
 		logrus.Errorf("error submitting idea stream: %v", err)
 		return domain.SubmitIdeaRequest{}, err
 	}
+	if idea.AutoTag {
+		u.autoTag(ctx, &createdIdea)
+	}
 	return createdIdea, nil
 }
 
 // DefendIdea implements domain.IdeaUsecase.
+func (u *usecase) DefendIdea(ctx context.Context, idea domain.Idea) ([]*domain.Message, error) {
+	stored, err := u.checkIdea(ctx, idea.Id)
+	if err != nil {
+		return nil, err
+	}
+	return u.evaluate(ctx, domain.PromptDefend, idea.Id, idea.PromptVersion,
+		domain.PromptData{Idea: idea.Text, Fields: stored.IdeaFields},
+		fmt.Sprintf("Critique: %s", idea.Critique),
+		"I'm sorry, I couldn't process your defense at this time.")
+}
 
-func (u *usecase) DefendIdea(ctx context.Context, critique string) ([]*domain.Message, error) {
-	var messages []*domain.Message
+// ImproveIdea implements domain.IdeaUsecase.
+func (u *usecase) ImproveIdea(ctx context.Context, idea domain.Idea) ([]*domain.Message, error) {
+	stored, err := u.checkIdea(ctx, idea.Id)
+	if err != nil {
+		return nil, err
+	}
+	return u.evaluate(ctx, domain.PromptImprove, idea.Id, idea.PromptVersion,
+		domain.PromptData{Idea: idea.Text, Fields: stored.IdeaFields},
+		fmt.Sprintf("Critique: %s", idea.Critique),
+		"I'm sorry, I couldn't process your defense at this time.")
+}
 
-	promptSystem := &domain.Message{
-		Role:    "system",
-		Content: domain.PROMPT_DEFEND,
+// SubmitIdea implements domain.IdeaUsecase.
+// A stored idea is critiqued with its saved form unless the request brings
+// its own fields.
+func (u *usecase) SubmitIdea(ctx context.Context, idea domain.Idea) ([]*domain.Message, error) {
+	stored, err := u.checkIdea(ctx, idea.Id)
+	if err != nil {
+		return nil, err
+	}
+	if idea.Id != 0 && !idea.IdeaFields.Structured() {
+		idea.IdeaFields = stored.IdeaFields
+		if idea.Text == "" {
+			idea.Text = stored.Idea
+		}
+	}
+	text, fields, err := normalizeSubmission(idea.Text, idea.IdeaFields)
+	if err != nil {
+		return nil, err
+	}
+	rubric, err := u.rubric(ctx, idea.RubricId)
+	if err != nil {
+		return nil, err
+	}
+	persona, err := u.persona(ctx, idea.Persona)
+	if err != nil {
+		return nil, err
 	}
 
-	promptUser := &domain.Message{
-		Role:    "user",
-		Content: fmt.Sprintf("Critique: %s", critique),
+	return u.evaluate(ctx, domain.PromptCritic, idea.Id, idea.PromptVersion,
+		domain.PromptData{Idea: text, Fields: fields, Rubric: rubric, Persona: persona},
+		text,
+		"I'm sorry, I couldn't process your idea at this time.")
+}
+
+// StreamSubmitIdea implements domain.IdeaUsecase.
+func (u *usecase) StreamSubmitIdea(ctx context.Context, id, promptVersion, rubricId int, stream domain.StreamFunc) error {
+	idea, err := u.repo.GetIdea(ctx, id)
+	if err != nil {
+		logrus.Errorf("error getting idea: %v", err)
+		return notFound(err)
+	}
+	if err := authorize(ctx, idea.UserId, domain.PermIdeaWriteOwn, domain.PermIdeaReview); err != nil {
+		return err
+	}
+
+	rubric, err := u.rubric(ctx, rubricId)
+	if err != nil {
+		return err
+	}
+
+	return u.stream(ctx, domain.PromptCritic, idea.Id, promptVersion,
+		domain.PromptData{Idea: idea.Idea, Fields: idea.IdeaFields, Rubric: rubric}, idea.Idea, stream)
+}
+
+// StreamDefendIdea implements domain.IdeaUsecase.
+func (u *usecase) StreamDefendIdea(ctx context.Context, idea domain.Idea, stream domain.StreamFunc) error {
+	stored, err := u.checkIdea(ctx, idea.Id)
+	if err != nil {
+		return err
+	}
+	return u.stream(ctx, domain.PromptDefend, idea.Id, idea.PromptVersion,
+		domain.PromptData{Idea: idea.Text, Fields: stored.IdeaFields}, idea.Critique, stream)
+}
+
+// StreamImproveIdea implements domain.IdeaUsecase.
+func (u *usecase) StreamImproveIdea(ctx context.Context, idea domain.Idea, stream domain.StreamFunc) error {
+	stored, err := u.checkIdea(ctx, idea.Id)
+	if err != nil {
+		return err
 	}
+	return u.stream(ctx, domain.PromptImprove, idea.Id, idea.PromptVersion,
+		domain.PromptData{Idea: idea.Text, Fields: stored.IdeaFields}, idea.Critique, stream)
+}
 
-	messages = append(messages, promptSystem, promptUser)
+// RateEvaluation implements domain.IdeaUsecase.
+func (u *usecase) RateEvaluation(ctx context.Context, id int, thumbsUp bool) (domain.Evaluation, error) {
+	data, err := u.repo.GetEvaluation(ctx, id)
+	if err != nil {
+		logrus.Errorf("error getting evaluation: %v", err)
+		return domain.Evaluation{}, notFound(err)
+	}
+	if err := authorize(ctx, data.UserId, domain.PermIdeaWriteOwn, domain.PermIdeaReview); err != nil {
+		return domain.Evaluation{}, err
+	}
 
-	response, err := ollama.PostPrompt(messages)
+	if err := u.repo.UpdateEvaluationFeedback(ctx, id, thumbsUp); err != nil {
+		logrus.Errorf("error rating evaluation: %v", err)
+		return domain.Evaluation{}, err
+	}
+	data.ThumbsUp = &thumbsUp
+	return data, nil
+}
+
+// evaluate renders the named prompt, sends it to the model and stores the
+// result as an evaluation stamped with the prompt version that was used.
+func (u *usecase) evaluate(ctx context.Context, kind string, ideaId, promptVersion int, data domain.PromptData, input, fallback string) ([]*domain.Message, error) {
+	messages, _, err := u.complete(ctx, kind, ideaId, promptVersion, data, input, fallback)
+	return messages, err
+}
+
+// complete is evaluate that also returns the stored evaluation.
+func (u *usecase) complete(ctx context.Context, kind string, ideaId, promptVersion int, data domain.PromptData, input, fallback string) ([]*domain.Message, domain.Evaluation, error) {
+	if err := u.checkQuota(ctx, 1); err != nil {
+		return nil, domain.Evaluation{}, err
+	}
+	messages, run, err := u.buildMessages(ctx, kind, promptVersion, data, input)
+	if err != nil {
+		return nil, domain.Evaluation{}, err
+	}
+
+	response, err := ollama.PostPrompt(run.model, messages)
 	if err != nil {
 		logrus.Errorf("error posting prompt: %v", err)
-		return nil, err
+		return nil, domain.Evaluation{}, err // Return error to caller
 	}
 
-	// Check if response is not nil and has messages
+	// Fall back to a canned answer when the model returned nothing
+	assistantMessage := &domain.Message{
+		Role:    "assistant",
+		Content: i18n.Translate(run.locale, fallback),
+	}
+	model := run.model
 	if response != nil && len(response.Messages) > 0 {
-		assistantMessage := &domain.Message{
-			Role:    "assistant",
-			Content: response.Messages[0].Content,
-		}
-		messages = append(messages, assistantMessage)
-	} else {
-		// Handle empty response case
-		assistantMessage := &domain.Message{
-			Role:    "assistant",
-			Content: "I'm sorry, I couldn't process your defense at this time.",
-		}
-		messages = append(messages, assistantMessage)
+		assistantMessage.Content = response.Messages[0].Content
+		model = response.Model
 	}
+	messages = append(messages, assistantMessage)
+
+	evaluation := u.saveEvaluation(ctx, kind, ideaId, run, model, input, assistantMessage.Content, estimateTokens(messages, assistantMessage.Content))
 
-	return messages, nil
+	return messages, evaluation, nil
 }
 
-// ImproveIdea implements domain.IdeaUsecase.
-func (u *usecase) ImproveIdea(ctx context.Context, critique string) ([]*domain.Message, error) {
-	var messages []*domain.Message
+func (u *usecase) stream(ctx context.Context, kind string, ideaId, promptVersion int, data domain.PromptData, input string, stream domain.StreamFunc) error {
+	if err := u.checkQuota(ctx, 1); err != nil {
+		return err
+	}
+	messages, run, err := u.buildMessages(ctx, kind, promptVersion, data, input)
+	if err != nil {
+		return err
+	}
+
+	output, err := stream(run.model, messages)
+	if err != nil {
+		logrus.Errorf("error streaming prompt: %v", err)
+		return err
+	}
+
+	u.saveEvaluation(ctx, kind, ideaId, run, run.model, input, output, estimateTokens(messages, output))
+	return nil
+}
+
+// buildMessages renders the system prompt. A pinned promptVersion always
+// wins, then the version the workspace pinned; otherwise a running
+// experiment may choose the version.
+func (u *usecase) buildMessages(ctx context.Context, kind string, promptVersion int, data domain.PromptData, input string) ([]*domain.Message, promptRun, error) {
+	run := promptRun{}
+	workspace, _ := domain.WorkspaceFromContext(ctx)
+	run.model = workspace.DefaultModel
+	if promptVersion == 0 {
+		promptVersion = workspace.PromptVersions[kind]
+	}
+	if promptVersion == 0 {
+		variant, err := u.experiment.Assign(ctx, kind, domain.SubjectFromContext(ctx))
+		if err != nil {
+			logrus.Errorf("error assigning %s experiment variant: %v", kind, err)
+		} else if variant != nil {
+			promptVersion = variant.PromptVersion
+			run.experimentId = variant.ExperimentId
+			run.variantId = variant.Id
+		}
+	}
+
+	if data.Locale == "" {
+		data.Locale = locale(ctx, data)
+	}
+	data.Language = i18n.Name(data.Locale)
+
+	system, tpl, err := u.prompt.Render(ctx, kind, promptVersion, data)
+	if err != nil {
+		logrus.Errorf("error rendering %s prompt: %v", kind, err)
+		return nil, promptRun{}, err
+	}
+	run.tpl = tpl
+	run.rubric = data.Rubric
+	run.locale = data.Locale
+	if data.Persona != nil {
+		run.persona = data.Persona.Key
+	}
+	run.startedAt = time.Now()
 
 	promptSystem := &domain.Message{
 		Role:    "system",
-		Content: domain.PROMPT_IMPROVE,
+		Content: system,
 	}
 
 	promptUser := &domain.Message{
 		Role:    "user",
-		Content: fmt.Sprintf("Critique: %s", critique),
+		Content: input,
 	}
 
-	messages = append(messages, promptSystem, promptUser)
+	return []*domain.Message{promptSystem, promptUser}, run, nil
+}
 
-	response, err := ollama.PostPrompt(messages)
-	if err != nil {
-		logrus.Errorf("error posting prompt: %v", err)
-		return nil, err
+// saveEvaluation only logs failures: the model answer is already produced and
+// should still reach the caller. The returned evaluation has no id when it
+// could not be stored.
+func (u *usecase) saveEvaluation(ctx context.Context, kind string, ideaId int, run promptRun, model, input, output string, tokens int64) domain.Evaluation {
+	user, _ := domain.UserFromContext(ctx)
+
+	now := time.Now()
+	evaluation := domain.Evaluation{
+		IdeaId:        ideaId,
+		UserId:        user.Id,
+		Kind:          kind,
+		PromptId:      run.tpl.Id,
+		PromptVersion: run.tpl.Version,
+		Persona:       run.persona,
+		Locale:        run.locale,
+		ExperimentId:  run.experimentId,
+		VariantId:     run.variantId,
+		Model:         model,
+		Input:         input,
+		Output:        output,
+		LatencyMs:     now.Sub(run.startedAt).Milliseconds(),
+		Tokens:        tokens,
+		CreatedAt:     &now,
 	}
 
-	// Check if response is not nil and has messages
-	if response != nil && len(response.Messages) > 0 {
-		assistantMessage := &domain.Message{
-			Role:    "assistant",
-			Content: response.Messages[0].Content,
-		}
-		messages = append(messages, assistantMessage)
+	if kind == domain.PromptCritic {
+		score(&evaluation, run.rubric, output)
+	}
+
+	saved, err := u.repo.CreateEvaluation(ctx, evaluation)
+	if err != nil {
+		logrus.Errorf("error saving %s evaluation: %v", kind, err)
 	} else {
-		// Handle empty response case
-		assistantMessage := &domain.Message{
-			Role:    "assistant",
-			Content: "I'm sorry, I couldn't process your defense at this time.",
+		evaluation = saved
+	}
+
+	if evaluation.OverallScore != nil && ideaId != 0 {
+		if err := u.repo.UpdateIdeaScore(ctx, ideaId, *evaluation.OverallScore); err != nil {
+			logrus.Errorf("error updating idea score: %v", err)
 		}
-		messages = append(messages, assistantMessage)
+	}
+	if kind == domain.PromptCritic && ideaId != 0 && err == nil {
+		u.markCritiqued(ctx, ideaId)
 	}
 
-	return messages, nil
+	if user.Id != 0 {
+		if err := u.usage.RecordUsage(ctx, user.Id, tokens); err != nil {
+			logrus.Errorf("error recording usage: %v", err)
+		}
+	}
+	return evaluation
 }
 
-// SubmitIdea implements domain.IdeaUsecase.
-func (u *usecase) SubmitIdea(ctx context.Context, idea string) ([]*domain.Message, error) {
-	var messages []*domain.Message
+// checkQuota runs before every model call, since a request or a job may
+// make several and QuotaMiddleware only sees the first. A request that
+// knows how many calls it makes checks for all of them up front, so it does
+// not stop halfway. saveEvaluation charges every call afterwards.
+func (u *usecase) checkQuota(ctx context.Context, calls int) error {
+	user, _ := domain.UserFromContext(ctx)
+	if user.Id == 0 {
+		return nil
+	}
+	status, err := u.usage.CheckQuotaFor(ctx, user, int64(calls))
+	if err != nil {
+		logrus.Errorf("error checking quota: %v", err)
+		return nil
+	}
+	if status.Exceeded && status.Remaining > 0 {
+		return fmt.Errorf("%w: this needs %d evaluations but the %s quota of the %s plan has %d left", domain.ErrQuotaExceeded, calls, status.Period, status.Plan, status.Remaining)
+	}
+	if status.Exceeded {
+		return fmt.Errorf("%w: the %s %s quota of the %s plan is used up", domain.ErrQuotaExceeded, status.Period, status.Unit, status.Plan)
+	}
+	return nil
+}
 
-	promptSystem := &domain.Message{
-		Role:    "system",
-		Content: domain.PROMPT_CRITIC,
+// score fills in the scores of a critique, from the rubric when there is
+// one and from the built-in dimensions otherwise.
+func score(evaluation *domain.Evaluation, rubric *domain.Rubric, output string) {
+	evaluation.Weaknesses = parseWeaknesses(output)
+	if rubric != nil {
+		scores, ok := parseRubricScores(output, *rubric)
+		evaluation.RubricId = rubric.Id
+		evaluation.DimensionScores = scores
+		evaluation.ParseFailed = !ok
+		if ok {
+			overall := weightedScore(*rubric, scores)
+			evaluation.OverallScore = &overall
+		}
+		return
 	}
 
-	promptUser := &domain.Message{
-		Role:    "user",
-		Content: idea,
+	s, ok := parseScores(output)
+	evaluation.ScoreOriginality = s.originality
+	evaluation.ScoreScalability = s.scalability
+	evaluation.ScoreFeasibility = s.feasibility
+	evaluation.ParseFailed = !ok
+	if ok {
+		overall := s.mean()
+		evaluation.OverallScore = &overall
 	}
+}
 
-	messages = append(messages, promptSystem, promptUser)
+// rubric returns the rubric a critique is scored with: the one asked for,
+// else the workspace default, else nil for the built-in dimensions.
+func (u *usecase) rubric(ctx context.Context, id int) (*domain.Rubric, error) {
+	var (
+		rubric domain.Rubric
+		err    error
+	)
+	if id != 0 {
+		rubric, err = u.rubrics.GetRubric(ctx, id)
+	} else {
+		rubric, err = u.rubrics.GetDefaultRubric(ctx)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if id != 0 {
+			return nil, fmt.Errorf("%w: unknown rubric %d", domain.ErrBadRequest, id)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		logrus.Errorf("error getting rubric: %v", err)
+		return nil, err
+	}
+	return &rubric, nil
+}
 
-	response, err := ollama.PostPrompt(messages)
+// persona resolves a persona key to a built-in or workspace persona; the
+// empty key is the default critic.
+func (u *usecase) persona(ctx context.Context, key string) (*domain.Persona, error) {
+	if key == "" {
+		return nil, nil
+	}
+	if persona, ok := domain.BuiltinPersona(key); ok {
+		return &persona, nil
+	}
+	persona, err := u.personas.GetPersonaByKey(ctx, key)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: unknown persona %q", domain.ErrBadRequest, key)
+	}
 	if err != nil {
-		logrus.Errorf("error posting prompt: %v", err)
-		return nil, err // Return error to caller
+		logrus.Errorf("error getting persona: %v", err)
+		return nil, err
 	}
+	return &persona, nil
+}
 
-	// Check if response is not nil and has messages
-	if response != nil && len(response.Messages) > 0 {
-		assistantMessage := &domain.Message{
-			Role:    "assistant",
-			Content: response.Messages[0].Content,
+// estimateTokens approximates the tokens of a run at four characters per
+// token; the model API does not report counts for every backend.
+func estimateTokens(messages []*domain.Message, output string) int64 {
+	chars := len(output)
+	for _, message := range messages {
+		chars += len(message.Content)
+	}
+	return int64((chars + 3) / 4)
+}
+
+// checkIdea makes sure an evaluation may be attached to a stored idea and
+// returns it. Ad-hoc evaluations without an idea id only need the write
+// permission.
+func (u *usecase) checkIdea(ctx context.Context, ideaId int) (domain.SubmitIdeaRequest, error) {
+	if ideaId == 0 {
+		user, ok := domain.UserFromContext(ctx)
+		if !ok {
+			return domain.SubmitIdeaRequest{}, domain.ErrUnauthorized
 		}
-		messages = append(messages, assistantMessage)
-	} else {
-		// Handle empty response case
-		assistantMessage := &domain.Message{
-			Role:    "assistant",
-			Content: "I'm sorry, I couldn't process your idea at this time.",
+		if !user.Can(domain.PermIdeaWriteOwn) {
+			return domain.SubmitIdeaRequest{}, domain.ErrForbidden
 		}
-		messages = append(messages, assistantMessage)
+		return domain.SubmitIdeaRequest{}, nil
 	}
+	idea, err := u.repo.GetIdea(ctx, ideaId)
+	if err != nil {
+		return domain.SubmitIdeaRequest{}, notFound(err)
+	}
+	if err := authorize(ctx, idea.UserId, domain.PermIdeaWriteOwn, domain.PermIdeaReview); err != nil {
+		return domain.SubmitIdeaRequest{}, err
+	}
+	return idea, nil
+}
+
+// authorize lets the owner through with ownPermission and anybody else only
+// with anyPermission. In a team workspace every member may read the shared
+// ideas with ownPermission, while acting on a teammate's idea takes a
+// workspace admin.
+func authorize(ctx context.Context, ownerId int, ownPermission, anyPermission string) error {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+	if ownerId != 0 && ownerId == user.Id && user.Can(ownPermission) {
+		return nil
+	}
+	if workspace, ok := domain.WorkspaceFromContext(ctx); ok && !workspace.Personal && user.Can(ownPermission) {
+		if ownPermission == domain.PermIdeaReadOwn || workspace.HasMemberRole(domain.WorkspaceAdmin) {
+			return nil
+		}
+	}
+	if user.Can(anyPermission) {
+		return nil
+	}
+	return domain.ErrForbidden
+}
 
-	return messages, nil
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrNotFound
+	}
+	return err
 }
 
 var (
 	uc *usecase
 )
 
-func NewIdeaUsecase(dbTx pkgDB.DatabaseTransaction, repo domain.IdeaRepository) domain.IdeaUsecase {
+func NewIdeaUsecase(dbTx pkgDB.DatabaseTransaction, repo domain.IdeaRepository, prompt domain.PromptUsecase, experiment domain.ExperimentUsecase, usage domain.UsageUsecase, audit domain.AuditUsecase, tags domain.TagRepository, rubrics domain.RubricRepository, personas domain.PersonaRepository) domain.IdeaUsecase {
 	if uc == nil {
 		uc = &usecase{
-			dbTx: dbTx,
-			repo: repo,
+			dbTx:       dbTx,
+			repo:       repo,
+			prompt:     prompt,
+			experiment: experiment,
+			usage:      usage,
+			audit:      audit,
+			tags:       tags,
+			rubrics:    rubrics,
+			personas:   personas,
 		}
 	}
 	return uc
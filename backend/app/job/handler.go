@@ -0,0 +1,185 @@
+package job
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	handler struct {
+		usecase domain.JobUsecase
+	}
+)
+
+// EnqueueJob implements domain.JobHandler.
+func (h *handler) EnqueueJob(w http.ResponseWriter, r *http.Request) {
+	req := domain.EnqueueJobRequest{}
+	if !decode(w, r, &req) {
+		return
+	}
+
+	data, err := h.usecase.EnqueueJob(r.Context(), req)
+	if err != nil {
+		utils.RespondError(w, "error enqueueing job", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusAccepted,
+		Message: "Job queued successfully",
+		Data:    data,
+	}, w)
+}
+
+// ListJobs implements domain.JobHandler.
+func (h *handler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	data, err := h.usecase.ListJobs(r.Context(), query.Get("status"), query.Get("type"))
+	if err != nil {
+		utils.RespondError(w, "error listing jobs", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Jobs retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// GetJob implements domain.JobHandler.
+func (h *handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := h.usecase.GetJob(r.Context(), id)
+	if err != nil {
+		utils.RespondError(w, "error getting job", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Job retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// CancelJob implements domain.JobHandler.
+func (h *handler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := h.usecase.CancelJob(r.Context(), id)
+	if err != nil {
+		utils.RespondError(w, "error cancelling job", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Job cancelled successfully",
+		Data:    data,
+	}, w)
+}
+
+// RetryJob implements domain.JobHandler.
+func (h *handler) RetryJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := h.usecase.RetryJob(r.Context(), id)
+	if err != nil {
+		utils.RespondError(w, "error retrying job", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Job queued again successfully",
+		Data:    data,
+	}, w)
+}
+
+// ListAllJobs implements domain.JobHandler.
+func (h *handler) ListAllJobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	data, err := h.usecase.ListAllJobs(r.Context(), query.Get("status"), query.Get("type"))
+	if err != nil {
+		utils.RespondError(w, "error listing jobs", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Jobs retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// GetJobStats implements domain.JobHandler.
+func (h *handler) GetJobStats(w http.ResponseWriter, r *http.Request) {
+	data, err := h.usecase.CountJobs(r.Context())
+	if err != nil {
+		utils.RespondError(w, "error counting jobs", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Job statistics retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+func pathId(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return 0, false
+	}
+	return id, true
+}
+
+func decode(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return false
+	}
+	return true
+}
+
+var (
+	handlr *handler
+)
+
+func NewJobHandler(usecase domain.JobUsecase) domain.JobHandler {
+	if handlr == nil {
+		handlr = &handler{
+			usecase,
+		}
+	}
+	return handlr
+}
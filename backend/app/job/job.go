@@ -0,0 +1,16 @@
+package job
+
+import (
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+)
+
+func InitJobRepository(db pkgDB.DatabaseTransaction) domain.JobRepository {
+	return NewJobRepository(db)
+}
+func InitJobUsecase(dbTx pkgDB.DatabaseTransaction, repo domain.JobRepository, users domain.UserRepository, workspaces domain.WorkspaceUsecase, audit domain.AuditUsecase) domain.JobUsecase {
+	return NewJobUsecase(dbTx, repo, users, workspaces, audit)
+}
+func InitJobHandler(usecase domain.JobUsecase) domain.JobHandler {
+	return NewJobHandler(usecase)
+}
@@ -0,0 +1,173 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	repository struct {
+		db pkgDB.DatabaseTransaction
+	}
+)
+
+// CreateJob implements domain.JobRepository.
+func (r *repository) CreateJob(ctx context.Context, job domain.Job) (domain.Job, error) {
+	if err := r.db.DB(ctx).Create(&job).Error; err != nil {
+		return domain.Job{}, err
+	}
+	return job, nil
+}
+
+// GetJob implements domain.JobRepository.
+func (r *repository) GetJob(ctx context.Context, id int) (domain.Job, error) {
+	data := domain.Job{}
+	if err := r.db.DB(ctx).First(&data, id).Error; err != nil {
+		return domain.Job{}, err
+	}
+	return data, nil
+}
+
+// ListJobs implements domain.JobRepository.
+func (r *repository) ListJobs(ctx context.Context, filter domain.JobFilter) ([]domain.Job, error) {
+	data := []domain.Job{}
+	query := r.db.DB(ctx)
+	if filter.WorkspaceId != 0 {
+		query = query.Where("workspace_id = ?", filter.WorkspaceId)
+	}
+	if filter.UserId != 0 {
+		query = query.Where("user_id = ?", filter.UserId)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if err := query.Order("id DESC").Limit(domain.JobListLimit).Find(&data).Error; err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CountJobs implements domain.JobRepository.
+func (r *repository) CountJobs(ctx context.Context) ([]domain.JobCount, error) {
+	data := []domain.JobCount{}
+	err := r.db.DB(ctx).Model(&domain.Job{}).
+		Select("type, status, COUNT(*) AS count").
+		Group("type, status").
+		Order("type, status").
+		Scan(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ClaimJob implements domain.JobRepository.
+func (r *repository) ClaimJob(ctx context.Context, types []string, staleBefore time.Time) (domain.Job, error) {
+	data := domain.Job{}
+	query := r.db.DB(ctx).Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_at < ?)",
+			domain.JobQueued, time.Now(), domain.JobRunning, staleBefore)
+	if len(types) > 0 {
+		query = query.Where("type IN ?", types)
+	}
+	if err := query.Order("run_at, id").Take(&data).Error; err != nil {
+		return domain.Job{}, err
+	}
+	return data, nil
+}
+
+// StartJob implements domain.JobRepository.
+func (r *repository) StartJob(ctx context.Context, id int, workerId string) error {
+	now := time.Now()
+	return r.db.DB(ctx).Model(&domain.Job{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     domain.JobRunning,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"locked_by":  workerId,
+			"locked_at":  now,
+			"started_at": now,
+		}).Error
+}
+
+// FinishJob implements domain.JobRepository.
+func (r *repository) FinishJob(ctx context.Context, job domain.Job, workerId string) error {
+	result := r.db.DB(ctx).Model(&domain.Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", job.Id, domain.JobRunning, workerId).
+		Updates(map[string]interface{}{
+			"status":      job.Status,
+			"result":      job.Result,
+			"run_at":      job.RunAt,
+			"last_error":  job.LastError,
+			"locked_by":   "",
+			"locked_at":   nil,
+			"finished_at": job.FinishedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdateJobStatus implements domain.JobRepository.
+func (r *repository) UpdateJobStatus(ctx context.Context, id int, from []string, to string) error {
+	updates := map[string]interface{}{"status": to}
+	if to == domain.JobCancelled {
+		updates["finished_at"] = time.Now()
+	}
+
+	result := r.db.DB(ctx).Model(&domain.Job{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RequeueJob implements domain.JobRepository.
+func (r *repository) RequeueJob(ctx context.Context, id int) error {
+	result := r.db.DB(ctx).Model(&domain.Job{}).
+		Where("id = ? AND status IN ?", id, []string{domain.JobDead, domain.JobCancelled}).
+		Updates(map[string]interface{}{
+			"status":      domain.JobQueued,
+			"attempts":    0,
+			"run_at":      time.Now(),
+			"last_error":  "",
+			"finished_at": nil,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+var (
+	repo *repository
+)
+
+func NewJobRepository(db pkgDB.DatabaseTransaction) domain.JobRepository {
+	if repo == nil {
+		repo = &repository{
+			db,
+		}
+	}
+	return repo
+}
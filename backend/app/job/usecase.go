@@ -0,0 +1,408 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// pollInterval is how long an idle worker waits before it looks for due
+// jobs again.
+const pollInterval = 2 * time.Second
+
+type (
+	usecase struct {
+		dbTx       pkgDB.DatabaseTransaction
+		repo       domain.JobRepository
+		users      domain.UserRepository
+		workspaces domain.WorkspaceUsecase
+		audit      domain.AuditUsecase
+		mu         sync.RWMutex
+		// runners maps the job types to the functions that run them
+		runners map[string]domain.JobFunc
+	}
+)
+
+// Register implements domain.JobUsecase.
+func (u *usecase) Register(jobType string, run domain.JobFunc) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.runners[jobType] = run
+}
+
+// EnqueueJob implements domain.JobUsecase.
+func (u *usecase) EnqueueJob(ctx context.Context, req domain.EnqueueJobRequest) (domain.Job, error) {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.Job{}, domain.ErrUnauthorized
+	}
+	if !user.Can(domain.PermIdeaWriteOwn) {
+		return domain.Job{}, domain.ErrForbidden
+	}
+	if err := req.CheckPayload(); err != nil {
+		return domain.Job{}, err
+	}
+	if req.MaxAttempts < 0 || req.MaxAttempts > domain.MaxJobAttempts {
+		return domain.Job{}, fmt.Errorf("%w: max_attempts must be between 1 and %d", domain.ErrBadRequest, domain.MaxJobAttempts)
+	}
+
+	job, err := domain.NewJob(req.Type, req.Payload)
+	if err != nil {
+		return domain.Job{}, err
+	}
+	workspace, _ := domain.WorkspaceFromContext(ctx)
+	job.UserId = user.Id
+	job.Scopes = user.Scopes
+	job.WorkspaceId = workspace.Id
+	if req.MaxAttempts != 0 {
+		job.MaxAttempts = req.MaxAttempts
+	}
+
+	job, err = u.repo.CreateJob(ctx, job)
+	if err != nil {
+		logrus.Errorf("error enqueueing job: %v", err)
+		return domain.Job{}, err
+	}
+	return job, nil
+}
+
+// ListJobs implements domain.JobUsecase.
+func (u *usecase) ListJobs(ctx context.Context, status, jobType string) ([]domain.Job, error) {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+	workspace, _ := domain.WorkspaceFromContext(ctx)
+	filter := domain.JobFilter{
+		WorkspaceId: workspace.Id,
+		UserId:      user.Id,
+		Status:      status,
+		Type:        jobType,
+	}
+	if workspace.HasMemberRole(domain.WorkspaceAdmin) {
+		filter.UserId = 0
+	}
+	return u.repo.ListJobs(ctx, filter)
+}
+
+// ListAllJobs implements domain.JobUsecase.
+func (u *usecase) ListAllJobs(ctx context.Context, status, jobType string) ([]domain.Job, error) {
+	if err := manage(ctx); err != nil {
+		return nil, err
+	}
+	return u.repo.ListJobs(ctx, domain.JobFilter{Status: status, Type: jobType})
+}
+
+// GetJob implements domain.JobUsecase.
+func (u *usecase) GetJob(ctx context.Context, id int) (domain.Job, error) {
+	job, err := u.repo.GetJob(ctx, id)
+	if err != nil {
+		return domain.Job{}, notFound(err)
+	}
+	if err := visible(ctx, job); err != nil {
+		return domain.Job{}, err
+	}
+	return job, nil
+}
+
+// CancelJob implements domain.JobUsecase.
+func (u *usecase) CancelJob(ctx context.Context, id int) (domain.Job, error) {
+	job, err := u.GetJob(ctx, id)
+	if err != nil {
+		return domain.Job{}, err
+	}
+	if job.Status != domain.JobQueued {
+		return domain.Job{}, fmt.Errorf("%w: only a queued job can be cancelled, this one is %s", domain.ErrConflict, job.Status)
+	}
+
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		err := u.repo.UpdateJobStatus(txCtx, id, []string{domain.JobQueued}, domain.JobCancelled)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: the job has already started", domain.ErrConflict)
+		}
+		if err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditJobCancel,
+			TargetType: "job",
+			TargetId:   strconv.Itoa(id),
+			Before:     map[string]interface{}{"status": job.Status},
+			After:      map[string]interface{}{"status": domain.JobCancelled},
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error cancelling job: %v", err)
+		return domain.Job{}, err
+	}
+	return u.repo.GetJob(ctx, id)
+}
+
+// RetryJob implements domain.JobUsecase.
+func (u *usecase) RetryJob(ctx context.Context, id int) (domain.Job, error) {
+	job, err := u.GetJob(ctx, id)
+	if err != nil {
+		return domain.Job{}, err
+	}
+	if job.Status != domain.JobDead && job.Status != domain.JobCancelled {
+		return domain.Job{}, fmt.Errorf("%w: only a dead or cancelled job can be retried, this one is %s", domain.ErrConflict, job.Status)
+	}
+
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		err := u.repo.RequeueJob(txCtx, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: the job was already queued again", domain.ErrConflict)
+		}
+		if err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditJobRetry,
+			TargetType: "job",
+			TargetId:   strconv.Itoa(id),
+			Before:     map[string]interface{}{"status": job.Status, "attempts": job.Attempts},
+			After:      map[string]interface{}{"status": domain.JobQueued, "attempts": 0},
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error retrying job: %v", err)
+		return domain.Job{}, err
+	}
+	return u.repo.GetJob(ctx, id)
+}
+
+// CountJobs implements domain.JobUsecase.
+func (u *usecase) CountJobs(ctx context.Context) ([]domain.JobCount, error) {
+	if err := manage(ctx); err != nil {
+		return nil, err
+	}
+	return u.repo.CountJobs(ctx)
+}
+
+// RunWorker implements domain.JobUsecase.
+func (u *usecase) RunWorker(ctx context.Context, options domain.WorkerOptions) error {
+	if options.Concurrency < 1 {
+		return fmt.Errorf("%w: concurrency must be at least 1", domain.ErrBadRequest)
+	}
+	u.mu.RLock()
+	for _, jobType := range options.Types {
+		if _, ok := u.runners[jobType]; !ok {
+			u.mu.RUnlock()
+			return fmt.Errorf("%w: unknown job type %q", domain.ErrBadRequest, jobType)
+		}
+	}
+	u.mu.RUnlock()
+
+	logrus.Infof("worker %s started with %d slots", options.Id, options.Concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < options.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u.poll(ctx, options)
+		}()
+	}
+	wg.Wait()
+	logrus.Infof("worker %s stopped", options.Id)
+	return nil
+}
+
+// poll runs due jobs one after the other and sleeps while the queue is
+// empty.
+func (u *usecase) poll(ctx context.Context, options domain.WorkerOptions) {
+	for ctx.Err() == nil {
+		job, err := u.claim(ctx, options)
+		if err == nil {
+			u.run(job, options.Id)
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logrus.Errorf("error claiming job: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// claim locks the next due job and marks it running in one short
+// transaction, so the lock is not held while the job runs.
+func (u *usecase) claim(ctx context.Context, options domain.WorkerOptions) (domain.Job, error) {
+	job := domain.Job{}
+	err := u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		claimed, err := u.repo.ClaimJob(txCtx, options.Types, time.Now().Add(-domain.JobLease))
+		if err != nil {
+			return err
+		}
+		if err := u.repo.StartJob(txCtx, claimed.Id, options.Id); err != nil {
+			return err
+		}
+		job = claimed
+		job.Attempts++
+		return nil
+	})
+	return job, err
+}
+
+// run executes a claimed job and stores its outcome. A job that was claimed
+// finishes even if the worker is asked to stop meanwhile.
+func (u *usecase) run(job domain.Job, workerId string) {
+	ctx, cancel := context.WithTimeout(context.Background(), domain.JobTimeout)
+	defer cancel()
+
+	result, err := u.execute(ctx, job)
+	now := time.Now()
+	job.LastError = ""
+	job.Result = nil
+	switch {
+	case err == nil:
+		job.Status = domain.JobSucceeded
+		job.FinishedAt = &now
+		if result != nil {
+			if job.Result, err = json.Marshal(result); err != nil {
+				logrus.Errorf("error encoding result of job %d: %v", job.Id, err)
+			}
+		}
+	case permanent(err) || job.Attempts >= job.MaxAttempts:
+		logrus.Errorf("job %d (%s) is dead after %d attempts: %v", job.Id, job.Type, job.Attempts, err)
+		job.Status = domain.JobDead
+		job.LastError = err.Error()
+		job.FinishedAt = &now
+	default:
+		logrus.Warnf("job %d (%s) failed, attempt %d of %d: %v", job.Id, job.Type, job.Attempts, job.MaxAttempts, err)
+		job.Status = domain.JobQueued
+		job.LastError = err.Error()
+		job.RunAt = now.Add(backoff(job.Attempts))
+	}
+
+	// the outcome is stored even when the run hit its timeout
+	if err := u.repo.FinishJob(context.WithoutCancel(ctx), job, workerId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logrus.Warnf("job %d was taken over by another worker, its outcome is dropped", job.Id)
+			return
+		}
+		logrus.Errorf("error finishing job %d: %v", job.Id, err)
+	}
+}
+
+// execute runs job on behalf of the user who queued it, in the workspace it
+// was queued in.
+func (u *usecase) execute(ctx context.Context, job domain.Job) (result interface{}, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+
+	u.mu.RLock()
+	runner, ok := u.runners[job.Type]
+	u.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown job type %q", domain.ErrBadRequest, job.Type)
+	}
+
+	if job.UserId != 0 {
+		user, err := u.users.FindById(ctx, job.UserId)
+		if err != nil {
+			return nil, fmt.Errorf("loading the user who queued the job: %w", notFound(err))
+		}
+		// a job queued with an API key keeps its scopes
+		user.Scopes = job.Scopes
+		workspace, err := u.workspaces.Resolve(ctx, user, job.WorkspaceId)
+		if err != nil {
+			return nil, err
+		}
+		ctx = context.WithValue(ctx, domain.ContextUser, user)
+		ctx = context.WithValue(ctx, domain.ContextWorkspace, workspace)
+		ctx = context.WithValue(ctx, domain.ContextSubject, "user:"+strconv.Itoa(user.Id))
+	}
+	ctx = context.WithValue(ctx, domain.ContextRequestId, "job-"+strconv.Itoa(job.Id))
+	return runner(ctx, job)
+}
+
+// backoff doubles the delay with every attempt.
+func backoff(attempts int) time.Duration {
+	delay := domain.JobRetryBaseDelay
+	for i := 1; i < attempts && delay < domain.JobRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > domain.JobRetryMaxDelay {
+		delay = domain.JobRetryMaxDelay
+	}
+	return delay
+}
+
+// permanent reports whether retrying cannot help.
+func permanent(err error) bool {
+	return errors.Is(err, domain.ErrBadRequest) || errors.Is(err, domain.ErrNotFound) ||
+		errors.Is(err, domain.ErrForbidden) || errors.Is(err, domain.ErrUnauthorized) ||
+		errors.Is(err, domain.ErrQuotaExceeded)
+}
+
+// visible lets job managers see every job and anybody else the jobs they
+// queued, or those of their workspace when they administer it.
+func visible(ctx context.Context, job domain.Job) error {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+	if user.Can(domain.PermJobManage) {
+		return nil
+	}
+	workspace, _ := domain.WorkspaceFromContext(ctx)
+	if job.WorkspaceId != workspace.Id {
+		return domain.ErrNotFound
+	}
+	if job.UserId != user.Id && !workspace.HasMemberRole(domain.WorkspaceAdmin) {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+func manage(ctx context.Context) error {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+	if !user.Can(domain.PermJobManage) {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrNotFound
+	}
+	return err
+}
+
+var (
+	uc *usecase
+)
+
+func NewJobUsecase(dbTx pkgDB.DatabaseTransaction, repo domain.JobRepository, users domain.UserRepository, workspaces domain.WorkspaceUsecase, audit domain.AuditUsecase) domain.JobUsecase {
+	if uc == nil {
+		uc = &usecase{
+			dbTx:       dbTx,
+			repo:       repo,
+			users:      users,
+			workspaces: workspaces,
+			audit:      audit,
+			runners:    map[string]domain.JobFunc{},
+		}
+	}
+	return uc
+}
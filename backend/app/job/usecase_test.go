@@ -0,0 +1,181 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+)
+
+// fakeRepo records the outcome of the last run.
+type fakeRepo struct {
+	domain.JobRepository
+	finished []domain.Job
+}
+
+func (r *fakeRepo) FinishJob(ctx context.Context, job domain.Job, workerId string) error {
+	r.finished = append(r.finished, job)
+	return nil
+}
+
+func (r *fakeRepo) last() domain.Job {
+	return r.finished[len(r.finished)-1]
+}
+
+type fakeUsers struct {
+	domain.UserRepository
+	user domain.User
+}
+
+func (f fakeUsers) FindById(ctx context.Context, id int) (domain.User, error) {
+	return f.user, nil
+}
+
+type fakeWorkspaces struct {
+	domain.WorkspaceUsecase
+}
+
+func (fakeWorkspaces) Resolve(ctx context.Context, user domain.User, workspaceId int) (domain.Workspace, error) {
+	return domain.Workspace{Id: workspaceId}, nil
+}
+
+func TestExecuteKeepsAPIKeyScopes(t *testing.T) {
+	u := &usecase{
+		users:      fakeUsers{user: domain.User{Id: 7, Role: domain.RoleMember}},
+		workspaces: fakeWorkspaces{},
+		runners:    map[string]domain.JobFunc{},
+	}
+	var runAs domain.User
+	u.Register(domain.JobEvaluation, func(ctx context.Context, job domain.Job) (interface{}, error) {
+		runAs, _ = domain.UserFromContext(ctx)
+		return nil, nil
+	})
+
+	for _, scopes := range [][]string{nil, {}, {domain.PermIdeaReadOwn}} {
+		job, err := domain.NewJob(domain.JobEvaluation, domain.EvaluationPayload{IdeaId: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		job.UserId, job.WorkspaceId, job.Scopes = 7, 3, scopes
+		if _, err := u.execute(context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+		if (runAs.Scopes == nil) != (scopes == nil) || len(runAs.Scopes) != len(scopes) {
+			t.Errorf("job with scopes %#v ran with %#v", scopes, runAs.Scopes)
+		}
+		if want := scopes == nil; runAs.Can(domain.PermIdeaWriteOwn) != want {
+			t.Errorf("job with scopes %#v: can write = %v, want %v", scopes, !want, want)
+		}
+	}
+}
+
+func TestRunDoesNotRetryExhaustedQuota(t *testing.T) {
+	repo := &fakeRepo{}
+	u := &usecase{repo: repo, runners: map[string]domain.JobFunc{}}
+	u.Register(domain.JobDebate, func(ctx context.Context, job domain.Job) (interface{}, error) {
+		return nil, fmt.Errorf("%w: the day evaluations quota of the free plan is used up", domain.ErrQuotaExceeded)
+	})
+
+	job, err := domain.NewJob(domain.JobDebate, domain.DebatePayload{IdeaId: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.Attempts = 1
+	u.run(job, "test")
+	if got := repo.last(); got.Status != domain.JobDead {
+		t.Errorf("job is %s after the quota ran out, want dead", got.Status)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute}
+	for i, delay := range want {
+		if got := backoff(i + 1); got != delay {
+			t.Errorf("backoff(%d) = %s, want %s", i+1, got, delay)
+		}
+	}
+	if got := backoff(20); got != domain.JobRetryMaxDelay {
+		t.Errorf("backoff(20) = %s, want the cap of %s", got, domain.JobRetryMaxDelay)
+	}
+}
+
+func TestRunRetriesWithBackoff(t *testing.T) {
+	repo := &fakeRepo{}
+	u := &usecase{repo: repo, runners: map[string]domain.JobFunc{}}
+	calls := 0
+	u.Register(domain.JobEmail, func(ctx context.Context, job domain.Job) (interface{}, error) {
+		calls++
+		return nil, errors.New("451 mailbox temporarily unavailable")
+	})
+
+	job, err := domain.NewJob(domain.JobEmail, domain.EmailPayload{EmailId: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.MaxAttempts = 3
+
+	for attempt := 1; attempt < job.MaxAttempts; attempt++ {
+		job.Attempts = attempt
+		before := time.Now()
+		u.run(job, "test")
+
+		got := repo.last()
+		if got.Status != domain.JobQueued || got.LastError == "" {
+			t.Fatalf("attempt %d left the job %s (%q), want it queued again", attempt, got.Status, got.LastError)
+		}
+		if delay := got.RunAt.Sub(before); delay < backoff(attempt) || delay > backoff(attempt)+time.Second {
+			t.Errorf("attempt %d is retried in %s, want %s", attempt, delay, backoff(attempt))
+		}
+		job = got
+	}
+
+	job.Attempts = job.MaxAttempts
+	u.run(job, "test")
+	if got := repo.last(); got.Status != domain.JobDead || got.FinishedAt == nil {
+		t.Errorf("the last attempt left the job %s, want dead", got.Status)
+	}
+	if calls != job.MaxAttempts {
+		t.Errorf("the runner ran %d times, want %d", calls, job.MaxAttempts)
+	}
+}
+
+func TestRunDoesNotRetryPermanentErrors(t *testing.T) {
+	repo := &fakeRepo{}
+	u := &usecase{repo: repo, runners: map[string]domain.JobFunc{}}
+	u.Register(domain.JobEmail, func(ctx context.Context, job domain.Job) (interface{}, error) {
+		return nil, fmt.Errorf("%w: invalid payload", domain.ErrBadRequest)
+	})
+
+	job, err := domain.NewJob(domain.JobEmail, domain.EmailPayload{EmailId: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.Attempts = 1
+	u.run(job, "test")
+	if got := repo.last(); got.Status != domain.JobDead {
+		t.Errorf("job is %s after a bad request, want dead", got.Status)
+	}
+}
+
+func TestRunStoresResult(t *testing.T) {
+	repo := &fakeRepo{}
+	u := &usecase{repo: repo, runners: map[string]domain.JobFunc{}}
+	u.Register(domain.JobEmail, func(ctx context.Context, job domain.Job) (interface{}, error) {
+		return map[string]int{"sent": 1}, nil
+	})
+
+	job, err := domain.NewJob(domain.JobEmail, domain.EmailPayload{EmailId: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.Attempts = 2
+	job.LastError = "451 mailbox temporarily unavailable"
+	u.run(job, "test")
+	got := repo.last()
+	if got.Status != domain.JobSucceeded || got.LastError != "" || string(got.Result) != `{"sent":1}` {
+		t.Errorf("job is %s with result %s and error %q", got.Status, got.Result, got.LastError)
+	}
+}
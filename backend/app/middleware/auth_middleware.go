@@ -1,14 +1,22 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
-	// "strconv"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/hammer-code/lms-be/domain"
-	"github.com/hammer-code/lms-be/pkg/ngelog"
-	"github.com/hammer-code/lms-be/utils"
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/pkg/token"
+	"github.com/Kocannn/self-dunking-ai/utils"
+	"github.com/sirupsen/logrus"
 )
 
+const revocationCacheTTL = 30 * time.Second
+
 func (m *Middleware) AuthMiddleware(allowedRole string) domain.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
@@ -16,8 +24,115 @@ func (m *Middleware) AuthMiddleware(allowedRole string) domain.MiddlewareFunc {
 			defer span.End()
 
 			token := utils.ExtractBearerToken(request)
-			if len(*token) < 5 {
-				ngelog.Error(ctx, "failed to extract bearer token", nil)
+			apiKey := request.Header.Get("X-API-Key")
+			if apiKey == "" && strings.HasPrefix(*token, domain.APIKeyPrefix) {
+				apiKey = *token
+			}
+
+			var (
+				user     domain.User
+				apiKeyId int
+				err      error
+			)
+			if apiKey != "" {
+				user, apiKeyId, err = m.authenticateAPIKey(ctx, apiKey)
+			} else {
+				user, err = m.authenticateToken(ctx, *token)
+			}
+			if err != nil {
+				logrus.Errorf("failed to authenticate request: %v", err)
+				utils.Response(domain.HttpResponse{
+					Code:    401,
+					Message: "Unauthorized",
+					Data:    nil,
+				}, writer)
+				return
+			}
+
+			if allowedRole != "" && !user.HasRole(allowedRole) {
+				logrus.Errorf("user %d with role %q is not %q", user.Id, user.Role, allowedRole)
+				utils.Response(domain.HttpResponse{
+					Code:    403,
+					Message: "Forbidden",
+					Data:    nil,
+				}, writer)
+				return
+			}
+
+			ctx = context.WithValue(ctx, domain.ContextUser, user)
+			if apiKeyId != 0 {
+				ctx = context.WithValue(ctx, domain.ContextAPIKey, apiKeyId)
+			}
+			// experiments stay sticky per account rather than per device
+			ctx = context.WithValue(ctx, domain.ContextSubject, "user:"+strconv.Itoa(user.Id))
+
+			next.ServeHTTP(writer, request.WithContext(ctx))
+		})
+	}
+}
+
+// authenticateToken validates a JWT access token and loads its user.
+func (m *Middleware) authenticateToken(ctx context.Context, token string) (domain.User, error) {
+	if len(token) < 5 {
+		return domain.User{}, errors.New("failed to extract bearer token")
+	}
+
+	verifyToken, err := m.Jwt.VerifyToken(token)
+	if err != nil {
+		return domain.User{}, fmt.Errorf("failed to verify token: %w", err)
+	}
+
+	revoked, err := m.isRevoked(ctx, verifyToken.RegisteredClaims.ID, verifyToken.ExpiresAt.Time)
+	if err != nil {
+		return domain.User{}, fmt.Errorf("failed to check revocation: %w", err)
+	}
+	if revoked {
+		return domain.User{}, errors.New("token revoked")
+	}
+
+	user, err := m.UserRepo.FindById(ctx, verifyToken.ID)
+	if err != nil {
+		return domain.User{}, fmt.Errorf("failed to find user by id: %w", err)
+	}
+	return user, nil
+}
+
+// authenticateAPIKey resolves a personal API key. The returned user carries
+// the key scopes so permission checks are narrowed accordingly.
+func (m *Middleware) authenticateAPIKey(ctx context.Context, key string) (domain.User, int, error) {
+	apiKey, err := m.APIKeyRepo.FindAPIKeyByHash(ctx, token.Hash(key))
+	if err != nil {
+		return domain.User{}, 0, fmt.Errorf("failed to find api key: %w", err)
+	}
+	if apiKey.RevokedAt != nil {
+		return domain.User{}, 0, errors.New("api key revoked")
+	}
+	if apiKey.ExpiresAt != nil && time.Now().After(*apiKey.ExpiresAt) {
+		return domain.User{}, 0, errors.New("api key expired")
+	}
+
+	user, err := m.UserRepo.FindById(ctx, apiKey.UserId)
+	if err != nil {
+		return domain.User{}, 0, fmt.Errorf("failed to find user by id: %w", err)
+	}
+
+	if err := m.APIKeyRepo.TouchAPIKey(ctx, apiKey.Id); err != nil {
+		logrus.Errorf("failed to record api key usage: %v", err)
+	}
+
+	user.Scopes = apiKey.Scopes
+	if user.Scopes == nil {
+		user.Scopes = []string{}
+	}
+	return user, apiKey.Id, nil
+}
+
+// RequirePermission rejects callers whose role does not grant permission.
+func (m *Middleware) RequirePermission(permission string) domain.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			user, ok := domain.UserFromContext(request.Context())
+			if !ok {
 				utils.Response(domain.HttpResponse{
 					Code:    401,
 					Message: "Unauthorized",
@@ -26,63 +141,67 @@ func (m *Middleware) AuthMiddleware(allowedRole string) domain.MiddlewareFunc {
 				return
 			}
 
-			// verifyToken, err := m.Jwt.VerifyToken(*token)
-			// if err != nil {
-			// 	ngelog.Error(ctx, "failed to verify token", err)
-			// 	utils.Response(domain.HttpResponse{
-			// 		Code:    500,
-			// 		Message: "failed to verify token",
-			// 		Data:    nil,
-			// 	}, writer)
-			// 	return
-			// }
-
-			// logoutToken, err := m.UserRepo.GetToken(request.Context(), *token)
-			// if err != nil {
-			// 	ngelog.Error(ctx, "failed to get token", err)
-			// 	utils.Response(domain.HttpResponse{
-			// 		Code:    401,
-			// 		Message: "Unauthorized",
-			// 		Data:    nil,
-			// 	}, writer)
-			// 	return
-			// }
-			// if logoutToken.Status == 0 {
-			// 	ngelog.Error(ctx, "unauthorized", nil)
-			// 	utils.Response(domain.HttpResponse{
-			// 		Code:    401,
-			// 		Message: "Unauthorized",
-			// 		Data:    nil,
-			// 	}, writer)
-			// 	return
-			// }
-
-			// user, err := m.UserRepo.FindByEmail(request.Context(), verifyToken.Email)
-			// if err != nil {
-			// 	ngelog.Error(ctx, "failed to find by email", err)
-			// 	utils.Response(domain.HttpResponse{
-			// 		Code:    401,
-			// 		Message: "Unauthorized",
-			// 		Data:    nil,
-			// 	}, writer)
-			// 	return
-			// }
-			//
-			// if user.Role != allowedRole {
-			// 	ngelog.Error(ctx, "role is not the role", nil)
-			// 	utils.Response(domain.HttpResponse{
-			// 		Code:    401,
-			// 		Message: "Unauthorized",
-			// 		Data:    nil,
-			// 	}, writer)
-			// 	return
-			// }
-			//
-			// writer.Header().Set("x-user-id", strconv.Itoa(user.ID))
-			// writer.Header().Set("x-username", user.Username)
+			if !user.Can(permission) {
+				logrus.Errorf("user %d with role %q lacks permission %q", user.Id, user.Role, permission)
+				utils.Response(domain.HttpResponse{
+					Code:    403,
+					Message: "Forbidden",
+					Data:    nil,
+				}, writer)
+				return
+			}
 
 			next.ServeHTTP(writer, request)
 		})
 	}
 }
 
+// ScopeMiddleware rejects API key requests that would change data when the
+// key was only issued read scopes. Sessions are left to the permission
+// checks of each route.
+func (m *Middleware) ScopeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		user, ok := domain.UserFromContext(request.Context())
+		if ok && user.Scopes != nil && !user.CanWrite() {
+			logrus.Errorf("api key of user %d has no write scope for %s %s", user.Id, request.Method, request.URL.Path)
+			utils.Response(domain.HttpResponse{
+				Code:    403,
+				Message: "Forbidden",
+				Data:    nil,
+			}, writer)
+			return
+		}
+
+		next.ServeHTTP(writer, request)
+	})
+}
+
+// isRevoked consults the in-memory cache before hitting the database. Valid
+// tokens are only cached briefly so a logout on another instance propagates
+// within revocationCacheTTL.
+func (m *Middleware) isRevoked(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	if jti == "" {
+		return true, nil
+	}
+	if revoked, ok := m.Revoked.Get(jti); ok {
+		return revoked, nil
+	}
+
+	revoked, err := m.UserRepo.IsAccessTokenRevoked(ctx, jti)
+	if err != nil {
+		return false, err
+	}
+
+	ttl := revocationCacheTTL
+	if revoked {
+		ttl = time.Until(expiresAt)
+	}
+	m.Revoked.Set(jti, revoked, ttl)
+	return revoked, nil
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/pkg/i18n"
+)
+
+// LocaleMiddleware stores the language the caller asked for in the request
+// context. The locale query parameter wins over Accept-Language. The chosen
+// language, or the default, is echoed in Content-Language, which is also
+// what utils.Response translates the message into.
+func (m *Middleware) LocaleMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale, ok := i18n.Normalize(r.URL.Query().Get("locale"))
+		if !ok {
+			locale = i18n.Negotiate(r.Header.Get("Accept-Language"))
+		}
+
+		ctx := r.Context()
+		if locale != "" {
+			ctx = context.WithValue(ctx, domain.ContextLocale, locale)
+		} else {
+			locale = i18n.Default
+		}
+		w.Header().Set("Content-Language", locale)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
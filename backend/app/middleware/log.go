@@ -1,7 +1,12 @@
 package middleware
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"net/http"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
 )
 
 func (m *Middleware) LogMiddleware(next http.Handler) http.Handler {
@@ -9,6 +14,14 @@ func (m *Middleware) LogMiddleware(next http.Handler) http.Handler {
 		ctx, span := tracer.Start(r.Context(), "HTTP "+r.Method+" "+r.URL.Path)
 		defer span.End()
 
+		// Keep the id of an upstream proxy so logs can be correlated
+		requestId := r.Header.Get("X-Request-Id")
+		if requestId == "" || len(requestId) > 64 {
+			requestId = newRequestId()
+		}
+		w.Header().Set("X-Request-Id", requestId)
+		ctx = context.WithValue(ctx, domain.ContextRequestId, requestId)
+
 		// Replace request context with the new one
 		r = r.WithContext(ctx)
 
@@ -21,3 +34,9 @@ func (m *Middleware) LogMiddleware(next http.Handler) http.Handler {
 		// })
 	})
 }
+
+func newRequestId() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -2,22 +2,31 @@ package middleware
 
 import (
 	"github.com/Kocannn/self-dunking-ai/domain"
-	"github.com/hammer-code/lms-be/pkg/jwt"
+	"github.com/Kocannn/self-dunking-ai/pkg/cache"
+	"github.com/Kocannn/self-dunking-ai/pkg/jwt"
 	"go.opentelemetry.io/otel"
 )
 
 type Middleware struct {
-	Jwt jwt.JWT
-	// UserRepo domain.UserRepository
+	Jwt        jwt.JWT
+	UserRepo   domain.UserRepository
+	APIKeyRepo domain.APIKeyRepository
+	Revoked    *cache.Cache[bool]
+	Usage      domain.UsageUsecase
+	Workspace  domain.WorkspaceUsecase
 }
 
 var (
 	tracer = otel.Tracer("Start Trace")
 )
 
-func InitMiddleware(jwt jwt.JWT) domain.Middleware {
+func InitMiddleware(jwt jwt.JWT, userRepo domain.UserRepository, apiKeyRepo domain.APIKeyRepository, revoked *cache.Cache[bool], usage domain.UsageUsecase, workspace domain.WorkspaceUsecase) domain.Middleware {
 	return &Middleware{
-		Jwt: jwt,
-		// UserRepo: userRepo,
+		Jwt:        jwt,
+		UserRepo:   userRepo,
+		APIKeyRepo: apiKeyRepo,
+		Revoked:    revoked,
+		Usage:      usage,
+		Workspace:  workspace,
 	}
 }
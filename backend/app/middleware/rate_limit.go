@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// RateLimitMiddleware applies the token bucket of the caller's plan. A
+// database failure lets the request through rather than taking the API
+// down with it.
+func (m *Middleware) RateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		key := "ip:" + clientIP(r)
+		plan := domain.Plans[domain.PlanAnonymous]
+		if user, ok := domain.UserFromContext(ctx); ok {
+			key = "user:" + strconv.Itoa(user.Id)
+			if apiKeyId, ok := ctx.Value(domain.ContextAPIKey).(int); ok {
+				key = "apikey:" + strconv.Itoa(apiKeyId)
+			}
+			plan = domain.PlanFor(user.Plan)
+		}
+
+		if m.limit(w, r, key, plan) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// AddressRateLimitMiddleware applies domain.AddressPlan to the remote IP,
+// whoever the request claims to be.
+func (m *Middleware) AddressRateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.limit(w, r, "addr:"+clientIP(r), domain.AddressPlan) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// limit takes a token from the bucket of key and reports whether the request
+// may go on; otherwise it has already answered 429.
+func (m *Middleware) limit(w http.ResponseWriter, r *http.Request, key string, plan domain.Plan) bool {
+	result, err := m.Usage.Allow(r.Context(), key, plan)
+	if err != nil {
+		logrus.Errorf("error checking rate limit: %v", err)
+		return true
+	}
+
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	if !result.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(result.RetryAfter/time.Second)))
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusTooManyRequests,
+			Message: "Too many requests",
+			Data:    nil,
+		}, w)
+		return false
+	}
+	return true
+}
+
+// QuotaMiddleware rejects LLM calls once a daily or monthly quota of the
+// user's plan is used up.
+func (m *Middleware) QuotaMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := domain.UserFromContext(r.Context())
+		if !ok {
+			utils.Response(domain.HttpResponse{
+				Code:    http.StatusUnauthorized,
+				Message: "Unauthorized",
+				Data:    nil,
+			}, w)
+			return
+		}
+
+		status, err := m.Usage.CheckQuota(r.Context(), user)
+		if err != nil {
+			logrus.Errorf("error checking quota: %v", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !status.Unlimited {
+			w.Header().Set("X-Quota-Limit", strconv.FormatInt(status.Limit, 10))
+			w.Header().Set("X-Quota-Remaining", strconv.FormatInt(status.Remaining, 10))
+			w.Header().Set("X-Quota-Reset", strconv.FormatInt(status.ResetAt.Unix(), 10))
+			w.Header().Set("X-Quota-Unit", status.Unit)
+			w.Header().Set("X-Quota-Period", status.Period)
+		}
+		if status.Exceeded {
+			retryAfter := int(time.Until(status.ResetAt).Seconds()) + 1
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			utils.Response(domain.HttpResponse{
+				Code:    http.StatusTooManyRequests,
+				Message: "Quota exceeded",
+				Data:    status,
+			}, w)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
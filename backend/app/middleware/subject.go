@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+)
+
+// SubjectMiddleware stores a stable caller key in the request context: the
+// remote IP, replaced by the user once AuthMiddleware ran. Nothing the
+// client sends picks it, so no caller can choose its experiment variant.
+func (m *Middleware) SubjectMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := clientIP(r)
+		ctx := context.WithValue(r.Context(), domain.ContextSubject, "ip:"+ip)
+		ctx = context.WithValue(ctx, domain.ContextClientIP, ip)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// clientIP returns the remote address without the port.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/utils"
+)
+
+// WorkspaceMiddleware stores the workspace named by the X-Workspace-Id header,
+// or the caller's personal one when the header is missing. Requests for a
+// workspace the caller does not belong to answer 403.
+func (m *Middleware) WorkspaceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := domain.UserFromContext(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		workspaceId := 0
+		if header := r.Header.Get("X-Workspace-Id"); header != "" {
+			id, err := strconv.Atoi(header)
+			if err != nil || id <= 0 {
+				utils.Response(domain.HttpResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Invalid X-Workspace-Id header",
+					Data:    nil,
+				}, w)
+				return
+			}
+			workspaceId = id
+		}
+
+		workspace, err := m.Workspace.Resolve(r.Context(), user, workspaceId)
+		if err != nil {
+			utils.RespondError(w, "error resolving workspace", err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), domain.ContextWorkspace, workspace)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
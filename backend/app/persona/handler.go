@@ -0,0 +1,136 @@
+package persona
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	handler struct {
+		usecase domain.PersonaUsecase
+	}
+)
+
+// ListPersonas implements domain.PersonaHandler.
+func (h *handler) ListPersonas(w http.ResponseWriter, r *http.Request) {
+	data, err := h.usecase.ListPersonas(r.Context())
+	if err != nil {
+		utils.RespondError(w, "error listing personas", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Personas retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// CreatePersona implements domain.PersonaHandler.
+func (h *handler) CreatePersona(w http.ResponseWriter, r *http.Request) {
+	dataBuffer := domain.PersonaRequest{}
+	if !decode(w, r, &dataBuffer) {
+		return
+	}
+
+	data, err := h.usecase.CreatePersona(r.Context(), dataBuffer)
+	if err != nil {
+		utils.RespondError(w, "error creating persona", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusCreated,
+		Message: "Persona created successfully",
+		Data:    data,
+	}, w)
+}
+
+// UpdatePersona implements domain.PersonaHandler.
+func (h *handler) UpdatePersona(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+	dataBuffer := domain.PersonaRequest{}
+	if !decode(w, r, &dataBuffer) {
+		return
+	}
+
+	data, err := h.usecase.UpdatePersona(r.Context(), id, dataBuffer)
+	if err != nil {
+		utils.RespondError(w, "error updating persona", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Persona updated successfully",
+		Data:    data,
+	}, w)
+}
+
+// DeletePersona implements domain.PersonaHandler.
+func (h *handler) DeletePersona(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.usecase.DeletePersona(r.Context(), id); err != nil {
+		utils.RespondError(w, "error deleting persona", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Persona deleted successfully",
+		Data:    nil,
+	}, w)
+}
+
+func pathId(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return 0, false
+	}
+	return id, true
+}
+
+func decode(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return false
+	}
+	return true
+}
+
+var (
+	handlr *handler
+)
+
+func NewPersonaHandler(usecase domain.PersonaUsecase) domain.PersonaHandler {
+	if handlr == nil {
+		handlr = &handler{
+			usecase,
+		}
+	}
+	return handlr
+}
@@ -0,0 +1,16 @@
+package persona
+
+import (
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+)
+
+func InitPersonaRepository(db pkgDB.DatabaseTransaction) domain.PersonaRepository {
+	return NewPersonaRepository(db)
+}
+func InitPersonaUsecase(dbTx pkgDB.DatabaseTransaction, repo domain.PersonaRepository, audit domain.AuditUsecase) domain.PersonaUsecase {
+	return NewPersonaUsecase(dbTx, repo, audit)
+}
+func InitPersonaHandler(usecase domain.PersonaUsecase) domain.PersonaHandler {
+	return NewPersonaHandler(usecase)
+}
@@ -0,0 +1,91 @@
+package persona
+
+import (
+	"context"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"gorm.io/gorm"
+)
+
+type (
+	repository struct {
+		db pkgDB.DatabaseTransaction
+	}
+)
+
+// ListPersonas implements domain.PersonaRepository.
+func (r *repository) ListPersonas(ctx context.Context) ([]domain.Persona, error) {
+	data := []domain.Persona{}
+	err := r.scoped(ctx).Order("name").Find(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetPersona implements domain.PersonaRepository.
+func (r *repository) GetPersona(ctx context.Context, id int) (domain.Persona, error) {
+	data := domain.Persona{}
+	err := r.scoped(ctx).First(&data, id).Error
+	if err != nil {
+		return domain.Persona{}, err
+	}
+	return data, nil
+}
+
+// GetPersonaByKey implements domain.PersonaRepository.
+func (r *repository) GetPersonaByKey(ctx context.Context, key string) (domain.Persona, error) {
+	data := domain.Persona{}
+	err := r.scoped(ctx).Where("key = ?", key).First(&data).Error
+	if err != nil {
+		return domain.Persona{}, err
+	}
+	return data, nil
+}
+
+// CreatePersona implements domain.PersonaRepository.
+func (r *repository) CreatePersona(ctx context.Context, persona domain.Persona) (domain.Persona, error) {
+	persona.WorkspaceId = workspaceId(ctx)
+	err := r.db.DB(ctx).Create(&persona).Error
+	if err != nil {
+		return domain.Persona{}, err
+	}
+	return persona, nil
+}
+
+// UpdatePersona implements domain.PersonaRepository.
+func (r *repository) UpdatePersona(ctx context.Context, persona domain.Persona) error {
+	return r.scoped(ctx).Model(&domain.Persona{}).
+		Where("id = ?", persona.Id).
+		Select("key", "name", "description", "prompt", "focus", "updated_at").
+		Updates(&persona).Error
+}
+
+// DeletePersona implements domain.PersonaRepository.
+func (r *repository) DeletePersona(ctx context.Context, id int) error {
+	return r.scoped(ctx).Delete(&domain.Persona{}, id).Error
+}
+
+// scoped limits a query to the personas of the active workspace.
+func (r *repository) scoped(ctx context.Context) *gorm.DB {
+	return r.db.DB(ctx).Where("workspace_id = ?", workspaceId(ctx))
+}
+
+func workspaceId(ctx context.Context) int {
+	workspace, _ := domain.WorkspaceFromContext(ctx)
+	return workspace.Id
+}
+
+var (
+	repo *repository
+)
+
+func NewPersonaRepository(db pkgDB.DatabaseTransaction) domain.PersonaRepository {
+	if repo == nil {
+		repo = &repository{
+			db,
+		}
+	}
+	return repo
+}
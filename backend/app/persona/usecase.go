@@ -0,0 +1,268 @@
+package persona
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	maxNameChars        = 128
+	maxDescriptionChars = 500
+	maxPromptChars      = 4000
+	maxFocus            = 8
+	maxFocusChars       = 64
+)
+
+var keyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+type (
+	usecase struct {
+		dbTx  pkgDB.DatabaseTransaction
+		repo  domain.PersonaRepository
+		audit domain.AuditUsecase
+	}
+)
+
+// ListPersonas implements domain.PersonaUsecase.
+func (u *usecase) ListPersonas(ctx context.Context) ([]domain.Persona, error) {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+	if !user.Can(domain.PermIdeaReadOwn) {
+		return nil, domain.ErrForbidden
+	}
+
+	custom, err := u.repo.ListPersonas(ctx)
+	if err != nil {
+		logrus.Errorf("error listing personas: %v", err)
+		return nil, err
+	}
+	return append(append([]domain.Persona{}, domain.BuiltinPersonas...), custom...), nil
+}
+
+// CreatePersona implements domain.PersonaUsecase. Any member who may write
+// ideas can add a persona to the workspace.
+func (u *usecase) CreatePersona(ctx context.Context, req domain.PersonaRequest) (domain.Persona, error) {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.Persona{}, domain.ErrUnauthorized
+	}
+	if !user.Can(domain.PermIdeaWriteOwn) {
+		return domain.Persona{}, domain.ErrForbidden
+	}
+	req, err := normalize(req)
+	if err != nil {
+		return domain.Persona{}, err
+	}
+
+	var created domain.Persona
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		if err := u.keyFree(txCtx, req.Key, 0); err != nil {
+			return err
+		}
+		now := time.Now()
+		created, err = u.repo.CreatePersona(txCtx, domain.Persona{
+			Key:         req.Key,
+			Name:        req.Name,
+			Description: req.Description,
+			Prompt:      req.Prompt,
+			Focus:       req.Focus,
+			CreatedBy:   user.Id,
+			CreatedAt:   &now,
+			UpdatedAt:   &now,
+		})
+		if err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditPersonaCreate,
+			TargetType: "persona",
+			TargetId:   strconv.Itoa(created.Id),
+			After:      created,
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error creating persona: %v", err)
+		return domain.Persona{}, err
+	}
+	return created, nil
+}
+
+// UpdatePersona implements domain.PersonaUsecase.
+func (u *usecase) UpdatePersona(ctx context.Context, id int, req domain.PersonaRequest) (domain.Persona, error) {
+	req, err := normalize(req)
+	if err != nil {
+		return domain.Persona{}, err
+	}
+
+	var updated domain.Persona
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		persona, err := u.repo.GetPersona(txCtx, id)
+		if err != nil {
+			return notFound(err)
+		}
+		if err := manage(txCtx, persona); err != nil {
+			return err
+		}
+		if err := u.keyFree(txCtx, req.Key, id); err != nil {
+			return err
+		}
+
+		updated = persona
+		now := time.Now()
+		updated.Key = req.Key
+		updated.Name = req.Name
+		updated.Description = req.Description
+		updated.Prompt = req.Prompt
+		updated.Focus = req.Focus
+		updated.UpdatedAt = &now
+		if err := u.repo.UpdatePersona(txCtx, updated); err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditPersonaUpdate,
+			TargetType: "persona",
+			TargetId:   strconv.Itoa(id),
+			Before:     persona,
+			After:      updated,
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error updating persona: %v", err)
+		return domain.Persona{}, err
+	}
+	return updated, nil
+}
+
+// DeletePersona implements domain.PersonaUsecase. Past critiques keep the
+// key of the persona they were written by.
+func (u *usecase) DeletePersona(ctx context.Context, id int) error {
+	return u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		persona, err := u.repo.GetPersona(txCtx, id)
+		if err != nil {
+			return notFound(err)
+		}
+		if err := manage(txCtx, persona); err != nil {
+			return err
+		}
+		if err := u.repo.DeletePersona(txCtx, id); err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditPersonaDelete,
+			TargetType: "persona",
+			TargetId:   strconv.Itoa(id),
+			Before:     persona,
+		})
+	})
+}
+
+// keyFree makes sure key is neither built in nor used by another persona of
+// the workspace than id.
+func (u *usecase) keyFree(ctx context.Context, key string, id int) error {
+	if _, ok := domain.BuiltinPersona(key); ok {
+		return fmt.Errorf("%w: %q is a built-in persona", domain.ErrConflict, key)
+	}
+	existing, err := u.repo.GetPersonaByKey(ctx, key)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.Id != id {
+		return fmt.Errorf("%w: persona %q already exists", domain.ErrConflict, key)
+	}
+	return nil
+}
+
+func normalize(req domain.PersonaRequest) (domain.PersonaRequest, error) {
+	req.Key = strings.ToLower(strings.TrimSpace(req.Key))
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+	req.Prompt = strings.TrimSpace(req.Prompt)
+	if !keyPattern.MatchString(req.Key) {
+		return req, fmt.Errorf("%w: key must be 1 to 64 lower case letters, digits, - or _", domain.ErrBadRequest)
+	}
+	if req.Name == "" || len([]rune(req.Name)) > maxNameChars {
+		return req, fmt.Errorf("%w: name must be 1 to %d characters", domain.ErrBadRequest, maxNameChars)
+	}
+	if len([]rune(req.Description)) > maxDescriptionChars {
+		return req, fmt.Errorf("%w: description must not exceed %d characters", domain.ErrBadRequest, maxDescriptionChars)
+	}
+	if req.Prompt == "" || len([]rune(req.Prompt)) > maxPromptChars {
+		return req, fmt.Errorf("%w: prompt must be 1 to %d characters", domain.ErrBadRequest, maxPromptChars)
+	}
+	// the prompt is inserted into a template, it must not be one itself
+	if strings.Contains(req.Prompt, "{{") {
+		return req, fmt.Errorf("%w: prompt must not contain template actions", domain.ErrBadRequest)
+	}
+
+	focus := []string{}
+	for _, item := range req.Focus {
+		item = strings.Join(strings.Fields(item), " ")
+		if item == "" {
+			continue
+		}
+		if len([]rune(item)) > maxFocusChars {
+			return req, fmt.Errorf("%w: focus items must not exceed %d characters", domain.ErrBadRequest, maxFocusChars)
+		}
+		focus = append(focus, item)
+	}
+	if len(focus) > maxFocus {
+		return req, fmt.Errorf("%w: a persona has at most %d focus items", domain.ErrBadRequest, maxFocus)
+	}
+	req.Focus = focus
+	return req, nil
+}
+
+// manage lets the author of a persona and workspace admins change it.
+func manage(ctx context.Context, persona domain.Persona) error {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+	if !user.Can(domain.PermIdeaWriteOwn) {
+		return domain.ErrForbidden
+	}
+	if persona.CreatedBy == user.Id {
+		return nil
+	}
+	if workspace, ok := domain.WorkspaceFromContext(ctx); ok && workspace.HasMemberRole(domain.WorkspaceAdmin) {
+		return nil
+	}
+	return domain.ErrForbidden
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrNotFound
+	}
+	return err
+}
+
+var (
+	uc *usecase
+)
+
+func NewPersonaUsecase(dbTx pkgDB.DatabaseTransaction, repo domain.PersonaRepository, audit domain.AuditUsecase) domain.PersonaUsecase {
+	if uc == nil {
+		uc = &usecase{
+			dbTx,
+			repo,
+			audit,
+		}
+	}
+	return uc
+}
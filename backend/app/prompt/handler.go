@@ -0,0 +1,176 @@
+package prompt
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	handler struct {
+		usecase domain.PromptUsecase
+	}
+)
+
+// ListPrompts implements domain.PromptHandler.
+func (h *handler) ListPrompts(w http.ResponseWriter, r *http.Request) {
+	data, err := h.usecase.ListPrompts(r.Context(), r.URL.Query().Get("name"))
+	if err != nil {
+		logrus.Errorf("error listing prompts: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error listing prompts",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Prompts retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// GetPrompt implements domain.PromptHandler.
+func (h *handler) GetPrompt(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.GetPrompt(r.Context(), id)
+	if err != nil {
+		logrus.Errorf("error getting prompt: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: "Error retrieving prompt",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Prompt retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// CreatePrompt implements domain.PromptHandler.
+func (h *handler) CreatePrompt(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logrus.Errorf("error reading request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error reading request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	dataBuffer := domain.CreatePromptRequest{}
+	if err := json.Unmarshal(bodyBytes, &dataBuffer); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.CreatePrompt(r.Context(), dataBuffer)
+	if err != nil {
+		utils.RespondError(w, "error creating prompt", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusCreated,
+		Message: "Prompt created successfully",
+		Data:    data,
+	}, w)
+}
+
+// ActivatePrompt implements domain.PromptHandler.
+func (h *handler) ActivatePrompt(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.ActivatePrompt(r.Context(), id)
+	if err != nil {
+		logrus.Errorf("error activating prompt: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: "Error activating prompt",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Prompt activated successfully",
+		Data:    data,
+	}, w)
+}
+
+// DeletePrompt implements domain.PromptHandler.
+func (h *handler) DeletePrompt(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	if err := h.usecase.DeletePrompt(r.Context(), id); err != nil {
+		utils.RespondError(w, "error deleting prompt", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Prompt deleted successfully",
+		Data:    nil,
+	}, w)
+}
+
+var (
+	handlr *handler
+)
+
+func NewPromptHandler(usecase domain.PromptUsecase) domain.PromptHandler {
+	if handlr == nil {
+		handlr = &handler{
+			usecase,
+		}
+	}
+	return handlr
+}
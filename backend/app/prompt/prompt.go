@@ -0,0 +1,16 @@
+package prompt
+
+import (
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+)
+
+func InitPromptRepository(db pkgDB.DatabaseTransaction) domain.PromptRepository {
+	return NewPromptRepository(db)
+}
+func InitPromptUsecase(dbTx pkgDB.DatabaseTransaction, repo domain.PromptRepository, audit domain.AuditUsecase) domain.PromptUsecase {
+	return NewPromptUsecase(dbTx, repo, audit)
+}
+func InitPromptHandler(usecase domain.PromptUsecase) domain.PromptHandler {
+	return NewPromptHandler(usecase)
+}
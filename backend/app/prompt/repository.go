@@ -0,0 +1,132 @@
+package prompt
+
+import (
+	"context"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	repository struct {
+		db pkgDB.DatabaseTransaction
+	}
+)
+
+// ListPrompts implements domain.PromptRepository.
+func (r *repository) ListPrompts(ctx context.Context, name string) ([]domain.PromptTemplate, error) {
+	data := []domain.PromptTemplate{}
+	query := r.db.DB(ctx).Order("name asc, version desc")
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	if err := query.Find(&data).Error; err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetPrompt implements domain.PromptRepository.
+func (r *repository) GetPrompt(ctx context.Context, id int) (domain.PromptTemplate, error) {
+	data := domain.PromptTemplate{}
+	err := r.db.DB(ctx).First(&data, id).Error
+	if err != nil {
+		return domain.PromptTemplate{}, err
+	}
+	return data, nil
+}
+
+// GetPromptVersion implements domain.PromptRepository.
+func (r *repository) GetPromptVersion(ctx context.Context, name string, version int) (domain.PromptTemplate, error) {
+	data := domain.PromptTemplate{}
+	err := r.db.DB(ctx).Where("name = ? AND version = ?", name, version).First(&data).Error
+	if err != nil {
+		return domain.PromptTemplate{}, err
+	}
+	return data, nil
+}
+
+// GetActivePrompt implements domain.PromptRepository.
+func (r *repository) GetActivePrompt(ctx context.Context, name string) (domain.PromptTemplate, error) {
+	data := domain.PromptTemplate{}
+	err := r.db.DB(ctx).Where("name = ? AND is_active = ?", name, true).First(&data).Error
+	if err != nil {
+		return domain.PromptTemplate{}, err
+	}
+	return data, nil
+}
+
+// LatestVersion implements domain.PromptRepository.
+func (r *repository) LatestVersion(ctx context.Context, name string) (int, error) {
+	var version int
+	err := r.db.DB(ctx).Model(&domain.PromptTemplate{}).
+		Where("name = ?", name).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&version).Error
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// CreatePrompt implements domain.PromptRepository.
+func (r *repository) CreatePrompt(ctx context.Context, prompt domain.PromptTemplate) (domain.PromptTemplate, error) {
+	err := r.db.DB(ctx).Create(&prompt).Error
+	if err != nil {
+		logrus.Error("repository.CreatePrompt: failed to save prompt")
+		return domain.PromptTemplate{}, err
+	}
+	return prompt, nil
+}
+
+// DeactivatePrompts implements domain.PromptRepository.
+func (r *repository) DeactivatePrompts(ctx context.Context, name string) error {
+	return r.db.DB(ctx).Model(&domain.PromptTemplate{}).
+		Where("name = ?", name).
+		Update("is_active", false).Error
+}
+
+// ActivatePrompt implements domain.PromptRepository.
+func (r *repository) ActivatePrompt(ctx context.Context, id int) error {
+	return r.db.DB(ctx).Model(&domain.PromptTemplate{}).
+		Where("id = ?", id).
+		Update("is_active", true).Error
+}
+
+// DeletePrompt implements domain.PromptRepository.
+func (r *repository) DeletePrompt(ctx context.Context, id int) error {
+	return r.db.DB(ctx).Delete(&domain.PromptTemplate{}, id).Error
+}
+
+// CountExperimentReferences implements domain.PromptRepository.
+func (r *repository) CountExperimentReferences(ctx context.Context, name string, version int) (int64, error) {
+	var count int64
+	err := r.db.DB(ctx).Table("experiment_variants v").
+		Joins("JOIN experiments x ON x.id = v.experiment_id").
+		Where("x.prompt_name = ? AND x.status <> ? AND v.prompt_version = ?", name, domain.ExperimentStopped, version).
+		Count(&count).Error
+	return count, err
+}
+
+// CountWorkspacePins implements domain.PromptRepository.
+func (r *repository) CountWorkspacePins(ctx context.Context, name string, version int) (int64, error) {
+	var count int64
+	err := r.db.DB(ctx).Model(&domain.Workspace{}).
+		Where("(NULLIF(prompt_versions, '')::jsonb ->> ?)::int = ?", name, version).
+		Count(&count).Error
+	return count, err
+}
+
+var (
+	repo *repository
+)
+
+func NewPromptRepository(db pkgDB.DatabaseTransaction) domain.PromptRepository {
+	if repo == nil {
+		repo = &repository{
+			db,
+		}
+	}
+	return repo
+}
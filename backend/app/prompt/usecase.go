@@ -0,0 +1,250 @@
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const builtinDescription = "built-in default"
+
+type (
+	usecase struct {
+		dbTx  pkgDB.DatabaseTransaction
+		repo  domain.PromptRepository
+		audit domain.AuditUsecase
+	}
+)
+
+// ListPrompts implements domain.PromptUsecase.
+func (u *usecase) ListPrompts(ctx context.Context, name string) ([]domain.PromptTemplate, error) {
+	return u.repo.ListPrompts(ctx, name)
+}
+
+// GetPrompt implements domain.PromptUsecase.
+func (u *usecase) GetPrompt(ctx context.Context, id int) (domain.PromptTemplate, error) {
+	data, err := u.repo.GetPrompt(ctx, id)
+	if err != nil {
+		return domain.PromptTemplate{}, notFound(err)
+	}
+	return data, nil
+}
+
+// CreatePrompt implements domain.PromptUsecase. Every call stores a new
+// version; existing versions are never modified so evaluations keep pointing
+// at the exact text they were produced with.
+func (u *usecase) CreatePrompt(ctx context.Context, req domain.CreatePromptRequest) (domain.PromptTemplate, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || strings.TrimSpace(req.Content) == "" {
+		return domain.PromptTemplate{}, fmt.Errorf("%w: name and content are required", domain.ErrBadRequest)
+	}
+	if _, err := render(req.Name, req.Content, domain.PromptData{}); err != nil {
+		return domain.PromptTemplate{}, fmt.Errorf("%w: %v", domain.ErrBadRequest, err)
+	}
+
+	var created domain.PromptTemplate
+	err := u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		latest, err := u.repo.LatestVersion(txCtx, req.Name)
+		if err != nil {
+			return err
+		}
+
+		// the first version of a prompt is always active
+		activate := req.Activate || latest == 0
+		if activate {
+			if err := u.repo.DeactivatePrompts(txCtx, req.Name); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		created, err = u.repo.CreatePrompt(txCtx, domain.PromptTemplate{
+			Name:        req.Name,
+			Version:     latest + 1,
+			Content:     req.Content,
+			Description: req.Description,
+			IsActive:    activate,
+			CreatedAt:   &now,
+		})
+		if err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditPromptCreate,
+			TargetType: "prompt",
+			TargetId:   strconv.Itoa(created.Id),
+			After:      created,
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error creating prompt: %v", err)
+		return domain.PromptTemplate{}, err
+	}
+	return created, nil
+}
+
+// ActivatePrompt implements domain.PromptUsecase.
+func (u *usecase) ActivatePrompt(ctx context.Context, id int) (domain.PromptTemplate, error) {
+	var activated domain.PromptTemplate
+	err := u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		data, err := u.repo.GetPrompt(txCtx, id)
+		if err != nil {
+			return notFound(err)
+		}
+		if err := u.repo.DeactivatePrompts(txCtx, data.Name); err != nil {
+			return err
+		}
+		if err := u.repo.ActivatePrompt(txCtx, id); err != nil {
+			return err
+		}
+		before := data
+		data.IsActive = true
+		activated = data
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditPromptActivate,
+			TargetType: "prompt",
+			TargetId:   strconv.Itoa(id),
+			Before:     before,
+			After:      data,
+		})
+	})
+	if err != nil {
+		return domain.PromptTemplate{}, err
+	}
+	return activated, nil
+}
+
+// DeletePrompt implements domain.PromptUsecase. Versions that Render may
+// still be asked for, through an experiment or a workspace pin, are kept.
+func (u *usecase) DeletePrompt(ctx context.Context, id int) error {
+	return u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		data, err := u.repo.GetPrompt(txCtx, id)
+		if err != nil {
+			return notFound(err)
+		}
+		if data.IsActive {
+			return fmt.Errorf("%w: cannot delete the active version of %q", domain.ErrConflict, data.Name)
+		}
+		variants, err := u.repo.CountExperimentReferences(txCtx, data.Name, data.Version)
+		if err != nil {
+			return err
+		}
+		if variants > 0 {
+			return fmt.Errorf("%w: version %d of %q is used by an experiment", domain.ErrConflict, data.Version, data.Name)
+		}
+		pins, err := u.repo.CountWorkspacePins(txCtx, data.Name, data.Version)
+		if err != nil {
+			return err
+		}
+		if pins > 0 {
+			return fmt.Errorf("%w: version %d of %q is pinned by a workspace", domain.ErrConflict, data.Version, data.Name)
+		}
+		if err := u.repo.DeletePrompt(txCtx, id); err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditPromptDelete,
+			TargetType: "prompt",
+			TargetId:   strconv.Itoa(id),
+			Before:     data,
+		})
+	})
+}
+
+// Render implements domain.PromptUsecase.
+func (u *usecase) Render(ctx context.Context, name string, version int, data domain.PromptData) (string, domain.PromptTemplate, error) {
+	var (
+		tpl domain.PromptTemplate
+		err error
+	)
+	if version > 0 {
+		tpl, err = u.repo.GetPromptVersion(ctx, name, version)
+	} else {
+		tpl, err = u.repo.GetActivePrompt(ctx, name)
+	}
+	if err != nil {
+		logrus.Errorf("error resolving prompt %s v%d: %v", name, version, err)
+		return "", domain.PromptTemplate{}, notFound(err)
+	}
+
+	content, err := render(tpl.Name, tpl.Content, data)
+	if err != nil {
+		logrus.Errorf("error rendering prompt %s v%d: %v", tpl.Name, tpl.Version, err)
+		return "", domain.PromptTemplate{}, err
+	}
+	return content, tpl, nil
+}
+
+// EnsureDefaults implements domain.PromptUsecase. A newer built-in prompt is
+// only activated while the current active version is the latest built-in
+// one, so prompts edited by an admin are never replaced.
+func (u *usecase) EnsureDefaults(ctx context.Context) error {
+	for name, content := range domain.DefaultPrompts {
+		latest, err := u.repo.LatestVersion(ctx, name)
+		if err != nil {
+			return err
+		}
+		if latest > 0 {
+			active, err := u.repo.GetActivePrompt(ctx, name)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if err != nil || active.Version != latest || active.Description != builtinDescription || active.Content == content {
+				continue
+			}
+		}
+		if _, err := u.CreatePrompt(ctx, domain.CreatePromptRequest{
+			Name:        name,
+			Content:     content,
+			Description: builtinDescription,
+			Activate:    true,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func render(name, content string, data domain.PromptData) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=zero").Parse(content)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrNotFound
+	}
+	return err
+}
+
+var (
+	uc *usecase
+)
+
+func NewPromptUsecase(dbTx pkgDB.DatabaseTransaction, repo domain.PromptRepository, audit domain.AuditUsecase) domain.PromptUsecase {
+	if uc == nil {
+		uc = &usecase{
+			dbTx:  dbTx,
+			repo:  repo,
+			audit: audit,
+		}
+	}
+	return uc
+}
@@ -0,0 +1,156 @@
+package rubric
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	handler struct {
+		usecase domain.RubricUsecase
+	}
+)
+
+// ListRubrics implements domain.RubricHandler.
+func (h *handler) ListRubrics(w http.ResponseWriter, r *http.Request) {
+	data, err := h.usecase.ListRubrics(r.Context())
+	if err != nil {
+		utils.RespondError(w, "error listing rubrics", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Rubrics retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// GetRubric implements domain.RubricHandler.
+func (h *handler) GetRubric(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := h.usecase.GetRubric(r.Context(), id)
+	if err != nil {
+		utils.RespondError(w, "error getting rubric", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Rubric retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// CreateRubric implements domain.RubricHandler.
+func (h *handler) CreateRubric(w http.ResponseWriter, r *http.Request) {
+	dataBuffer := domain.RubricRequest{}
+	if !decode(w, r, &dataBuffer) {
+		return
+	}
+
+	data, err := h.usecase.CreateRubric(r.Context(), dataBuffer)
+	if err != nil {
+		utils.RespondError(w, "error creating rubric", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusCreated,
+		Message: "Rubric created successfully",
+		Data:    data,
+	}, w)
+}
+
+// UpdateRubric implements domain.RubricHandler.
+func (h *handler) UpdateRubric(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+	dataBuffer := domain.RubricRequest{}
+	if !decode(w, r, &dataBuffer) {
+		return
+	}
+
+	data, err := h.usecase.UpdateRubric(r.Context(), id, dataBuffer)
+	if err != nil {
+		utils.RespondError(w, "error updating rubric", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Rubric updated successfully",
+		Data:    data,
+	}, w)
+}
+
+// DeleteRubric implements domain.RubricHandler.
+func (h *handler) DeleteRubric(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.usecase.DeleteRubric(r.Context(), id); err != nil {
+		utils.RespondError(w, "error deleting rubric", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Rubric deleted successfully",
+		Data:    nil,
+	}, w)
+}
+
+func pathId(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return 0, false
+	}
+	return id, true
+}
+
+func decode(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return false
+	}
+	return true
+}
+
+var (
+	handlr *handler
+)
+
+func NewRubricHandler(usecase domain.RubricUsecase) domain.RubricHandler {
+	if handlr == nil {
+		handlr = &handler{
+			usecase,
+		}
+	}
+	return handlr
+}
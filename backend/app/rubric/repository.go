@@ -0,0 +1,98 @@
+package rubric
+
+import (
+	"context"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"gorm.io/gorm"
+)
+
+type (
+	repository struct {
+		db pkgDB.DatabaseTransaction
+	}
+)
+
+// ListRubrics implements domain.RubricRepository.
+func (r *repository) ListRubrics(ctx context.Context) ([]domain.Rubric, error) {
+	data := []domain.Rubric{}
+	err := r.scoped(ctx).Order("name").Find(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetRubric implements domain.RubricRepository.
+func (r *repository) GetRubric(ctx context.Context, id int) (domain.Rubric, error) {
+	data := domain.Rubric{}
+	err := r.scoped(ctx).First(&data, id).Error
+	if err != nil {
+		return domain.Rubric{}, err
+	}
+	return data, nil
+}
+
+// GetDefaultRubric implements domain.RubricRepository.
+func (r *repository) GetDefaultRubric(ctx context.Context) (domain.Rubric, error) {
+	data := domain.Rubric{}
+	err := r.scoped(ctx).Where("is_default").First(&data).Error
+	if err != nil {
+		return domain.Rubric{}, err
+	}
+	return data, nil
+}
+
+// CreateRubric implements domain.RubricRepository.
+func (r *repository) CreateRubric(ctx context.Context, rubric domain.Rubric) (domain.Rubric, error) {
+	rubric.WorkspaceId = workspaceId(ctx)
+	err := r.db.DB(ctx).Create(&rubric).Error
+	if err != nil {
+		return domain.Rubric{}, err
+	}
+	return rubric, nil
+}
+
+// UpdateRubric implements domain.RubricRepository.
+func (r *repository) UpdateRubric(ctx context.Context, rubric domain.Rubric) error {
+	return r.scoped(ctx).Model(&domain.Rubric{}).
+		Where("id = ?", rubric.Id).
+		Select("name", "description", "dimensions", "is_default", "updated_at").
+		Updates(&rubric).Error
+}
+
+// DeleteRubric implements domain.RubricRepository.
+func (r *repository) DeleteRubric(ctx context.Context, id int) error {
+	return r.scoped(ctx).Delete(&domain.Rubric{}, id).Error
+}
+
+// ClearDefaultRubric implements domain.RubricRepository.
+func (r *repository) ClearDefaultRubric(ctx context.Context) error {
+	return r.scoped(ctx).Model(&domain.Rubric{}).
+		Where("is_default").
+		Update("is_default", false).Error
+}
+
+// scoped limits a query to the rubrics of the active workspace.
+func (r *repository) scoped(ctx context.Context) *gorm.DB {
+	return r.db.DB(ctx).Where("workspace_id = ?", workspaceId(ctx))
+}
+
+func workspaceId(ctx context.Context) int {
+	workspace, _ := domain.WorkspaceFromContext(ctx)
+	return workspace.Id
+}
+
+var (
+	repo *repository
+)
+
+func NewRubricRepository(db pkgDB.DatabaseTransaction) domain.RubricRepository {
+	if repo == nil {
+		repo = &repository{
+			db,
+		}
+	}
+	return repo
+}
@@ -0,0 +1,16 @@
+package rubric
+
+import (
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+)
+
+func InitRubricRepository(db pkgDB.DatabaseTransaction) domain.RubricRepository {
+	return NewRubricRepository(db)
+}
+func InitRubricUsecase(dbTx pkgDB.DatabaseTransaction, repo domain.RubricRepository, audit domain.AuditUsecase) domain.RubricUsecase {
+	return NewRubricUsecase(dbTx, repo, audit)
+}
+func InitRubricHandler(usecase domain.RubricUsecase) domain.RubricHandler {
+	return NewRubricHandler(usecase)
+}
@@ -0,0 +1,256 @@
+package rubric
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	maxNameChars        = 128
+	maxDimensionChars   = 64
+	maxDescriptionChars = 500
+	maxWeight           = 100
+	defaultMin          = 1
+	defaultMax          = 10
+)
+
+type (
+	usecase struct {
+		dbTx  pkgDB.DatabaseTransaction
+		repo  domain.RubricRepository
+		audit domain.AuditUsecase
+	}
+)
+
+// ListRubrics implements domain.RubricUsecase.
+func (u *usecase) ListRubrics(ctx context.Context) ([]domain.Rubric, error) {
+	if err := read(ctx); err != nil {
+		return nil, err
+	}
+	data, err := u.repo.ListRubrics(ctx)
+	if err != nil {
+		logrus.Errorf("error listing rubrics: %v", err)
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetRubric implements domain.RubricUsecase.
+func (u *usecase) GetRubric(ctx context.Context, id int) (domain.Rubric, error) {
+	if err := read(ctx); err != nil {
+		return domain.Rubric{}, err
+	}
+	data, err := u.repo.GetRubric(ctx, id)
+	if err != nil {
+		return domain.Rubric{}, notFound(err)
+	}
+	return data, nil
+}
+
+// CreateRubric implements domain.RubricUsecase.
+func (u *usecase) CreateRubric(ctx context.Context, req domain.RubricRequest) (domain.Rubric, error) {
+	if err := domain.ManageWorkspace(ctx); err != nil {
+		return domain.Rubric{}, err
+	}
+	req, err := normalize(req)
+	if err != nil {
+		return domain.Rubric{}, err
+	}
+
+	var created domain.Rubric
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		if req.IsDefault {
+			if err := u.repo.ClearDefaultRubric(txCtx); err != nil {
+				return err
+			}
+		}
+		now := time.Now()
+		created, err = u.repo.CreateRubric(txCtx, domain.Rubric{
+			Name:        req.Name,
+			Description: req.Description,
+			Dimensions:  req.Dimensions,
+			IsDefault:   req.IsDefault,
+			CreatedAt:   &now,
+			UpdatedAt:   &now,
+		})
+		if err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditRubricCreate,
+			TargetType: "rubric",
+			TargetId:   strconv.Itoa(created.Id),
+			After:      created,
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error creating rubric: %v", err)
+		return domain.Rubric{}, err
+	}
+	return created, nil
+}
+
+// UpdateRubric implements domain.RubricUsecase. Past critiques keep the
+// scores they were given.
+func (u *usecase) UpdateRubric(ctx context.Context, id int, req domain.RubricRequest) (domain.Rubric, error) {
+	if err := domain.ManageWorkspace(ctx); err != nil {
+		return domain.Rubric{}, err
+	}
+	req, err := normalize(req)
+	if err != nil {
+		return domain.Rubric{}, err
+	}
+
+	var updated domain.Rubric
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		rubric, err := u.repo.GetRubric(txCtx, id)
+		if err != nil {
+			return notFound(err)
+		}
+		if req.IsDefault && !rubric.IsDefault {
+			if err := u.repo.ClearDefaultRubric(txCtx); err != nil {
+				return err
+			}
+		}
+
+		updated = rubric
+		now := time.Now()
+		updated.Name = req.Name
+		updated.Description = req.Description
+		updated.Dimensions = req.Dimensions
+		updated.IsDefault = req.IsDefault
+		updated.UpdatedAt = &now
+		if err := u.repo.UpdateRubric(txCtx, updated); err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditRubricUpdate,
+			TargetType: "rubric",
+			TargetId:   strconv.Itoa(id),
+			Before:     rubric,
+			After:      updated,
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error updating rubric: %v", err)
+		return domain.Rubric{}, err
+	}
+	return updated, nil
+}
+
+// DeleteRubric implements domain.RubricUsecase. Deleting the default falls
+// back to the built-in dimensions.
+func (u *usecase) DeleteRubric(ctx context.Context, id int) error {
+	if err := domain.ManageWorkspace(ctx); err != nil {
+		return err
+	}
+
+	return u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		rubric, err := u.repo.GetRubric(txCtx, id)
+		if err != nil {
+			return notFound(err)
+		}
+		if err := u.repo.DeleteRubric(txCtx, id); err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditRubricDelete,
+			TargetType: "rubric",
+			TargetId:   strconv.Itoa(id),
+			Before:     rubric,
+		})
+	})
+}
+
+// normalize trims the request and fills in the default weight of 1 and the
+// default 1 to 10 scale.
+func normalize(req domain.RubricRequest) (domain.RubricRequest, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+	if req.Name == "" || len([]rune(req.Name)) > maxNameChars {
+		return req, fmt.Errorf("%w: name must be 1 to %d characters", domain.ErrBadRequest, maxNameChars)
+	}
+	if len(req.Dimensions) == 0 || len(req.Dimensions) > domain.MaxRubricDimensions {
+		return req, fmt.Errorf("%w: a rubric needs 1 to %d dimensions", domain.ErrBadRequest, domain.MaxRubricDimensions)
+	}
+
+	seen := map[string]bool{}
+	dimensions := make([]domain.RubricDimension, 0, len(req.Dimensions))
+	for _, dimension := range req.Dimensions {
+		dimension.Name = strings.Join(strings.Fields(dimension.Name), " ")
+		dimension.Description = strings.TrimSpace(dimension.Description)
+		if dimension.Name == "" || len([]rune(dimension.Name)) > maxDimensionChars {
+			return req, fmt.Errorf("%w: dimension names must be 1 to %d characters", domain.ErrBadRequest, maxDimensionChars)
+		}
+		if strings.IndexFunc(dimension.Name, unicode.IsControl) >= 0 || strings.Contains(dimension.Name, ":") {
+			return req, fmt.Errorf("%w: dimension %q must not contain colons or control characters", domain.ErrBadRequest, dimension.Name)
+		}
+		key := strings.ToLower(dimension.Name)
+		if seen[key] {
+			return req, fmt.Errorf("%w: dimension %q is listed twice", domain.ErrBadRequest, dimension.Name)
+		}
+		seen[key] = true
+		if len([]rune(dimension.Description)) > maxDescriptionChars {
+			return req, fmt.Errorf("%w: dimension descriptions must not exceed %d characters", domain.ErrBadRequest, maxDescriptionChars)
+		}
+
+		if dimension.Weight == 0 {
+			dimension.Weight = 1
+		}
+		if dimension.Weight < 0 || dimension.Weight > maxWeight {
+			return req, fmt.Errorf("%w: dimension %q needs a weight above 0 and up to %d", domain.ErrBadRequest, dimension.Name, maxWeight)
+		}
+		if dimension.Min == 0 && dimension.Max == 0 {
+			dimension.Min, dimension.Max = defaultMin, defaultMax
+		}
+		if dimension.Min < 0 || dimension.Min >= dimension.Max || dimension.Max > domain.MaxRubricScale {
+			return req, fmt.Errorf("%w: dimension %q needs a scale with 0 <= min < max <= %d", domain.ErrBadRequest, dimension.Name, domain.MaxRubricScale)
+		}
+		dimensions = append(dimensions, dimension)
+	}
+	req.Dimensions = dimensions
+	return req, nil
+}
+
+func read(ctx context.Context) error {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+	if !user.Can(domain.PermIdeaReadOwn) {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrNotFound
+	}
+	return err
+}
+
+var (
+	uc *usecase
+)
+
+func NewRubricUsecase(dbTx pkgDB.DatabaseTransaction, repo domain.RubricRepository, audit domain.AuditUsecase) domain.RubricUsecase {
+	if uc == nil {
+		uc = &usecase{
+			dbTx,
+			repo,
+			audit,
+		}
+	}
+	return uc
+}
@@ -0,0 +1,93 @@
+package search
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/utils"
+)
+
+type (
+	handler struct {
+		usecase domain.SearchUsecase
+	}
+)
+
+// Search implements domain.SearchHandler.
+func (h *handler) Search(w http.ResponseWriter, r *http.Request) {
+	query, err := parseQuery(r.URL.Query())
+	if err != nil {
+		utils.RespondError(w, "error parsing search query", err)
+		return
+	}
+
+	data, err := h.usecase.Search(r.Context(), query)
+	if err != nil {
+		utils.RespondError(w, "error searching", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Search completed successfully",
+		Data:    data,
+	}, w)
+}
+
+// parseQuery reads q, type, kind, status, owner_id, from, to (RFC 3339),
+// limit and offset.
+func parseQuery(values url.Values) (domain.SearchQuery, error) {
+	query := domain.SearchQuery{
+		Query:  values.Get("q"),
+		Type:   values.Get("type"),
+		Kind:   values.Get("kind"),
+		Status: values.Get("status"),
+	}
+
+	ints := map[string]*int{
+		"owner_id": &query.OwnerId,
+		"limit":    &query.Limit,
+		"offset":   &query.Offset,
+	}
+	for name, dst := range ints {
+		if value := values.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return domain.SearchQuery{}, fmt.Errorf("%w: Invalid %s", domain.ErrBadRequest, name)
+			}
+			*dst = n
+		}
+	}
+
+	times := map[string]**time.Time{
+		"from": &query.From,
+		"to":   &query.To,
+	}
+	for name, dst := range times {
+		if value := values.Get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return domain.SearchQuery{}, fmt.Errorf("%w: Invalid %s", domain.ErrBadRequest, name)
+			}
+			*dst = &t
+		}
+	}
+	return query, nil
+}
+
+var (
+	handlr *handler
+)
+
+func NewSearchHandler(usecase domain.SearchUsecase) domain.SearchHandler {
+	if handlr == nil {
+		handlr = &handler{
+			usecase,
+		}
+	}
+	return handlr
+}
@@ -0,0 +1,213 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+)
+
+type (
+	repository struct {
+		db pkgDB.DatabaseTransaction
+	}
+
+	// conditions collects the WHERE clauses of one side of the union.
+	conditions struct {
+		clauses []string
+		args    []interface{}
+	}
+)
+
+const (
+	searchConfig = "english"
+	// snippet markers the database puts around matches before the snippet
+	// is HTML escaped; they cannot be typed into a form
+	markStart = "\x02"
+	markStop  = "\x03"
+)
+
+// ideaDocument is the searchable text of an idea: the free text and every
+// field of the structured form.
+const ideaDocument = "coalesce(i.idea, '') || ' ' || coalesce(i.problem, '') || ' ' || coalesce(i.solution, '') || ' ' || " +
+	"coalesce(i.customer, '') || ' ' || coalesce(i.revenue_model, '') || ' ' || coalesce(i.competitors, '') || ' ' || coalesce(i.constraints, '')"
+
+var searchIndexStatements = []string{
+	`ALTER TABLE submit_idea_requests ADD COLUMN IF NOT EXISTS search_vector tsvector
+	GENERATED ALWAYS AS (to_tsvector('` + searchConfig + `', ` + strings.ReplaceAll(ideaDocument, "i.", "") + `)) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_idea_search ON submit_idea_requests USING GIN (search_vector)`,
+	`ALTER TABLE evaluations ADD COLUMN IF NOT EXISTS search_vector tsvector
+	GENERATED ALWAYS AS (to_tsvector('` + searchConfig + `', coalesce(output, ''))) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_evaluation_search ON evaluations USING GIN (search_vector)`,
+}
+
+// Search implements domain.SearchRepository.
+func (r *repository) Search(ctx context.Context, query domain.SearchQuery) ([]domain.SearchResult, error) {
+	if r.db.DB(ctx).Dialector.Name() == "postgres" {
+		return r.fullText(ctx, query)
+	}
+	return r.like(ctx, query)
+}
+
+// fullText ranks with ts_rank over the indexed tsvector columns.
+func (r *repository) fullText(ctx context.Context, query domain.SearchQuery) ([]domain.SearchResult, error) {
+	headline := fmt.Sprintf("StartSel=%s, StopSel=%s, MaxFragments=2, MaxWords=25, MinWords=8", markStart, markStop)
+
+	var (
+		parts []string
+		args  []interface{}
+	)
+	if searchesIdeas(query) {
+		where := filters(ctx, query, "i")
+		where.add("i.search_vector @@ q")
+		parts = append(parts, `SELECT 'idea' AS type, i.id AS idea_id, 0 AS evaluation_id, '' AS kind,
+			ts_rank(i.search_vector, q) AS rank,
+			ts_headline('`+searchConfig+`', `+ideaDocument+`, q, ?) AS snippet, i.created_at
+		FROM submit_idea_requests i CROSS JOIN websearch_to_tsquery('`+searchConfig+`', ?) q
+		WHERE `+where.sql())
+		args = append(args, headline, query.Query)
+		args = append(args, where.args...)
+	}
+	if searchesEvaluations(query) {
+		where := filters(ctx, query, "e")
+		where.add("e.search_vector @@ q")
+		parts = append(parts, `SELECT 'evaluation' AS type, e.idea_id, e.id AS evaluation_id, e.kind,
+			ts_rank(e.search_vector, q) AS rank,
+			ts_headline('`+searchConfig+`', coalesce(e.output, ''), q, ?) AS snippet, e.created_at
+		FROM evaluations e LEFT JOIN submit_idea_requests i ON i.id = e.idea_id
+		CROSS JOIN websearch_to_tsquery('`+searchConfig+`', ?) q
+		WHERE `+where.sql())
+		args = append(args, headline, query.Query)
+		args = append(args, where.args...)
+	}
+
+	sql := "SELECT * FROM (" + strings.Join(parts, " UNION ALL ") + ") results ORDER BY rank DESC, created_at DESC LIMIT ? OFFSET ?"
+	args = append(args, query.Limit, query.Offset)
+
+	data := []domain.SearchResult{}
+	if err := r.db.DB(ctx).Raw(sql, args...).Scan(&data).Error; err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// like is the unindexed fallback for the SQLite development database. Every
+// term has to appear; ranking and snippets are computed here instead of in
+// the database.
+func (r *repository) like(ctx context.Context, query domain.SearchQuery) ([]domain.SearchResult, error) {
+	terms := searchTerms(query.Query)
+	if len(terms) == 0 {
+		return []domain.SearchResult{}, nil
+	}
+
+	var (
+		parts []string
+		args  []interface{}
+	)
+	if searchesIdeas(query) {
+		where := filters(ctx, query, "i")
+		for _, term := range terms {
+			where.add("lower("+ideaDocument+") LIKE ? ESCAPE '\\'", likePattern(term))
+		}
+		parts = append(parts, `SELECT 'idea' AS type, i.id AS idea_id, 0 AS evaluation_id, '' AS kind,
+			0 AS rank, `+ideaDocument+` AS snippet, i.created_at
+		FROM submit_idea_requests i
+		WHERE `+where.sql())
+		args = append(args, where.args...)
+	}
+	if searchesEvaluations(query) {
+		where := filters(ctx, query, "e")
+		for _, term := range terms {
+			where.add("lower(coalesce(e.output, '')) LIKE ? ESCAPE '\\'", likePattern(term))
+		}
+		parts = append(parts, `SELECT 'evaluation' AS type, e.idea_id, e.id AS evaluation_id, e.kind,
+			0 AS rank, coalesce(e.output, '') AS snippet, e.created_at
+		FROM evaluations e LEFT JOIN submit_idea_requests i ON i.id = e.idea_id
+		WHERE `+where.sql())
+		args = append(args, where.args...)
+	}
+
+	sql := "SELECT * FROM (" + strings.Join(parts, " UNION ALL ") + ") results ORDER BY created_at DESC LIMIT ? OFFSET ?"
+	args = append(args, query.Limit, query.Offset)
+
+	data := []domain.SearchResult{}
+	if err := r.db.DB(ctx).Raw(sql, args...).Scan(&data).Error; err != nil {
+		return nil, err
+	}
+	for i := range data {
+		data[i].Rank, data[i].Snippet = likeSnippet(data[i].Snippet, terms)
+	}
+	return data, nil
+}
+
+// EnsureSearchIndex implements domain.SearchRepository.
+func (r *repository) EnsureSearchIndex(ctx context.Context) error {
+	db := r.db.DB(ctx)
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	for _, statement := range searchIndexStatements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filters builds the conditions shared by both search strategies. alias is
+// the table the result row comes from; i is the idea either way.
+func filters(ctx context.Context, query domain.SearchQuery, alias string) *conditions {
+	workspace, _ := domain.WorkspaceFromContext(ctx)
+
+	where := &conditions{}
+	where.add(alias+".workspace_id = ?", workspace.Id)
+	where.add("i.deleted_at IS NULL")
+	if query.Kind != "" && alias == "e" {
+		where.add("e.kind = ?", query.Kind)
+	}
+	if query.Status != "" {
+		where.add("i.status = ?", query.Status)
+	}
+	if query.OwnerId != 0 {
+		where.add(alias+".user_id = ?", query.OwnerId)
+	}
+	if query.From != nil {
+		where.add(alias+".created_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		where.add(alias+".created_at < ?", *query.To)
+	}
+	return where
+}
+
+func (c *conditions) add(clause string, args ...interface{}) {
+	c.clauses = append(c.clauses, clause)
+	c.args = append(c.args, args...)
+}
+
+func (c *conditions) sql() string {
+	return strings.Join(c.clauses, " AND ")
+}
+
+func searchesIdeas(query domain.SearchQuery) bool {
+	return (query.Type == "" && query.Kind == "") || query.Type == domain.SearchIdeas
+}
+
+func searchesEvaluations(query domain.SearchQuery) bool {
+	return query.Type == "" || query.Type == domain.SearchEvaluations
+}
+
+var (
+	repo *repository
+)
+
+func NewSearchRepository(db pkgDB.DatabaseTransaction) domain.SearchRepository {
+	if repo == nil {
+		repo = &repository{
+			db,
+		}
+	}
+	return repo
+}
@@ -0,0 +1,16 @@
+package search
+
+import (
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+)
+
+func InitSearchRepository(db pkgDB.DatabaseTransaction) domain.SearchRepository {
+	return NewSearchRepository(db)
+}
+func InitSearchUsecase(repo domain.SearchRepository) domain.SearchUsecase {
+	return NewSearchUsecase(repo)
+}
+func InitSearchHandler(usecase domain.SearchUsecase) domain.SearchHandler {
+	return NewSearchHandler(usecase)
+}
@@ -0,0 +1,120 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+)
+
+const snippetRadius = 80
+
+// searchTerms splits a query into the lower case words the LIKE fallback
+// looks for. Websearch operators are dropped, and so are excluded words,
+// which the fallback does not support.
+func searchTerms(query string) []string {
+	var terms []string
+	for _, field := range strings.Fields(strings.ToLower(query)) {
+		if strings.HasPrefix(field, "-") {
+			continue
+		}
+		field = strings.Trim(field, `"'()`)
+		if field == "" || field == "or" {
+			continue
+		}
+		terms = append(terms, field)
+	}
+	return terms
+}
+
+func likePattern(term string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + replacer.Replace(term) + "%"
+}
+
+// likeSnippet ranks text by how often the terms occur and cuts a window
+// around the first match with every occurrence marked. It works on runes so
+// the window never splits a character; runes are lowered one by one, which
+// keeps the lowered text aligned with the original.
+func likeSnippet(text string, terms []string) (float64, string) {
+	runes := []rune(text)
+	lower := lowerRunes(runes)
+	needles := make([][]rune, 0, len(terms))
+	for _, term := range terms {
+		needles = append(needles, lowerRunes([]rune(term)))
+	}
+
+	first, hits := -1, 0
+	for i := range lower {
+		if n := longestMatch(lower[i:], needles); n > 0 {
+			hits++
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+	if first < 0 {
+		first = 0
+	}
+
+	start, end := first-snippetRadius, first+snippetRadius
+	if start < 0 {
+		start = 0
+	}
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; {
+		n := longestMatch(lower[i:end], needles)
+		if n == 0 {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		b.WriteString(markStart + string(runes[i:i+n]) + markStop)
+		i += n
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+
+	rank := 0.0
+	if len(runes) > 0 {
+		rank = float64(hits) / float64(len(runes)) * 100
+	}
+	return rank, b.String()
+}
+
+func lowerRunes(runes []rune) []rune {
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	return lower
+}
+
+// longestMatch returns the length in runes of the longest needle text
+// starts with, or 0 when none does.
+func longestMatch(text []rune, needles [][]rune) int {
+	longest := 0
+	for _, needle := range needles {
+		if len(needle) > longest && len(needle) <= len(text) && string(text[:len(needle)]) == string(needle) {
+			longest = len(needle)
+		}
+	}
+	return longest
+}
+
+// highlight escapes a snippet for HTML and turns the database markers into
+// domain.SnippetStart and domain.SnippetStop.
+func highlight(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	snippet = strings.ReplaceAll(snippet, markStart, domain.SnippetStart)
+	return strings.ReplaceAll(snippet, markStop, domain.SnippetStop)
+}
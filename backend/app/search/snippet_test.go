@@ -0,0 +1,50 @@
+package search
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestLikeSnippetMarksMatchesIgnoringCase(t *testing.T) {
+	_, snippet := likeSnippet("Kopi Susu dan KOPI hitam", []string{"kopi"})
+
+	want := markStart + "Kopi" + markStop + " Susu dan " + markStart + "KOPI" + markStop + " hitam"
+	if snippet != want {
+		t.Errorf("snippet %q, want %q", snippet, want)
+	}
+}
+
+func TestLikeSnippetCutsOnRunes(t *testing.T) {
+	// every rune before the match takes two bytes, so a byte window would
+	// start halfway through one of them
+	text := strings.Repeat("é", 3*snippetRadius) + " ramen " + strings.Repeat("ü", 3*snippetRadius)
+
+	rank, snippet := likeSnippet(text, []string{"ramen"})
+	if !utf8.ValidString(snippet) {
+		t.Fatalf("snippet %q is not valid UTF-8", snippet)
+	}
+	if !strings.HasPrefix(snippet, "…") || !strings.HasSuffix(snippet, "…") {
+		t.Errorf("snippet %q is not cut on both sides", snippet)
+	}
+	if !strings.Contains(snippet, markStart+"ramen"+markStop) {
+		t.Errorf("snippet %q does not mark the match", snippet)
+	}
+	if got := utf8.RuneCountInString(snippet); got != 2*snippetRadius+4 {
+		t.Errorf("snippet is %d runes, want %d", got, 2*snippetRadius+4)
+	}
+	if rank <= 0 {
+		t.Errorf("rank %v, want a positive rank", rank)
+	}
+}
+
+func TestLikeSnippetKeepsCaseChangingRunesAligned(t *testing.T) {
+	// İ lowers to two runes with strings.ToLower, which would shift every
+	// match after it
+	_, snippet := likeSnippet("İstanbul kebab", []string{"kebab"})
+
+	want := "İstanbul " + markStart + "kebab" + markStop
+	if snippet != want {
+		t.Errorf("snippet %q, want %q", snippet, want)
+	}
+}
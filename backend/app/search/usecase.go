@@ -0,0 +1,81 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultLimit  = 20
+	maxLimit      = 100
+	maxQueryChars = 256
+)
+
+type (
+	usecase struct {
+		repo domain.SearchRepository
+	}
+)
+
+// Search implements domain.SearchUsecase.
+func (u *usecase) Search(ctx context.Context, query domain.SearchQuery) ([]domain.SearchResult, error) {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+	if !user.Can(domain.PermIdeaReadOwn) {
+		return nil, domain.ErrForbidden
+	}
+
+	query.Query = strings.TrimSpace(query.Query)
+	if query.Query == "" {
+		return nil, fmt.Errorf("%w: q is required", domain.ErrBadRequest)
+	}
+	if len([]rune(query.Query)) > maxQueryChars {
+		return nil, fmt.Errorf("%w: q must not exceed %d characters", domain.ErrBadRequest, maxQueryChars)
+	}
+	switch query.Type {
+	case "", domain.SearchIdeas, domain.SearchEvaluations:
+	default:
+		return nil, fmt.Errorf("%w: type must be idea or evaluation", domain.ErrBadRequest)
+	}
+	if query.Type == domain.SearchIdeas && query.Kind != "" {
+		return nil, fmt.Errorf("%w: kind only applies to evaluations", domain.ErrBadRequest)
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultLimit
+	}
+	if query.Limit > maxLimit {
+		query.Limit = maxLimit
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+
+	data, err := u.repo.Search(ctx, query)
+	if err != nil {
+		logrus.Errorf("error searching %q: %v", query.Query, err)
+		return nil, err
+	}
+	for i := range data {
+		data[i].Snippet = highlight(data[i].Snippet)
+	}
+	return data, nil
+}
+
+var (
+	uc *usecase
+)
+
+func NewSearchUsecase(repo domain.SearchRepository) domain.SearchUsecase {
+	if uc == nil {
+		uc = &usecase{
+			repo,
+		}
+	}
+	return uc
+}
@@ -0,0 +1,152 @@
+package share
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	handler struct {
+		usecase domain.ShareUsecase
+	}
+)
+
+// ListShareLinks implements domain.ShareHandler. The idea_id query parameter
+// narrows the list to one idea.
+func (h *handler) ListShareLinks(w http.ResponseWriter, r *http.Request) {
+	ideaId := 0
+	if value := r.URL.Query().Get("idea_id"); value != "" {
+		var err error
+		if ideaId, err = strconv.Atoi(value); err != nil {
+			utils.Response(domain.HttpResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid idea_id",
+				Data:    nil,
+			}, w)
+			return
+		}
+	}
+
+	data, err := h.usecase.ListShareLinks(r.Context(), ideaId)
+	if err != nil {
+		utils.RespondError(w, "error listing share links", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Share links retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// CreateShareLink implements domain.ShareHandler.
+func (h *handler) CreateShareLink(w http.ResponseWriter, r *http.Request) {
+	req := domain.CreateShareLinkRequest{}
+	if !decode(w, r, &req) {
+		return
+	}
+
+	data, err := h.usecase.CreateShareLink(r.Context(), req)
+	if err != nil {
+		utils.RespondError(w, "error creating share link", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusCreated,
+		Message: "Share link created successfully, store it now as it will not be shown again",
+		Data:    data,
+	}, w)
+}
+
+// RevokeShareLink implements domain.ShareHandler.
+func (h *handler) RevokeShareLink(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.usecase.RevokeShareLink(r.Context(), id); err != nil {
+		utils.RespondError(w, "error revoking share link", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Share link revoked successfully",
+		Data:    nil,
+	}, w)
+}
+
+// GetSharedView implements domain.ShareHandler. It is served without
+// authentication, so errors never say more than that nothing was found.
+func (h *handler) GetSharedView(w http.ResponseWriter, r *http.Request) {
+	data, err := h.usecase.GetSharedView(r.Context(), mux.Vars(r)["token"])
+	if err != nil {
+		if !errors.Is(err, domain.ErrNotFound) {
+			logrus.Errorf("error opening share link: %v", err)
+		}
+		utils.Response(domain.HttpResponse{
+			Code:    utils.ErrorStatus(err),
+			Message: "Share link not found",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	// the view counter must see every visit
+	w.Header().Set("Cache-Control", "no-store")
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Shared idea retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+func pathId(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return 0, false
+	}
+	return id, true
+}
+
+func decode(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return false
+	}
+	return true
+}
+
+var (
+	handlr *handler
+)
+
+func NewShareHandler(usecase domain.ShareUsecase) domain.ShareHandler {
+	if handlr == nil {
+		handlr = &handler{
+			usecase,
+		}
+	}
+	return handlr
+}
@@ -0,0 +1,101 @@
+package share
+
+import (
+	"context"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type (
+	repository struct {
+		db pkgDB.DatabaseTransaction
+	}
+)
+
+// ListShareLinks implements domain.ShareRepository.
+func (r *repository) ListShareLinks(ctx context.Context, filter domain.ShareLinkFilter) ([]domain.ShareLink, error) {
+	data := []domain.ShareLink{}
+	query := r.scoped(ctx)
+	if filter.UserId != 0 {
+		query = query.Where("user_id = ?", filter.UserId)
+	}
+	if filter.IdeaId != 0 {
+		query = query.Where("idea_id = ?", filter.IdeaId)
+	}
+	if err := query.Order("id DESC").Find(&data).Error; err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetShareLink implements domain.ShareRepository.
+func (r *repository) GetShareLink(ctx context.Context, id int) (domain.ShareLink, error) {
+	data := domain.ShareLink{}
+	if err := r.scoped(ctx).First(&data, id).Error; err != nil {
+		return domain.ShareLink{}, err
+	}
+	return data, nil
+}
+
+// FindShareLinkByHash implements domain.ShareRepository.
+func (r *repository) FindShareLinkByHash(ctx context.Context, hash string) (domain.ShareLink, error) {
+	data := domain.ShareLink{}
+	if err := r.db.DB(ctx).Where("token_hash = ?", hash).First(&data).Error; err != nil {
+		return domain.ShareLink{}, err
+	}
+	return data, nil
+}
+
+// CreateShareLink implements domain.ShareRepository.
+func (r *repository) CreateShareLink(ctx context.Context, link domain.ShareLink) (domain.ShareLink, error) {
+	link.WorkspaceId = workspaceId(ctx)
+	if err := r.db.DB(ctx).Create(&link).Error; err != nil {
+		logrus.Error("repository.CreateShareLink: failed to save share link")
+		return domain.ShareLink{}, err
+	}
+	return link, nil
+}
+
+// RevokeShareLink implements domain.ShareRepository.
+func (r *repository) RevokeShareLink(ctx context.Context, id int) error {
+	return r.scoped(ctx).Model(&domain.ShareLink{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RecordShareView implements domain.ShareRepository.
+func (r *repository) RecordShareView(ctx context.Context, id int) error {
+	return r.db.DB(ctx).Model(&domain.ShareLink{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"views":          gorm.Expr("views + 1"),
+			"last_viewed_at": time.Now(),
+		}).Error
+}
+
+// scoped limits a query to the caller's active workspace.
+func (r *repository) scoped(ctx context.Context) *gorm.DB {
+	return r.db.DB(ctx).Where("workspace_id = ?", workspaceId(ctx))
+}
+
+func workspaceId(ctx context.Context) int {
+	workspace, _ := domain.WorkspaceFromContext(ctx)
+	return workspace.Id
+}
+
+var (
+	repo *repository
+)
+
+func NewShareRepository(db pkgDB.DatabaseTransaction) domain.ShareRepository {
+	if repo == nil {
+		repo = &repository{
+			db,
+		}
+	}
+	return repo
+}
@@ -0,0 +1,17 @@
+package share
+
+import (
+	"github.com/Kocannn/self-dunking-ai/config"
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+)
+
+func InitShareRepository(db pkgDB.DatabaseTransaction) domain.ShareRepository {
+	return NewShareRepository(db)
+}
+func InitShareUsecase(cfg config.Config, dbTx pkgDB.DatabaseTransaction, repo domain.ShareRepository, ideas domain.IdeaRepository, audit domain.AuditUsecase) domain.ShareUsecase {
+	return NewShareUsecase(cfg, dbTx, repo, ideas, audit)
+}
+func InitShareHandler(usecase domain.ShareUsecase) domain.ShareHandler {
+	return NewShareHandler(usecase)
+}
@@ -0,0 +1,287 @@
+package share
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/config"
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/Kocannn/self-dunking-ai/pkg/token"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// publicPath is where GetSharedView is served, see registerHandler.
+const publicPath = "/api/v1/public/shares/"
+
+type (
+	usecase struct {
+		cfg   config.Config
+		dbTx  pkgDB.DatabaseTransaction
+		repo  domain.ShareRepository
+		ideas domain.IdeaRepository
+		audit domain.AuditUsecase
+	}
+)
+
+// ListShareLinks implements domain.ShareUsecase.
+func (u *usecase) ListShareLinks(ctx context.Context, ideaId int) ([]domain.ShareLink, error) {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+	filter := domain.ShareLinkFilter{IdeaId: ideaId}
+	if !domain.WorkspaceManager(ctx, user) {
+		filter.UserId = user.Id
+	}
+	return u.repo.ListShareLinks(ctx, filter)
+}
+
+// CreateShareLink implements domain.ShareUsecase. Sharing publishes the
+// idea, so it takes the right to change it rather than to read it.
+func (u *usecase) CreateShareLink(ctx context.Context, req domain.CreateShareLinkRequest) (domain.CreateShareLinkResponse, error) {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.CreateShareLinkResponse{}, domain.ErrUnauthorized
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > domain.MaxShareLinkDays {
+		return domain.CreateShareLinkResponse{}, fmt.Errorf("%w: expires_in_days must be between 0 and %d", domain.ErrBadRequest, domain.MaxShareLinkDays)
+	}
+
+	if req.EvaluationId != 0 {
+		evaluation, err := u.ideas.GetEvaluation(ctx, req.EvaluationId)
+		if err != nil {
+			return domain.CreateShareLinkResponse{}, notFound(err)
+		}
+		if !shared(evaluation.Kind) {
+			return domain.CreateShareLinkResponse{}, fmt.Errorf("%w: %s evaluations cannot be shared", domain.ErrBadRequest, evaluation.Kind)
+		}
+		if req.IdeaId != 0 && req.IdeaId != evaluation.IdeaId {
+			return domain.CreateShareLinkResponse{}, fmt.Errorf("%w: the evaluation belongs to another idea", domain.ErrBadRequest)
+		}
+		req.IdeaId = evaluation.IdeaId
+	}
+	if req.IdeaId == 0 {
+		return domain.CreateShareLinkResponse{}, fmt.Errorf("%w: idea_id or evaluation_id is required", domain.ErrBadRequest)
+	}
+	idea, err := u.ideas.GetIdea(ctx, req.IdeaId)
+	if err != nil {
+		return domain.CreateShareLinkResponse{}, notFound(err)
+	}
+	if err := authorize(ctx, user, idea.UserId); err != nil {
+		return domain.CreateShareLinkResponse{}, err
+	}
+
+	secret, err := token.NewOpaque()
+	if err != nil {
+		return domain.CreateShareLinkResponse{}, err
+	}
+	payload := domain.ShareLinkPrefix + secret
+	signed := token.Sign(u.cfg.JWT_SECRET_KEY, payload)
+
+	now := time.Now()
+	link := domain.ShareLink{
+		UserId:       user.Id,
+		IdeaId:       req.IdeaId,
+		EvaluationId: req.EvaluationId,
+		Prefix:       payload[:len(domain.ShareLinkPrefix)+8],
+		TokenHash:    token.Hash(signed),
+		CreatedAt:    &now,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := now.AddDate(0, 0, req.ExpiresInDays)
+		link.ExpiresAt = &expiresAt
+	}
+
+	var created domain.ShareLink
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		created, err = u.repo.CreateShareLink(txCtx, link)
+		if err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditShareCreate,
+			TargetType: "share_link",
+			TargetId:   strconv.Itoa(created.Id),
+			After:      created,
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error creating share link: %v", err)
+		return domain.CreateShareLinkResponse{}, err
+	}
+
+	return domain.CreateShareLinkResponse{
+		ShareLink: created,
+		Token:     signed,
+		URL:       strings.TrimSuffix(u.cfg.BaseURL, "/") + publicPath + signed,
+	}, nil
+}
+
+// RevokeShareLink implements domain.ShareUsecase.
+func (u *usecase) RevokeShareLink(ctx context.Context, id int) error {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+	link, err := u.repo.GetShareLink(ctx, id)
+	if err != nil {
+		return notFound(err)
+	}
+	if err := authorize(ctx, user, link.UserId); err != nil {
+		return err
+	}
+	if link.RevokedAt != nil {
+		return fmt.Errorf("%w: share link is already revoked", domain.ErrConflict)
+	}
+
+	return u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		if err := u.repo.RevokeShareLink(txCtx, id); err != nil {
+			return err
+		}
+		revoked := link
+		now := time.Now()
+		revoked.RevokedAt = &now
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditShareRevoke,
+			TargetType: "share_link",
+			TargetId:   strconv.Itoa(id),
+			Before:     link,
+			After:      revoked,
+		})
+	})
+}
+
+// GetSharedView implements domain.ShareUsecase. Forged, unknown, expired and
+// revoked tokens all look the same to the caller.
+func (u *usecase) GetSharedView(ctx context.Context, signed string) (domain.SharedView, error) {
+	payload, ok := token.Verify(u.cfg.JWT_SECRET_KEY, signed)
+	if !ok || !strings.HasPrefix(payload, domain.ShareLinkPrefix) {
+		return domain.SharedView{}, domain.ErrNotFound
+	}
+	link, err := u.repo.FindShareLinkByHash(ctx, token.Hash(signed))
+	if err != nil {
+		return domain.SharedView{}, notFound(err)
+	}
+	if !link.Active(time.Now()) {
+		return domain.SharedView{}, domain.ErrNotFound
+	}
+
+	// the idea repository only reads the active workspace, which is the
+	// one the link was made in
+	ctx = context.WithValue(ctx, domain.ContextWorkspace, domain.Workspace{Id: link.WorkspaceId})
+	idea, err := u.ideas.GetIdea(ctx, link.IdeaId)
+	if err != nil {
+		return domain.SharedView{}, notFound(err)
+	}
+
+	var evaluations []domain.Evaluation
+	if link.EvaluationId != 0 {
+		evaluation, err := u.ideas.GetEvaluation(ctx, link.EvaluationId)
+		if err != nil {
+			return domain.SharedView{}, notFound(err)
+		}
+		if evaluation.IdeaId != link.IdeaId {
+			return domain.SharedView{}, domain.ErrNotFound
+		}
+		evaluations = []domain.Evaluation{evaluation}
+	} else {
+		evaluations, err = u.ideas.ListEvaluations(ctx, link.IdeaId)
+		if err != nil {
+			logrus.Errorf("error listing shared evaluations: %v", err)
+			return domain.SharedView{}, err
+		}
+	}
+
+	if err := u.repo.RecordShareView(ctx, link.Id); err != nil {
+		logrus.Errorf("error counting share link view: %v", err)
+	}
+	return redact(idea, evaluations, link.Views+1, link.ExpiresAt), nil
+}
+
+// redact keeps what the idea says and what the reviewers answered, nothing
+// about who wrote it or how the answers were produced.
+func redact(idea domain.SubmitIdeaRequest, evaluations []domain.Evaluation, views int64, expiresAt *time.Time) domain.SharedView {
+	view := domain.SharedView{
+		Idea: domain.SharedIdea{
+			Idea:       idea.Idea,
+			Status:     idea.Status,
+			Score:      idea.Score,
+			Language:   idea.Language,
+			CreatedAt:  idea.CreatedAt,
+			IdeaFields: idea.IdeaFields,
+		},
+		Evaluations: []domain.SharedEvaluation{},
+		Views:       views,
+		ExpiresAt:   expiresAt,
+	}
+	for _, evaluation := range evaluations {
+		if !shared(evaluation.Kind) {
+			continue
+		}
+		view.Evaluations = append(view.Evaluations, domain.SharedEvaluation{
+			Kind:             evaluation.Kind,
+			Persona:          evaluation.Persona,
+			Output:           evaluation.Output,
+			ScoreOriginality: evaluation.ScoreOriginality,
+			ScoreScalability: evaluation.ScoreScalability,
+			ScoreFeasibility: evaluation.ScoreFeasibility,
+			DimensionScores:  evaluation.DimensionScores,
+			OverallScore:     evaluation.OverallScore,
+			Weaknesses:       evaluation.Weaknesses,
+			CreatedAt:        evaluation.CreatedAt,
+		})
+	}
+	return view
+}
+
+func shared(kind string) bool {
+	for _, k := range domain.SharedEvaluationKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// authorize lets owners share and revoke with the own idea permission,
+// everybody else only as a manager.
+func authorize(ctx context.Context, user domain.User, ownerId int) error {
+	if ownerId == user.Id && user.Can(domain.PermIdeaWriteOwn) {
+		return nil
+	}
+	if domain.WorkspaceManager(ctx, user) {
+		return nil
+	}
+	return domain.ErrForbidden
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrNotFound
+	}
+	return err
+}
+
+var (
+	uc *usecase
+)
+
+func NewShareUsecase(cfg config.Config, dbTx pkgDB.DatabaseTransaction, repo domain.ShareRepository, ideas domain.IdeaRepository, audit domain.AuditUsecase) domain.ShareUsecase {
+	if uc == nil {
+		uc = &usecase{
+			cfg:   cfg,
+			dbTx:  dbTx,
+			repo:  repo,
+			ideas: ideas,
+			audit: audit,
+		}
+	}
+	return uc
+}
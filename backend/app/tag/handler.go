@@ -0,0 +1,97 @@
+package tag
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	handler struct {
+		usecase domain.TagUsecase
+	}
+)
+
+// ListTags implements domain.TagHandler.
+func (h *handler) ListTags(w http.ResponseWriter, r *http.Request) {
+	data, err := h.usecase.ListTags(r.Context())
+	if err != nil {
+		utils.RespondError(w, "error listing tags", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Tags retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// CreateTag implements domain.TagHandler.
+func (h *handler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	dataBuffer := domain.TagRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&dataBuffer); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.CreateTags(r.Context(), dataBuffer.Tags)
+	if err != nil {
+		utils.RespondError(w, "error creating tags", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusCreated,
+		Message: "Tags created successfully",
+		Data:    data,
+	}, w)
+}
+
+// DeleteTag implements domain.TagHandler.
+func (h *handler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	if err := h.usecase.DeleteTag(r.Context(), id); err != nil {
+		utils.RespondError(w, "error deleting tag", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Tag deleted successfully",
+		Data:    nil,
+	}, w)
+}
+
+var (
+	handlr *handler
+)
+
+func NewTagHandler(usecase domain.TagUsecase) domain.TagHandler {
+	if handlr == nil {
+		handlr = &handler{
+			usecase,
+		}
+	}
+	return handlr
+}
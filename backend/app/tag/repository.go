@@ -0,0 +1,145 @@
+package tag
+
+import (
+	"context"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	repository struct {
+		db pkgDB.DatabaseTransaction
+	}
+
+	// ideaTag is a tag of the idea IdeaId as read by ListIdeaTags.
+	ideaTag struct {
+		domain.Tag
+		IdeaId int
+	}
+)
+
+// ListTags implements domain.TagRepository.
+func (r *repository) ListTags(ctx context.Context) ([]domain.Tag, error) {
+	data := []domain.Tag{}
+	err := r.scoped(ctx).Model(&domain.Tag{}).
+		Select("tags.*, count(submit_idea_requests.id) AS ideas").
+		Joins("LEFT JOIN idea_tags ON idea_tags.tag_id = tags.id").
+		Joins("LEFT JOIN submit_idea_requests ON submit_idea_requests.id = idea_tags.idea_id AND submit_idea_requests.deleted_at IS NULL").
+		Group("tags.id").
+		Order("tags.name").
+		Find(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetTag implements domain.TagRepository.
+func (r *repository) GetTag(ctx context.Context, id int) (domain.Tag, error) {
+	data := domain.Tag{}
+	err := r.scoped(ctx).First(&data, id).Error
+	if err != nil {
+		return domain.Tag{}, err
+	}
+	return data, nil
+}
+
+// CreateTags implements domain.TagRepository.
+func (r *repository) CreateTags(ctx context.Context, names []string) ([]domain.Tag, error) {
+	workspaceId := workspaceId(ctx)
+	now := time.Now()
+	tags := make([]domain.Tag, 0, len(names))
+	for _, name := range names {
+		tags = append(tags, domain.Tag{WorkspaceId: workspaceId, Name: name, CreatedAt: &now})
+	}
+	err := r.db.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// ids of tags that already existed are not returned by the insert
+	data := []domain.Tag{}
+	err = r.scoped(ctx).Where("name IN ?", names).Order("name").Find(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// DeleteTag implements domain.TagRepository.
+func (r *repository) DeleteTag(ctx context.Context, id int) error {
+	err := r.db.DB(ctx).Where("tag_id = ?", id).Delete(&domain.IdeaTag{}).Error
+	if err != nil {
+		return err
+	}
+	return r.scoped(ctx).Delete(&domain.Tag{}, id).Error
+}
+
+// AddIdeaTags implements domain.TagRepository. Tags already on the idea keep
+// their original source.
+func (r *repository) AddIdeaTags(ctx context.Context, ideaId int, tagIds []int, source string) error {
+	if len(tagIds) == 0 {
+		return nil
+	}
+	now := time.Now()
+	rows := make([]domain.IdeaTag, 0, len(tagIds))
+	for _, tagId := range tagIds {
+		rows = append(rows, domain.IdeaTag{IdeaId: ideaId, TagId: tagId, Source: source, CreatedAt: &now})
+	}
+	return r.db.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+// RemoveIdeaTag implements domain.TagRepository.
+func (r *repository) RemoveIdeaTag(ctx context.Context, ideaId, tagId int) error {
+	return r.db.DB(ctx).Where("idea_id = ? AND tag_id = ?", ideaId, tagId).Delete(&domain.IdeaTag{}).Error
+}
+
+// ListIdeaTags implements domain.TagRepository.
+func (r *repository) ListIdeaTags(ctx context.Context, ideaIds []int) (map[int][]domain.Tag, error) {
+	data := map[int][]domain.Tag{}
+	if len(ideaIds) == 0 {
+		return data, nil
+	}
+
+	rows := []ideaTag{}
+	err := r.scoped(ctx).Model(&domain.Tag{}).
+		Select("tags.*, idea_tags.source, idea_tags.idea_id").
+		Joins("JOIN idea_tags ON idea_tags.tag_id = tags.id").
+		Where("idea_tags.idea_id IN ?", ideaIds).
+		Order("tags.name").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		data[row.IdeaId] = append(data[row.IdeaId], row.Tag)
+	}
+	return data, nil
+}
+
+// scoped limits a query to the tags of the active workspace.
+func (r *repository) scoped(ctx context.Context) *gorm.DB {
+	return r.db.DB(ctx).Where("tags.workspace_id = ?", workspaceId(ctx))
+}
+
+func workspaceId(ctx context.Context) int {
+	workspace, _ := domain.WorkspaceFromContext(ctx)
+	return workspace.Id
+}
+
+var (
+	repo *repository
+)
+
+func NewTagRepository(db pkgDB.DatabaseTransaction) domain.TagRepository {
+	if repo == nil {
+		repo = &repository{
+			db,
+		}
+	}
+	return repo
+}
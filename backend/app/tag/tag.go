@@ -0,0 +1,16 @@
+package tag
+
+import (
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+)
+
+func InitTagRepository(db pkgDB.DatabaseTransaction) domain.TagRepository {
+	return NewTagRepository(db)
+}
+func InitTagUsecase(dbTx pkgDB.DatabaseTransaction, repo domain.TagRepository, audit domain.AuditUsecase) domain.TagUsecase {
+	return NewTagUsecase(dbTx, repo, audit)
+}
+func InitTagHandler(usecase domain.TagUsecase) domain.TagHandler {
+	return NewTagHandler(usecase)
+}
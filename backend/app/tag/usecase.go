@@ -0,0 +1,113 @@
+package tag
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type (
+	usecase struct {
+		dbTx  pkgDB.DatabaseTransaction
+		repo  domain.TagRepository
+		audit domain.AuditUsecase
+	}
+)
+
+// ListTags implements domain.TagUsecase.
+func (u *usecase) ListTags(ctx context.Context) ([]domain.Tag, error) {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+	if !user.Can(domain.PermIdeaReadOwn) {
+		return nil, domain.ErrForbidden
+	}
+
+	data, err := u.repo.ListTags(ctx)
+	if err != nil {
+		logrus.Errorf("error listing tags: %v", err)
+		return nil, err
+	}
+	return data, nil
+}
+
+// CreateTags implements domain.TagUsecase. Existing names are returned as
+// they are.
+func (u *usecase) CreateTags(ctx context.Context, names []string) ([]domain.Tag, error) {
+	if err := domain.ManageWorkspace(ctx); err != nil {
+		return nil, err
+	}
+	names, err := domain.NormalizeTags(names)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []domain.Tag
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		data, err = u.repo.CreateTags(txCtx, names)
+		if err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditTagCreate,
+			TargetType: "tag",
+			After:      map[string]interface{}{"tags": names},
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error creating tags: %v", err)
+		return nil, err
+	}
+	return data, nil
+}
+
+// DeleteTag implements domain.TagUsecase. The tag is taken off every idea.
+func (u *usecase) DeleteTag(ctx context.Context, id int) error {
+	if err := domain.ManageWorkspace(ctx); err != nil {
+		return err
+	}
+
+	return u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		tag, err := u.repo.GetTag(txCtx, id)
+		if err != nil {
+			return notFound(err)
+		}
+		if err := u.repo.DeleteTag(txCtx, id); err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditTagDelete,
+			TargetType: "tag",
+			TargetId:   strconv.Itoa(id),
+			Before:     tag,
+		})
+	})
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrNotFound
+	}
+	return err
+}
+
+var (
+	uc *usecase
+)
+
+func NewTagUsecase(dbTx pkgDB.DatabaseTransaction, repo domain.TagRepository, audit domain.AuditUsecase) domain.TagUsecase {
+	if uc == nil {
+		uc = &usecase{
+			dbTx,
+			repo,
+			audit,
+		}
+	}
+	return uc
+}
@@ -0,0 +1,71 @@
+package usage
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	handler struct {
+		usecase domain.UsageUsecase
+	}
+)
+
+// GetUsage implements domain.UsageHandler.
+func (h *handler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	data, err := h.usecase.GetUsage(r.Context())
+	if err != nil {
+		utils.RespondError(w, "error getting usage", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Usage retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// GetUserUsage implements domain.UsageHandler.
+func (h *handler) GetUserUsage(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.GetUserUsage(r.Context(), id)
+	if err != nil {
+		utils.RespondError(w, "error getting usage", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Usage retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+var (
+	handlr *handler
+)
+
+func NewUsageHandler(usecase domain.UsageUsecase) domain.UsageHandler {
+	if handlr == nil {
+		handlr = &handler{
+			usecase,
+		}
+	}
+	return handlr
+}
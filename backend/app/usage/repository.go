@@ -0,0 +1,104 @@
+package usage
+
+import (
+	"context"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// refilled is the bucket content after refilling for the time elapsed since
+// the last request, capped at the capacity.
+const refilled = `LEAST(CAST(@capacity AS double precision), rate_limit_buckets.tokens + EXTRACT(EPOCH FROM (NOW() - rate_limit_buckets.updated_at)) * CAST(@rate AS double precision))`
+
+// takeTokenSQL refills and takes a token in one statement so concurrent
+// requests on the same key cannot overdraw the bucket.
+const takeTokenSQL = `
+INSERT INTO rate_limit_buckets (bucket_key, tokens, allowed, updated_at)
+VALUES (@key, CAST(@capacity AS double precision) - 1, true, NOW())
+ON CONFLICT (bucket_key) DO UPDATE SET
+	tokens = CASE WHEN ` + refilled + ` >= 1 THEN ` + refilled + ` - 1 ELSE ` + refilled + ` END,
+	allowed = ` + refilled + ` >= 1,
+	updated_at = NOW()
+RETURNING bucket_key, tokens, allowed, updated_at`
+
+type (
+	repository struct {
+		db pkgDB.DatabaseTransaction
+	}
+)
+
+// TakeToken implements domain.UsageRepository.
+func (r *repository) TakeToken(ctx context.Context, key string, capacity, perSecond float64) (domain.RateLimitBucket, error) {
+	data := domain.RateLimitBucket{}
+	err := r.db.DB(ctx).Raw(takeTokenSQL, map[string]interface{}{
+		"key":      key,
+		"capacity": capacity,
+		"rate":     perSecond,
+	}).Scan(&data).Error
+	if err != nil {
+		return domain.RateLimitBucket{}, err
+	}
+	return data, nil
+}
+
+// GetUsageCounters implements domain.UsageRepository. Missing periods come
+// back as zero counters.
+func (r *repository) GetUsageCounters(ctx context.Context, userId int, dayStart, monthStart time.Time) (domain.UsageCounter, domain.UsageCounter, error) {
+	data := []domain.UsageCounter{}
+	err := r.db.DB(ctx).
+		Where("user_id = ?", userId).
+		Where("(period = ? AND period_start = ?) OR (period = ? AND period_start = ?)",
+			domain.UsagePeriodDay, dayStart, domain.UsagePeriodMonth, monthStart).
+		Find(&data).Error
+	if err != nil {
+		return domain.UsageCounter{}, domain.UsageCounter{}, err
+	}
+
+	day := domain.UsageCounter{UserId: userId, Period: domain.UsagePeriodDay, PeriodStart: dayStart}
+	month := domain.UsageCounter{UserId: userId, Period: domain.UsagePeriodMonth, PeriodStart: monthStart}
+	for _, counter := range data {
+		if counter.Period == domain.UsagePeriodDay {
+			day = counter
+		} else {
+			month = counter
+		}
+	}
+	return day, month, nil
+}
+
+// IncrementUsage implements domain.UsageRepository.
+func (r *repository) IncrementUsage(ctx context.Context, userId int, period string, periodStart time.Time, evaluations, tokens int64) error {
+	counter := domain.UsageCounter{
+		UserId:      userId,
+		Period:      period,
+		PeriodStart: periodStart,
+		Evaluations: evaluations,
+		Tokens:      tokens,
+		UpdatedAt:   time.Now(),
+	}
+	return r.db.DB(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "period"}, {Name: "period_start"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"evaluations": gorm.Expr("usage_counters.evaluations + ?", evaluations),
+			"tokens":      gorm.Expr("usage_counters.tokens + ?", tokens),
+			"updated_at":  counter.UpdatedAt,
+		}),
+	}).Create(&counter).Error
+}
+
+var (
+	repo *repository
+)
+
+func NewUsageRepository(db pkgDB.DatabaseTransaction) domain.UsageRepository {
+	if repo == nil {
+		repo = &repository{
+			db,
+		}
+	}
+	return repo
+}
@@ -0,0 +1,16 @@
+package usage
+
+import (
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+)
+
+func InitUsageRepository(db pkgDB.DatabaseTransaction) domain.UsageRepository {
+	return NewUsageRepository(db)
+}
+func InitUsageUsecase(dbTx pkgDB.DatabaseTransaction, repo domain.UsageRepository, userRepo domain.UserRepository) domain.UsageUsecase {
+	return NewUsageUsecase(dbTx, repo, userRepo)
+}
+func InitUsageHandler(usecase domain.UsageUsecase) domain.UsageHandler {
+	return NewUsageHandler(usecase)
+}
@@ -0,0 +1,175 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type (
+	usecase struct {
+		dbTx     pkgDB.DatabaseTransaction
+		repo     domain.UsageRepository
+		userRepo domain.UserRepository
+	}
+)
+
+// Allow implements domain.UsageUsecase.
+func (u *usecase) Allow(ctx context.Context, key string, plan domain.Plan) (domain.RateLimitResult, error) {
+	perSecond := float64(plan.RequestsPerMinute) / 60
+	bucket, err := u.repo.TakeToken(ctx, key, float64(plan.Burst), perSecond)
+	if err != nil {
+		return domain.RateLimitResult{}, err
+	}
+
+	result := domain.RateLimitResult{
+		Allowed:   bucket.Allowed,
+		Limit:     plan.Burst,
+		Remaining: int(math.Max(0, math.Floor(bucket.Tokens))),
+	}
+	if !bucket.Allowed && perSecond > 0 {
+		result.RetryAfter = time.Duration(math.Ceil((1-bucket.Tokens)/perSecond)) * time.Second
+	}
+	return result, nil
+}
+
+// CheckQuota implements domain.UsageUsecase.
+func (u *usecase) CheckQuota(ctx context.Context, user domain.User) (domain.QuotaStatus, error) {
+	return u.CheckQuotaFor(ctx, user, 1)
+}
+
+// CheckQuotaFor implements domain.UsageUsecase.
+func (u *usecase) CheckQuotaFor(ctx context.Context, user domain.User, calls int64) (domain.QuotaStatus, error) {
+	now := time.Now().UTC()
+	dayStart, monthStart := periodStarts(now)
+
+	day, month, err := u.repo.GetUsageCounters(ctx, user.Id, dayStart, monthStart)
+	if err != nil {
+		return domain.QuotaStatus{}, err
+	}
+	return quotaStatus(domain.PlanFor(user.Plan), day, month, now, calls), nil
+}
+
+// RecordUsage implements domain.UsageUsecase. One call counts one
+// evaluation.
+func (u *usecase) RecordUsage(ctx context.Context, userId int, tokens int64) error {
+	dayStart, monthStart := periodStarts(time.Now().UTC())
+	return u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		if err := u.repo.IncrementUsage(txCtx, userId, domain.UsagePeriodDay, dayStart, 1, tokens); err != nil {
+			return err
+		}
+		return u.repo.IncrementUsage(txCtx, userId, domain.UsagePeriodMonth, monthStart, 1, tokens)
+	})
+}
+
+// GetUsage implements domain.UsageUsecase.
+func (u *usecase) GetUsage(ctx context.Context) (domain.UsageSummary, error) {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.UsageSummary{}, domain.ErrUnauthorized
+	}
+	return u.summary(ctx, user)
+}
+
+// GetUserUsage implements domain.UsageUsecase.
+func (u *usecase) GetUserUsage(ctx context.Context, userId int) (domain.UsageSummary, error) {
+	user, err := u.userRepo.FindById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.UsageSummary{}, domain.ErrNotFound
+		}
+		return domain.UsageSummary{}, err
+	}
+	return u.summary(ctx, user)
+}
+
+func (u *usecase) summary(ctx context.Context, user domain.User) (domain.UsageSummary, error) {
+	now := time.Now().UTC()
+	dayStart, monthStart := periodStarts(now)
+
+	day, month, err := u.repo.GetUsageCounters(ctx, user.Id, dayStart, monthStart)
+	if err != nil {
+		logrus.Errorf("error getting usage counters: %v", err)
+		return domain.UsageSummary{}, err
+	}
+
+	plan := domain.PlanFor(user.Plan)
+	return domain.UsageSummary{
+		Plan:  plan,
+		Day:   day,
+		Month: month,
+		Quota: quotaStatus(plan, day, month, now, 1),
+	}, nil
+}
+
+// periodStarts returns the start of the current UTC day and month.
+func periodStarts(now time.Time) (time.Time, time.Time) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return day, month
+}
+
+// quotaStatus picks the exhausted window with the latest reset, or else the
+// window with the smallest share left. An evaluations window is exhausted
+// when it cannot cover calls more.
+func quotaStatus(plan domain.Plan, day, month domain.UsageCounter, now time.Time, calls int64) domain.QuotaStatus {
+	dayReset := day.PeriodStart.AddDate(0, 0, 1)
+	monthReset := month.PeriodStart.AddDate(0, 1, 0)
+
+	windows := []domain.QuotaStatus{
+		{Period: domain.UsagePeriodDay, Unit: domain.QuotaUnitEvaluations, Limit: plan.DailyEvaluations, Used: day.Evaluations, ResetAt: dayReset},
+		{Period: domain.UsagePeriodDay, Unit: domain.QuotaUnitTokens, Limit: plan.DailyTokens, Used: day.Tokens, ResetAt: dayReset},
+		{Period: domain.UsagePeriodMonth, Unit: domain.QuotaUnitEvaluations, Limit: plan.MonthlyEvaluations, Used: month.Evaluations, ResetAt: monthReset},
+		{Period: domain.UsagePeriodMonth, Unit: domain.QuotaUnitTokens, Limit: plan.MonthlyTokens, Used: month.Tokens, ResetAt: monthReset},
+	}
+
+	status := domain.QuotaStatus{Plan: plan.Name, Unlimited: true}
+	share := math.Inf(1)
+	for _, window := range windows {
+		if window.Limit <= 0 {
+			continue
+		}
+		window.Plan = plan.Name
+		window.Remaining = window.Limit - window.Used
+		if window.Remaining < 0 {
+			window.Remaining = 0
+		}
+		need := int64(1)
+		if window.Unit == domain.QuotaUnitEvaluations {
+			need = calls
+		}
+		window.Exceeded = window.Remaining < need
+
+		switch {
+		case window.Exceeded:
+			if !status.Exceeded || window.ResetAt.After(status.ResetAt) {
+				status = window
+			}
+		case !status.Exceeded && float64(window.Remaining)/float64(window.Limit) < share:
+			share = float64(window.Remaining) / float64(window.Limit)
+			status = window
+		}
+	}
+	return status
+}
+
+var (
+	uc *usecase
+)
+
+func NewUsageUsecase(dbTx pkgDB.DatabaseTransaction, repo domain.UsageRepository, userRepo domain.UserRepository) domain.UsageUsecase {
+	if uc == nil {
+		uc = &usecase{
+			dbTx:     dbTx,
+			repo:     repo,
+			userRepo: userRepo,
+		}
+	}
+	return uc
+}
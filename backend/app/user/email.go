@@ -0,0 +1,223 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/pkg/mailer"
+	"github.com/Kocannn/self-dunking-ai/pkg/token"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// mailedTokens describes each kind of emailed token: how long it lives, which
+// template carries it and which frontend page consumes it.
+var mailedTokens = map[string]struct {
+	ttl       time.Duration
+	expiresIn string
+	template  string
+	path      string
+}{
+	domain.TokenVerifyEmail:   {24 * time.Hour, "24 hours", mailer.TemplateVerifyEmail, "/verify-email"},
+	domain.TokenResetPassword: {time.Hour, "1 hour", mailer.TemplateResetPassword, "/reset-password"},
+}
+
+// VerifyEmail implements domain.UserUsecase.
+func (u *usecase) VerifyEmail(ctx context.Context, rawToken string) (domain.User, error) {
+	var user domain.User
+	err := u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		stored, err := u.useToken(txCtx, rawToken, domain.TokenVerifyEmail)
+		if err != nil {
+			return err
+		}
+		if err := u.repo.MarkEmailVerified(txCtx, stored.UserId); err != nil {
+			return err
+		}
+		if err := u.promoteBootstrapAdmin(txCtx, stored.UserId); err != nil {
+			return err
+		}
+		user, err = u.repo.FindById(txCtx, stored.UserId)
+		return err
+	})
+	if err != nil {
+		logrus.Errorf("error verifying email: %v", err)
+		return domain.User{}, err
+	}
+	return user, nil
+}
+
+// ResendVerification implements domain.UserUsecase.
+func (u *usecase) ResendVerification(ctx context.Context) error {
+	caller, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+	if caller.EmailVerifiedAt != nil {
+		return fmt.Errorf("%w: email is already verified", domain.ErrConflict)
+	}
+
+	return u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		if err := u.repo.InvalidateUserTokens(txCtx, caller.Id, domain.TokenVerifyEmail); err != nil {
+			return err
+		}
+		return u.mailToken(txCtx, caller, domain.TokenVerifyEmail)
+	})
+}
+
+// ForgotPassword implements domain.UserUsecase.
+func (u *usecase) ForgotPassword(ctx context.Context, email string) error {
+	user, err := u.repo.FindByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		// only the most recent link stays usable
+		if err := u.repo.InvalidateUserTokens(txCtx, user.Id, domain.TokenResetPassword); err != nil {
+			return err
+		}
+		return u.mailToken(txCtx, user, domain.TokenResetPassword)
+	})
+}
+
+// ResetPassword implements domain.UserUsecase. Every session of the user is
+// revoked afterwards.
+func (u *usecase) ResetPassword(ctx context.Context, req domain.ResetPasswordRequest) error {
+	if err := validatePassword(req.Password); err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		logrus.Errorf("error hashing password: %v", err)
+		return err
+	}
+
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		stored, err := u.useToken(txCtx, req.Token, domain.TokenResetPassword)
+		if err != nil {
+			return err
+		}
+		if err := u.repo.UpdatePassword(txCtx, stored.UserId, string(hash)); err != nil {
+			return err
+		}
+		// the link arrived by mail, so the address is proven as well
+		if err := u.repo.MarkEmailVerified(txCtx, stored.UserId); err != nil {
+			return err
+		}
+		if err := u.promoteBootstrapAdmin(txCtx, stored.UserId); err != nil {
+			return err
+		}
+		if err := u.repo.RevokeUserRefreshTokens(txCtx, stored.UserId); err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditUserPasswordReset,
+			TargetType: "user",
+			TargetId:   strconv.Itoa(stored.UserId),
+			ActorId:    stored.UserId,
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error resetting password: %v", err)
+		return err
+	}
+	return nil
+}
+
+// useToken consumes a mailed token of purpose. It must run inside a
+// transaction so the row lock makes it single use.
+func (u *usecase) useToken(ctx context.Context, rawToken, purpose string) (domain.UserToken, error) {
+	invalid := fmt.Errorf("%w: invalid or expired token", domain.ErrBadRequest)
+	if rawToken == "" {
+		return domain.UserToken{}, invalid
+	}
+
+	stored, err := u.repo.FindUserTokenByHash(ctx, token.Hash(rawToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.UserToken{}, invalid
+		}
+		return domain.UserToken{}, err
+	}
+	if stored.Purpose != purpose || stored.UsedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return domain.UserToken{}, invalid
+	}
+
+	if err := u.repo.MarkUserTokenUsed(ctx, stored.Id); err != nil {
+		return domain.UserToken{}, err
+	}
+	return stored, nil
+}
+
+// mailToken creates a token of purpose for user and queues the email that
+// carries it.
+func (u *usecase) mailToken(ctx context.Context, user domain.User, purpose string) error {
+	kind := mailedTokens[purpose]
+
+	rawToken, err := token.NewOpaque()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if _, err := u.repo.CreateUserToken(ctx, domain.UserToken{
+		UserId:    user.Id,
+		Purpose:   purpose,
+		TokenHash: token.Hash(rawToken),
+		ExpiresAt: now.Add(kind.ttl),
+		CreatedAt: &now,
+	}); err != nil {
+		return err
+	}
+
+	base := u.cfg.BASE_URL_FE
+	if base == "" {
+		base = u.cfg.BaseURL
+	}
+	link := strings.TrimSuffix(base, "/") + kind.path + "?token=" + url.QueryEscape(rawToken)
+
+	return u.email.Enqueue(ctx, user.Email, kind.template, map[string]interface{}{
+		"Username":  user.Username,
+		"Link":      link,
+		"ExpiresIn": kind.expiresIn,
+	})
+}
+
+// bootstrapAdmin reports whether email is listed in BOOTSTRAP_ADMIN_EMAILS.
+// Listed accounts administer the instance once they prove their address,
+// so the first admin does not depend on who registers first.
+func (u *usecase) bootstrapAdmin(email string) bool {
+	return slices.Contains(u.cfg.BOOTSTRAP_ADMIN_EMAILS, strings.ToLower(email))
+}
+
+// promoteBootstrapAdmin makes the user an admin when their just verified
+// email is a bootstrap admin.
+func (u *usecase) promoteBootstrapAdmin(ctx context.Context, id int) error {
+	user, err := u.repo.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.Role == domain.RoleAdmin || !u.bootstrapAdmin(user.Email) {
+		return nil
+	}
+	return u.repo.UpdateRole(ctx, id, domain.RoleAdmin)
+}
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("%w: password must be at least %d characters", domain.ErrBadRequest, minPasswordLength)
+	}
+	return nil
+}
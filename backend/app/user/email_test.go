@@ -0,0 +1,218 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/config"
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/pkg/token"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTokenTest(t *testing.T) (*usecase, *fakeRepo, *fakeOutbox, domain.User) {
+	t.Helper()
+	repo := newFakeRepo()
+	outbox := &fakeOutbox{}
+	u := &usecase{
+		cfg:   config.Config{BASE_URL_FE: "https://app.example.com/"},
+		dbTx:  fakeTx{},
+		repo:  repo,
+		jwt:   fakeJWT{},
+		email: outbox,
+		audit: &fakeAudit{},
+	}
+	user, err := repo.CreateUser(context.Background(), domain.User{Username: "ada", Email: "ada@example.com", Role: domain.RoleMember})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u, repo, outbox, user
+}
+
+func resetPassword(u *usecase, rawToken string) error {
+	return u.ResetPassword(context.Background(), domain.ResetPasswordRequest{Token: rawToken, Password: "new-password"})
+}
+
+func TestForgotPasswordMailsLink(t *testing.T) {
+	u, repo, outbox, user := newTokenTest(t)
+
+	if err := u.ForgotPassword(context.Background(), " ADA@example.com "); err != nil {
+		t.Fatal(err)
+	}
+	if len(outbox.links) != 1 || !strings.HasPrefix(outbox.links[0], "https://app.example.com/reset-password?token=") {
+		t.Fatalf("mailed %v", outbox.links)
+	}
+
+	// only the hash of the mailed token is stored
+	stored, err := repo.FindUserTokenByHash(context.Background(), token.Hash(outbox.token(t, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.UserId != user.Id || stored.Purpose != domain.TokenResetPassword {
+		t.Errorf("stored %+v", stored)
+	}
+	if ttl := time.Until(stored.ExpiresAt); ttl < 59*time.Minute || ttl > time.Hour {
+		t.Errorf("reset token lives %s, want an hour", ttl)
+	}
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	u, _, outbox, _ := newTokenTest(t)
+
+	// unknown addresses look the same to the caller
+	if err := u.ForgotPassword(context.Background(), "nobody@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if len(outbox.links) != 0 {
+		t.Error("a mail was queued for an unknown address")
+	}
+}
+
+func TestResetPasswordTokenIsSingleUse(t *testing.T) {
+	u, repo, outbox, user := newTokenTest(t)
+	repo.CreateRefreshToken(context.Background(), domain.RefreshToken{UserId: user.Id})
+	if err := u.ForgotPassword(context.Background(), user.Email); err != nil {
+		t.Fatal(err)
+	}
+	rawToken := outbox.token(t, 0)
+
+	if err := resetPassword(u, rawToken); err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := repo.FindById(context.Background(), user.Id)
+	if bcrypt.CompareHashAndPassword([]byte(updated.Password), []byte("new-password")) != nil {
+		t.Error("the password was not changed")
+	}
+	if updated.EmailVerifiedAt == nil {
+		t.Error("a used reset link should verify the email")
+	}
+	if repo.refreshTokens[0].RevokedAt == nil {
+		t.Error("the sessions were not revoked")
+	}
+
+	if err := resetPassword(u, rawToken); !errors.Is(err, domain.ErrBadRequest) {
+		t.Errorf("second use err = %v, want bad request", err)
+	}
+}
+
+func TestResetPasswordTokenExpires(t *testing.T) {
+	u, repo, outbox, user := newTokenTest(t)
+	if err := u.ForgotPassword(context.Background(), user.Email); err != nil {
+		t.Fatal(err)
+	}
+	for id, stored := range repo.userTokens {
+		stored.ExpiresAt = time.Now().Add(-time.Second)
+		repo.userTokens[id] = stored
+	}
+
+	if err := resetPassword(u, outbox.token(t, 0)); !errors.Is(err, domain.ErrBadRequest) {
+		t.Errorf("err = %v, want bad request", err)
+	}
+	if updated, _ := repo.FindById(context.Background(), user.Id); updated.Password != "" {
+		t.Error("an expired token changed the password")
+	}
+}
+
+func TestResetPasswordOnlyLatestTokenIsValid(t *testing.T) {
+	u, _, outbox, user := newTokenTest(t)
+	for i := 0; i < 2; i++ {
+		if err := u.ForgotPassword(context.Background(), user.Email); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := resetPassword(u, outbox.token(t, 0)); !errors.Is(err, domain.ErrBadRequest) {
+		t.Errorf("older token err = %v, want bad request", err)
+	}
+	if err := resetPassword(u, outbox.token(t, 1)); err != nil {
+		t.Errorf("latest token err = %v", err)
+	}
+}
+
+func TestResetPasswordRejectsBadTokens(t *testing.T) {
+	u, _, outbox, user := newTokenTest(t)
+	ctx := context.WithValue(context.Background(), domain.ContextUser, user)
+	if err := u.ResendVerification(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, rawToken := range map[string]string{
+		"empty":              "",
+		"unknown":            "not-a-token",
+		"verification token": outbox.token(t, 0),
+	} {
+		if err := resetPassword(u, rawToken); !errors.Is(err, domain.ErrBadRequest) {
+			t.Errorf("%s: err = %v, want bad request", name, err)
+		}
+	}
+}
+
+func TestVerifyEmailTokenIsSingleUse(t *testing.T) {
+	u, repo, outbox, user := newTokenTest(t)
+	ctx := context.WithValue(context.Background(), domain.ContextUser, user)
+	if err := u.ResendVerification(ctx); err != nil {
+		t.Fatal(err)
+	}
+	rawToken := outbox.token(t, 0)
+	stored, _ := repo.FindUserTokenByHash(context.Background(), token.Hash(rawToken))
+	if ttl := time.Until(stored.ExpiresAt); ttl < 23*time.Hour || ttl > 24*time.Hour {
+		t.Errorf("verification token lives %s, want a day", ttl)
+	}
+
+	verified, err := u.VerifyEmail(context.Background(), rawToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified.EmailVerifiedAt == nil {
+		t.Error("the email was not verified")
+	}
+	if _, err := u.VerifyEmail(context.Background(), rawToken); !errors.Is(err, domain.ErrBadRequest) {
+		t.Errorf("second use err = %v, want bad request", err)
+	}
+
+	// a verified address needs no other link
+	if err := u.ResendVerification(context.WithValue(context.Background(), domain.ContextUser, verified)); !errors.Is(err, domain.ErrConflict) {
+		t.Errorf("resend err = %v, want conflict", err)
+	}
+}
+
+func TestVerifyEmailPromotesBootstrapAdmin(t *testing.T) {
+	u, _, outbox, user := newTokenTest(t)
+	u.cfg.BOOTSTRAP_ADMIN_EMAILS = []string{"ada@example.com"}
+
+	// a listed address grants nothing until it is proven
+	if user.Role != domain.RoleMember {
+		t.Fatalf("role = %s before verification", user.Role)
+	}
+	ctx := context.WithValue(context.Background(), domain.ContextUser, user)
+	if err := u.ResendVerification(ctx); err != nil {
+		t.Fatal(err)
+	}
+	verified, err := u.VerifyEmail(context.Background(), outbox.token(t, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified.Role != domain.RoleAdmin {
+		t.Errorf("role = %s, want admin for a bootstrap admin email", verified.Role)
+	}
+}
+
+func TestResendVerificationInvalidatesOlderLinks(t *testing.T) {
+	u, _, outbox, user := newTokenTest(t)
+	ctx := context.WithValue(context.Background(), domain.ContextUser, user)
+	for i := 0; i < 2; i++ {
+		if err := u.ResendVerification(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := u.VerifyEmail(context.Background(), outbox.token(t, 0)); !errors.Is(err, domain.ErrBadRequest) {
+		t.Errorf("older token err = %v, want bad request", err)
+	}
+	if _, err := u.VerifyEmail(context.Background(), outbox.token(t, 1)); err != nil {
+		t.Errorf("latest token err = %v", err)
+	}
+}
@@ -0,0 +1,279 @@
+package user
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgjwt "github.com/Kocannn/self-dunking-ai/pkg/jwt"
+	"gorm.io/gorm"
+)
+
+// fakeRepo keeps users and their tokens in memory. Like the gorm
+// repository it returns gorm.ErrRecordNotFound for missing rows.
+type fakeRepo struct {
+	domain.UserRepository
+
+	mu            sync.Mutex
+	users         map[int]domain.User
+	states        map[string]domain.OIDCState
+	userTokens    map[int]domain.UserToken
+	refreshTokens []domain.RefreshToken
+	nextId        int
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{
+		users:      map[int]domain.User{},
+		states:     map[string]domain.OIDCState{},
+		userTokens: map[int]domain.UserToken{},
+	}
+}
+
+func (r *fakeRepo) id() int {
+	r.nextId++
+	return r.nextId
+}
+
+func (r *fakeRepo) CreateUser(ctx context.Context, user domain.User) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user.Id = r.id()
+	r.users[user.Id] = user
+	return user, nil
+}
+
+func (r *fakeRepo) FindById(ctx context.Context, id int) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return domain.User{}, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (r *fakeRepo) FindByEmail(ctx context.Context, email string) (domain.User, error) {
+	return r.find(func(user domain.User) bool { return user.Email == email })
+}
+
+func (r *fakeRepo) FindByOIDCSubject(ctx context.Context, subject string) (domain.User, error) {
+	return r.find(func(user domain.User) bool { return user.OIDCSubject != nil && *user.OIDCSubject == subject })
+}
+
+func (r *fakeRepo) find(match func(domain.User) bool) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if match(user) {
+			return user, nil
+		}
+	}
+	return domain.User{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRepo) update(id int, change func(*domain.User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	change(&user)
+	r.users[id] = user
+	return nil
+}
+
+func (r *fakeRepo) LinkOIDCSubject(ctx context.Context, id int, subject string) error {
+	return r.update(id, func(user *domain.User) { user.OIDCSubject = &subject })
+}
+
+func (r *fakeRepo) UpdateRole(ctx context.Context, id int, role string) error {
+	return r.update(id, func(user *domain.User) { user.Role = role })
+}
+
+func (r *fakeRepo) UpdatePassword(ctx context.Context, id int, hash string) error {
+	return r.update(id, func(user *domain.User) { user.Password = hash })
+}
+
+func (r *fakeRepo) MarkEmailVerified(ctx context.Context, id int) error {
+	now := time.Now()
+	return r.update(id, func(user *domain.User) { user.EmailVerifiedAt = &now })
+}
+
+func (r *fakeRepo) ExistsByEmailOrUsername(ctx context.Context, email, username string) (bool, error) {
+	_, err := r.find(func(user domain.User) bool { return user.Email == email || user.Username == username })
+	return err == nil, nil
+}
+
+func (r *fakeRepo) CountUsers(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int64(len(r.users)), nil
+}
+
+func (r *fakeRepo) CreateRefreshToken(ctx context.Context, token domain.RefreshToken) (domain.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token.Id = r.id()
+	r.refreshTokens = append(r.refreshTokens, token)
+	return token, nil
+}
+
+func (r *fakeRepo) RevokeUserRefreshTokens(ctx context.Context, userId int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for i := range r.refreshTokens {
+		if r.refreshTokens[i].UserId == userId && r.refreshTokens[i].RevokedAt == nil {
+			r.refreshTokens[i].RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepo) CreateOIDCState(ctx context.Context, state domain.OIDCState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states[state.StateHash] = state
+	return nil
+}
+
+func (r *fakeRepo) TakeOIDCState(ctx context.Context, stateHash string) (domain.OIDCState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.states[stateHash]
+	if !ok {
+		return domain.OIDCState{}, gorm.ErrRecordNotFound
+	}
+	delete(r.states, stateHash)
+	return state, nil
+}
+
+// onlyState returns the single pending login state.
+func (r *fakeRepo) onlyState() (string, domain.OIDCState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, state := range r.states {
+		return hash, state
+	}
+	return "", domain.OIDCState{}
+}
+
+func (r *fakeRepo) CreateUserToken(ctx context.Context, token domain.UserToken) (domain.UserToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token.Id = r.id()
+	r.userTokens[token.Id] = token
+	return token, nil
+}
+
+func (r *fakeRepo) FindUserTokenByHash(ctx context.Context, hash string) (domain.UserToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.userTokens {
+		if token.TokenHash == hash {
+			return token, nil
+		}
+	}
+	return domain.UserToken{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRepo) MarkUserTokenUsed(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token := r.userTokens[id]
+	now := time.Now()
+	token.UsedAt = &now
+	r.userTokens[id] = token
+	return nil
+}
+
+func (r *fakeRepo) InvalidateUserTokens(ctx context.Context, userId int, purpose string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for id, token := range r.userTokens {
+		if token.UserId == userId && token.Purpose == purpose && token.UsedAt == nil {
+			token.UsedAt = &now
+			r.userTokens[id] = token
+		}
+	}
+	return nil
+}
+
+// fakeTx runs the function without a database; the fakes apply changes
+// right away, so nothing is rolled back on error.
+type fakeTx struct{}
+
+func (fakeTx) DB(ctx context.Context) *gorm.DB { return nil }
+
+func (fakeTx) StartTransaction(ctx context.Context, fn func(txCtx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeJWT struct{}
+
+func (fakeJWT) GenerateAccessToken(ctx context.Context, user *domain.User, minutes int) (*string, time.Time, error) {
+	token := "access-" + strconv.Itoa(user.Id)
+	return &token, time.Now().Add(time.Duration(minutes) * time.Minute), nil
+}
+
+func (fakeJWT) VerifyToken(token string) (*pkgjwt.JwtCustomClaims, error) {
+	return nil, nil
+}
+
+// fakeAudit collects the recorded entries.
+type fakeAudit struct {
+	mu      sync.Mutex
+	entries []domain.AuditEntry
+}
+
+func (a *fakeAudit) Record(ctx context.Context, entry domain.AuditEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, entry)
+	return nil
+}
+
+func (a *fakeAudit) ListAuditLogs(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditLog, error) {
+	return nil, nil
+}
+
+func (a *fakeAudit) ExportAuditLogs(ctx context.Context, filter domain.AuditFilter, w io.Writer) error {
+	return nil
+}
+
+// fakeOutbox keeps the links of the queued mails.
+type fakeOutbox struct {
+	domain.EmailUsecase
+	mu    sync.Mutex
+	links []string
+}
+
+func (o *fakeOutbox) Enqueue(ctx context.Context, to, template string, data map[string]interface{}) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.links = append(o.links, data["Link"].(string))
+	return nil
+}
+
+// token returns the token carried by the n-th mail.
+func (o *fakeOutbox) token(t *testing.T, n int) string {
+	t.Helper()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if n >= len(o.links) {
+		t.Fatalf("%d mails were queued, want at least %d", len(o.links), n+1)
+	}
+	link, err := url.Parse(o.links[n])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return link.Query().Get("token")
+}
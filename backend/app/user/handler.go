@@ -0,0 +1,422 @@
+package user
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	handler struct {
+		usecase domain.UserUsecase
+	}
+)
+
+// Register implements domain.UserHandler.
+func (h *handler) Register(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logrus.Errorf("error reading request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error reading request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	dataBuffer := domain.RegisterRequest{}
+	if err := json.Unmarshal(bodyBytes, &dataBuffer); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.Register(r.Context(), dataBuffer)
+	if err != nil {
+		utils.RespondError(w, "error registering user", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusCreated,
+		Message: "User registered successfully",
+		Data:    data,
+	}, w)
+}
+
+// Login implements domain.UserHandler.
+func (h *handler) Login(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		logrus.Errorf("error reading request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error reading request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	dataBuffer := domain.LoginRequest{}
+	if err := json.Unmarshal(bodyBytes, &dataBuffer); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.Login(r.Context(), dataBuffer)
+	if err != nil {
+		utils.RespondError(w, "error logging in", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Login successful",
+		Data:    data,
+	}, w)
+}
+
+// Me implements domain.UserHandler.
+func (h *handler) Me(w http.ResponseWriter, r *http.Request) {
+	user, ok := domain.UserFromContext(r.Context())
+	if !ok {
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusUnauthorized,
+			Message: "Unauthorized",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "User retrieved successfully",
+		Data:    user,
+	}, w)
+}
+
+// Refresh implements domain.UserHandler.
+func (h *handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	dataBuffer := domain.RefreshRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&dataBuffer); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.Refresh(r.Context(), dataBuffer.RefreshToken)
+	if err != nil {
+		utils.RespondError(w, "error refreshing token", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Token refreshed successfully",
+		Data:    data,
+	}, w)
+}
+
+// Logout implements domain.UserHandler. The refresh token in the body is
+// optional; when given, every token rotated from the same login is revoked.
+func (h *handler) Logout(w http.ResponseWriter, r *http.Request) {
+	dataBuffer := domain.RefreshRequest{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&dataBuffer); err != nil {
+			logrus.Errorf("error unmarshalling request body: %v", err)
+			utils.Response(domain.HttpResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Error parsing request body",
+				Data:    nil,
+			}, w)
+			return
+		}
+	}
+
+	token := utils.ExtractBearerToken(r)
+	if err := h.usecase.Logout(r.Context(), *token, dataBuffer.RefreshToken); err != nil {
+		utils.RespondError(w, "error logging out", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Logout successful",
+		Data:    nil,
+	}, w)
+}
+
+// ListUsers implements domain.UserHandler.
+func (h *handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	data, err := h.usecase.ListUsers(r.Context())
+	if err != nil {
+		logrus.Errorf("error listing users: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error listing users",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Users retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// UpdateRole implements domain.UserHandler.
+func (h *handler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	dataBuffer := domain.UpdateRoleRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&dataBuffer); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.UpdateRole(r.Context(), id, dataBuffer.Role)
+	if err != nil {
+		utils.RespondError(w, "error updating role", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Role updated successfully",
+		Data:    data,
+	}, w)
+}
+
+// UpdatePlan implements domain.UserHandler.
+func (h *handler) UpdatePlan(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		logrus.Errorf("error converting id to int: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	dataBuffer := domain.UpdatePlanRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&dataBuffer); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.UpdatePlan(r.Context(), id, dataBuffer.Plan)
+	if err != nil {
+		utils.RespondError(w, "error updating plan", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Plan updated successfully",
+		Data:    data,
+	}, w)
+}
+
+// OIDCLogin implements domain.UserHandler. It redirects the browser to the
+// identity provider; ?redirect=/path picks the frontend page to return to.
+func (h *handler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	authURL, err := h.usecase.OIDCAuthURL(r.Context(), r.URL.Query().Get("redirect"))
+	if err != nil {
+		utils.RespondError(w, "error starting oidc login", err)
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback implements domain.UserHandler. Tokens are handed to the
+// frontend in the URL fragment so they never reach server logs.
+func (h *handler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if idpErr := query.Get("error"); idpErr != "" {
+		logrus.Errorf("identity provider rejected login: %s %s", idpErr, query.Get("error_description"))
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusUnauthorized,
+			Message: "Single sign-on failed: " + idpErr,
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, redirectTo, err := h.usecase.OIDCCallback(r.Context(), query.Get("code"), query.Get("state"))
+	if err != nil {
+		utils.RespondError(w, "error completing oidc login", err)
+		return
+	}
+
+	if redirectTo != "" {
+		fragment := url.Values{
+			"access_token":       {data.AccessToken},
+			"expires_at":         {data.ExpiresAt.Format(time.RFC3339)},
+			"refresh_token":      {data.RefreshToken},
+			"refresh_expires_at": {data.RefreshExpiresAt.Format(time.RFC3339)},
+		}
+		http.Redirect(w, r, redirectTo+"#"+fragment.Encode(), http.StatusFound)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Login successful",
+		Data:    data,
+	}, w)
+}
+
+// VerifyEmail implements domain.UserHandler.
+func (h *handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	dataBuffer := domain.VerifyEmailRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&dataBuffer); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	data, err := h.usecase.VerifyEmail(r.Context(), dataBuffer.Token)
+	if err != nil {
+		utils.RespondError(w, "error verifying email", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Email verified successfully",
+		Data:    data,
+	}, w)
+}
+
+// ResendVerification implements domain.UserHandler.
+func (h *handler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	if err := h.usecase.ResendVerification(r.Context()); err != nil {
+		utils.RespondError(w, "error resending verification", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Verification email sent",
+		Data:    nil,
+	}, w)
+}
+
+// ForgotPassword implements domain.UserHandler. The response is the same
+// whether or not the email is registered.
+func (h *handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	dataBuffer := domain.ForgotPasswordRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&dataBuffer); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	if err := h.usecase.ForgotPassword(r.Context(), dataBuffer.Email); err != nil {
+		logrus.Errorf("error requesting password reset: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error requesting password reset",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "If the email is registered, a reset link has been sent",
+		Data:    nil,
+	}, w)
+}
+
+// ResetPassword implements domain.UserHandler.
+func (h *handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	dataBuffer := domain.ResetPasswordRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&dataBuffer); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return
+	}
+
+	if err := h.usecase.ResetPassword(r.Context(), dataBuffer); err != nil {
+		utils.RespondError(w, "error resetting password", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Password reset successfully",
+		Data:    nil,
+	}, w)
+}
+
+var (
+	handlr *handler
+)
+
+func NewUserHandler(usecase domain.UserUsecase) domain.UserHandler {
+	if handlr == nil {
+		handlr = &handler{
+			usecase,
+		}
+	}
+	return handlr
+}
@@ -0,0 +1,294 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/pkg/oidc"
+	"github.com/Kocannn/self-dunking-ai/pkg/token"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	oidcStateTTL         = 10 * time.Minute
+	defaultOIDCRoleClaim = "groups"
+)
+
+// OIDCAuthURL implements domain.UserUsecase.
+func (u *usecase) OIDCAuthURL(ctx context.Context, redirectTo string) (string, error) {
+	if u.oidc == nil {
+		return "", fmt.Errorf("%w: single sign-on is not configured", domain.ErrNotFound)
+	}
+	// only same-site paths, otherwise the callback becomes an open redirect
+	if redirectTo != "" && (!strings.HasPrefix(redirectTo, "/") || strings.HasPrefix(redirectTo, "//")) {
+		return "", fmt.Errorf("%w: redirect must be a relative path", domain.ErrBadRequest)
+	}
+
+	state, err := oidc.NewRandom()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.NewRandom()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := oidc.NewRandom()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	err = u.repo.CreateOIDCState(ctx, domain.OIDCState{
+		StateHash:    token.Hash(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		RedirectTo:   redirectTo,
+		ExpiresAt:    now.Add(oidcStateTTL),
+		CreatedAt:    &now,
+	})
+	if err != nil {
+		logrus.Errorf("error saving oidc state: %v", err)
+		return "", err
+	}
+
+	url, err := u.oidc.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		logrus.Errorf("error building oidc auth url: %v", err)
+		return "", err
+	}
+	return url, nil
+}
+
+// OIDCCallback implements domain.UserUsecase.
+func (u *usecase) OIDCCallback(ctx context.Context, code, state string) (domain.AuthResponse, string, error) {
+	if u.oidc == nil {
+		return domain.AuthResponse{}, "", fmt.Errorf("%w: single sign-on is not configured", domain.ErrNotFound)
+	}
+	if code == "" || state == "" {
+		return domain.AuthResponse{}, "", fmt.Errorf("%w: missing code or state", domain.ErrBadRequest)
+	}
+
+	stored, err := u.repo.TakeOIDCState(ctx, token.Hash(state))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.AuthResponse{}, "", fmt.Errorf("%w: unknown or used login state", domain.ErrUnauthorized)
+		}
+		return domain.AuthResponse{}, "", err
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return domain.AuthResponse{}, "", fmt.Errorf("%w: login state expired", domain.ErrUnauthorized)
+	}
+
+	idToken, err := u.oidc.Exchange(ctx, code, stored.CodeVerifier)
+	if err != nil {
+		return domain.AuthResponse{}, "", fmt.Errorf("%w: %v", domain.ErrUnauthorized, err)
+	}
+	claims, err := u.oidc.VerifyIDToken(ctx, idToken, stored.Nonce)
+	if err != nil {
+		return domain.AuthResponse{}, "", fmt.Errorf("%w: %v", domain.ErrUnauthorized, err)
+	}
+
+	familyId, err := token.NewOpaque()
+	if err != nil {
+		return domain.AuthResponse{}, "", err
+	}
+
+	var response domain.AuthResponse
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		user, err := u.provisionOIDCUser(txCtx, claims)
+		if err != nil {
+			return err
+		}
+		response, _, err = u.issueTokens(txCtx, user, familyId)
+		if err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditUserLogin,
+			TargetType: "user",
+			TargetId:   strconv.Itoa(user.Id),
+			After:      map[string]string{"method": "oidc"},
+			ActorId:    user.Id,
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error completing oidc login: %v", err)
+		return domain.AuthResponse{}, "", err
+	}
+	return response, u.frontendURL(stored.RedirectTo), nil
+}
+
+// frontendURL resolves the post-login redirect against BASE_URL_FE. Without
+// a frontend the session is returned as JSON instead.
+func (u *usecase) frontendURL(path string) string {
+	if u.cfg.BASE_URL_FE == "" {
+		return ""
+	}
+	if path == "" {
+		path = "/"
+	}
+	return strings.TrimSuffix(u.cfg.BASE_URL_FE, "/") + path
+}
+
+// provisionOIDCUser resolves the account for the identity provider subject.
+// Existing password accounts are only linked on an email the provider
+// verified; one whose email was never verified here loses its password and
+// sessions on the link. New accounts are created just in time. A role mapped
+// from the claims is synced on every login so the provider stays the source
+// of truth.
+func (u *usecase) provisionOIDCUser(ctx context.Context, claims oidc.Claims) (domain.User, error) {
+	mappedRole := u.oidcRole(claims)
+
+	user, err := u.repo.FindByOIDCSubject(ctx, claims.Subject)
+	if err == nil {
+		return u.syncOIDCRole(ctx, user, mappedRole)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.User{}, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	if _, err := mail.ParseAddress(email); err != nil {
+		return domain.User{}, fmt.Errorf("%w: identity provider did not return an email address", domain.ErrBadRequest)
+	}
+
+	user, err = u.repo.FindByEmail(ctx, email)
+	if err == nil {
+		if !claims.EmailVerified {
+			return domain.User{}, fmt.Errorf("%w: email is not verified by the identity provider", domain.ErrConflict)
+		}
+		if user.OIDCSubject != nil {
+			return domain.User{}, fmt.Errorf("%w: account is linked to another identity", domain.ErrConflict)
+		}
+		if user.EmailVerifiedAt == nil {
+			// whoever registered the unverified account may not own the
+			// address, so their password and sessions do not survive the link
+			if err := u.repo.UpdatePassword(ctx, user.Id, ""); err != nil {
+				return domain.User{}, err
+			}
+			if err := u.repo.RevokeUserRefreshTokens(ctx, user.Id); err != nil {
+				return domain.User{}, err
+			}
+			if err := u.repo.MarkEmailVerified(ctx, user.Id); err != nil {
+				return domain.User{}, err
+			}
+		}
+		if err := u.repo.LinkOIDCSubject(ctx, user.Id, claims.Subject); err != nil {
+			return domain.User{}, err
+		}
+		if mappedRole == "" && u.bootstrapAdmin(email) {
+			mappedRole = domain.RoleAdmin
+		}
+		return u.syncOIDCRole(ctx, user, mappedRole)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.User{}, err
+	}
+
+	username, err := u.oidcUsername(ctx, claims, email)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	// SSO accounts get a random password nobody knows, so password login is
+	// effectively disabled for them
+	secret, err := token.NewOpaque()
+	if err != nil {
+		return domain.User{}, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	role := mappedRole
+	if role == "" {
+		role = domain.RoleMember
+		if claims.EmailVerified && u.bootstrapAdmin(email) {
+			role = domain.RoleAdmin
+		}
+	}
+
+	now := time.Now()
+	subject := claims.Subject
+	user = domain.User{
+		Username:    username,
+		Email:       email,
+		Password:    string(hash),
+		Role:        role,
+		OIDCSubject: &subject,
+		CreatedAt:   &now,
+		UpdatedAt:   &now,
+	}
+	if claims.EmailVerified {
+		user.EmailVerifiedAt = &now
+	}
+	return u.repo.CreateUser(ctx, user)
+}
+
+func (u *usecase) syncOIDCRole(ctx context.Context, user domain.User, role string) (domain.User, error) {
+	if role == "" || role == user.Role {
+		return user, nil
+	}
+	if err := u.repo.UpdateRole(ctx, user.Id, role); err != nil {
+		return domain.User{}, err
+	}
+	user.Role = role
+	return user, nil
+}
+
+// oidcRole maps the configured claim values to the highest matching role, or
+// "" when nothing matches.
+func (u *usecase) oidcRole(claims oidc.Claims) string {
+	claim := u.cfg.OIDC_ROLE_CLAIM
+	if claim == "" {
+		claim = defaultOIDCRoleClaim
+	}
+
+	best := ""
+	for _, value := range claims.Strings(claim) {
+		role, ok := u.cfg.OIDC_ROLE_MAPPING[value]
+		if !ok || !domain.IsValidRole(role) {
+			continue
+		}
+		if best == "" || (domain.User{Role: role}).HasRole(best) {
+			best = role
+		}
+	}
+	return best
+}
+
+// oidcUsername picks a free username from the profile claims.
+func (u *usecase) oidcUsername(ctx context.Context, claims oidc.Claims, email string) (string, error) {
+	base := claims.Username
+	if base == "" {
+		base, _, _ = strings.Cut(email, "@")
+	}
+	base = strings.TrimSpace(base)
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		exists, err := u.repo.ExistsByEmailOrUsername(ctx, "", candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+
+		suffix, err := token.NewOpaque()
+		if err != nil {
+			return "", err
+		}
+		candidate = base + "-" + strings.ToLower(suffix[:6])
+	}
+	return "", fmt.Errorf("%w: could not derive a free username", domain.ErrConflict)
+}
@@ -0,0 +1,298 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/config"
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/pkg/oidc"
+	"github.com/Kocannn/self-dunking-ai/pkg/oidc/oidctest"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type oidcTest struct {
+	idp   *oidctest.Server
+	repo  *fakeRepo
+	audit *fakeAudit
+	uc    *usecase
+}
+
+func newOIDCTest(t *testing.T) *oidcTest {
+	t.Helper()
+	idp, err := oidctest.NewServer("inverta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(idp.Close)
+
+	cfg := config.Config{
+		BASE_URL_FE:       "https://app.example.com",
+		OIDC_ROLE_MAPPING: map[string]string{"idp-reviewers": domain.RoleReviewer, "idp-admins": domain.RoleAdmin},
+	}
+	provider := oidc.NewProvider(oidc.Config{
+		IssuerURL:   idp.URL,
+		ClientID:    "inverta",
+		RedirectURL: "https://api.example.com/api/v1/auth/oidc/callback",
+	}, idp.Client())
+
+	test := &oidcTest{idp: idp, repo: newFakeRepo(), audit: &fakeAudit{}}
+	test.uc = &usecase{
+		cfg:   cfg,
+		dbTx:  fakeTx{},
+		repo:  test.repo,
+		jwt:   fakeJWT{},
+		oidc:  provider,
+		audit: test.audit,
+	}
+	return test
+}
+
+// start begins a login and lets the user sign in at the provider.
+func (o *oidcTest) start(t *testing.T, claims jwt.MapClaims) (code, state string) {
+	t.Helper()
+	authURL, err := o.uc.OIDCAuthURL(context.Background(), "/ideas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state, err = o.idp.Login(authURL, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code, state
+}
+
+func (o *oidcTest) login(t *testing.T, claims jwt.MapClaims) (domain.AuthResponse, string, error) {
+	t.Helper()
+	code, state := o.start(t, claims)
+	return o.uc.OIDCCallback(context.Background(), code, state)
+}
+
+func TestOIDCCallbackProvisionsUser(t *testing.T) {
+	o := newOIDCTest(t)
+	o.uc.cfg.BOOTSTRAP_ADMIN_EMAILS = []string{"ada@example.com"}
+
+	resp, redirect, err := o.login(t, jwt.MapClaims{
+		"sub":                "idp|1",
+		"email":              "Ada@Example.com",
+		"email_verified":     true,
+		"preferred_username": "ada",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redirect != "https://app.example.com/ideas" {
+		t.Errorf("redirect = %q", redirect)
+	}
+	if resp.AccessToken == "" || resp.RefreshToken == "" {
+		t.Error("no session was issued")
+	}
+
+	user := resp.User
+	if user.Email != "ada@example.com" || user.Username != "ada" {
+		t.Errorf("provisioned %s <%s>", user.Username, user.Email)
+	}
+	if user.OIDCSubject == nil || *user.OIDCSubject != "idp|1" {
+		t.Errorf("subject = %v, want idp|1", user.OIDCSubject)
+	}
+	if user.EmailVerifiedAt == nil {
+		t.Error("a verified provider email should verify the account")
+	}
+	if user.Role != domain.RoleAdmin {
+		t.Errorf("role = %s, want admin for a bootstrap admin email", user.Role)
+	}
+	if len(o.audit.entries) != 1 || o.audit.entries[0].Action != domain.AuditUserLogin {
+		t.Errorf("audit entries = %+v", o.audit.entries)
+	}
+}
+
+func TestOIDCCallbackProvisionsMappedRole(t *testing.T) {
+	o := newOIDCTest(t)
+	o.repo.CreateUser(context.Background(), domain.User{Username: "first", Email: "first@example.com", Role: domain.RoleAdmin})
+
+	resp, _, err := o.login(t, jwt.MapClaims{
+		"sub":    "idp|2",
+		"email":  "grace@example.com",
+		"groups": []string{"staff", "idp-reviewers"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.User.Role != domain.RoleReviewer {
+		t.Errorf("role = %s, want reviewer from the groups claim", resp.User.Role)
+	}
+	if resp.User.Username != "grace" {
+		t.Errorf("username = %s, want the local part of the email", resp.User.Username)
+	}
+	if resp.User.EmailVerifiedAt != nil {
+		t.Error("an unverified provider email must not verify the account")
+	}
+
+	// the provider stays the source of truth for the role
+	resp, _, err = o.login(t, jwt.MapClaims{"sub": "idp|2", "email": "grace@example.com", "groups": "idp-admins"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.User.Role != domain.RoleAdmin {
+		t.Errorf("role = %s after the groups changed, want admin", resp.User.Role)
+	}
+	if count, _ := o.repo.CountUsers(context.Background()); count != 2 {
+		t.Errorf("%d users, a returning login must not create another", count)
+	}
+}
+
+func TestOIDCCallbackLinksExistingUser(t *testing.T) {
+	o := newOIDCTest(t)
+	verifiedAt := time.Now()
+	existing, _ := o.repo.CreateUser(context.Background(), domain.User{
+		Username:        "ada",
+		Email:           "ada@example.com",
+		Password:        "hash",
+		Role:            domain.RoleMember,
+		EmailVerifiedAt: &verifiedAt,
+	})
+
+	resp, _, err := o.login(t, jwt.MapClaims{"sub": "idp|1", "email": "ada@example.com", "email_verified": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.User.Id != existing.Id {
+		t.Fatalf("logged in as user %d, want the existing user %d", resp.User.Id, existing.Id)
+	}
+	linked, _ := o.repo.FindById(context.Background(), existing.Id)
+	if linked.OIDCSubject == nil || *linked.OIDCSubject != "idp|1" {
+		t.Errorf("subject = %v, want the account linked", linked.OIDCSubject)
+	}
+	if linked.EmailVerifiedAt == nil || linked.Password != "hash" {
+		t.Error("linking a verified account should keep the password")
+	}
+}
+
+func TestOIDCCallbackLinkClearsUnverifiedAccount(t *testing.T) {
+	o := newOIDCTest(t)
+	existing, _ := o.repo.CreateUser(context.Background(), domain.User{
+		Username: "ada",
+		Email:    "ada@example.com",
+		Password: "hash",
+		Role:     domain.RoleMember,
+	})
+	o.repo.CreateRefreshToken(context.Background(), domain.RefreshToken{UserId: existing.Id})
+
+	resp, _, err := o.login(t, jwt.MapClaims{"sub": "idp|1", "email": "ada@example.com", "email_verified": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.User.Id != existing.Id {
+		t.Fatalf("logged in as user %d, want the existing user %d", resp.User.Id, existing.Id)
+	}
+	linked, _ := o.repo.FindById(context.Background(), existing.Id)
+	if linked.OIDCSubject == nil || linked.EmailVerifiedAt == nil {
+		t.Error("the account should be linked and verified")
+	}
+	if linked.Password != "" {
+		t.Error("whoever registered the unverified account kept their password")
+	}
+	if o.repo.refreshTokens[0].RevokedAt == nil {
+		t.Error("sessions from before the link were not revoked")
+	}
+}
+
+func TestOIDCCallbackRefusesUnverifiedLink(t *testing.T) {
+	o := newOIDCTest(t)
+	existing, _ := o.repo.CreateUser(context.Background(), domain.User{Username: "ada", Email: "ada@example.com"})
+
+	_, _, err := o.login(t, jwt.MapClaims{"sub": "idp|1", "email": "ada@example.com", "email_verified": false})
+	if !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("err = %v, want a conflict", err)
+	}
+	if user, _ := o.repo.FindById(context.Background(), existing.Id); user.OIDCSubject != nil {
+		t.Error("an account was linked on an unverified email")
+	}
+}
+
+func TestOIDCCallbackRefusesSecondIdentity(t *testing.T) {
+	o := newOIDCTest(t)
+	subject := "idp|old"
+	o.repo.CreateUser(context.Background(), domain.User{Username: "ada", Email: "ada@example.com", OIDCSubject: &subject})
+
+	_, _, err := o.login(t, jwt.MapClaims{"sub": "idp|new", "email": "ada@example.com", "email_verified": true})
+	if !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("err = %v, want a conflict", err)
+	}
+}
+
+func TestOIDCCallbackRejectsUnknownState(t *testing.T) {
+	o := newOIDCTest(t)
+	code, _ := o.start(t, jwt.MapClaims{"sub": "idp|1", "email": "ada@example.com"})
+
+	_, _, err := o.uc.OIDCCallback(context.Background(), code, "forged-state")
+	if !errors.Is(err, domain.ErrUnauthorized) {
+		t.Fatalf("err = %v, want unauthorized", err)
+	}
+	if o.idp.TokenRequests != 0 {
+		t.Error("the code was exchanged for a forged state")
+	}
+}
+
+func TestOIDCCallbackRejectsReplayedState(t *testing.T) {
+	o := newOIDCTest(t)
+	code, state := o.start(t, jwt.MapClaims{"sub": "idp|1", "email": "ada@example.com"})
+
+	if _, _, err := o.uc.OIDCCallback(context.Background(), code, state); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := o.uc.OIDCCallback(context.Background(), code, state); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Fatalf("replayed callback err = %v, want unauthorized", err)
+	}
+}
+
+func TestOIDCCallbackRejectsExpiredState(t *testing.T) {
+	o := newOIDCTest(t)
+	code, state := o.start(t, jwt.MapClaims{"sub": "idp|1", "email": "ada@example.com"})
+	hash, stored := o.repo.onlyState()
+	stored.ExpiresAt = time.Now().Add(-time.Second)
+	o.repo.states[hash] = stored
+
+	if _, _, err := o.uc.OIDCCallback(context.Background(), code, state); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Fatalf("err = %v, want unauthorized", err)
+	}
+}
+
+func TestOIDCCallbackRejectsPKCEMismatch(t *testing.T) {
+	o := newOIDCTest(t)
+	code, state := o.start(t, jwt.MapClaims{"sub": "idp|1", "email": "ada@example.com"})
+	// the code was requested with another login's challenge
+	hash, stored := o.repo.onlyState()
+	stored.CodeVerifier = "verifier-of-another-login"
+	o.repo.states[hash] = stored
+
+	if _, _, err := o.uc.OIDCCallback(context.Background(), code, state); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Fatalf("err = %v, want unauthorized", err)
+	}
+	if count, _ := o.repo.CountUsers(context.Background()); count != 0 {
+		t.Error("a user was provisioned without a valid exchange")
+	}
+}
+
+func TestOIDCCallbackRejectsNonceMismatch(t *testing.T) {
+	o := newOIDCTest(t)
+
+	_, _, err := o.login(t, jwt.MapClaims{"sub": "idp|1", "email": "ada@example.com", "nonce": "replayed-nonce"})
+	if !errors.Is(err, domain.ErrUnauthorized) {
+		t.Fatalf("err = %v, want unauthorized", err)
+	}
+	if count, _ := o.repo.CountUsers(context.Background()); count != 0 {
+		t.Error("a user was provisioned from a token with another nonce")
+	}
+}
+
+func TestOIDCAuthURLRejectsOpenRedirect(t *testing.T) {
+	o := newOIDCTest(t)
+	for _, redirect := range []string{"https://evil.example.com", "//evil.example.com/path", "ideas"} {
+		if _, err := o.uc.OIDCAuthURL(context.Background(), redirect); !errors.Is(err, domain.ErrBadRequest) {
+			t.Errorf("redirect %q: err = %v, want bad request", redirect, err)
+		}
+	}
+}
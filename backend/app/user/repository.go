@@ -0,0 +1,263 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	repository struct {
+		db pkgDB.DatabaseTransaction
+	}
+)
+
+// CreateUser implements domain.UserRepository.
+func (r *repository) CreateUser(ctx context.Context, user domain.User) (domain.User, error) {
+	err := r.db.DB(ctx).Create(&user).Error
+	if err != nil {
+		logrus.Error("repository.CreateUser: failed to save user")
+		return domain.User{}, err
+	}
+	return user, nil
+}
+
+// FindById implements domain.UserRepository.
+func (r *repository) FindById(ctx context.Context, id int) (domain.User, error) {
+	data := domain.User{}
+	err := r.db.DB(ctx).First(&data, id).Error
+	if err != nil {
+		return domain.User{}, err
+	}
+	return data, nil
+}
+
+// FindByEmail implements domain.UserRepository.
+func (r *repository) FindByEmail(ctx context.Context, email string) (domain.User, error) {
+	data := domain.User{}
+	err := r.db.DB(ctx).Where("email = ?", email).First(&data).Error
+	if err != nil {
+		return domain.User{}, err
+	}
+	return data, nil
+}
+
+// FindByOIDCSubject implements domain.UserRepository.
+func (r *repository) FindByOIDCSubject(ctx context.Context, subject string) (domain.User, error) {
+	data := domain.User{}
+	err := r.db.DB(ctx).Where("oidc_subject = ?", subject).First(&data).Error
+	if err != nil {
+		return domain.User{}, err
+	}
+	return data, nil
+}
+
+// LinkOIDCSubject implements domain.UserRepository.
+func (r *repository) LinkOIDCSubject(ctx context.Context, id int, subject string) error {
+	return r.db.DB(ctx).Model(&domain.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"oidc_subject": subject,
+			"updated_at":   time.Now(),
+		}).Error
+}
+
+// ExistsByEmailOrUsername implements domain.UserRepository.
+func (r *repository) ExistsByEmailOrUsername(ctx context.Context, email, username string) (bool, error) {
+	var count int64
+	err := r.db.DB(ctx).Model(&domain.User{}).
+		Where("email = ? OR username = ?", email, username).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ListUsers implements domain.UserRepository.
+func (r *repository) ListUsers(ctx context.Context) ([]domain.User, error) {
+	data := []domain.User{}
+	err := r.db.DB(ctx).Order("id asc").Find(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// UpdateRole implements domain.UserRepository.
+func (r *repository) UpdateRole(ctx context.Context, id int, role string) error {
+	return r.db.DB(ctx).Model(&domain.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"role":       role,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// UpdatePlan implements domain.UserRepository.
+func (r *repository) UpdatePlan(ctx context.Context, id int, plan string) error {
+	return r.db.DB(ctx).Model(&domain.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"plan":       plan,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// UpdatePassword implements domain.UserRepository.
+func (r *repository) UpdatePassword(ctx context.Context, id int, hash string) error {
+	return r.db.DB(ctx).Model(&domain.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"password":   hash,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// MarkEmailVerified implements domain.UserRepository.
+func (r *repository) MarkEmailVerified(ctx context.Context, id int) error {
+	return r.db.DB(ctx).Model(&domain.User{}).
+		Where("id = ? AND email_verified_at IS NULL", id).
+		Update("email_verified_at", time.Now()).Error
+}
+
+// CreateRefreshToken implements domain.UserRepository.
+func (r *repository) CreateRefreshToken(ctx context.Context, token domain.RefreshToken) (domain.RefreshToken, error) {
+	err := r.db.DB(ctx).Create(&token).Error
+	if err != nil {
+		logrus.Error("repository.CreateRefreshToken: failed to save refresh token")
+		return domain.RefreshToken{}, err
+	}
+	return token, nil
+}
+
+// FindRefreshTokenByHash implements domain.UserRepository.
+func (r *repository) FindRefreshTokenByHash(ctx context.Context, hash string) (domain.RefreshToken, error) {
+	data := domain.RefreshToken{}
+	err := r.db.DB(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", hash).
+		First(&data).Error
+	if err != nil {
+		return domain.RefreshToken{}, err
+	}
+	return data, nil
+}
+
+// RevokeRefreshToken implements domain.UserRepository.
+func (r *repository) RevokeRefreshToken(ctx context.Context, id, replacedById int) error {
+	return r.db.DB(ctx).Model(&domain.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"replaced_by_id": replacedById,
+		}).Error
+}
+
+// RevokeRefreshTokenFamily implements domain.UserRepository.
+func (r *repository) RevokeRefreshTokenFamily(ctx context.Context, familyId string) error {
+	return r.db.DB(ctx).Model(&domain.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyId).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeUserRefreshTokens implements domain.UserRepository.
+func (r *repository) RevokeUserRefreshTokens(ctx context.Context, userId int) error {
+	return r.db.DB(ctx).Model(&domain.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userId).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAccessToken implements domain.UserRepository.
+func (r *repository) RevokeAccessToken(ctx context.Context, token domain.RevokedToken) error {
+	return r.db.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&token).Error
+}
+
+// IsAccessTokenRevoked implements domain.UserRepository.
+func (r *repository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var count int64
+	err := r.db.DB(ctx).Model(&domain.RevokedToken{}).
+		Where("jti = ?", jti).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// CreateOIDCState implements domain.UserRepository. Abandoned logins are
+// swept on the way.
+func (r *repository) CreateOIDCState(ctx context.Context, state domain.OIDCState) error {
+	if err := r.db.DB(ctx).Where("expires_at < ?", time.Now()).Delete(&domain.OIDCState{}).Error; err != nil {
+		logrus.Errorf("repository.CreateOIDCState: failed to sweep expired states: %v", err)
+	}
+	return r.db.DB(ctx).Create(&state).Error
+}
+
+// TakeOIDCState implements domain.UserRepository.
+func (r *repository) TakeOIDCState(ctx context.Context, stateHash string) (domain.OIDCState, error) {
+	data := domain.OIDCState{}
+	result := r.db.DB(ctx).Clauses(clause.Returning{}).
+		Where("state_hash = ?", stateHash).
+		Delete(&data)
+	if result.Error != nil {
+		return domain.OIDCState{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.OIDCState{}, gorm.ErrRecordNotFound
+	}
+	return data, nil
+}
+
+// CreateUserToken implements domain.UserRepository.
+func (r *repository) CreateUserToken(ctx context.Context, token domain.UserToken) (domain.UserToken, error) {
+	err := r.db.DB(ctx).Create(&token).Error
+	if err != nil {
+		logrus.Error("repository.CreateUserToken: failed to save user token")
+		return domain.UserToken{}, err
+	}
+	return token, nil
+}
+
+// FindUserTokenByHash implements domain.UserRepository.
+func (r *repository) FindUserTokenByHash(ctx context.Context, hash string) (domain.UserToken, error) {
+	data := domain.UserToken{}
+	err := r.db.DB(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", hash).
+		First(&data).Error
+	if err != nil {
+		return domain.UserToken{}, err
+	}
+	return data, nil
+}
+
+// MarkUserTokenUsed implements domain.UserRepository.
+func (r *repository) MarkUserTokenUsed(ctx context.Context, id int) error {
+	return r.db.DB(ctx).Model(&domain.UserToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now()).Error
+}
+
+// InvalidateUserTokens implements domain.UserRepository.
+func (r *repository) InvalidateUserTokens(ctx context.Context, userId int, purpose string) error {
+	return r.db.DB(ctx).Model(&domain.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userId, purpose).
+		Update("used_at", time.Now()).Error
+}
+
+var (
+	repo *repository
+)
+
+func NewUserRepository(db pkgDB.DatabaseTransaction) domain.UserRepository {
+	if repo == nil {
+		repo = &repository{
+			db,
+		}
+	}
+	return repo
+}
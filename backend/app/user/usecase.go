@@ -0,0 +1,371 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/config"
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/pkg/cache"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/Kocannn/self-dunking-ai/pkg/jwt"
+	"github.com/Kocannn/self-dunking-ai/pkg/oidc"
+	"github.com/Kocannn/self-dunking-ai/pkg/token"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	minPasswordLength        = 8
+	defaultAccessTokenMinute = 15
+	defaultRefreshTokenDays  = 30
+)
+
+type (
+	usecase struct {
+		cfg  config.Config
+		dbTx pkgDB.DatabaseTransaction
+		repo domain.UserRepository
+		jwt  jwt.JWT
+		// revoked is shared with AuthMiddleware so a logout is honoured
+		// immediately on this instance
+		revoked *cache.Cache[bool]
+		// oidc is nil when single sign-on is not configured
+		oidc  *oidc.Provider
+		email domain.EmailUsecase
+		audit domain.AuditUsecase
+	}
+)
+
+// Register implements domain.UserUsecase.
+func (u *usecase) Register(ctx context.Context, req domain.RegisterRequest) (domain.User, error) {
+	req.Username = strings.TrimSpace(req.Username)
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+
+	if req.Username == "" {
+		return domain.User{}, fmt.Errorf("%w: username is required", domain.ErrBadRequest)
+	}
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		return domain.User{}, fmt.Errorf("%w: invalid email address", domain.ErrBadRequest)
+	}
+	if err := validatePassword(req.Password); err != nil {
+		return domain.User{}, err
+	}
+
+	exists, err := u.repo.ExistsByEmailOrUsername(ctx, req.Email, req.Username)
+	if err != nil {
+		logrus.Errorf("error checking user: %v", err)
+		return domain.User{}, err
+	}
+	if exists {
+		return domain.User{}, fmt.Errorf("%w: email or username already registered", domain.ErrConflict)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		logrus.Errorf("error hashing password: %v", err)
+		return domain.User{}, err
+	}
+
+	var created domain.User
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		now := time.Now()
+		created, err = u.repo.CreateUser(txCtx, domain.User{
+			Username:  req.Username,
+			Email:     req.Email,
+			Password:  string(hash),
+			Role:      domain.RoleMember,
+			CreatedAt: &now,
+			UpdatedAt: &now,
+		})
+		if err != nil {
+			return err
+		}
+		return u.mailToken(txCtx, created, domain.TokenVerifyEmail)
+	})
+	if err != nil {
+		logrus.Errorf("error creating user: %v", err)
+		return domain.User{}, err
+	}
+	return created, nil
+}
+
+// Login implements domain.UserUsecase.
+func (u *usecase) Login(ctx context.Context, req domain.LoginRequest) (domain.AuthResponse, error) {
+	user, err := u.repo.FindByEmail(ctx, strings.ToLower(strings.TrimSpace(req.Email)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.AuthResponse{}, fmt.Errorf("%w: invalid email or password", domain.ErrUnauthorized)
+		}
+		logrus.Errorf("error finding user: %v", err)
+		return domain.AuthResponse{}, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return domain.AuthResponse{}, fmt.Errorf("%w: invalid email or password", domain.ErrUnauthorized)
+	}
+
+	familyId, err := token.NewOpaque()
+	if err != nil {
+		return domain.AuthResponse{}, err
+	}
+
+	var response domain.AuthResponse
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		response, _, err = u.issueTokens(txCtx, user, familyId)
+		if err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditUserLogin,
+			TargetType: "user",
+			TargetId:   strconv.Itoa(user.Id),
+			After:      map[string]string{"method": "password"},
+			ActorId:    user.Id,
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error issuing tokens: %v", err)
+		return domain.AuthResponse{}, err
+	}
+	return response, nil
+}
+
+// Refresh implements domain.UserUsecase. Each refresh token can be used once;
+// presenting an already rotated token is treated as theft and revokes every
+// token of its family.
+func (u *usecase) Refresh(ctx context.Context, refreshToken string) (domain.AuthResponse, error) {
+	var (
+		response    domain.AuthResponse
+		reuseFamily string
+	)
+
+	err := u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		stored, err := u.repo.FindRefreshTokenByHash(txCtx, token.Hash(refreshToken))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: invalid refresh token", domain.ErrUnauthorized)
+			}
+			return err
+		}
+
+		if stored.RevokedAt != nil {
+			reuseFamily = stored.FamilyId
+			return fmt.Errorf("%w: refresh token reuse detected", domain.ErrUnauthorized)
+		}
+		if time.Now().After(stored.ExpiresAt) {
+			return fmt.Errorf("%w: refresh token expired", domain.ErrUnauthorized)
+		}
+
+		user, err := u.repo.FindById(txCtx, stored.UserId)
+		if err != nil {
+			return fmt.Errorf("%w: %v", domain.ErrUnauthorized, err)
+		}
+
+		var next domain.RefreshToken
+		response, next, err = u.issueTokens(txCtx, user, stored.FamilyId)
+		if err != nil {
+			return err
+		}
+		return u.repo.RevokeRefreshToken(txCtx, stored.Id, next.Id)
+	})
+
+	// revoke outside the failed transaction so it is not rolled back
+	if reuseFamily != "" {
+		logrus.Warnf("refresh token reuse detected, revoking family %s", reuseFamily)
+		if err := u.repo.RevokeRefreshTokenFamily(ctx, reuseFamily); err != nil {
+			logrus.Errorf("error revoking refresh token family: %v", err)
+		}
+	}
+	if err != nil {
+		return domain.AuthResponse{}, err
+	}
+	return response, nil
+}
+
+// Logout implements domain.UserUsecase.
+func (u *usecase) Logout(ctx context.Context, accessToken, refreshToken string) error {
+	claims, err := u.jwt.VerifyToken(accessToken)
+	if err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrUnauthorized, err)
+	}
+
+	now := time.Now()
+	expiresAt := claims.ExpiresAt.Time
+	if err := u.repo.RevokeAccessToken(ctx, domain.RevokedToken{
+		Jti:       claims.RegisteredClaims.ID,
+		UserId:    claims.ID,
+		ExpiresAt: expiresAt,
+		CreatedAt: &now,
+	}); err != nil {
+		logrus.Errorf("error revoking access token: %v", err)
+		return err
+	}
+	u.revoked.Set(claims.RegisteredClaims.ID, true, time.Until(expiresAt))
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	stored, err := u.repo.FindRefreshTokenByHash(ctx, token.Hash(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if stored.UserId != claims.ID {
+		return fmt.Errorf("%w: refresh token belongs to another user", domain.ErrForbidden)
+	}
+	return u.repo.RevokeRefreshTokenFamily(ctx, stored.FamilyId)
+}
+
+// ListUsers implements domain.UserUsecase.
+func (u *usecase) ListUsers(ctx context.Context) ([]domain.User, error) {
+	return u.repo.ListUsers(ctx)
+}
+
+// UpdateRole implements domain.UserUsecase.
+func (u *usecase) UpdateRole(ctx context.Context, id int, role string) (domain.User, error) {
+	if !domain.IsValidRole(role) {
+		return domain.User{}, fmt.Errorf("%w: unknown role %q", domain.ErrBadRequest, role)
+	}
+
+	user, err := u.repo.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.User{}, domain.ErrNotFound
+		}
+		return domain.User{}, err
+	}
+
+	if caller, ok := domain.UserFromContext(ctx); ok && caller.Id == id && role != domain.RoleAdmin {
+		return domain.User{}, fmt.Errorf("%w: admins cannot demote themselves", domain.ErrConflict)
+	}
+
+	updated := user
+	updated.Role = role
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		if err := u.repo.UpdateRole(txCtx, id, role); err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditUserRoleUpdate,
+			TargetType: "user",
+			TargetId:   strconv.Itoa(id),
+			Before:     user,
+			After:      updated,
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error updating role: %v", err)
+		return domain.User{}, err
+	}
+	return updated, nil
+}
+
+// UpdatePlan implements domain.UserUsecase.
+func (u *usecase) UpdatePlan(ctx context.Context, id int, plan string) (domain.User, error) {
+	if !domain.IsValidPlan(plan) {
+		return domain.User{}, fmt.Errorf("%w: unknown plan %q", domain.ErrBadRequest, plan)
+	}
+
+	user, err := u.repo.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.User{}, domain.ErrNotFound
+		}
+		return domain.User{}, err
+	}
+
+	updated := user
+	updated.Plan = plan
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		if err := u.repo.UpdatePlan(txCtx, id, plan); err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:     domain.AuditUserPlanUpdate,
+			TargetType: "user",
+			TargetId:   strconv.Itoa(id),
+			Before:     user,
+			After:      updated,
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error updating plan: %v", err)
+		return domain.User{}, err
+	}
+	return updated, nil
+}
+
+// issueTokens signs a new access token and stores a new refresh token in
+// familyId.
+func (u *usecase) issueTokens(ctx context.Context, user domain.User, familyId string) (domain.AuthResponse, domain.RefreshToken, error) {
+	minutes := u.cfg.JWT_ACCESS_TOKEN_MINUTES
+	if minutes == 0 {
+		minutes = defaultAccessTokenMinute
+	}
+	days := u.cfg.JWT_REFRESH_TOKEN_DAYS
+	if days == 0 {
+		days = defaultRefreshTokenDays
+	}
+
+	accessToken, expiresAt, err := u.jwt.GenerateAccessToken(ctx, &user, minutes)
+	if err != nil {
+		logrus.Errorf("error generating access token: %v", err)
+		return domain.AuthResponse{}, domain.RefreshToken{}, err
+	}
+
+	refreshToken, err := token.NewOpaque()
+	if err != nil {
+		return domain.AuthResponse{}, domain.RefreshToken{}, err
+	}
+
+	now := time.Now()
+	stored, err := u.repo.CreateRefreshToken(ctx, domain.RefreshToken{
+		UserId:    user.Id,
+		FamilyId:  familyId,
+		TokenHash: token.Hash(refreshToken),
+		ExpiresAt: now.AddDate(0, 0, days),
+		CreatedAt: &now,
+	})
+	if err != nil {
+		return domain.AuthResponse{}, domain.RefreshToken{}, err
+	}
+
+	return domain.AuthResponse{
+		AccessToken:      *accessToken,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: stored.ExpiresAt,
+		User:             user,
+	}, stored, nil
+}
+
+var (
+	uc *usecase
+)
+
+func NewUserUsecase(cfg config.Config, dbTx pkgDB.DatabaseTransaction, repo domain.UserRepository, jwt jwt.JWT, revoked *cache.Cache[bool], oidc *oidc.Provider, email domain.EmailUsecase, audit domain.AuditUsecase) domain.UserUsecase {
+	if uc == nil {
+		uc = &usecase{
+			cfg:     cfg,
+			dbTx:    dbTx,
+			repo:    repo,
+			jwt:     jwt,
+			revoked: revoked,
+			oidc:    oidc,
+			email:   email,
+			audit:   audit,
+		}
+	}
+	return uc
+}
@@ -0,0 +1,20 @@
+package user
+
+import (
+	"github.com/Kocannn/self-dunking-ai/config"
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/pkg/cache"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/Kocannn/self-dunking-ai/pkg/jwt"
+	"github.com/Kocannn/self-dunking-ai/pkg/oidc"
+)
+
+func InitUserRepository(db pkgDB.DatabaseTransaction) domain.UserRepository {
+	return NewUserRepository(db)
+}
+func InitUserUsecase(cfg config.Config, dbTx pkgDB.DatabaseTransaction, repo domain.UserRepository, jwt jwt.JWT, revoked *cache.Cache[bool], oidc *oidc.Provider, email domain.EmailUsecase, audit domain.AuditUsecase) domain.UserUsecase {
+	return NewUserUsecase(cfg, dbTx, repo, jwt, revoked, oidc, email, audit)
+}
+func InitUserHandler(usecase domain.UserUsecase) domain.UserHandler {
+	return NewUserHandler(usecase)
+}
@@ -0,0 +1,316 @@
+package workspace
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	handler struct {
+		usecase domain.WorkspaceUsecase
+	}
+)
+
+// ListWorkspaces implements domain.WorkspaceHandler.
+func (h *handler) ListWorkspaces(w http.ResponseWriter, r *http.Request) {
+	data, err := h.usecase.ListWorkspaces(r.Context())
+	if err != nil {
+		utils.RespondError(w, "error listing workspaces", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Workspaces retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// CreateWorkspace implements domain.WorkspaceHandler.
+func (h *handler) CreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	dataBuffer := domain.CreateWorkspaceRequest{}
+	if !decode(w, r, &dataBuffer) {
+		return
+	}
+
+	data, err := h.usecase.CreateWorkspace(r.Context(), dataBuffer)
+	if err != nil {
+		utils.RespondError(w, "error creating workspace", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusCreated,
+		Message: "Workspace created successfully",
+		Data:    data,
+	}, w)
+}
+
+// GetWorkspace implements domain.WorkspaceHandler.
+func (h *handler) GetWorkspace(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r, "id")
+	if !ok {
+		return
+	}
+
+	data, err := h.usecase.GetWorkspace(r.Context(), id)
+	if err != nil {
+		utils.RespondError(w, "error getting workspace", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Workspace retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// UpdateWorkspace implements domain.WorkspaceHandler.
+func (h *handler) UpdateWorkspace(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r, "id")
+	if !ok {
+		return
+	}
+	dataBuffer := domain.UpdateWorkspaceRequest{}
+	if !decode(w, r, &dataBuffer) {
+		return
+	}
+
+	data, err := h.usecase.UpdateWorkspace(r.Context(), id, dataBuffer)
+	if err != nil {
+		utils.RespondError(w, "error updating workspace", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Workspace updated successfully",
+		Data:    data,
+	}, w)
+}
+
+// DeleteWorkspace implements domain.WorkspaceHandler.
+func (h *handler) DeleteWorkspace(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.usecase.DeleteWorkspace(r.Context(), id); err != nil {
+		utils.RespondError(w, "error deleting workspace", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Workspace deleted successfully",
+		Data:    nil,
+	}, w)
+}
+
+// ListMembers implements domain.WorkspaceHandler.
+func (h *handler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r, "id")
+	if !ok {
+		return
+	}
+
+	data, err := h.usecase.ListMembers(r.Context(), id)
+	if err != nil {
+		utils.RespondError(w, "error listing workspace members", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Members retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// UpdateMember implements domain.WorkspaceHandler.
+func (h *handler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r, "id")
+	if !ok {
+		return
+	}
+	userId, ok := pathId(w, r, "userId")
+	if !ok {
+		return
+	}
+	dataBuffer := domain.UpdateMemberRequest{}
+	if !decode(w, r, &dataBuffer) {
+		return
+	}
+
+	data, err := h.usecase.UpdateMember(r.Context(), id, userId, dataBuffer.Role)
+	if err != nil {
+		utils.RespondError(w, "error updating workspace member", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Member updated successfully",
+		Data:    data,
+	}, w)
+}
+
+// RemoveMember implements domain.WorkspaceHandler.
+func (h *handler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r, "id")
+	if !ok {
+		return
+	}
+	userId, ok := pathId(w, r, "userId")
+	if !ok {
+		return
+	}
+
+	if err := h.usecase.RemoveMember(r.Context(), id, userId); err != nil {
+		utils.RespondError(w, "error removing workspace member", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Member removed successfully",
+		Data:    nil,
+	}, w)
+}
+
+// ListInvitations implements domain.WorkspaceHandler.
+func (h *handler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r, "id")
+	if !ok {
+		return
+	}
+
+	data, err := h.usecase.ListInvitations(r.Context(), id)
+	if err != nil {
+		utils.RespondError(w, "error listing invitations", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Invitations retrieved successfully",
+		Data:    data,
+	}, w)
+}
+
+// InviteMember implements domain.WorkspaceHandler.
+func (h *handler) InviteMember(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r, "id")
+	if !ok {
+		return
+	}
+	dataBuffer := domain.InviteMemberRequest{}
+	if !decode(w, r, &dataBuffer) {
+		return
+	}
+
+	data, err := h.usecase.InviteMember(r.Context(), id, dataBuffer)
+	if err != nil {
+		utils.RespondError(w, "error inviting workspace member", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusCreated,
+		Message: "Invitation sent successfully",
+		Data:    data,
+	}, w)
+}
+
+// RevokeInvitation implements domain.WorkspaceHandler.
+func (h *handler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r, "id")
+	if !ok {
+		return
+	}
+	invitationId, ok := pathId(w, r, "invitationId")
+	if !ok {
+		return
+	}
+
+	if err := h.usecase.RevokeInvitation(r.Context(), id, invitationId); err != nil {
+		utils.RespondError(w, "error revoking invitation", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Invitation revoked successfully",
+		Data:    nil,
+	}, w)
+}
+
+// AcceptInvitation implements domain.WorkspaceHandler.
+func (h *handler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	dataBuffer := domain.AcceptInvitationRequest{}
+	if !decode(w, r, &dataBuffer) {
+		return
+	}
+
+	data, err := h.usecase.AcceptInvitation(r.Context(), dataBuffer.Token)
+	if err != nil {
+		utils.RespondError(w, "error accepting invitation", err)
+		return
+	}
+
+	utils.Response(domain.HttpResponse{
+		Code:    http.StatusOK,
+		Message: "Invitation accepted successfully",
+		Data:    data,
+	}, w)
+}
+
+// pathId parses the mux variable name, answering 400 when it is not a
+// number.
+func pathId(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)[name])
+	if err != nil {
+		logrus.Errorf("error converting %s to int: %v", name, err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid ID format",
+			Data:    nil,
+		}, w)
+		return 0, false
+	}
+	return id, true
+}
+
+func decode(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		logrus.Errorf("error unmarshalling request body: %v", err)
+		utils.Response(domain.HttpResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request body",
+			Data:    nil,
+		}, w)
+		return false
+	}
+	return true
+}
+
+var (
+	handlr *handler
+)
+
+func NewWorkspaceHandler(usecase domain.WorkspaceUsecase) domain.WorkspaceHandler {
+	if handlr == nil {
+		handlr = &handler{
+			usecase,
+		}
+	}
+	return handlr
+}
@@ -0,0 +1,248 @@
+package workspace
+
+import (
+	"context"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	repository struct {
+		db pkgDB.DatabaseTransaction
+	}
+)
+
+// GetWorkspace implements domain.WorkspaceRepository.
+func (r *repository) GetWorkspace(ctx context.Context, id int) (domain.Workspace, error) {
+	data := domain.Workspace{}
+	err := r.db.DB(ctx).First(&data, id).Error
+	if err != nil {
+		return domain.Workspace{}, err
+	}
+	return data, nil
+}
+
+// FindPersonalWorkspace implements domain.WorkspaceRepository.
+func (r *repository) FindPersonalWorkspace(ctx context.Context, userId int) (domain.Workspace, error) {
+	data := domain.Workspace{}
+	err := r.db.DB(ctx).Where("owner_id = ? AND personal", userId).First(&data).Error
+	if err != nil {
+		return domain.Workspace{}, err
+	}
+	return data, nil
+}
+
+// ListWorkspacesForUser implements domain.WorkspaceRepository.
+func (r *repository) ListWorkspacesForUser(ctx context.Context, userId int) ([]domain.Workspace, error) {
+	rows := []struct {
+		domain.Workspace
+		Role string
+	}{}
+	err := r.db.DB(ctx).Table("workspaces").
+		Select("workspaces.*, workspace_memberships.role").
+		Joins("JOIN workspace_memberships ON workspace_memberships.workspace_id = workspaces.id").
+		Where("workspace_memberships.user_id = ?", userId).
+		Order("workspaces.personal desc, workspaces.name asc").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]domain.Workspace, 0, len(rows))
+	for _, row := range rows {
+		row.Workspace.MemberRole = row.Role
+		data = append(data, row.Workspace)
+	}
+	return data, nil
+}
+
+// CreateWorkspace implements domain.WorkspaceRepository.
+func (r *repository) CreateWorkspace(ctx context.Context, workspace domain.Workspace) (domain.Workspace, error) {
+	err := r.db.DB(ctx).Create(&workspace).Error
+	if err != nil {
+		logrus.Error("repository.CreateWorkspace: failed to save workspace")
+		return domain.Workspace{}, err
+	}
+	return workspace, nil
+}
+
+// UpdateWorkspace implements domain.WorkspaceRepository.
+func (r *repository) UpdateWorkspace(ctx context.Context, workspace domain.Workspace) error {
+	return r.db.DB(ctx).Model(&domain.Workspace{Id: workspace.Id}).
+		Select("name", "default_model", "prompt_versions", "updated_at").
+		Updates(&workspace).Error
+}
+
+// DeleteWorkspace implements domain.WorkspaceRepository. Memberships and
+// invitations go with it.
+func (r *repository) DeleteWorkspace(ctx context.Context, id int) error {
+	db := r.db.DB(ctx)
+	if err := db.Where("workspace_id = ?", id).Delete(&domain.WorkspaceInvitation{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("workspace_id = ?", id).Delete(&domain.WorkspaceMembership{}).Error; err != nil {
+		return err
+	}
+	return db.Delete(&domain.Workspace{}, id).Error
+}
+
+// CountIdeas implements domain.WorkspaceRepository.
+func (r *repository) CountIdeas(ctx context.Context, workspaceId int) (int64, error) {
+	var count int64
+	err := r.db.DB(ctx).Model(&domain.SubmitIdeaRequest{}).
+		Where("workspace_id = ?", workspaceId).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// AdoptUnscopedIdeas implements domain.WorkspaceRepository.
+func (r *repository) AdoptUnscopedIdeas(ctx context.Context, userId, workspaceId int) error {
+	db := r.db.DB(ctx)
+	err := db.Model(&domain.SubmitIdeaRequest{}).
+		Where("user_id = ? AND workspace_id = 0", userId).
+		Update("workspace_id", workspaceId).Error
+	if err != nil {
+		return err
+	}
+	return db.Model(&domain.Evaluation{}).
+		Where("user_id = ? AND workspace_id = 0", userId).
+		Update("workspace_id", workspaceId).Error
+}
+
+// GetMembership implements domain.WorkspaceRepository.
+func (r *repository) GetMembership(ctx context.Context, workspaceId, userId int) (domain.WorkspaceMembership, error) {
+	data := domain.WorkspaceMembership{}
+	err := r.db.DB(ctx).
+		Where("workspace_id = ? AND user_id = ?", workspaceId, userId).
+		First(&data).Error
+	if err != nil {
+		return domain.WorkspaceMembership{}, err
+	}
+	return data, nil
+}
+
+// ListMembers implements domain.WorkspaceRepository.
+func (r *repository) ListMembers(ctx context.Context, workspaceId int) ([]domain.WorkspaceMembership, error) {
+	data := []domain.WorkspaceMembership{}
+	err := r.db.DB(ctx).Preload("User").
+		Where("workspace_id = ?", workspaceId).
+		Order("id asc").
+		Find(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CountOwners implements domain.WorkspaceRepository.
+func (r *repository) CountOwners(ctx context.Context, workspaceId int) (int64, error) {
+	var count int64
+	err := r.db.DB(ctx).Model(&domain.WorkspaceMembership{}).
+		Where("workspace_id = ? AND role = ?", workspaceId, domain.WorkspaceOwner).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// AddMember implements domain.WorkspaceRepository.
+func (r *repository) AddMember(ctx context.Context, member domain.WorkspaceMembership) (domain.WorkspaceMembership, error) {
+	err := r.db.DB(ctx).Create(&member).Error
+	if err != nil {
+		logrus.Error("repository.AddMember: failed to save membership")
+		return domain.WorkspaceMembership{}, err
+	}
+	return member, nil
+}
+
+// UpdateMemberRole implements domain.WorkspaceRepository.
+func (r *repository) UpdateMemberRole(ctx context.Context, workspaceId, userId int, role string) error {
+	return r.db.DB(ctx).Model(&domain.WorkspaceMembership{}).
+		Where("workspace_id = ? AND user_id = ?", workspaceId, userId).
+		Update("role", role).Error
+}
+
+// RemoveMember implements domain.WorkspaceRepository.
+func (r *repository) RemoveMember(ctx context.Context, workspaceId, userId int) error {
+	return r.db.DB(ctx).
+		Where("workspace_id = ? AND user_id = ?", workspaceId, userId).
+		Delete(&domain.WorkspaceMembership{}).Error
+}
+
+// ListInvitations implements domain.WorkspaceRepository.
+func (r *repository) ListInvitations(ctx context.Context, workspaceId int) ([]domain.WorkspaceInvitation, error) {
+	data := []domain.WorkspaceInvitation{}
+	err := r.db.DB(ctx).
+		Where("workspace_id = ? AND accepted_at IS NULL", workspaceId).
+		Order("id desc").
+		Find(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetInvitation implements domain.WorkspaceRepository.
+func (r *repository) GetInvitation(ctx context.Context, id int) (domain.WorkspaceInvitation, error) {
+	data := domain.WorkspaceInvitation{}
+	err := r.db.DB(ctx).First(&data, id).Error
+	if err != nil {
+		return domain.WorkspaceInvitation{}, err
+	}
+	return data, nil
+}
+
+// FindInvitationByHash implements domain.WorkspaceRepository.
+func (r *repository) FindInvitationByHash(ctx context.Context, hash string) (domain.WorkspaceInvitation, error) {
+	data := domain.WorkspaceInvitation{}
+	err := r.db.DB(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", hash).
+		First(&data).Error
+	if err != nil {
+		return domain.WorkspaceInvitation{}, err
+	}
+	return data, nil
+}
+
+// CreateInvitation implements domain.WorkspaceRepository.
+func (r *repository) CreateInvitation(ctx context.Context, invitation domain.WorkspaceInvitation) (domain.WorkspaceInvitation, error) {
+	err := r.db.DB(ctx).Create(&invitation).Error
+	if err != nil {
+		logrus.Error("repository.CreateInvitation: failed to save invitation")
+		return domain.WorkspaceInvitation{}, err
+	}
+	return invitation, nil
+}
+
+// AcceptInvitation implements domain.WorkspaceRepository.
+func (r *repository) AcceptInvitation(ctx context.Context, id int) error {
+	return r.db.DB(ctx).Model(&domain.WorkspaceInvitation{}).
+		Where("id = ? AND accepted_at IS NULL", id).
+		Update("accepted_at", time.Now()).Error
+}
+
+// DeleteInvitation implements domain.WorkspaceRepository.
+func (r *repository) DeleteInvitation(ctx context.Context, id int) error {
+	return r.db.DB(ctx).Delete(&domain.WorkspaceInvitation{}, id).Error
+}
+
+var (
+	repo *repository
+)
+
+func NewWorkspaceRepository(db pkgDB.DatabaseTransaction) domain.WorkspaceRepository {
+	if repo == nil {
+		repo = &repository{
+			db,
+		}
+	}
+	return repo
+}
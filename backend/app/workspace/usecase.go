@@ -0,0 +1,608 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/config"
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+	"github.com/Kocannn/self-dunking-ai/pkg/mailer"
+	"github.com/Kocannn/self-dunking-ai/pkg/token"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+type (
+	usecase struct {
+		cfg        config.Config
+		dbTx       pkgDB.DatabaseTransaction
+		repo       domain.WorkspaceRepository
+		promptRepo domain.PromptRepository
+		email      domain.EmailUsecase
+		audit      domain.AuditUsecase
+	}
+)
+
+// Resolve implements domain.WorkspaceUsecase.
+func (u *usecase) Resolve(ctx context.Context, user domain.User, workspaceId int) (domain.Workspace, error) {
+	if workspaceId == 0 {
+		return u.personal(ctx, user)
+	}
+
+	workspace, err := u.repo.GetWorkspace(ctx, workspaceId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.Workspace{}, fmt.Errorf("%w: not a member of this workspace", domain.ErrForbidden)
+		}
+		return domain.Workspace{}, err
+	}
+	member, err := u.repo.GetMembership(ctx, workspaceId, user.Id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.Workspace{}, fmt.Errorf("%w: not a member of this workspace", domain.ErrForbidden)
+		}
+		return domain.Workspace{}, err
+	}
+	workspace.MemberRole = member.Role
+	return workspace, nil
+}
+
+// ListWorkspaces implements domain.WorkspaceUsecase.
+func (u *usecase) ListWorkspaces(ctx context.Context) ([]domain.Workspace, error) {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+	// make sure the personal workspace exists before listing
+	if _, err := u.personal(ctx, user); err != nil {
+		return nil, err
+	}
+	return u.repo.ListWorkspacesForUser(ctx, user.Id)
+}
+
+// CreateWorkspace implements domain.WorkspaceUsecase.
+func (u *usecase) CreateWorkspace(ctx context.Context, req domain.CreateWorkspaceRequest) (domain.Workspace, error) {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.Workspace{}, domain.ErrUnauthorized
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return domain.Workspace{}, fmt.Errorf("%w: name is required", domain.ErrBadRequest)
+	}
+
+	var created domain.Workspace
+	err := u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		created, err = u.create(txCtx, user, req.Name, false)
+		if err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:      domain.AuditWorkspaceCreate,
+			TargetType:  "workspace",
+			TargetId:    strconv.Itoa(created.Id),
+			WorkspaceId: created.Id,
+			After:       created,
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error creating workspace: %v", err)
+		return domain.Workspace{}, err
+	}
+	return created, nil
+}
+
+// GetWorkspace implements domain.WorkspaceUsecase.
+func (u *usecase) GetWorkspace(ctx context.Context, id int) (domain.Workspace, error) {
+	return u.member(ctx, id, domain.WorkspaceMember)
+}
+
+// UpdateWorkspace implements domain.WorkspaceUsecase.
+func (u *usecase) UpdateWorkspace(ctx context.Context, id int, req domain.UpdateWorkspaceRequest) (domain.Workspace, error) {
+	workspace, err := u.member(ctx, id, domain.WorkspaceAdmin)
+	if err != nil {
+		return domain.Workspace{}, err
+	}
+	before := workspace
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return domain.Workspace{}, fmt.Errorf("%w: name is required", domain.ErrBadRequest)
+		}
+		workspace.Name = name
+	}
+	if req.DefaultModel != nil {
+		workspace.DefaultModel = strings.TrimSpace(*req.DefaultModel)
+	}
+	if req.PromptVersions != nil {
+		versions := map[string]int{}
+		for name, version := range req.PromptVersions {
+			if version == 0 {
+				continue
+			}
+			if _, err := u.promptRepo.GetPromptVersion(ctx, name, version); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return domain.Workspace{}, fmt.Errorf("%w: prompt %s has no version %d", domain.ErrBadRequest, name, version)
+				}
+				return domain.Workspace{}, err
+			}
+			versions[name] = version
+		}
+		workspace.PromptVersions = versions
+	}
+
+	now := time.Now()
+	workspace.UpdatedAt = &now
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		if err := u.repo.UpdateWorkspace(txCtx, workspace); err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:      domain.AuditWorkspaceUpdate,
+			TargetType:  "workspace",
+			TargetId:    strconv.Itoa(id),
+			WorkspaceId: id,
+			Before:      before,
+			After:       workspace,
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error updating workspace: %v", err)
+		return domain.Workspace{}, err
+	}
+	return workspace, nil
+}
+
+// DeleteWorkspace implements domain.WorkspaceUsecase. Only empty team
+// workspaces can be deleted so no idea is orphaned.
+func (u *usecase) DeleteWorkspace(ctx context.Context, id int) error {
+	workspace, err := u.member(ctx, id, domain.WorkspaceOwner)
+	if err != nil {
+		return err
+	}
+	if workspace.Personal {
+		return fmt.Errorf("%w: the personal workspace cannot be deleted", domain.ErrConflict)
+	}
+
+	return u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		count, err := u.repo.CountIdeas(txCtx, id)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: workspace still has %d ideas", domain.ErrConflict, count)
+		}
+		if err := u.repo.DeleteWorkspace(txCtx, id); err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:      domain.AuditWorkspaceDelete,
+			TargetType:  "workspace",
+			TargetId:    strconv.Itoa(id),
+			WorkspaceId: id,
+			Before:      workspace,
+		})
+	})
+}
+
+// ListMembers implements domain.WorkspaceUsecase.
+func (u *usecase) ListMembers(ctx context.Context, id int) ([]domain.WorkspaceMembership, error) {
+	if _, err := u.member(ctx, id, domain.WorkspaceMember); err != nil {
+		return nil, err
+	}
+	return u.repo.ListMembers(ctx, id)
+}
+
+// UpdateMember implements domain.WorkspaceUsecase. Only owners hand out or
+// take away ownership, and the last owner cannot step down.
+func (u *usecase) UpdateMember(ctx context.Context, id, userId int, role string) (domain.WorkspaceMembership, error) {
+	if !domain.IsValidWorkspaceRole(role) {
+		return domain.WorkspaceMembership{}, fmt.Errorf("%w: unknown workspace role %q", domain.ErrBadRequest, role)
+	}
+
+	workspace, err := u.member(ctx, id, domain.WorkspaceAdmin)
+	if err != nil {
+		return domain.WorkspaceMembership{}, err
+	}
+
+	var updated domain.WorkspaceMembership
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		target, err := u.repo.GetMembership(txCtx, id, userId)
+		if err != nil {
+			return notFound(err)
+		}
+
+		touchesOwner := role == domain.WorkspaceOwner || target.Role == domain.WorkspaceOwner
+		if touchesOwner && !workspace.HasMemberRole(domain.WorkspaceOwner) {
+			return fmt.Errorf("%w: only owners can change ownership", domain.ErrForbidden)
+		}
+		if err := u.keepOwner(txCtx, id, target, role); err != nil {
+			return err
+		}
+
+		if err := u.repo.UpdateMemberRole(txCtx, id, userId, role); err != nil {
+			return err
+		}
+		updated = target
+		updated.Role = role
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:      domain.AuditMemberUpdate,
+			TargetType:  "workspace_member",
+			TargetId:    strconv.Itoa(target.Id),
+			WorkspaceId: id,
+			Before:      target,
+			After:       updated,
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error updating workspace member: %v", err)
+		return domain.WorkspaceMembership{}, err
+	}
+	return updated, nil
+}
+
+// RemoveMember implements domain.WorkspaceUsecase. Members may always
+// leave; removing somebody else needs the admin role.
+func (u *usecase) RemoveMember(ctx context.Context, id, userId int) error {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+
+	minRole := domain.WorkspaceAdmin
+	if userId == user.Id {
+		minRole = domain.WorkspaceMember
+	}
+	workspace, err := u.member(ctx, id, minRole)
+	if err != nil {
+		return err
+	}
+	if workspace.Personal {
+		return fmt.Errorf("%w: members cannot leave a personal workspace", domain.ErrConflict)
+	}
+
+	return u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		target, err := u.repo.GetMembership(txCtx, id, userId)
+		if err != nil {
+			return notFound(err)
+		}
+		if target.Role == domain.WorkspaceOwner && userId != user.Id && !workspace.HasMemberRole(domain.WorkspaceOwner) {
+			return fmt.Errorf("%w: only owners can remove an owner", domain.ErrForbidden)
+		}
+		if err := u.keepOwner(txCtx, id, target, ""); err != nil {
+			return err
+		}
+		if err := u.repo.RemoveMember(txCtx, id, userId); err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:      domain.AuditMemberRemove,
+			TargetType:  "workspace_member",
+			TargetId:    strconv.Itoa(target.Id),
+			WorkspaceId: id,
+			Before:      target,
+		})
+	})
+}
+
+// ListInvitations implements domain.WorkspaceUsecase.
+func (u *usecase) ListInvitations(ctx context.Context, id int) ([]domain.WorkspaceInvitation, error) {
+	if _, err := u.member(ctx, id, domain.WorkspaceAdmin); err != nil {
+		return nil, err
+	}
+	return u.repo.ListInvitations(ctx, id)
+}
+
+// InviteMember implements domain.WorkspaceUsecase.
+func (u *usecase) InviteMember(ctx context.Context, id int, req domain.InviteMemberRequest) (domain.WorkspaceInvitation, error) {
+	workspace, err := u.member(ctx, id, domain.WorkspaceAdmin)
+	if err != nil {
+		return domain.WorkspaceInvitation{}, err
+	}
+	if workspace.Personal {
+		return domain.WorkspaceInvitation{}, fmt.Errorf("%w: create a team workspace to invite members", domain.ErrConflict)
+	}
+
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		return domain.WorkspaceInvitation{}, fmt.Errorf("%w: invalid email address", domain.ErrBadRequest)
+	}
+	if req.Role == "" {
+		req.Role = domain.WorkspaceMember
+	}
+	if !domain.IsValidWorkspaceRole(req.Role) {
+		return domain.WorkspaceInvitation{}, fmt.Errorf("%w: unknown workspace role %q", domain.ErrBadRequest, req.Role)
+	}
+	if req.Role == domain.WorkspaceOwner && !workspace.HasMemberRole(domain.WorkspaceOwner) {
+		return domain.WorkspaceInvitation{}, fmt.Errorf("%w: only owners can invite owners", domain.ErrForbidden)
+	}
+
+	inviter, _ := domain.UserFromContext(ctx)
+	rawToken, err := token.NewOpaque()
+	if err != nil {
+		return domain.WorkspaceInvitation{}, err
+	}
+
+	var created domain.WorkspaceInvitation
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		now := time.Now()
+		created, err = u.repo.CreateInvitation(txCtx, domain.WorkspaceInvitation{
+			WorkspaceId: id,
+			Email:       req.Email,
+			Role:        req.Role,
+			TokenHash:   token.Hash(rawToken),
+			InvitedById: inviter.Id,
+			ExpiresAt:   now.Add(invitationTTL),
+			CreatedAt:   &now,
+		})
+		if err != nil {
+			return err
+		}
+		if err := u.audit.Record(txCtx, domain.AuditEntry{
+			Action:      domain.AuditInvitationCreate,
+			TargetType:  "workspace_invitation",
+			TargetId:    strconv.Itoa(created.Id),
+			WorkspaceId: id,
+			After:       created,
+		}); err != nil {
+			return err
+		}
+
+		return u.email.Enqueue(txCtx, req.Email, mailer.TemplateWorkspaceInvite, map[string]interface{}{
+			"Inviter":   inviter.Username,
+			"Workspace": workspace.Name,
+			"Role":      req.Role,
+			"Link":      u.link("/invitations/accept", rawToken),
+			"ExpiresIn": "7 days",
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error inviting workspace member: %v", err)
+		return domain.WorkspaceInvitation{}, err
+	}
+	return created, nil
+}
+
+// RevokeInvitation implements domain.WorkspaceUsecase.
+func (u *usecase) RevokeInvitation(ctx context.Context, id, invitationId int) error {
+	if _, err := u.member(ctx, id, domain.WorkspaceAdmin); err != nil {
+		return err
+	}
+
+	invitation, err := u.repo.GetInvitation(ctx, invitationId)
+	if err != nil {
+		return notFound(err)
+	}
+	if invitation.WorkspaceId != id {
+		return domain.ErrNotFound
+	}
+	return u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		if err := u.repo.DeleteInvitation(txCtx, invitationId); err != nil {
+			return err
+		}
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:      domain.AuditInvitationRevoke,
+			TargetType:  "workspace_invitation",
+			TargetId:    strconv.Itoa(invitationId),
+			WorkspaceId: id,
+			Before:      invitation,
+		})
+	})
+}
+
+// AcceptInvitation implements domain.WorkspaceUsecase. The invitation is
+// bound to the invited address, so forwarding the link does not help.
+func (u *usecase) AcceptInvitation(ctx context.Context, rawToken string) (domain.Workspace, error) {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.Workspace{}, domain.ErrUnauthorized
+	}
+
+	var workspace domain.Workspace
+	err := u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		invitation, err := u.repo.FindInvitationByHash(txCtx, token.Hash(rawToken))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: invalid or expired invitation", domain.ErrBadRequest)
+			}
+			return err
+		}
+		if invitation.AcceptedAt != nil || time.Now().After(invitation.ExpiresAt) {
+			return fmt.Errorf("%w: invalid or expired invitation", domain.ErrBadRequest)
+		}
+		if !strings.EqualFold(invitation.Email, user.Email) {
+			return fmt.Errorf("%w: invitation was sent to another email address", domain.ErrForbidden)
+		}
+
+		workspace, err = u.repo.GetWorkspace(txCtx, invitation.WorkspaceId)
+		if err != nil {
+			return notFound(err)
+		}
+
+		member, err := u.repo.GetMembership(txCtx, invitation.WorkspaceId, user.Id)
+		switch {
+		case err == nil:
+			// already a member: keep the current role
+			workspace.MemberRole = member.Role
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			now := time.Now()
+			if _, err := u.repo.AddMember(txCtx, domain.WorkspaceMembership{
+				WorkspaceId: invitation.WorkspaceId,
+				UserId:      user.Id,
+				Role:        invitation.Role,
+				CreatedAt:   &now,
+			}); err != nil {
+				return err
+			}
+			workspace.MemberRole = invitation.Role
+		default:
+			return err
+		}
+
+		if err := u.repo.AcceptInvitation(txCtx, invitation.Id); err != nil {
+			return err
+		}
+		accepted := invitation
+		now := time.Now()
+		accepted.AcceptedAt = &now
+		return u.audit.Record(txCtx, domain.AuditEntry{
+			Action:      domain.AuditInvitationAccept,
+			TargetType:  "workspace_invitation",
+			TargetId:    strconv.Itoa(invitation.Id),
+			WorkspaceId: invitation.WorkspaceId,
+			Before:      invitation,
+			After:       accepted,
+		})
+	})
+	if err != nil {
+		logrus.Errorf("error accepting invitation: %v", err)
+		return domain.Workspace{}, err
+	}
+	return workspace, nil
+}
+
+// personal returns the user's personal workspace, creating it on first use
+// and adopting the ideas the user created before workspaces existed.
+func (u *usecase) personal(ctx context.Context, user domain.User) (domain.Workspace, error) {
+	workspace, err := u.repo.FindPersonalWorkspace(ctx, user.Id)
+	if err == nil {
+		workspace.MemberRole = domain.WorkspaceOwner
+		return workspace, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.Workspace{}, err
+	}
+
+	err = u.dbTx.StartTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		workspace, err = u.create(txCtx, user, user.Username, true)
+		if err != nil {
+			return err
+		}
+		return u.repo.AdoptUnscopedIdeas(txCtx, user.Id, workspace.Id)
+	})
+	if err != nil {
+		// a concurrent request may have won the unique index race
+		if existing, findErr := u.repo.FindPersonalWorkspace(ctx, user.Id); findErr == nil {
+			existing.MemberRole = domain.WorkspaceOwner
+			return existing, nil
+		}
+		logrus.Errorf("error creating personal workspace: %v", err)
+		return domain.Workspace{}, err
+	}
+	return workspace, nil
+}
+
+// create stores a workspace with user as its owner. It must run inside a
+// transaction.
+func (u *usecase) create(ctx context.Context, user domain.User, name string, personal bool) (domain.Workspace, error) {
+	now := time.Now()
+	workspace, err := u.repo.CreateWorkspace(ctx, domain.Workspace{
+		Name:           name,
+		OwnerId:        user.Id,
+		Personal:       personal,
+		PromptVersions: map[string]int{},
+		CreatedAt:      &now,
+		UpdatedAt:      &now,
+	})
+	if err != nil {
+		return domain.Workspace{}, err
+	}
+
+	if _, err := u.repo.AddMember(ctx, domain.WorkspaceMembership{
+		WorkspaceId: workspace.Id,
+		UserId:      user.Id,
+		Role:        domain.WorkspaceOwner,
+		CreatedAt:   &now,
+	}); err != nil {
+		return domain.Workspace{}, err
+	}
+
+	workspace.MemberRole = domain.WorkspaceOwner
+	return workspace, nil
+}
+
+// member loads workspace id for the caller and requires at least minRole.
+// Non-members get a not found so workspace ids cannot be probed.
+func (u *usecase) member(ctx context.Context, id int, minRole string) (domain.Workspace, error) {
+	user, ok := domain.UserFromContext(ctx)
+	if !ok {
+		return domain.Workspace{}, domain.ErrUnauthorized
+	}
+
+	workspace, err := u.repo.GetWorkspace(ctx, id)
+	if err != nil {
+		return domain.Workspace{}, notFound(err)
+	}
+	membership, err := u.repo.GetMembership(ctx, id, user.Id)
+	if err != nil {
+		return domain.Workspace{}, notFound(err)
+	}
+
+	workspace.MemberRole = membership.Role
+	if !workspace.HasMemberRole(minRole) {
+		return domain.Workspace{}, fmt.Errorf("%w: requires the %s workspace role", domain.ErrForbidden, minRole)
+	}
+	return workspace, nil
+}
+
+// keepOwner refuses to change target to role ("" when removed) if that would
+// leave the workspace without an owner.
+func (u *usecase) keepOwner(ctx context.Context, id int, target domain.WorkspaceMembership, role string) error {
+	if target.Role != domain.WorkspaceOwner || role == domain.WorkspaceOwner {
+		return nil
+	}
+	owners, err := u.repo.CountOwners(ctx, id)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return fmt.Errorf("%w: a workspace needs at least one owner", domain.ErrConflict)
+	}
+	return nil
+}
+
+func (u *usecase) link(path, rawToken string) string {
+	base := u.cfg.BASE_URL_FE
+	if base == "" {
+		base = u.cfg.BaseURL
+	}
+	return strings.TrimSuffix(base, "/") + path + "?token=" + url.QueryEscape(rawToken)
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrNotFound
+	}
+	return err
+}
+
+var (
+	uc *usecase
+)
+
+func NewWorkspaceUsecase(cfg config.Config, dbTx pkgDB.DatabaseTransaction, repo domain.WorkspaceRepository, promptRepo domain.PromptRepository, email domain.EmailUsecase, audit domain.AuditUsecase) domain.WorkspaceUsecase {
+	if uc == nil {
+		uc = &usecase{
+			cfg:        cfg,
+			dbTx:       dbTx,
+			repo:       repo,
+			promptRepo: promptRepo,
+			email:      email,
+			audit:      audit,
+		}
+	}
+	return uc
+}
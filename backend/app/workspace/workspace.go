@@ -0,0 +1,17 @@
+package workspace
+
+import (
+	"github.com/Kocannn/self-dunking-ai/config"
+	"github.com/Kocannn/self-dunking-ai/domain"
+	pkgDB "github.com/Kocannn/self-dunking-ai/pkg/database"
+)
+
+func InitWorkspaceRepository(db pkgDB.DatabaseTransaction) domain.WorkspaceRepository {
+	return NewWorkspaceRepository(db)
+}
+func InitWorkspaceUsecase(cfg config.Config, dbTx pkgDB.DatabaseTransaction, repo domain.WorkspaceRepository, promptRepo domain.PromptRepository, email domain.EmailUsecase, audit domain.AuditUsecase) domain.WorkspaceUsecase {
+	return NewWorkspaceUsecase(cfg, dbTx, repo, promptRepo, email, audit)
+}
+func InitWorkspaceHandler(usecase domain.WorkspaceUsecase) domain.WorkspaceHandler {
+	return NewWorkspaceHandler(usecase)
+}
@@ -39,16 +39,31 @@ var serveHttpCmd = &cobra.Command{
 
 		app := app.InitApp(cfg)
 
+		// mails go out without a separate worker process
+		mailCtx, stopMail := context.WithCancel(ctx)
+		defer stopMail()
+		go func() {
+			hostname, _ := os.Hostname()
+			err := app.Jobs.RunWorker(mailCtx, domain.WorkerOptions{
+				Id:          fmt.Sprintf("%s-%d-http", hostname, os.Getpid()),
+				Concurrency: 1,
+				Types:       []string{domain.JobEmail},
+			})
+			if err != nil {
+				ngelog.Error(ctx, "email worker failed", err)
+			}
+		}()
+
 		// route
 		router := registerHandler(app)
 
 		// build cors
 		muxCorsWithRouter := cors.New(cors.Options{
 			AllowedOrigins:   cfg.CORS_ALLOWED_ORIGINS,
-			AllowedHeaders:   append(cfg.CORS_ALLOWED_HEADERS, "Authorization", "Cache-Control", "X-Requested-With"),
+			AllowedHeaders:   append(cfg.CORS_ALLOWED_HEADERS, "Authorization", "Cache-Control", "X-Requested-With", "X-API-Key", "X-Workspace-Id", "X-Request-Id", "Accept-Language"),
 			AllowedMethods:   append(cfg.CORS_ALLOWED_METHODS, "OPTIONS"),
 			AllowCredentials: true,
-			ExposedHeaders:   []string{"Content-Type", "Content-Length", "Cache-Control"},
+			ExposedHeaders:   []string{"Content-Type", "Content-Length", "Content-Disposition", "Cache-Control", "Content-Language", "Retry-After", "X-Request-Id", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-Quota-Limit", "X-Quota-Remaining", "X-Quota-Reset", "X-Quota-Unit", "X-Quota-Period"},
 		}).Handler(router)
 
 		srv := &http.Server{
@@ -117,23 +132,186 @@ func registerHandler(app app.App) *mux.Router {
 
 	router := mux.NewRouter()
 	router.Use(app.Middleware.LogMiddleware)
+	router.Use(app.Middleware.SubjectMiddleware)
+	router.Use(app.Middleware.LocaleMiddleware)
 	router.HandleFunc("/health", health)
 
 	v1 := router.PathPrefix("/api/v1").Subrouter()
 
-	v1.HandleFunc("/submit-idea", app.IdeaHandler.SubmitIdea).Methods(http.MethodPost)
-	v1.HandleFunc("/defend-idea", app.IdeaHandler.DefendIdea).Methods(http.MethodPost)
-	v1.HandleFunc("/improve-idea", app.IdeaHandler.ImproveIdea).Methods(http.MethodPost)
+	// Authentication
+	auth := v1.PathPrefix("/auth").Subrouter()
+	auth.Use(app.Middleware.RateLimitMiddleware)
+	auth.HandleFunc("/register", app.UserHandler.Register).Methods(http.MethodPost)
+	auth.HandleFunc("/login", app.UserHandler.Login).Methods(http.MethodPost)
+	auth.HandleFunc("/refresh", app.UserHandler.Refresh).Methods(http.MethodPost)
+	auth.HandleFunc("/verify-email", app.UserHandler.VerifyEmail).Methods(http.MethodPost)
+	auth.HandleFunc("/forgot-password", app.UserHandler.ForgotPassword).Methods(http.MethodPost)
+	auth.HandleFunc("/reset-password", app.UserHandler.ResetPassword).Methods(http.MethodPost)
+	auth.HandleFunc("/oidc/login", app.UserHandler.OIDCLogin).Methods(http.MethodGet)
+	auth.HandleFunc("/oidc/callback", app.UserHandler.OIDCCallback).Methods(http.MethodGet)
+	auth.Handle("/logout", app.Middleware.AuthMiddleware("")(http.HandlerFunc(app.UserHandler.Logout))).Methods(http.MethodPost)
+	auth.Handle("/resend-verification", app.Middleware.AuthMiddleware("")(http.HandlerFunc(app.UserHandler.ResendVerification))).Methods(http.MethodPost)
+	auth.Handle("/me", app.Middleware.AuthMiddleware("")(http.HandlerFunc(app.UserHandler.Me))).Methods(http.MethodGet)
 
-	v1.HandleFunc("/get-idea/{id}", app.IdeaHandler.GetIdea).Methods(http.MethodGet)
+	// Share links are opened without an account
+	public := v1.PathPrefix("/public").Subrouter()
+	public.Use(app.Middleware.RateLimitMiddleware)
+	public.HandleFunc("/shares/{token}", app.ShareHandler.GetSharedView).Methods(http.MethodGet)
+
+	// Everything below requires a valid access token
+	api := v1.NewRoute().Subrouter()
+	api.Use(app.Middleware.AddressRateLimitMiddleware)
+	api.Use(app.Middleware.AuthMiddleware(""))
+	api.Use(app.Middleware.ScopeMiddleware)
+	api.Use(app.Middleware.RateLimitMiddleware)
+	api.Use(app.Middleware.WorkspaceMiddleware)
+
+	// Routes calling the model count against the plan quotas
+	llm := api.NewRoute().Subrouter()
+	llm.Use(app.Middleware.QuotaMiddleware)
+	// every model call stores an evaluation, streamed GETs included
+	llm.Use(app.Middleware.RequirePermission(domain.PermIdeaWriteOwn))
+	llm.HandleFunc("/submit-idea", app.IdeaHandler.SubmitIdea).Methods(http.MethodPost)
+	llm.HandleFunc("/defend-idea", app.IdeaHandler.DefendIdea).Methods(http.MethodPost)
+	llm.HandleFunc("/improve-idea", app.IdeaHandler.ImproveIdea).Methods(http.MethodPost)
+	llm.HandleFunc("/ideas/{id}/panel", app.IdeaHandler.PanelReview).Methods(http.MethodPost)
+	llm.HandleFunc("/compare", app.IdeaHandler.CompareIdeas).Methods(http.MethodPost)
+	llm.HandleFunc("/batches", app.BatchHandler.CreateBatch).Methods(http.MethodPost)
+	llm.HandleFunc("/jobs", app.JobHandler.EnqueueJob).Methods(http.MethodPost)
 
 	// Streaming endpoints
-	v1.HandleFunc("/stream/submit-idea/{id}", app.IdeaHandler.StreamSubmitIdea).Methods(http.MethodGet)
-	v1.HandleFunc("/stream/submit-idea", app.IdeaHandler.StreamSubmitIdea).Methods(http.MethodPost)
-	// v1.HandleFunc("/stream/defend-idea", app.IdeaHandler.StreamDefendIdea).Methods(http.MethodPost)
-	// v1.HandleFunc("/stream/improve-idea", app.IdeaHandler.StreamImproveIdea).Methods(http.MethodPost)
+	llm.HandleFunc("/stream/submit-idea/{id}", app.IdeaHandler.StreamSubmitIdea).Methods(http.MethodGet)
+	llm.HandleFunc("/stream/submit-idea", app.IdeaHandler.StreamSubmitIdea).Methods(http.MethodPost)
+	// llm.HandleFunc("/stream/defend-idea", app.IdeaHandler.StreamDefendIdea).Methods(http.MethodPost)
+	// llm.HandleFunc("/stream/improve-idea", app.IdeaHandler.StreamImproveIdea).Methods(http.MethodPost)
+
+	api.HandleFunc("/get-idea/{id}", app.IdeaHandler.GetIdea).Methods(http.MethodGet)
+
+	// Ideas; the auto_tag call of a new idea is checked against the quota in
+	// the usecase
+	api.HandleFunc("/ideas", app.IdeaHandler.ListIdeas).Methods(http.MethodGet)
+	api.HandleFunc("/ideas", app.IdeaHandler.SubmitIdeaStream).Methods(http.MethodPost)
+	api.HandleFunc("/ideas/{id}", app.IdeaHandler.GetIdea).Methods(http.MethodGet)
+	api.HandleFunc("/ideas/{id}", app.IdeaHandler.UpdateIdea).Methods(http.MethodPatch)
+	api.HandleFunc("/ideas/{id}", app.IdeaHandler.DeleteIdea).Methods(http.MethodDelete)
+	api.HandleFunc("/ideas/{id}/restore", app.IdeaHandler.RestoreIdea).Methods(http.MethodPost)
+	api.HandleFunc("/ideas/{id}/transitions", app.IdeaHandler.GetIdeaWorkflow).Methods(http.MethodGet)
+	api.HandleFunc("/ideas/{id}/report", app.IdeaHandler.GetIdeaReport).Methods(http.MethodGet)
+	api.HandleFunc("/ideas/{id}/transitions", app.IdeaHandler.TransitionIdea).Methods(http.MethodPost)
+	api.HandleFunc("/ideas/{id}/tags", app.IdeaHandler.AddIdeaTags).Methods(http.MethodPost)
+	api.HandleFunc("/ideas/{id}/tags/{tagId}", app.IdeaHandler.RemoveIdeaTag).Methods(http.MethodDelete)
+	api.HandleFunc("/compare/{id}", app.IdeaHandler.GetComparison).Methods(http.MethodGet)
+	api.HandleFunc("/submit-idea-stream", app.IdeaHandler.SubmitIdeaStream).Methods(http.MethodPost)
+
+	// Workspace tag vocabulary
+	api.HandleFunc("/tags", app.TagHandler.ListTags).Methods(http.MethodGet)
+	api.HandleFunc("/tags", app.TagHandler.CreateTag).Methods(http.MethodPost)
+	api.HandleFunc("/tags/{id}", app.TagHandler.DeleteTag).Methods(http.MethodDelete)
+
+	// Workspace evaluation rubrics
+	api.HandleFunc("/rubrics", app.RubricHandler.ListRubrics).Methods(http.MethodGet)
+	api.HandleFunc("/rubrics", app.RubricHandler.CreateRubric).Methods(http.MethodPost)
+	api.HandleFunc("/rubrics/{id}", app.RubricHandler.GetRubric).Methods(http.MethodGet)
+	api.HandleFunc("/rubrics/{id}", app.RubricHandler.UpdateRubric).Methods(http.MethodPut)
+	api.HandleFunc("/rubrics/{id}", app.RubricHandler.DeleteRubric).Methods(http.MethodDelete)
+
+	// Reviewer personas
+	api.HandleFunc("/personas", app.PersonaHandler.ListPersonas).Methods(http.MethodGet)
+	api.HandleFunc("/personas", app.PersonaHandler.CreatePersona).Methods(http.MethodPost)
+	api.HandleFunc("/personas/{id}", app.PersonaHandler.UpdatePersona).Methods(http.MethodPut)
+	api.HandleFunc("/personas/{id}", app.PersonaHandler.DeletePersona).Methods(http.MethodDelete)
+
+	api.HandleFunc("/search", app.SearchHandler.Search).Methods(http.MethodGet)
+
+	// Batch evaluation
+	api.HandleFunc("/batches", app.BatchHandler.ListBatches).Methods(http.MethodGet)
+	api.HandleFunc("/batches/{id}", app.BatchHandler.GetBatch).Methods(http.MethodGet)
+	api.HandleFunc("/batches/{id}/items", app.BatchHandler.ListBatchItems).Methods(http.MethodGet)
+	api.HandleFunc("/batches/{id}/events", app.BatchHandler.StreamBatch).Methods(http.MethodGet)
+	api.HandleFunc("/batches/{id}/results", app.BatchHandler.ExportBatch).Methods(http.MethodGet)
+	api.HandleFunc("/batches/{id}/cancel", app.BatchHandler.CancelBatch).Methods(http.MethodPost)
+
+	// Background jobs
+	api.HandleFunc("/jobs", app.JobHandler.ListJobs).Methods(http.MethodGet)
+	api.HandleFunc("/jobs/{id}", app.JobHandler.GetJob).Methods(http.MethodGet)
+	api.HandleFunc("/jobs/{id}/cancel", app.JobHandler.CancelJob).Methods(http.MethodPost)
+	api.HandleFunc("/jobs/{id}/retry", app.JobHandler.RetryJob).Methods(http.MethodPost)
+
+	// Share links
+	api.HandleFunc("/shares", app.ShareHandler.ListShareLinks).Methods(http.MethodGet)
+	api.HandleFunc("/shares", app.ShareHandler.CreateShareLink).Methods(http.MethodPost)
+	api.HandleFunc("/shares/{id}", app.ShareHandler.RevokeShareLink).Methods(http.MethodDelete)
+
+	// Comments
+	api.HandleFunc("/ideas/{id}/comments", app.CommentHandler.ListComments).Methods(http.MethodGet)
+	api.HandleFunc("/ideas/{id}/comments", app.CommentHandler.CreateComment).Methods(http.MethodPost)
+	api.HandleFunc("/comments/{id}", app.CommentHandler.UpdateComment).Methods(http.MethodPatch)
+	api.HandleFunc("/comments/{id}", app.CommentHandler.DeleteComment).Methods(http.MethodDelete)
+	api.HandleFunc("/comments/{id}/history", app.CommentHandler.ListCommentHistory).Methods(http.MethodGet)
+
+	api.HandleFunc("/evaluations/{id}/feedback", app.IdeaHandler.RateEvaluation).Methods(http.MethodPost)
+
+	api.HandleFunc("/usage", app.UsageHandler.GetUsage).Methods(http.MethodGet)
+
+	// Team workspaces
+	api.HandleFunc("/workspaces", app.WorkspaceHandler.ListWorkspaces).Methods(http.MethodGet)
+	api.HandleFunc("/workspaces", app.WorkspaceHandler.CreateWorkspace).Methods(http.MethodPost)
+	api.HandleFunc("/workspaces/{id}", app.WorkspaceHandler.GetWorkspace).Methods(http.MethodGet)
+	api.HandleFunc("/workspaces/{id}", app.WorkspaceHandler.UpdateWorkspace).Methods(http.MethodPatch)
+	api.HandleFunc("/workspaces/{id}", app.WorkspaceHandler.DeleteWorkspace).Methods(http.MethodDelete)
+	api.HandleFunc("/workspaces/{id}/members", app.WorkspaceHandler.ListMembers).Methods(http.MethodGet)
+	api.HandleFunc("/workspaces/{id}/members/{userId}", app.WorkspaceHandler.UpdateMember).Methods(http.MethodPut)
+	api.HandleFunc("/workspaces/{id}/members/{userId}", app.WorkspaceHandler.RemoveMember).Methods(http.MethodDelete)
+	api.HandleFunc("/workspaces/{id}/invitations", app.WorkspaceHandler.ListInvitations).Methods(http.MethodGet)
+	api.HandleFunc("/workspaces/{id}/invitations", app.WorkspaceHandler.InviteMember).Methods(http.MethodPost)
+	api.HandleFunc("/workspaces/{id}/invitations/{invitationId}", app.WorkspaceHandler.RevokeInvitation).Methods(http.MethodDelete)
+	api.HandleFunc("/invitations/accept", app.WorkspaceHandler.AcceptInvitation).Methods(http.MethodPost)
+
+	// Personal API keys
+	api.HandleFunc("/api-keys", app.APIKeyHandler.ListAPIKeys).Methods(http.MethodGet)
+	api.HandleFunc("/api-keys", app.APIKeyHandler.CreateAPIKey).Methods(http.MethodPost)
+	api.HandleFunc("/api-keys/{id}", app.APIKeyHandler.RevokeAPIKey).Methods(http.MethodDelete)
+
+	admin := api.PathPrefix("/admin").Subrouter()
+
+	// Prompt registry
+	promptAdmin := admin.NewRoute().Subrouter()
+	promptAdmin.Use(app.Middleware.RequirePermission(domain.PermPromptManage))
+	promptAdmin.HandleFunc("/prompts", app.PromptHandler.ListPrompts).Methods(http.MethodGet)
+	promptAdmin.HandleFunc("/prompts", app.PromptHandler.CreatePrompt).Methods(http.MethodPost)
+	promptAdmin.HandleFunc("/prompts/{id}", app.PromptHandler.GetPrompt).Methods(http.MethodGet)
+	promptAdmin.HandleFunc("/prompts/{id}", app.PromptHandler.DeletePrompt).Methods(http.MethodDelete)
+	promptAdmin.HandleFunc("/prompts/{id}/activate", app.PromptHandler.ActivatePrompt).Methods(http.MethodPost)
+
+	// Prompt experiments
+	promptAdmin.HandleFunc("/experiments", app.ExperimentHandler.ListExperiments).Methods(http.MethodGet)
+	promptAdmin.HandleFunc("/experiments", app.ExperimentHandler.CreateExperiment).Methods(http.MethodPost)
+	promptAdmin.HandleFunc("/experiments/{id}", app.ExperimentHandler.GetExperiment).Methods(http.MethodGet)
+	promptAdmin.HandleFunc("/experiments/{id}/status", app.ExperimentHandler.UpdateExperimentStatus).Methods(http.MethodPut)
+	promptAdmin.HandleFunc("/experiments/{id}/metrics", app.ExperimentHandler.GetExperimentMetrics).Methods(http.MethodGet)
+
+	// User management
+	userAdmin := admin.NewRoute().Subrouter()
+	userAdmin.Use(app.Middleware.RequirePermission(domain.PermUserManage))
+	userAdmin.HandleFunc("/users", app.UserHandler.ListUsers).Methods(http.MethodGet)
+	userAdmin.HandleFunc("/users/{id}/role", app.UserHandler.UpdateRole).Methods(http.MethodPut)
+	userAdmin.HandleFunc("/users/{id}/plan", app.UserHandler.UpdatePlan).Methods(http.MethodPut)
+
+	usageAdmin := admin.NewRoute().Subrouter()
+	usageAdmin.Use(app.Middleware.RequirePermission(domain.PermUsageRead))
+	usageAdmin.HandleFunc("/users/{id}/usage", app.UsageHandler.GetUserUsage).Methods(http.MethodGet)
+
+	// Audit log
+	auditAdmin := admin.NewRoute().Subrouter()
+	auditAdmin.Use(app.Middleware.RequirePermission(domain.PermAuditRead))
+	auditAdmin.HandleFunc("/audit-logs", app.AuditHandler.ListAuditLogs).Methods(http.MethodGet)
+	auditAdmin.HandleFunc("/audit-logs/export", app.AuditHandler.ExportAuditLogs).Methods(http.MethodGet)
 
-	v1.HandleFunc("/submit-idea-stream", app.IdeaHandler.SubmitIdeaStream).Methods(http.MethodPost)
+	// Job queue
+	jobAdmin := admin.NewRoute().Subrouter()
+	jobAdmin.Use(app.Middleware.RequirePermission(domain.PermJobManage))
+	jobAdmin.HandleFunc("/jobs", app.JobHandler.ListAllJobs).Methods(http.MethodGet)
+	jobAdmin.HandleFunc("/jobs/stats", app.JobHandler.GetJobStats).Methods(http.MethodGet)
 
 	return router
 }
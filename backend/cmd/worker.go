@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Kocannn/self-dunking-ai/app"
+	"github.com/Kocannn/self-dunking-ai/config"
+	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	workerConcurrency int
+	workerTypes       []string
+)
+
+var workerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "runs the background job queue",
+	Long:  "the worker command claims queued jobs from the database and runs them until it is stopped",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		cfg := config.GetConfig()
+		app := app.InitApp(cfg)
+
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "worker"
+		}
+		err = app.Jobs.RunWorker(ctx, domain.WorkerOptions{
+			Id:          fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+			Concurrency: workerConcurrency,
+			Types:       workerTypes,
+		})
+		if err != nil {
+			logrus.Fatal("worker failed: ", err)
+		}
+	},
+}
+
+func init() {
+	workerCmd.Flags().IntVar(&workerConcurrency, "concurrency", 2, "how many jobs run at the same time")
+	workerCmd.Flags().StringSliceVar(&workerTypes, "types", nil, "only run these job types, all when empty")
+	rootCmd.AddCommand(workerCmd)
+}
@@ -21,11 +21,28 @@ type (
 		JWT_SECRET_KEY  string
 		OLLAMA_HOST     string
 		OLLAMA_MODEL    string
+		// OLLAMA_EMBED_MODEL computes idea embeddings, OLLAMA_MODEL when empty
+		OLLAMA_EMBED_MODEL string
+
+		JWT_ACCESS_TOKEN_MINUTES int
+		JWT_REFRESH_TOKEN_DAYS   int
+
+		OIDC_ISSUER_URL    string
+		OIDC_CLIENT_ID     string
+		OIDC_CLIENT_SECRET string
+		OIDC_REDIRECT_URL  string
+		OIDC_SCOPES        []string
+		OIDC_ROLE_CLAIM    string
+		OIDC_ROLE_MAPPING  map[string]string
+
+		// BOOTSTRAP_ADMIN_EMAILS become admins once their email is verified
+		BOOTSTRAP_ADMIN_EMAILS []string
 
 		SMTP_HOST     string
 		SMTP_PORT     string
 		SMTP_EMAIL    string
 		SMTP_PASSWORD string
+		SMTP_FROM     string
 
 		CORS_ALLOWED_ORIGINS []string
 		CORS_ALLOWED_METHODS []string
@@ -69,23 +86,56 @@ func GetConfig() Config {
 			methods = strings.Split(corsMethods, ",")
 		}
 
+		// OIDC_ROLE_MAPPING="idp-admins=admin,idp-reviewers=reviewer"
+		oidcRoles := map[string]string{}
+		for _, pair := range strings.Split(viper.GetString("OIDC_ROLE_MAPPING"), ",") {
+			claim, role, ok := strings.Cut(pair, "=")
+			if ok {
+				oidcRoles[strings.TrimSpace(claim)] = strings.TrimSpace(role)
+			}
+		}
+
+		var oidcScopes []string
+		if scopes := viper.GetString("OIDC_SCOPES"); scopes != "" {
+			oidcScopes = strings.Fields(strings.ReplaceAll(scopes, ",", " "))
+		}
+
+		var bootstrapAdmins []string
+		for _, email := range strings.Split(viper.GetString("BOOTSTRAP_ADMIN_EMAILS"), ",") {
+			if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+				bootstrapAdmins = append(bootstrapAdmins, email)
+			}
+		}
+
 		c = &Config{
-			APP_ENV:              viper.GetString("APP_ENV"),
-			APP_NAME:             viper.GetString("APP_NAME"),
-			APP_PORT:             viper.GetString("APP_PORT"),
-			DB_POSTGRES_DSN:      viper.GetString("DB_POSTGRES_DSN"),
-			JWT_SECRET_KEY:       viper.GetString("JWT_SECRET_KEY"),
-			SMTP_HOST:            viper.GetString("SMTP_HOST"),
-			SMTP_PORT:            viper.GetString("SMTP_PORT"),
-			SMTP_EMAIL:           viper.GetString("SMTP_EMAIL"),
-			SMTP_PASSWORD:        viper.GetString("SMTP_PASSWORD"),
-			OLLAMA_HOST:          viper.GetString("OLLAMA_HOST"),
-			OLLAMA_MODEL:         viper.GetString("OLLAMA_MODEL"),
-			CORS_ALLOWED_ORIGINS: origins,
-			CORS_ALLOWED_METHODS: methods,
-			CORS_ALLOWED_HEADERS: headers,
-			BaseURL:              viper.GetString("BASE_URL"),
-			BASE_URL_FE:          viper.GetString("BASE_URL_FE"),
+			APP_ENV:                  viper.GetString("APP_ENV"),
+			APP_NAME:                 viper.GetString("APP_NAME"),
+			APP_PORT:                 viper.GetString("APP_PORT"),
+			DB_POSTGRES_DSN:          viper.GetString("DB_POSTGRES_DSN"),
+			JWT_SECRET_KEY:           viper.GetString("JWT_SECRET_KEY"),
+			JWT_ACCESS_TOKEN_MINUTES: viper.GetInt("JWT_ACCESS_TOKEN_MINUTES"),
+			JWT_REFRESH_TOKEN_DAYS:   viper.GetInt("JWT_REFRESH_TOKEN_DAYS"),
+			OIDC_ISSUER_URL:          viper.GetString("OIDC_ISSUER_URL"),
+			OIDC_CLIENT_ID:           viper.GetString("OIDC_CLIENT_ID"),
+			OIDC_CLIENT_SECRET:       viper.GetString("OIDC_CLIENT_SECRET"),
+			OIDC_REDIRECT_URL:        viper.GetString("OIDC_REDIRECT_URL"),
+			OIDC_SCOPES:              oidcScopes,
+			OIDC_ROLE_CLAIM:          viper.GetString("OIDC_ROLE_CLAIM"),
+			OIDC_ROLE_MAPPING:        oidcRoles,
+			BOOTSTRAP_ADMIN_EMAILS:   bootstrapAdmins,
+			SMTP_HOST:                viper.GetString("SMTP_HOST"),
+			SMTP_PORT:                viper.GetString("SMTP_PORT"),
+			SMTP_EMAIL:               viper.GetString("SMTP_EMAIL"),
+			SMTP_PASSWORD:            viper.GetString("SMTP_PASSWORD"),
+			SMTP_FROM:                viper.GetString("SMTP_FROM"),
+			OLLAMA_HOST:              viper.GetString("OLLAMA_HOST"),
+			OLLAMA_MODEL:             viper.GetString("OLLAMA_MODEL"),
+			OLLAMA_EMBED_MODEL:       viper.GetString("OLLAMA_EMBED_MODEL"),
+			CORS_ALLOWED_ORIGINS:     origins,
+			CORS_ALLOWED_METHODS:     methods,
+			CORS_ALLOWED_HEADERS:     headers,
+			BaseURL:                  viper.GetString("BASE_URL"),
+			BASE_URL_FE:              viper.GetString("BASE_URL_FE"),
 		}
 	}
 
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// APIKeyPrefix marks personal API keys so they can be told apart from JWTs
+// in the Authorization header.
+const APIKeyPrefix = "im_"
+
+// APIKey is a personal, named credential for scripts and CI. Only the hash is
+// stored; the plain key is returned once on creation.
+type APIKey struct {
+	Id         int        `json:"id" gorm:"primaryKey"`
+	UserId     int        `json:"user_id" gorm:"index;not null"`
+	Name       string     `json:"name" gorm:"type:varchar(128);not null"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(16);not null"`
+	KeyHash    string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  *time.Time `json:"created_at"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type APIKeyHandler interface {
+	ListAPIKeys(w http.ResponseWriter, r *http.Request)
+	CreateAPIKey(w http.ResponseWriter, r *http.Request)
+	RevokeAPIKey(w http.ResponseWriter, r *http.Request)
+}
+
+type APIKeyUsecase interface {
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	CreateAPIKey(ctx context.Context, req CreateAPIKeyRequest) (CreateAPIKeyResponse, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+}
+
+type APIKeyRepository interface {
+	ListAPIKeys(ctx context.Context, userId int) ([]APIKey, error)
+	GetAPIKey(ctx context.Context, id int) (APIKey, error)
+	FindAPIKeyByHash(ctx context.Context, hash string) (APIKey, error)
+	CreateAPIKey(ctx context.Context, key APIKey) (APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+	// TouchAPIKey records usage, throttled to avoid a write per request
+	TouchAPIKey(ctx context.Context, id int) error
+}
@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	AuditUserLogin         = "user.login"
+	AuditUserRoleUpdate    = "user.role_update"
+	AuditUserPlanUpdate    = "user.plan_update"
+	AuditUserPasswordReset = "user.password_reset"
+
+	AuditAPIKeyCreate = "api_key.create"
+	AuditAPIKeyRevoke = "api_key.revoke"
+
+	AuditPromptCreate   = "prompt.create"
+	AuditPromptActivate = "prompt.activate"
+	AuditPromptDelete   = "prompt.delete"
+
+	AuditIdeaUpdate  = "idea.update"
+	AuditIdeaDelete  = "idea.delete"
+	AuditIdeaRestore = "idea.restore"
+	AuditIdeaTag     = "idea.tag"
+	AuditIdeaUntag   = "idea.untag"
+	AuditIdeaStatus  = "idea.status_update"
+
+	AuditTagCreate = "tag.create"
+	AuditTagDelete = "tag.delete"
+
+	AuditRubricCreate = "rubric.create"
+	AuditRubricUpdate = "rubric.update"
+	AuditRubricDelete = "rubric.delete"
+
+	AuditPersonaCreate = "persona.create"
+	AuditPersonaUpdate = "persona.update"
+	AuditPersonaDelete = "persona.delete"
+
+	AuditBatchCreate = "batch.create"
+	AuditBatchCancel = "batch.cancel"
+
+	AuditShareCreate = "share.create"
+	AuditShareRevoke = "share.revoke"
+
+	AuditCommentDelete = "comment.delete"
+
+	AuditJobCancel = "job.cancel"
+	AuditJobRetry  = "job.retry"
+
+	AuditExperimentCreate       = "experiment.create"
+	AuditExperimentStatusUpdate = "experiment.status_update"
+
+	AuditWorkspaceCreate  = "workspace.create"
+	AuditWorkspaceUpdate  = "workspace.update"
+	AuditWorkspaceDelete  = "workspace.delete"
+	AuditMemberUpdate     = "workspace.member_update"
+	AuditMemberRemove     = "workspace.member_remove"
+	AuditInvitationCreate = "workspace.invitation_create"
+	AuditInvitationRevoke = "workspace.invitation_revoke"
+	AuditInvitationAccept = "workspace.invitation_accept"
+)
+
+// AuditLog is an append-only record of who changed what. Before and After
+// only hold the fields that changed; a create has no Before and a delete no
+// After.
+type AuditLog struct {
+	Id          int                    `json:"id" gorm:"primaryKey"`
+	ActorId     int                    `json:"actor_id" gorm:"index"`
+	ActorEmail  string                 `json:"actor_email" gorm:"type:varchar(255)"`
+	APIKeyId    int                    `json:"api_key_id,omitempty"`
+	Action      string                 `json:"action" gorm:"type:varchar(64);not null;index"`
+	TargetType  string                 `json:"target_type" gorm:"type:varchar(64);index:idx_audit_target"`
+	TargetId    string                 `json:"target_id" gorm:"type:varchar(64);index:idx_audit_target"`
+	WorkspaceId int                    `json:"workspace_id,omitempty" gorm:"index"`
+	RequestId   string                 `json:"request_id" gorm:"type:varchar(64);index"`
+	IP          string                 `json:"ip" gorm:"type:varchar(64)"`
+	Before      map[string]interface{} `json:"before,omitempty" gorm:"serializer:json"`
+	After       map[string]interface{} `json:"after,omitempty" gorm:"serializer:json"`
+	CreatedAt   *time.Time             `json:"created_at" gorm:"index"`
+}
+
+// AuditEntry describes a change to record. Before and After take the
+// domain values themselves; the diff is computed when the entry is written.
+type AuditEntry struct {
+	Action     string
+	TargetType string
+	TargetId   string
+	Before     interface{}
+	After      interface{}
+	// ActorId names the actor when the context has no user yet, e.g. on
+	// login
+	ActorId int
+	// WorkspaceId overrides the active workspace for changes made to
+	// another one
+	WorkspaceId int
+}
+
+type AuditFilter struct {
+	ActorId     int
+	Action      string
+	TargetType  string
+	TargetId    string
+	WorkspaceId int
+	RequestId   string
+	From        *time.Time
+	To          *time.Time
+	Limit       int
+	Offset      int
+}
+
+type AuditHandler interface {
+	ListAuditLogs(w http.ResponseWriter, r *http.Request)
+	ExportAuditLogs(w http.ResponseWriter, r *http.Request)
+}
+
+type AuditUsecase interface {
+	// Record must be given the transaction context of the change so both
+	// commit or roll back together
+	Record(ctx context.Context, entry AuditEntry) error
+	ListAuditLogs(ctx context.Context, filter AuditFilter) ([]AuditLog, error)
+	// ExportAuditLogs writes every matching entry to w as CSV
+	ExportAuditLogs(ctx context.Context, filter AuditFilter, w io.Writer) error
+}
+
+type AuditRepository interface {
+	CreateAuditLog(ctx context.Context, log AuditLog) error
+	ListAuditLogs(ctx context.Context, filter AuditFilter) ([]AuditLog, error)
+	// EnsureAppendOnly installs a trigger rejecting updates and deletes of
+	// audit rows
+	EnsureAppendOnly(ctx context.Context) error
+}
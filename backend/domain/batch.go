@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	BatchPending   = "pending"
+	BatchRunning   = "running"
+	BatchCompleted = "completed"
+	BatchFailed    = "failed"
+	BatchCancelled = "cancelled"
+)
+
+const (
+	BatchItemPending   = "pending"
+	BatchItemSucceeded = "succeeded"
+	BatchItemFailed    = "failed"
+	BatchItemCancelled = "cancelled"
+)
+
+const (
+	BatchFormatCSV   = "csv"
+	BatchFormatJSONL = "jsonl"
+)
+
+const (
+	MaxBatchRows        = 500
+	MaxBatchUploadBytes = 5 << 20
+	// DefaultBatchConcurrency is how many rows of one job are evaluated at
+	// the same time when the upload does not say
+	DefaultBatchConcurrency = 2
+	MaxBatchConcurrency     = 4
+	// MaxBatchModelCalls bounds the model calls of all batch jobs of one
+	// server together, so a large upload cannot starve interactive use
+	MaxBatchModelCalls = 4
+)
+
+// BatchJob evaluates every row of an uploaded CSV or JSONL file as a new
+// idea. The counters are updated as rows finish, so polling the job shows
+// its progress.
+type BatchJob struct {
+	Id          int    `json:"id" gorm:"primaryKey"`
+	WorkspaceId int    `json:"workspace_id" gorm:"index"`
+	UserId      int    `json:"user_id" gorm:"index"`
+	Status      string `json:"status" gorm:"type:varchar(16);not null;default:pending;index"`
+	Format      string `json:"format" gorm:"type:varchar(8);not null"`
+	// Filename is the name of the uploaded file, empty for a raw body
+	Filename    string `json:"filename,omitempty" gorm:"type:varchar(255)"`
+	Concurrency int    `json:"concurrency"`
+	// RubricId and Persona are applied to the critique of every row
+	RubricId  int    `json:"rubric_id,omitempty"`
+	Persona   string `json:"persona,omitempty" gorm:"type:varchar(64)"`
+	Total     int    `json:"total"`
+	Processed int    `json:"processed"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	// Error says why a job stopped early
+	Error      string     `json:"error,omitempty" gorm:"type:text"`
+	CreatedAt  *time.Time `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+// Finished reports whether the job reached a final status.
+func (j BatchJob) Finished() bool {
+	return j.Status == BatchCompleted || j.Status == BatchFailed || j.Status == BatchCancelled
+}
+
+// BatchItem is one row of a batch upload and the outcome of its evaluation.
+type BatchItem struct {
+	Id    int `json:"id" gorm:"primaryKey"`
+	JobId int `json:"job_id" gorm:"not null;index"`
+	// Line is the line of the upload the item was read from
+	Line   int    `json:"line"`
+	Status string `json:"status" gorm:"type:varchar(16);not null;default:pending;index"`
+	Idea   string `json:"idea" gorm:"type:text"`
+	// IdeaId is the idea created for the row, stored together with the idea
+	// so a resumed row does not create another one
+	IdeaId int      `json:"idea_id,omitempty"`
+	Score  *float64 `json:"score"`
+	// Output is the critique of the row
+	Output    string     `json:"output,omitempty" gorm:"type:text"`
+	Error     string     `json:"error,omitempty" gorm:"type:text"`
+	UpdatedAt *time.Time `json:"updated_at"`
+
+	IdeaFields `gorm:"embedded"`
+}
+
+// BatchRequest describes an upload. Format may be empty, it is then taken
+// from the file name or the content type.
+type BatchRequest struct {
+	Format      string
+	Filename    string
+	ContentType string
+	Concurrency int
+	RubricId    int
+	Persona     string
+}
+
+type BatchHandler interface {
+	CreateBatch(w http.ResponseWriter, r *http.Request)
+	ListBatches(w http.ResponseWriter, r *http.Request)
+	GetBatch(w http.ResponseWriter, r *http.Request)
+	ListBatchItems(w http.ResponseWriter, r *http.Request)
+	CancelBatch(w http.ResponseWriter, r *http.Request)
+	StreamBatch(w http.ResponseWriter, r *http.Request)
+	ExportBatch(w http.ResponseWriter, r *http.Request)
+}
+
+type BatchUsecase interface {
+	// CreateBatch stores the rows of upload and queues a JobBatch that
+	// evaluates them
+	CreateBatch(ctx context.Context, req BatchRequest, upload io.Reader) (BatchJob, error)
+	ListBatches(ctx context.Context) ([]BatchJob, error)
+	GetBatch(ctx context.Context, id int) (BatchJob, error)
+	// ListBatchItems returns the rows of a job, only those in status when
+	// it is not empty
+	ListBatchItems(ctx context.Context, id int, status string) ([]BatchItem, error)
+	CancelBatch(ctx context.Context, id int) (BatchJob, error)
+	// WatchBatch calls send with the job whenever its progress changes,
+	// until it is finished or ctx is done
+	WatchBatch(ctx context.Context, id int, send func(BatchJob) error) error
+	// ExportBatch writes the rows and their results to w as CSV or JSONL
+	ExportBatch(ctx context.Context, id int, format string, w io.Writer) error
+	// RunJob evaluates the pending rows of a JobBatch. A job picked up again
+	// after its worker died resumes where it stopped.
+	RunJob(ctx context.Context, job Job) (interface{}, error)
+}
+
+// BatchRepository works on the batch jobs of the active workspace.
+type BatchRepository interface {
+	CreateBatchJob(ctx context.Context, job BatchJob) (BatchJob, error)
+	CreateBatchItems(ctx context.Context, items []BatchItem) error
+	GetBatchJob(ctx context.Context, id int) (BatchJob, error)
+	// ListBatchJobs lists the jobs of userId, or of everybody when it is 0
+	ListBatchJobs(ctx context.Context, userId int) ([]BatchJob, error)
+	ListBatchItems(ctx context.Context, jobId int, status string) ([]BatchItem, error)
+	// UpdateBatchStatus only moves a job that is in one of from
+	UpdateBatchStatus(ctx context.Context, id int, from []string, to, reason string) error
+	// SetBatchItemIdea records the idea created for an item
+	SetBatchItemIdea(ctx context.Context, id, ideaId int) error
+	// FinishBatchItem stores the outcome of an item that is pending, or was
+	// cancelled while it ran; finished items are left alone
+	FinishBatchItem(ctx context.Context, item BatchItem) error
+	// CountBatchItem adds a finished item in status to the job counters
+	CountBatchItem(ctx context.Context, jobId int, status string) error
+	CancelPendingBatchItems(ctx context.Context, jobId int) error
+}
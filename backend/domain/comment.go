@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	// MaxCommentLength bounds a comment body, in characters
+	MaxCommentLength = 5000
+	// MaxCommentMentions bounds how many members one comment notifies
+	MaxCommentMentions = 10
+)
+
+// Comment is a remark on an idea or on one of its evaluations. Replies point
+// at a top level comment through ParentId; threads are one level deep.
+type Comment struct {
+	Id          int `json:"id" gorm:"primaryKey"`
+	WorkspaceId int `json:"workspace_id" gorm:"index"`
+	IdeaId      int `json:"idea_id" gorm:"index;not null"`
+	// EvaluationId is the evaluation discussed, 0 for the idea itself
+	EvaluationId int `json:"evaluation_id,omitempty" gorm:"index"`
+	// ParentId is the comment answered, 0 for a new thread
+	ParentId int    `json:"parent_id,omitempty" gorm:"index"`
+	UserId   int    `json:"user_id" gorm:"index;not null"`
+	Body     string `json:"body" gorm:"type:text;not null"`
+	// Anchor is the span of the evaluation output the comment is about
+	Anchor *CommentAnchor `json:"anchor,omitempty" gorm:"serializer:json"`
+	// Mentions are the ids of the members mentioned in Body
+	Mentions  []int      `json:"mentions,omitempty" gorm:"serializer:json"`
+	EditedAt  *time.Time `json:"edited_at"`
+	DeletedAt *time.Time `json:"deleted_at"`
+	CreatedAt *time.Time `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+	User      *User      `json:"user,omitempty" gorm:"foreignKey:UserId"`
+	Replies   []Comment  `json:"replies,omitempty" gorm:"-"`
+}
+
+// CommentAnchor marks characters Start to End of an evaluation output. Quote
+// keeps the text as it was, so the anchor still reads if the output moves.
+type CommentAnchor struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Quote string `json:"quote"`
+}
+
+// CommentRevision is a body a comment had before it was edited or deleted.
+type CommentRevision struct {
+	Id        int        `json:"id" gorm:"primaryKey"`
+	CommentId int        `json:"comment_id" gorm:"index;not null"`
+	Body      string     `json:"body" gorm:"type:text;not null"`
+	EditorId  int        `json:"editor_id"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+type CreateCommentRequest struct {
+	EvaluationId int    `json:"evaluation_id"`
+	ParentId     int    `json:"parent_id"`
+	Body         string `json:"body"`
+	// Anchor needs an evaluation; the quote is taken from its output
+	Anchor *CommentAnchor `json:"anchor"`
+}
+
+type UpdateCommentRequest struct {
+	Body string `json:"body"`
+}
+
+type CommentFilter struct {
+	IdeaId int
+	// EvaluationId is ignored when it is 0, listing every comment of the idea
+	EvaluationId int
+}
+
+type CommentHandler interface {
+	ListComments(w http.ResponseWriter, r *http.Request)
+	CreateComment(w http.ResponseWriter, r *http.Request)
+	UpdateComment(w http.ResponseWriter, r *http.Request)
+	DeleteComment(w http.ResponseWriter, r *http.Request)
+	ListCommentHistory(w http.ResponseWriter, r *http.Request)
+}
+
+type CommentUsecase interface {
+	// ListComments returns the threads of an idea, oldest first, with their
+	// replies nested
+	ListComments(ctx context.Context, filter CommentFilter) ([]Comment, error)
+	// CreateComment notifies the members mentioned in the body
+	CreateComment(ctx context.Context, ideaId int, req CreateCommentRequest) (Comment, error)
+	UpdateComment(ctx context.Context, id int, req UpdateCommentRequest) (Comment, error)
+	// DeleteComment clears the body and keeps the comment, so its replies
+	// stay in place
+	DeleteComment(ctx context.Context, id int) error
+	ListCommentHistory(ctx context.Context, id int) ([]CommentRevision, error)
+}
+
+type CommentRepository interface {
+	ListComments(ctx context.Context, filter CommentFilter) ([]Comment, error)
+	GetComment(ctx context.Context, id int) (Comment, error)
+	CreateComment(ctx context.Context, comment Comment) (Comment, error)
+	UpdateComment(ctx context.Context, comment Comment) (Comment, error)
+	CreateCommentRevision(ctx context.Context, revision CommentRevision) error
+	ListCommentRevisions(ctx context.Context, commentId int) ([]CommentRevision, error)
+}
@@ -0,0 +1,94 @@
+package domain
+
+import "time"
+
+const (
+	// CompareModeRanked ranks every idea on every dimension in one prompt
+	CompareModeRanked = "ranked"
+	// CompareModeTournament plays every pair of ideas against each other
+	// and ranks them by Elo rating
+	CompareModeTournament = "tournament"
+)
+
+const (
+	MinCompareIdeas = 2
+	// MaxRankedIdeas is how many ideas still fit in one comparative prompt
+	MaxRankedIdeas = 5
+	// MaxTournamentIdeas bounds the round robin, which takes n*(n-1)/2
+	// model calls
+	MaxTournamentIdeas = 8
+	EloInitialRating   = 1500
+	EloK               = 32
+)
+
+// CompareDimensions are used when the comparison has no rubric, the same
+// ones the critic scores.
+var CompareDimensions = []string{"Originality", "Scalability", "Feasibility"}
+
+// Comparison is a stored head-to-head of two or more ideas.
+type Comparison struct {
+	Id          int    `json:"id" gorm:"primaryKey"`
+	WorkspaceId int    `json:"workspace_id" gorm:"index"`
+	UserId      int    `json:"user_id" gorm:"index"`
+	Mode        string `json:"mode" gorm:"type:varchar(16);not null"`
+	IdeaIds     []int  `json:"idea_ids" gorm:"serializer:json"`
+	RubricId    int    `json:"rubric_id,omitempty"`
+	// Dimensions ranks the ideas on every dimension, ranked mode only
+	Dimensions []DimensionRanking `json:"dimensions,omitempty" gorm:"serializer:json"`
+	// Matches are the pairwise results, tournament mode only
+	Matches   []ComparisonMatch `json:"matches,omitempty" gorm:"serializer:json"`
+	Standings []Standing        `json:"standings" gorm:"serializer:json"`
+	// Summary is the recommendation closing a ranked comparison
+	Summary string `json:"summary,omitempty" gorm:"type:text"`
+	// EvaluationId is the model run of a ranked comparison
+	EvaluationId int        `json:"evaluation_id,omitempty"`
+	ParseFailed  bool       `json:"parse_failed"`
+	CreatedAt    *time.Time `json:"created_at"`
+}
+
+type CompareRequest struct {
+	IdeaIds []int `json:"idea_ids"`
+	// Mode is ranked or tournament; empty picks ranked while the ideas fit
+	// in one prompt
+	Mode     string `json:"mode"`
+	RubricId int    `json:"rubric_id"`
+}
+
+type DimensionRanking struct {
+	Name    string       `json:"name"`
+	Ranking []RankedIdea `json:"ranking"`
+}
+
+type RankedIdea struct {
+	IdeaId        int    `json:"idea_id"`
+	Rank          int    `json:"rank"`
+	Justification string `json:"justification"`
+}
+
+type ComparisonMatch struct {
+	IdeaA int `json:"idea_a"`
+	IdeaB int `json:"idea_b"`
+	// Winner is the id of the stronger idea, 0 for a draw
+	Winner       int    `json:"winner"`
+	Reason       string `json:"reason"`
+	EvaluationId int    `json:"evaluation_id"`
+}
+
+// Standing is the final position of an idea. Score is the weighted mean
+// rank in ranked mode, lower is better, and the Elo rating in tournament
+// mode.
+type Standing struct {
+	IdeaId int     `json:"idea_id"`
+	Rank   int     `json:"rank"`
+	Score  float64 `json:"score"`
+	Wins   int     `json:"wins,omitempty"`
+	Losses int     `json:"losses,omitempty"`
+	Draws  int     `json:"draws,omitempty"`
+}
+
+// Candidate is an idea as the compare prompts show it, labelled A, B, ...
+type Candidate struct {
+	Label  string
+	Idea   string
+	Fields IdeaFields
+}
@@ -2,4 +2,18 @@ package domain
 
 const (
 	ContextDatabaseTransaction = "database-transaction"
+	// ContextSubject identifies the caller for sticky experiment bucketing
+	ContextSubject = "subject"
+	// ContextUser holds the domain.User resolved by AuthMiddleware
+	ContextUser = "user"
+	// ContextAPIKey holds the id of the API key a request was made with
+	ContextAPIKey = "api_key"
+	// ContextWorkspace holds the active domain.Workspace
+	ContextWorkspace = "workspace"
+	// ContextRequestId holds the id LogMiddleware gave the request
+	ContextRequestId = "request_id"
+	// ContextClientIP holds the remote address without the port
+	ContextClientIP = "client_ip"
+	// ContextLocale holds the language the caller explicitly asked for
+	ContextLocale = "locale"
 )
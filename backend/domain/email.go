@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	EmailPending = "pending"
+	EmailSent    = "sent"
+	EmailFailed  = "failed"
+)
+
+// Email is a row of the outbox. Mails are enqueued in the same transaction
+// as the change that triggers them, together with the JobEmail that delivers
+// them; the job queue retries failed deliveries with its backoff.
+type Email struct {
+	Id        int        `json:"id" gorm:"primaryKey"`
+	To        string     `json:"to" gorm:"column:recipient;type:varchar(255);not null"`
+	Template  string     `json:"template" gorm:"type:varchar(64)"`
+	Subject   string     `json:"subject" gorm:"type:varchar(255);not null"`
+	TextBody  string     `json:"-" gorm:"type:text"`
+	HTMLBody  string     `json:"-" gorm:"column:html_body;type:text"`
+	Status    string     `json:"status" gorm:"type:varchar(16);not null;default:pending;index"`
+	LastError string     `json:"last_error" gorm:"type:text"`
+	SentAt    *time.Time `json:"sent_at"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+type EmailUsecase interface {
+	// Enqueue renders template for to, stores it in the outbox and queues
+	// its delivery. It joins the transaction carried by ctx, if any.
+	Enqueue(ctx context.Context, to, template string, data map[string]interface{}) error
+	// RunJob delivers the mail of a JobEmail. The mail is marked failed on
+	// the last attempt of the job.
+	RunJob(ctx context.Context, job Job) (interface{}, error)
+}
+
+type EmailRepository interface {
+	CreateEmail(ctx context.Context, email Email) (Email, error)
+	// ClaimEmail locks a pending mail, or finds none when it was sent or
+	// another instance holds it
+	ClaimEmail(ctx context.Context, id int) (Email, error)
+	MarkEmailSent(ctx context.Context, id int) error
+	MarkEmailFailed(ctx context.Context, id int, status string, lastError string) error
+}
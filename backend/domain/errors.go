@@ -0,0 +1,14 @@
+package domain
+
+import "errors"
+
+var (
+	ErrNotFound     = errors.New("resource not found")
+	ErrBadRequest   = errors.New("bad request")
+	ErrConflict     = errors.New("conflict")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	// ErrQuotaExceeded is returned when the plan quota cannot cover a model
+	// call
+	ErrQuotaExceeded = errors.New("quota exceeded")
+)
@@ -0,0 +1,50 @@
+package domain
+
+import "time"
+
+// Evaluation records a single LLM run (critique, defense or improvement)
+// together with the prompt version that produced it.
+type Evaluation struct {
+	Id               int    `json:"id" gorm:"primaryKey"`
+	IdeaId           int    `json:"idea_id" gorm:"index"`
+	UserId           int    `json:"user_id" gorm:"index"`
+	WorkspaceId      int    `json:"workspace_id" gorm:"index"`
+	Kind             string `json:"kind" gorm:"type:varchar(64);not null"`
+	PromptId         int    `json:"prompt_id"`
+	PromptVersion    int    `json:"prompt_version"`
+	ExperimentId     int    `json:"experiment_id,omitempty" gorm:"index"`
+	VariantId        int    `json:"variant_id,omitempty"`
+	Model            string `json:"model"`
+	Input            string `json:"input" gorm:"type:text"`
+	Output           string `json:"output" gorm:"type:text"`
+	LatencyMs        int64  `json:"latency_ms"`
+	Tokens           int64  `json:"tokens"`
+	ScoreOriginality int    `json:"score_originality"`
+	ScoreScalability int    `json:"score_scalability"`
+	ScoreFeasibility int    `json:"score_feasibility"`
+	ParseFailed      bool   `json:"parse_failed"`
+	// Locale is the language the model was asked to answer in, "" when
+	// it was left to the model
+	Locale string `json:"locale,omitempty" gorm:"type:varchar(8)"`
+	// Persona is the key of the reviewer persona of a critique
+	Persona string `json:"persona,omitempty" gorm:"type:varchar(64);index"`
+	// RubricId is the rubric the critique was scored with, 0 for the
+	// built-in dimensions
+	RubricId        int              `json:"rubric_id,omitempty" gorm:"index"`
+	DimensionScores []DimensionScore `json:"dimension_scores,omitempty" gorm:"serializer:json"`
+	// OverallScore is the weighted score on the OverallScale, nil when the
+	// critique could not be scored
+	OverallScore *float64 `json:"overall_score,omitempty"`
+	// Weaknesses are the critique points tagged with the field they concern
+	Weaknesses []Weakness `json:"weaknesses,omitempty" gorm:"serializer:json"`
+	ThumbsUp   *bool      `json:"thumbs_up"`
+	CreatedAt  *time.Time `json:"created_at"`
+}
+
+type EvaluationFeedbackRequest struct {
+	ThumbsUp bool `json:"thumbs_up"`
+}
+
+// StreamFunc sends messages to model (the default one when empty), streaming
+// the answer to the caller, and returns the full assistant output.
+type StreamFunc func(model string, messages []*Message) (string, error)
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	ExperimentDraft   = "draft"
+	ExperimentRunning = "running"
+	ExperimentStopped = "stopped"
+)
+
+// Experiment splits traffic for one prompt name between several versions.
+type Experiment struct {
+	Id         int                 `json:"id" gorm:"primaryKey"`
+	Name       string              `json:"name" gorm:"type:varchar(128);not null"`
+	PromptName string              `json:"prompt_name" gorm:"type:varchar(64);not null;index"`
+	Status     string              `json:"status" gorm:"type:varchar(16);not null;default:draft"`
+	Variants   []ExperimentVariant `json:"variants" gorm:"foreignKey:ExperimentId"`
+	CreatedAt  *time.Time          `json:"created_at"`
+}
+
+type ExperimentVariant struct {
+	Id            int    `json:"id" gorm:"primaryKey"`
+	ExperimentId  int    `json:"experiment_id" gorm:"index;not null"`
+	Label         string `json:"label" gorm:"type:varchar(64);not null"`
+	PromptVersion int    `json:"prompt_version" gorm:"not null"`
+	Weight        int    `json:"weight" gorm:"not null"`
+}
+
+type VariantMetrics struct {
+	VariantId        int     `json:"variant_id"`
+	Label            string  `json:"label"`
+	PromptVersion    int     `json:"prompt_version"`
+	Evaluations      int     `json:"evaluations"`
+	AvgOriginality   float64 `json:"avg_originality"`
+	AvgScalability   float64 `json:"avg_scalability"`
+	AvgFeasibility   float64 `json:"avg_feasibility"`
+	AvgOverall       float64 `json:"avg_overall"`
+	ParseFailureRate float64 `json:"parse_failure_rate"`
+	AvgLatencyMs     float64 `json:"avg_latency_ms"`
+	Ratings          int     `json:"ratings"`
+	ThumbsUpRate     float64 `json:"thumbs_up_rate"`
+}
+
+type UpdateExperimentStatusRequest struct {
+	Status string `json:"status"`
+}
+
+type ExperimentHandler interface {
+	ListExperiments(w http.ResponseWriter, r *http.Request)
+	GetExperiment(w http.ResponseWriter, r *http.Request)
+	CreateExperiment(w http.ResponseWriter, r *http.Request)
+	UpdateExperimentStatus(w http.ResponseWriter, r *http.Request)
+	GetExperimentMetrics(w http.ResponseWriter, r *http.Request)
+}
+
+type ExperimentUsecase interface {
+	ListExperiments(ctx context.Context) ([]Experiment, error)
+	GetExperiment(ctx context.Context, id int) (Experiment, error)
+	CreateExperiment(ctx context.Context, experiment Experiment) (Experiment, error)
+	UpdateExperimentStatus(ctx context.Context, id int, status string) (Experiment, error)
+	GetExperimentMetrics(ctx context.Context, id int) ([]VariantMetrics, error)
+	// Assign picks the variant of the running experiment for promptName that
+	// subject is bucketed into. It returns nil when no experiment is running.
+	Assign(ctx context.Context, promptName, subject string) (*ExperimentVariant, error)
+}
+
+type ExperimentRepository interface {
+	ListExperiments(ctx context.Context) ([]Experiment, error)
+	GetExperiment(ctx context.Context, id int) (Experiment, error)
+	GetRunningExperiment(ctx context.Context, promptName string) (Experiment, error)
+	// LockPromptExperiments holds the experiments of the prompt until the
+	// transaction ends
+	LockPromptExperiments(ctx context.Context, promptName string) error
+	CreateExperiment(ctx context.Context, experiment Experiment) (Experiment, error)
+	UpdateExperimentStatus(ctx context.Context, id int, status string) error
+	GetExperimentMetrics(ctx context.Context, id int) ([]VariantMetrics, error)
+}
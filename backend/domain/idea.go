@@ -6,25 +6,139 @@ import (
 	"time"
 )
 
+const (
+	StageIdea      = "idea"
+	StagePrototype = "prototype"
+	StageMVP       = "mvp"
+	StageRevenue   = "revenue"
+	StageScaling   = "scaling"
+)
+
+// IdeaStages lists the valid IdeaFields.Stage values in order of maturity.
+var IdeaStages = []string{StageIdea, StagePrototype, StageMVP, StageRevenue, StageScaling}
+
+// IdeaFields is the structured submission form. Problem and Solution are
+// required once any field is filled; a submission without fields falls back
+// to the free text idea.
+type IdeaFields struct {
+	Problem      string `json:"problem" gorm:"type:text"`
+	Solution     string `json:"solution" gorm:"type:text"`
+	Customer     string `json:"customer" gorm:"type:text"`
+	RevenueModel string `json:"revenue_model" gorm:"type:text"`
+	Competitors  string `json:"competitors" gorm:"type:text"`
+	Stage        string `json:"stage" gorm:"type:varchar(32)"`
+	Constraints  string `json:"constraints" gorm:"type:text"`
+}
+
+// Structured reports whether any field of the form was filled in.
+func (f IdeaFields) Structured() bool {
+	return f != IdeaFields{}
+}
+
+// Weakness is one point of a critique tied to the submission field it is
+// about; Field is "general" when it concerns the idea as a whole.
+type Weakness struct {
+	Field string `json:"field"`
+	Text  string `json:"text"`
+}
+
 type Idea struct {
 	Id               int    `json:"id"`
 	UserId           int    `json:"user_id"`
 	Text             string `json:"text"`
-	Critique         string `json:"critique"`          // Add this field for the critique
-	Feedback         string `json:"feedback"`          // feedback dari reviewer
-	ScoreOriginaly   int    `json:"score_originaly"`   // skor orisinal
-	ScoreScalability int    `json:"score_scalability"` // skor skalabilitas
-	ScoreFeasibility int    `json:"score_feasibility"` //skor kelayakan
-	CreatedAt        string `json:"created_at"`        //tanggal pembuatan
+	Critique         string `json:"critique"`                 // Add this field for the critique
+	Feedback         string `json:"feedback"`                 // feedback dari reviewer
+	ScoreOriginaly   int    `json:"score_originaly"`          // skor orisinal
+	ScoreScalability int    `json:"score_scalability"`        // skor skalabilitas
+	ScoreFeasibility int    `json:"score_feasibility"`        //skor kelayakan
+	CreatedAt        string `json:"created_at"`               //tanggal pembuatan
+	PromptVersion    int    `json:"prompt_version,omitempty"` // versi prompt yang dipakai, 0 = aktif
+	RubricId         int    `json:"rubric_id,omitempty"`      // rubrik penilaian, 0 = default workspace
+	Persona          string `json:"persona,omitempty"`        // persona reviewer, kosong = mentor umum
+	IdeaFields
 }
 
 type SubmitIdeaRequest struct {
-	Id        int        `json:"id" gorm:"primary_key auto_increment"`
-	Idea      string     `json:"idea"`
+	Id          int    `json:"id" gorm:"primary_key auto_increment"`
+	UserId      int    `json:"user_id" gorm:"index"`
+	WorkspaceId int    `json:"workspace_id" gorm:"index"`
+	Idea        string `json:"idea"`
+	// Status is one of the Idea lifecycle states, see IdeaTransitions
+	Status string `json:"status" gorm:"type:varchar(32);not null;default:draft;index"`
+	// Score is the overall score of the latest critique, nil until critiqued
+	Score     *float64   `json:"score" gorm:"index"`
 	CreatedAt *time.Time `json:"created_at" gorm:"not null" default:"CURRENT_TIMESTAMP"`
+	UpdatedAt *time.Time `json:"updated_at"`
+	// DeletedAt marks a soft deleted idea that can still be restored
+	DeletedAt *time.Time `json:"deleted_at,omitempty" gorm:"index"`
+	Tags      []Tag      `json:"tags" gorm:"-"`
+	// AutoTag asks the model to pick tags from the workspace vocabulary
+	// when the idea is submitted
+	AutoTag bool `json:"auto_tag,omitempty" gorm:"-"`
+	// Language is detected from the idea text, "" when it could not be told
+	Language string `json:"language" gorm:"type:varchar(8);index"`
+
+	IdeaFields `gorm:"embedded"`
+}
+
+const (
+	IdeaSortCreated     = "created_at"
+	IdeaSortCreatedDesc = "-created_at"
+	IdeaSortScore       = "score"
+	IdeaSortScoreDesc   = "-score"
+)
+
+// IdeaCursor is the position after the last idea of a page: the sort key
+// of that idea and its id as a tie breaker.
+type IdeaCursor struct {
+	Value string `json:"v"`
+	Id    int    `json:"id"`
+}
+
+type IdeaFilter struct {
+	OwnerId  int
+	Status   string
+	Tag      string
+	MinScore *float64
+	MaxScore *float64
+	// Sort is one of the IdeaSort values, newest first by default
+	Sort  string
+	After *IdeaCursor
+	Limit int
+	// Deleted lists the trash instead of the live ideas
+	Deleted bool
+}
+
+type IdeaPage struct {
+	Ideas      []SubmitIdeaRequest `json:"ideas"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+// UpdateIdeaRequest patches only the fields that are present.
+type UpdateIdeaRequest struct {
+	Idea         *string `json:"idea"`
+	Problem      *string `json:"problem"`
+	Solution     *string `json:"solution"`
+	Customer     *string `json:"customer"`
+	RevenueModel *string `json:"revenue_model"`
+	Competitors  *string `json:"competitors"`
+	Stage        *string `json:"stage"`
+	Constraints  *string `json:"constraints"`
 }
 
 type IdeaHandler interface {
+	ListIdeas(w http.ResponseWriter, r *http.Request)
+	UpdateIdea(w http.ResponseWriter, r *http.Request)
+	DeleteIdea(w http.ResponseWriter, r *http.Request)
+	RestoreIdea(w http.ResponseWriter, r *http.Request)
+	AddIdeaTags(w http.ResponseWriter, r *http.Request)
+	RemoveIdeaTag(w http.ResponseWriter, r *http.Request)
+	TransitionIdea(w http.ResponseWriter, r *http.Request)
+	PanelReview(w http.ResponseWriter, r *http.Request)
+	CompareIdeas(w http.ResponseWriter, r *http.Request)
+	GetComparison(w http.ResponseWriter, r *http.Request)
+	GetIdeaWorkflow(w http.ResponseWriter, r *http.Request)
+	GetIdeaReport(w http.ResponseWriter, r *http.Request)
 	SubmitIdea(w http.ResponseWriter, r *http.Request)
 	GetIdea(w http.ResponseWriter, r *http.Request)
 	StreamSubmitIdea(w http.ResponseWriter, r *http.Request)
@@ -33,18 +147,82 @@ type IdeaHandler interface {
 	DefendIdea(w http.ResponseWriter, r *http.Request)
 	ImproveIdea(w http.ResponseWriter, r *http.Request)
 	SubmitIdeaStream(w http.ResponseWriter, r *http.Request)
+	RateEvaluation(w http.ResponseWriter, r *http.Request)
 }
 
 type IdeaUsecase interface {
 	GetIdea(ctx context.Context, id int) (SubmitIdeaRequest, error)
-	SubmitIdea(ctx context.Context, idea string) ([]*Message, error)
-	DefendIdea(ctx context.Context, critique string) ([]*Message, error)
-	ImproveIdea(ctx context.Context, critique string) ([]*Message, error)
+	// ListIdeas pages through the ideas of the active workspace; cursor is
+	// the NextCursor of the previous page
+	ListIdeas(ctx context.Context, filter IdeaFilter, cursor string) (IdeaPage, error)
+	UpdateIdea(ctx context.Context, id int, req UpdateIdeaRequest) (SubmitIdeaRequest, error)
+	DeleteIdea(ctx context.Context, id int) error
+	RestoreIdea(ctx context.Context, id int) (SubmitIdeaRequest, error)
+	// AddIdeaTags tags an idea with tags of the workspace vocabulary
+	AddIdeaTags(ctx context.Context, id int, names []string) (SubmitIdeaRequest, error)
+	RemoveIdeaTag(ctx context.Context, id, tagId int) (SubmitIdeaRequest, error)
+	// TransitionIdea moves an idea to another status, see IdeaTransitions
+	TransitionIdea(ctx context.Context, id int, req TransitionRequest) (SubmitIdeaRequest, error)
+	GetIdeaWorkflow(ctx context.Context, id int) (IdeaWorkflow, error)
+	// GetIdeaReport gathers the idea with its evaluations, debates and
+	// status history
+	GetIdeaReport(ctx context.Context, id int) (IdeaReport, error)
+	// ExportIdeaReport renders the report as one of the ReportMarkdown,
+	// ReportHTML or ReportPDF formats
+	ExportIdeaReport(ctx context.Context, id int, format string) (ReportFile, error)
+	// PanelReview critiques a stored idea as each persona in turn and merges
+	// the findings
+	PanelReview(ctx context.Context, id int, req PanelRequest) (PanelReview, error)
+	// CompareIdeas ranks stored ideas against each other and keeps the
+	// result
+	CompareIdeas(ctx context.Context, req CompareRequest) (Comparison, error)
+	GetComparison(ctx context.Context, id int) (Comparison, error)
+	SubmitIdea(ctx context.Context, idea Idea) ([]*Message, error)
+	DefendIdea(ctx context.Context, idea Idea) ([]*Message, error)
+	ImproveIdea(ctx context.Context, idea Idea) ([]*Message, error)
 	SubmitIdeaStream(ctx context.Context, idea SubmitIdeaRequest) (SubmitIdeaRequest, error)
+	// RunJob runs the evaluation, debate and embedding jobs of the queue
+	RunJob(ctx context.Context, job Job) (interface{}, error)
+	// StreamSubmitIdea streams a critique scored with rubricId, or the
+	// workspace default when it is 0
+	StreamSubmitIdea(ctx context.Context, id, promptVersion, rubricId int, stream StreamFunc) error
+	StreamDefendIdea(ctx context.Context, idea Idea, stream StreamFunc) error
+	StreamImproveIdea(ctx context.Context, idea Idea, stream StreamFunc) error
+	RateEvaluation(ctx context.Context, id int, thumbsUp bool) (Evaluation, error)
 }
 
 type IdeaRepository interface {
+	// GetIdea never returns soft deleted ideas
 	GetIdea(ctx context.Context, id int) (SubmitIdeaRequest, error)
+	GetDeletedIdea(ctx context.Context, id int) (SubmitIdeaRequest, error)
+	ListIdeas(ctx context.Context, filter IdeaFilter) ([]SubmitIdeaRequest, error)
+	UpdateIdea(ctx context.Context, idea SubmitIdeaRequest) error
+	UpdateIdeaScore(ctx context.Context, id int, score float64) error
+	DeleteIdea(ctx context.Context, id int) error
+	RestoreIdea(ctx context.Context, id int) error
+	// UpdateIdeaStatus only moves an idea that is still in status from
+	UpdateIdeaStatus(ctx context.Context, id int, from, to string) error
+	CreateIdeaTransition(ctx context.Context, transition IdeaTransition) error
+	ListIdeaTransitions(ctx context.Context, ideaId int) ([]IdeaTransition, error)
 	SubmitIdea(ctx context.Context, idea string) error
 	SubmitIdeaStream(ctx context.Context, idea SubmitIdeaRequest) (SubmitIdeaRequest, error)
+	CreateEvaluation(ctx context.Context, evaluation Evaluation) (Evaluation, error)
+	GetEvaluation(ctx context.Context, id int) (Evaluation, error)
+	// ListEvaluations lists the evaluations of an idea, oldest first
+	ListEvaluations(ctx context.Context, ideaId int) ([]Evaluation, error)
+	UpdateEvaluationFeedback(ctx context.Context, id int, thumbsUp bool) error
+	CreateComparison(ctx context.Context, comparison Comparison) (Comparison, error)
+	GetComparison(ctx context.Context, id int) (Comparison, error)
+	// SaveIdeaEmbedding replaces the embedding of an idea
+	SaveIdeaEmbedding(ctx context.Context, embedding IdeaEmbedding) error
+}
+
+// IdeaEmbedding is the vector of the text and form of an idea, computed in
+// the background by a JobEmbedding.
+type IdeaEmbedding struct {
+	IdeaId     int       `json:"idea_id" gorm:"primaryKey;autoIncrement:false"`
+	Model      string    `json:"model" gorm:"type:varchar(128);not null"`
+	Dimensions int       `json:"dimensions"`
+	Vector     []float64 `json:"vector" gorm:"serializer:json"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
@@ -0,0 +1,271 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	// JobDead holds jobs that ran out of attempts or failed for good; they
+	// stay until somebody retries them
+	JobDead      = "dead"
+	JobCancelled = "cancelled"
+)
+
+const (
+	// JobEvaluation critiques a stored idea
+	JobEvaluation = "evaluation"
+	// JobDebate lets the critic and the founder argue over a stored idea
+	JobDebate = "debate"
+	// JobEmbedding computes the vector of a stored idea
+	JobEmbedding = "embedding"
+	// JobEmail delivers one mail of the outbox
+	JobEmail = "email"
+	// JobBatch evaluates the pending rows of a batch upload
+	JobBatch = "batch"
+)
+
+const (
+	DefaultJobAttempts = 5
+	MaxJobAttempts     = 10
+	JobRetryBaseDelay  = 30 * time.Second
+	JobRetryMaxDelay   = time.Hour
+	// JobTimeout bounds a single run of a job
+	JobTimeout = 10 * time.Minute
+	// JobLease is how long a running job stays claimed; a job whose worker
+	// died is picked up again once its lease expired
+	JobLease = 15 * time.Minute
+	// JobListLimit is how many jobs a listing returns, newest first
+	JobListLimit = 100
+	// MaxDebateRounds bounds the critique and defense pairs of a debate
+	MaxDebateRounds = 3
+)
+
+// Job is a row of the background queue. Workers claim due jobs with
+// FOR UPDATE SKIP LOCKED, so any number of them can share the table.
+type Job struct {
+	Id          int `json:"id" gorm:"primaryKey"`
+	WorkspaceId int `json:"workspace_id" gorm:"index"`
+	// UserId is who enqueued the job; the job runs on their behalf. System
+	// jobs such as emails have none.
+	UserId int `json:"user_id" gorm:"index"`
+	// Scopes are those of the API key the job was queued with, nil for a
+	// session; they narrow the user's permissions while the job runs
+	Scopes  []string        `json:"scopes,omitempty" gorm:"serializer:json"`
+	Type    string          `json:"type" gorm:"type:varchar(32);not null;index"`
+	Status  string          `json:"status" gorm:"type:varchar(16);not null;default:queued;index:idx_job_due,priority:1"`
+	Payload json.RawMessage `json:"payload" gorm:"type:jsonb"`
+	// Result is what a succeeded job produced
+	Result      json.RawMessage `json:"result,omitempty" gorm:"type:jsonb"`
+	Attempts    int             `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts int             `json:"max_attempts" gorm:"not null"`
+	// RunAt is when the job is due, pushed back after a failed attempt
+	RunAt time.Time `json:"run_at" gorm:"not null;index:idx_job_due,priority:2"`
+	// LockedBy names the worker running the job
+	LockedBy   string     `json:"locked_by,omitempty" gorm:"type:varchar(128)"`
+	LockedAt   *time.Time `json:"locked_at,omitempty"`
+	LastError  string     `json:"last_error,omitempty" gorm:"type:text"`
+	CreatedAt  *time.Time `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+// NewJob builds a queued job of jobType that is due right away.
+func NewJob(jobType string, payload interface{}) (Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Job{}, err
+	}
+	now := time.Now()
+	return Job{
+		Type:        jobType,
+		Status:      JobQueued,
+		Payload:     data,
+		MaxAttempts: DefaultJobAttempts,
+		RunAt:       now,
+		CreatedAt:   &now,
+	}, nil
+}
+
+// EvaluationPayload is the payload of a JobEvaluation.
+type EvaluationPayload struct {
+	IdeaId        int    `json:"idea_id"`
+	PromptVersion int    `json:"prompt_version,omitempty"`
+	RubricId      int    `json:"rubric_id,omitempty"`
+	Persona       string `json:"persona,omitempty"`
+}
+
+// DebatePayload is the payload of a JobDebate.
+type DebatePayload struct {
+	IdeaId int `json:"idea_id"`
+	// Rounds is how many critiques the founder answers, 1 when empty
+	Rounds int `json:"rounds,omitempty"`
+	// Improve asks for an improved idea once the debate is over
+	Improve bool `json:"improve,omitempty"`
+}
+
+// EmbeddingPayload is the payload of a JobEmbedding.
+type EmbeddingPayload struct {
+	IdeaId int `json:"idea_id"`
+}
+
+// EmailPayload is the payload of a JobEmail.
+type EmailPayload struct {
+	EmailId int `json:"email_id"`
+}
+
+// BatchPayload is the payload of a JobBatch.
+type BatchPayload struct {
+	BatchId int `json:"batch_id"`
+}
+
+// EvaluationResult is the result of a JobEvaluation.
+type EvaluationResult struct {
+	Critique string   `json:"critique"`
+	Score    *float64 `json:"score"`
+}
+
+// DebateTurn is one message of a debate, spoken by the critic or the
+// founder.
+type DebateTurn struct {
+	Round   int    `json:"round"`
+	Speaker string `json:"speaker"`
+	Content string `json:"content"`
+}
+
+// DebateResult is the result of a JobDebate.
+type DebateResult struct {
+	Turns       []DebateTurn `json:"turns"`
+	Improvement string       `json:"improvement,omitempty"`
+}
+
+// EmbeddingResult is the result of a JobEmbedding.
+type EmbeddingResult struct {
+	Model      string `json:"model"`
+	Dimensions int    `json:"dimensions"`
+}
+
+// EnqueueJobRequest is how API callers queue work. Email and batch jobs are
+// only queued by the server itself.
+type EnqueueJobRequest struct {
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	MaxAttempts int             `json:"max_attempts"`
+}
+
+// CheckPayload validates the payload of a job type callers may queue.
+func (r EnqueueJobRequest) CheckPayload() error {
+	ideaId := 0
+	switch r.Type {
+	case JobEvaluation:
+		payload := EvaluationPayload{}
+		if err := json.Unmarshal(r.Payload, &payload); err != nil {
+			return fmt.Errorf("%w: invalid payload: %v", ErrBadRequest, err)
+		}
+		ideaId = payload.IdeaId
+	case JobDebate:
+		payload := DebatePayload{}
+		if err := json.Unmarshal(r.Payload, &payload); err != nil {
+			return fmt.Errorf("%w: invalid payload: %v", ErrBadRequest, err)
+		}
+		if payload.Rounds < 0 || payload.Rounds > MaxDebateRounds {
+			return fmt.Errorf("%w: rounds must be between 1 and %d", ErrBadRequest, MaxDebateRounds)
+		}
+		ideaId = payload.IdeaId
+	case JobEmbedding:
+		payload := EmbeddingPayload{}
+		if err := json.Unmarshal(r.Payload, &payload); err != nil {
+			return fmt.Errorf("%w: invalid payload: %v", ErrBadRequest, err)
+		}
+		ideaId = payload.IdeaId
+	default:
+		return fmt.Errorf("%w: type must be %s, %s or %s", ErrBadRequest, JobEvaluation, JobDebate, JobEmbedding)
+	}
+	if ideaId <= 0 {
+		return fmt.Errorf("%w: payload needs an idea_id", ErrBadRequest)
+	}
+	return nil
+}
+
+type JobFilter struct {
+	// WorkspaceId and UserId are ignored when they are 0
+	WorkspaceId int
+	UserId      int
+	Status      string
+	Type        string
+}
+
+// JobCount is the size of one type and status of the queue.
+type JobCount struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+// JobFunc runs one job and returns its result. Errors wrapping
+// ErrBadRequest, ErrNotFound, ErrForbidden, ErrUnauthorized or
+// ErrQuotaExceeded are not retried.
+type JobFunc func(ctx context.Context, job Job) (interface{}, error)
+
+type WorkerOptions struct {
+	// Id names the worker in the locked_by column
+	Id          string
+	Concurrency int
+	// Types limits the worker to some job types, all when empty
+	Types []string
+}
+
+type JobHandler interface {
+	EnqueueJob(w http.ResponseWriter, r *http.Request)
+	ListJobs(w http.ResponseWriter, r *http.Request)
+	GetJob(w http.ResponseWriter, r *http.Request)
+	CancelJob(w http.ResponseWriter, r *http.Request)
+	RetryJob(w http.ResponseWriter, r *http.Request)
+	ListAllJobs(w http.ResponseWriter, r *http.Request)
+	GetJobStats(w http.ResponseWriter, r *http.Request)
+}
+
+type JobUsecase interface {
+	// Register sets the function that runs jobs of jobType
+	Register(jobType string, run JobFunc)
+	EnqueueJob(ctx context.Context, req EnqueueJobRequest) (Job, error)
+	// ListJobs lists the caller's jobs of the active workspace, or those of
+	// every member for a workspace admin
+	ListJobs(ctx context.Context, status, jobType string) ([]Job, error)
+	// ListAllJobs lists the jobs of every workspace, including system jobs
+	ListAllJobs(ctx context.Context, status, jobType string) ([]Job, error)
+	GetJob(ctx context.Context, id int) (Job, error)
+	// CancelJob drops a job that has not started yet
+	CancelJob(ctx context.Context, id int) (Job, error)
+	// RetryJob queues a dead or cancelled job again with fresh attempts
+	RetryJob(ctx context.Context, id int) (Job, error)
+	CountJobs(ctx context.Context) ([]JobCount, error)
+	// RunWorker claims and runs due jobs until ctx is done, then waits for
+	// the running jobs to finish
+	RunWorker(ctx context.Context, options WorkerOptions) error
+}
+
+type JobRepository interface {
+	// CreateJob joins the transaction carried by ctx, if any
+	CreateJob(ctx context.Context, job Job) (Job, error)
+	GetJob(ctx context.Context, id int) (Job, error)
+	ListJobs(ctx context.Context, filter JobFilter) ([]Job, error)
+	CountJobs(ctx context.Context) ([]JobCount, error)
+	// ClaimJob locks the next due job of types, or a running one whose
+	// lease expired before staleBefore, skipping rows other workers hold
+	ClaimJob(ctx context.Context, types []string, staleBefore time.Time) (Job, error)
+	StartJob(ctx context.Context, id int, workerId string) error
+	// FinishJob stores the outcome of a run, only while workerId still holds
+	// the job
+	FinishJob(ctx context.Context, job Job, workerId string) error
+	// UpdateJobStatus only moves a job that is in one of from
+	UpdateJobStatus(ctx context.Context, id int, from []string, to string) error
+	// RequeueJob makes a dead or cancelled job due again with no attempts
+	RequeueJob(ctx context.Context, id int) error
+}
@@ -0,0 +1,78 @@
+package domain
+
+import "time"
+
+const (
+	IdeaDraft       = "draft"
+	IdeaSubmitted   = "submitted"
+	IdeaUnderReview = "under_review"
+	IdeaCritiqued   = "critiqued"
+	IdeaImproving   = "improving"
+	IdeaAccepted    = "accepted"
+	IdeaRejected    = "rejected"
+	IdeaArchived    = "archived"
+)
+
+// IdeaTransitions lists the statuses an idea may move to from each status.
+var IdeaTransitions = map[string][]string{
+	IdeaDraft:       {IdeaSubmitted, IdeaArchived},
+	IdeaSubmitted:   {IdeaUnderReview, IdeaCritiqued, IdeaDraft, IdeaArchived},
+	IdeaUnderReview: {IdeaCritiqued, IdeaAccepted, IdeaRejected},
+	IdeaCritiqued:   {IdeaImproving, IdeaAccepted, IdeaRejected, IdeaArchived},
+	IdeaImproving:   {IdeaSubmitted, IdeaArchived},
+	IdeaAccepted:    {IdeaArchived},
+	IdeaRejected:    {IdeaImproving, IdeaArchived},
+	IdeaArchived:    {IdeaDraft},
+}
+
+// ReviewStatuses are decided by reviewers rather than by the author.
+var ReviewStatuses = map[string]bool{
+	IdeaUnderReview: true,
+	IdeaAccepted:    true,
+	IdeaRejected:    true,
+}
+
+// SystemStatuses are only set by the server: an idea becomes critiqued when
+// its critique is stored.
+var SystemStatuses = map[string]bool{
+	IdeaCritiqued: true,
+}
+
+// CanTransition reports whether an idea may move from one status to another.
+func CanTransition(from, to string) bool {
+	for _, next := range IdeaTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+func IsValidIdeaStatus(status string) bool {
+	_, ok := IdeaTransitions[status]
+	return ok
+}
+
+// IdeaTransition is one step in the history of an idea.
+type IdeaTransition struct {
+	Id        int        `json:"id" gorm:"primaryKey"`
+	IdeaId    int        `json:"idea_id" gorm:"not null;index"`
+	From      string     `json:"from" gorm:"type:varchar(32);not null"`
+	To        string     `json:"to" gorm:"type:varchar(32);not null"`
+	ActorId   int        `json:"actor_id" gorm:"index"`
+	Reason    string     `json:"reason" gorm:"type:text"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+type TransitionRequest struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// IdeaWorkflow is where an idea stands: its status, where it can go next and
+// how it got here, oldest step first.
+type IdeaWorkflow struct {
+	Status  string           `json:"status"`
+	Next    []string         `json:"next"`
+	History []IdeaTransition `json:"history"`
+}
@@ -1,10 +1,58 @@
 package domain
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+)
 
 type Middleware interface {
 	AuthMiddleware(allowedRole string) MiddlewareFunc
+	// RequirePermission must run after AuthMiddleware
+	RequirePermission(permission string) MiddlewareFunc
+	// ScopeMiddleware keeps API keys without a write scope to reads and must
+	// run after AuthMiddleware
+	ScopeMiddleware(next http.Handler) http.Handler
 	LogMiddleware(next http.Handler) http.Handler
+	SubjectMiddleware(next http.Handler) http.Handler
+	// LocaleMiddleware picks the response language from the locale query
+	// parameter or the Accept-Language header
+	LocaleMiddleware(next http.Handler) http.Handler
+	// RateLimitMiddleware keys the bucket by API key, user or IP, in that
+	// order, so it should run after AuthMiddleware when there is one
+	RateLimitMiddleware(next http.Handler) http.Handler
+	// AddressRateLimitMiddleware applies the AddressPlan by IP and runs
+	// before AuthMiddleware
+	AddressRateLimitMiddleware(next http.Handler) http.Handler
+	// WorkspaceMiddleware resolves the active workspace and must run after
+	// AuthMiddleware
+	WorkspaceMiddleware(next http.Handler) http.Handler
+	// QuotaMiddleware guards LLM routes and must run after AuthMiddleware
+	QuotaMiddleware(next http.Handler) http.Handler
 }
 
 type MiddlewareFunc = func(http.Handler) http.Handler
+
+// SubjectFromContext returns the caller key stored by SubjectMiddleware.
+func SubjectFromContext(ctx context.Context) string {
+	subject, _ := ctx.Value(ContextSubject).(string)
+	return subject
+}
+
+// RequestIdFromContext returns the request id stored by LogMiddleware.
+func RequestIdFromContext(ctx context.Context) string {
+	requestId, _ := ctx.Value(ContextRequestId).(string)
+	return requestId
+}
+
+// ClientIPFromContext returns the caller address stored by SubjectMiddleware.
+func ClientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(ContextClientIP).(string)
+	return ip
+}
+
+// LocaleFromContext returns the locale the caller asked for, or "" when the
+// request named no supported language.
+func LocaleFromContext(ctx context.Context) string {
+	locale, _ := ctx.Value(ContextLocale).(string)
+	return locale
+}
@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// OIDCState keeps the per-login secrets of an authorization-code flow between
+// the redirect to the identity provider and its callback. It is single use.
+type OIDCState struct {
+	StateHash    string     `json:"-" gorm:"type:varchar(64);primaryKey"`
+	Nonce        string     `json:"-" gorm:"type:varchar(64);not null"`
+	CodeVerifier string     `json:"-" gorm:"type:varchar(128);not null"`
+	RedirectTo   string     `json:"-" gorm:"type:varchar(512)"`
+	ExpiresAt    time.Time  `json:"-" gorm:"index;not null"`
+	CreatedAt    *time.Time `json:"-"`
+}
+
+// TableName implements gorm schema.Tabler.
+func (OIDCState) TableName() string {
+	return "oidc_states"
+}
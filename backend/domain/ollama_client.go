@@ -32,18 +32,56 @@ type Ollama struct {
 
 var (
 	PROMPT_CRITIC string = `
-You are an objective business idea evaluator.
+{{with .Persona}}{{.Prompt}}{{else}}You are an objective business idea evaluator.{{end}}
 
 Given a user-submitted idea, you must critically analyze it by identifying potential weaknesses or unrealistic aspects. Be honest, direct, and constructive.
-
+{{- with .Persona}}{{if .Focus}}
+
+Look at the idea from your own point of view first, in particular:
+{{- range .Focus}}
+- {{.}}
+{{- end}}{{end}}{{end}}
+{{if .Rubric}}
+Evaluate the idea across these dimensions:
+{{- range .Rubric.Dimensions}}
+- {{.Name}}{{with .Description}} – {{.}}{{end}} (score from {{.Min}} to {{.Max}})
+{{- end}}
+{{- else}}
 Evaluate the idea across these 3 dimensions:
 1. Originality – Is the idea truly unique or just another variant of existing ideas?
 2. Scalability – Can the idea grow into a sustainable and large-scale business?
 3. Feasibility – Is the idea realistically executable given common technical, market, and financial constraints?
+{{- end}}
+
+{{- with .Fields}}{{if .Problem}}
+
+The founder described the idea with this form:
+- Problem: {{.Problem}}
+- Proposed solution: {{.Solution}}
+{{- if .Customer}}
+- Target customer: {{.Customer}}{{end}}
+{{- if .RevenueModel}}
+- Revenue model: {{.RevenueModel}}{{end}}
+{{- if .Competitors}}
+- Competitors: {{.Competitors}}{{end}}
+{{- if .Stage}}
+- Stage: {{.Stage}}{{end}}
+{{- if .Constraints}}
+- Constraints: {{.Constraints}}{{end}}
+
+Treat an empty field as information the founder has not thought through yet.
+{{- end}}{{end}}
+
+List each weakness on its own line, starting with the field it relates to in square brackets: [problem], [solution], [customer], [revenue_model], [competitors], [stage], [constraints], or [general] when it concerns the idea as a whole. For example:
+- [revenue_model] One-off sales will not cover the ongoing hosting costs.
 
-At the end, return a brief score (from 1 to 10) for each dimension and a summary criticism.
+{{if .Rubric}}At the end, write the score of each dimension on its own line as "<dimension name>: <score>", then a summary criticism.{{else}}At the end, return a brief score (from 1 to 10) for each dimension and a summary criticism.{{end}}
 
-Your tone should be analytical, but supportive – like a startup mentor giving tough but useful feedback.
+{{if .Persona}}Stay in character, but keep your feedback useful to the founder.{{else}}Your tone should be analytical, but supportive – like a startup mentor giving tough but useful feedback.{{end}}
+{{- with .Language}}
+
+Write your whole answer in {{.}}, but keep the field names in square brackets and the dimension names exactly as given above.
+{{- end}}
 `
 
 	PROMPT_DEFEND string = `
@@ -57,6 +95,10 @@ Consider possible solutions to the issues raised, provide analogies to similar s
 End with a confident statement of belief in the idea’s potential.
 
 Keep your tone persuasive, factual, and hopeful – like a passionate founder pitching to a skeptical investor.
+{{- with .Language}}
+
+Write your whole answer in {{.}}.
+{{- end}}
 	`
 
 	PROMPT_IMPROVE string = `
@@ -73,5 +115,96 @@ Revise the idea description to:
 Then, provide a short paragraph explaining how the improved idea is better than the original.
 
 Your tone should be constructive and helpful – like a coach guiding someone to refine a pitch.
+{{- with .Language}}
+
+Write your whole answer in {{.}}.
+{{- end}}
 	`
+
+	PROMPT_TAG string = `
+You are organising the idea board of a startup team.
+
+Choose the tags that describe the user's idea from this list, and no others:
+{{- range .Tags}}
+- {{.}}
+{{- end}}
+
+Answer with at most three tags, one per line, written exactly as in the list and nothing else. Answer with "none" when no tag fits.
+`
+
+	PROMPT_PANEL string = `
+You are chairing a review panel for a startup idea. Reviewers with different backgrounds have each critiqued the user's idea:
+{{range .Reviews}}
+### {{.Name}}
+{{.Critique}}
+{{end}}
+Merge their findings into one report with these sections:
+1. Consensus – the concerns most reviewers share.
+2. Disagreements – where the reviewers see the idea differently, and why.
+3. Top risks – the three issues the founder should address first, most important first.
+
+Name the reviewers behind every point. Do not add concerns no reviewer raised.
+{{- with .Language}}
+
+Write your whole answer in {{.}}.
+{{- end}}
+`
+
+	PROMPT_COMPARE string = `
+You are helping a startup team choose which idea to build. Compare these ideas:
+{{range .Candidates}}
+### Idea {{.Label}}
+{{.Idea}}
+{{- with .Fields}}{{if .Problem}}
+- Problem: {{.Problem}}
+- Proposed solution: {{.Solution}}
+{{- if .Customer}}
+- Target customer: {{.Customer}}{{end}}
+{{- if .RevenueModel}}
+- Revenue model: {{.RevenueModel}}{{end}}
+{{- if .Stage}}
+- Stage: {{.Stage}}{{end}}
+{{- end}}{{end}}
+{{end}}
+Rank the ideas against each other on every one of these dimensions:
+{{- if .Rubric}}
+{{- range .Rubric.Dimensions}}
+- {{.Name}}{{with .Description}} – {{.}}{{end}}
+{{- end}}
+{{- else}}
+- Originality – Is the idea truly unique or just another variant of existing ideas?
+- Scalability – Can the idea grow into a sustainable and large-scale business?
+- Feasibility – Is the idea realistically executable given common technical, market, and financial constraints?
+{{- end}}
+
+For every dimension write one line per idea, best first, as "[<dimension name>] <rank>. <idea letter> – <one sentence justification>". For example:
+[Feasibility] 1. B – It only needs an existing API and a small team.
+
+Give every idea its own rank. End with a short recommendation of which idea to build first and why.
+{{- with .Language}}
+
+Write your whole answer in {{.}}, but keep the dimension names and the idea letters exactly as given above.
+{{- end}}
+`
+
+	PROMPT_MATCH string = `
+You are judging a head-to-head between two startup ideas.
+{{range .Candidates}}
+### Idea {{.Label}}
+{{.Idea}}
+{{- with .Fields}}{{if .Problem}}
+- Problem: {{.Problem}}
+- Proposed solution: {{.Solution}}
+{{- if .Customer}}
+- Target customer: {{.Customer}}{{end}}
+{{- end}}{{end}}
+{{end}}
+Weigh them on {{if .Rubric}}{{range $i, $d := .Rubric.Dimensions}}{{if $i}}, {{end}}{{$d.Name}}{{end}}{{else}}originality, scalability and feasibility{{end}}, and decide which one is the stronger idea to build.
+
+Answer with "Winner: A", "Winner: B" or "Winner: tie" on the first line, then one short paragraph starting with "Reason:" that explains the decision.
+{{- with .Language}}
+
+Write the reason in {{.}}, but keep the "Winner:" line exactly as described.
+{{- end}}
+`
 )
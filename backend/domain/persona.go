@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	// MinPanelPersonas and MaxPanelPersonas bound the size of a panel review
+	MinPanelPersonas = 2
+	MaxPanelPersonas = 5
+)
+
+// Persona is a reviewer the critic speaks as. Prompt replaces the opening
+// of the critic prompt and Focus lists what the reviewer looks at first.
+// Built-in personas live in BuiltinPersonas and have no id; the others
+// belong to a workspace.
+type Persona struct {
+	Id          int        `json:"id,omitempty" gorm:"primaryKey"`
+	WorkspaceId int        `json:"workspace_id,omitempty" gorm:"not null;uniqueIndex:idx_workspace_persona"`
+	Key         string     `json:"key" gorm:"type:varchar(64);not null;uniqueIndex:idx_workspace_persona"`
+	Name        string     `json:"name" gorm:"type:varchar(128);not null"`
+	Description string     `json:"description" gorm:"type:text"`
+	Prompt      string     `json:"prompt" gorm:"type:text;not null"`
+	Focus       []string   `json:"focus" gorm:"serializer:json"`
+	CreatedBy   int        `json:"created_by,omitempty" gorm:"index"`
+	BuiltIn     bool       `json:"built_in" gorm:"-"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// BuiltinPersonas are available in every workspace. Their keys cannot be
+// taken by workspace personas.
+var BuiltinPersonas = []Persona{
+	{
+		Key:         "vc",
+		Name:        "Venture capitalist",
+		Description: "A partner at a venture firm deciding whether to take the pitch further.",
+		Prompt:      "You are a partner at a venture capital firm reviewing a pitch. You only back companies that can return the fund, so you are blunt about small markets, weak moats and business models that do not scale.",
+		Focus:       []string{"market size", "growth potential", "defensibility", "business model", "exit potential"},
+		BuiltIn:     true,
+	},
+	{
+		Key:         "engineer",
+		Name:        "Senior engineer",
+		Description: "An experienced engineer who would have to build and run the product.",
+		Prompt:      "You are a senior software engineer who has shipped and operated several products. You judge ideas by what it would really take to build, run and maintain them, and you call out hand-waving about technology.",
+		Focus:       []string{"technical feasibility", "architecture risks", "build and running costs", "dependencies on third parties", "security"},
+		BuiltIn:     true,
+	},
+	{
+		Key:         "customer",
+		Name:        "Target customer",
+		Description: "Someone the idea is aimed at, deciding whether to use and pay for it.",
+		Prompt:      "You are a typical customer the idea is aimed at. You have limited time and budget and already get by with other tools, so you only switch for something that clearly solves a problem you feel.",
+		Focus:       []string{"how painful the problem is", "willingness to pay", "switching costs", "ease of use", "trust"},
+		BuiltIn:     true,
+	},
+	{
+		Key:         "regulator",
+		Name:        "Regulator",
+		Description: "A regulator checking the idea against law and consumer protection.",
+		Prompt:      "You are a regulator responsible for consumer protection and data privacy. You look for legal exposure, licensing requirements and ways the product could harm its users or third parties.",
+		Focus:       []string{"licensing", "data protection and privacy", "consumer protection", "liability", "sector specific rules"},
+		BuiltIn:     true,
+	},
+}
+
+// BuiltinPersona returns the built-in persona with key.
+func BuiltinPersona(key string) (Persona, bool) {
+	for _, persona := range BuiltinPersonas {
+		if persona.Key == key {
+			return persona, true
+		}
+	}
+	return Persona{}, false
+}
+
+type PersonaRequest struct {
+	Key         string   `json:"key"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Prompt      string   `json:"prompt"`
+	Focus       []string `json:"focus"`
+}
+
+type PanelRequest struct {
+	Personas []string `json:"personas"`
+	RubricId int      `json:"rubric_id,omitempty"`
+}
+
+// PersonaReview is the critique one persona gave in a panel review.
+type PersonaReview struct {
+	Persona      string     `json:"persona"`
+	Name         string     `json:"name"`
+	EvaluationId int        `json:"evaluation_id"`
+	Critique     string     `json:"critique"`
+	OverallScore *float64   `json:"overall_score"`
+	Weaknesses   []Weakness `json:"weaknesses"`
+}
+
+// PanelReview merges the critiques of several personas. Summary is the
+// model's consensus report and OverallScore the mean of the persona scores.
+type PanelReview struct {
+	IdeaId              int             `json:"idea_id"`
+	Reviews             []PersonaReview `json:"reviews"`
+	Summary             string          `json:"summary"`
+	SummaryEvaluationId int             `json:"summary_evaluation_id"`
+	OverallScore        *float64        `json:"overall_score"`
+}
+
+type PersonaHandler interface {
+	ListPersonas(w http.ResponseWriter, r *http.Request)
+	CreatePersona(w http.ResponseWriter, r *http.Request)
+	UpdatePersona(w http.ResponseWriter, r *http.Request)
+	DeletePersona(w http.ResponseWriter, r *http.Request)
+}
+
+type PersonaUsecase interface {
+	// ListPersonas returns the built-in personas followed by the ones of the
+	// active workspace
+	ListPersonas(ctx context.Context) ([]Persona, error)
+	CreatePersona(ctx context.Context, req PersonaRequest) (Persona, error)
+	UpdatePersona(ctx context.Context, id int, req PersonaRequest) (Persona, error)
+	DeletePersona(ctx context.Context, id int) error
+}
+
+// PersonaRepository works on the personas of the active workspace.
+type PersonaRepository interface {
+	ListPersonas(ctx context.Context) ([]Persona, error)
+	GetPersona(ctx context.Context, id int) (Persona, error)
+	GetPersonaByKey(ctx context.Context, key string) (Persona, error)
+	CreatePersona(ctx context.Context, persona Persona) (Persona, error)
+	UpdatePersona(ctx context.Context, persona Persona) error
+	DeletePersona(ctx context.Context, id int) error
+}
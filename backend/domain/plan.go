@@ -0,0 +1,78 @@
+package domain
+
+const (
+	PlanFree       = "free"
+	PlanPro        = "pro"
+	PlanEnterprise = "enterprise"
+	// PlanAnonymous applies to requests without credentials, keyed by IP
+	PlanAnonymous = "anonymous"
+)
+
+// Plan bounds how hard a caller may use the API. RequestsPerMinute refills
+// the token bucket, Burst is its capacity. A zero quota means unlimited.
+type Plan struct {
+	Name               string `json:"name"`
+	RequestsPerMinute  int    `json:"requests_per_minute"`
+	Burst              int    `json:"burst"`
+	DailyEvaluations   int64  `json:"daily_evaluations"`
+	MonthlyEvaluations int64  `json:"monthly_evaluations"`
+	DailyTokens        int64  `json:"daily_tokens"`
+	MonthlyTokens      int64  `json:"monthly_tokens"`
+}
+
+var Plans = map[string]Plan{
+	PlanAnonymous: {
+		Name:              PlanAnonymous,
+		RequestsPerMinute: 20,
+		Burst:             10,
+	},
+	PlanFree: {
+		Name:               PlanFree,
+		RequestsPerMinute:  60,
+		Burst:              30,
+		DailyEvaluations:   50,
+		MonthlyEvaluations: 500,
+		DailyTokens:        200_000,
+		MonthlyTokens:      2_000_000,
+	},
+	PlanPro: {
+		Name:               PlanPro,
+		RequestsPerMinute:  300,
+		Burst:              100,
+		DailyEvaluations:   500,
+		MonthlyEvaluations: 10_000,
+		DailyTokens:        2_000_000,
+		MonthlyTokens:      40_000_000,
+	},
+	PlanEnterprise: {
+		Name:              PlanEnterprise,
+		RequestsPerMinute: 1200,
+		Burst:             300,
+	},
+}
+
+// AddressPlan bounds every request from one IP address before its
+// credentials are checked, so guessing tokens or API keys is throttled too.
+// It is generous enough for a team behind one address.
+var AddressPlan = Plan{
+	Name:              "address",
+	RequestsPerMinute: 600,
+	Burst:             200,
+}
+
+type UpdatePlanRequest struct {
+	Plan string `json:"plan"`
+}
+
+// PlanFor returns the named plan, falling back to the free plan.
+func PlanFor(name string) Plan {
+	if plan, ok := Plans[name]; ok {
+		return plan
+	}
+	return Plans[PlanFree]
+}
+
+func IsValidPlan(name string) bool {
+	_, ok := Plans[name]
+	return ok && name != PlanAnonymous
+}
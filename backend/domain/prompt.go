@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	PromptCritic  = "critic"
+	PromptDefend  = "defend"
+	PromptImprove = "improve"
+	PromptTag     = "tag"
+	PromptPanel   = "panel"
+	PromptCompare = "compare"
+	PromptMatch   = "match"
+)
+
+// PromptTemplate is one immutable version of a named prompt. Content is a Go
+// text/template rendered with PromptData.
+type PromptTemplate struct {
+	Id          int        `json:"id" gorm:"primaryKey"`
+	Name        string     `json:"name" gorm:"type:varchar(64);not null;uniqueIndex:idx_prompt_name_version"`
+	Version     int        `json:"version" gorm:"not null;uniqueIndex:idx_prompt_name_version"`
+	Content     string     `json:"content" gorm:"type:text;not null"`
+	Description string     `json:"description"`
+	IsActive    bool       `json:"is_active" gorm:"not null;default:false"`
+	CreatedAt   *time.Time `json:"created_at"`
+}
+
+// PromptData holds the variables available inside a prompt template.
+type PromptData struct {
+	Idea    string
+	Fields  IdeaFields
+	History []*Message
+	// Locale is the language code the answer should be written in and
+	// Language its name, both empty to let the model choose
+	Locale   string
+	Language string
+	// Rubric replaces the built-in critic dimensions when set
+	Rubric *Rubric
+	// Tags is the workspace vocabulary offered to the tag prompt
+	Tags []string
+	// Persona is the reviewer the critic speaks as, nil for the default
+	Persona *Persona
+	// Reviews are the persona critiques the panel prompt merges
+	Reviews []PersonaReview
+	// Candidates are the ideas the compare and match prompts weigh
+	Candidates []Candidate
+}
+
+type CreatePromptRequest struct {
+	Name        string `json:"name"`
+	Content     string `json:"content"`
+	Description string `json:"description"`
+	Activate    bool   `json:"activate"`
+}
+
+type PromptHandler interface {
+	ListPrompts(w http.ResponseWriter, r *http.Request)
+	GetPrompt(w http.ResponseWriter, r *http.Request)
+	CreatePrompt(w http.ResponseWriter, r *http.Request)
+	ActivatePrompt(w http.ResponseWriter, r *http.Request)
+	DeletePrompt(w http.ResponseWriter, r *http.Request)
+}
+
+type PromptUsecase interface {
+	ListPrompts(ctx context.Context, name string) ([]PromptTemplate, error)
+	GetPrompt(ctx context.Context, id int) (PromptTemplate, error)
+	CreatePrompt(ctx context.Context, req CreatePromptRequest) (PromptTemplate, error)
+	ActivatePrompt(ctx context.Context, id int) (PromptTemplate, error)
+	DeletePrompt(ctx context.Context, id int) error
+	// Render resolves the template for name (the active one when version is 0)
+	// and executes it with data.
+	Render(ctx context.Context, name string, version int, data PromptData) (string, PromptTemplate, error)
+	EnsureDefaults(ctx context.Context) error
+}
+
+type PromptRepository interface {
+	ListPrompts(ctx context.Context, name string) ([]PromptTemplate, error)
+	GetPrompt(ctx context.Context, id int) (PromptTemplate, error)
+	GetPromptVersion(ctx context.Context, name string, version int) (PromptTemplate, error)
+	GetActivePrompt(ctx context.Context, name string) (PromptTemplate, error)
+	LatestVersion(ctx context.Context, name string) (int, error)
+	CreatePrompt(ctx context.Context, prompt PromptTemplate) (PromptTemplate, error)
+	DeactivatePrompts(ctx context.Context, name string) error
+	ActivatePrompt(ctx context.Context, id int) error
+	DeletePrompt(ctx context.Context, id int) error
+	// CountExperimentReferences counts the variants of draft and running
+	// experiments that use the version
+	CountExperimentReferences(ctx context.Context, name string, version int) (int64, error)
+	// CountWorkspacePins counts the workspaces pinned to the version
+	CountWorkspacePins(ctx context.Context, name string, version int) (int64, error)
+}
+
+// DefaultPrompts seeds the registry with the built-in prompts on first start.
+var DefaultPrompts = map[string]string{
+	PromptCritic:  PROMPT_CRITIC,
+	PromptDefend:  PROMPT_DEFEND,
+	PromptImprove: PROMPT_IMPROVE,
+	PromptTag:     PROMPT_TAG,
+	PromptPanel:   PROMPT_PANEL,
+	PromptCompare: PROMPT_COMPARE,
+	PromptMatch:   PROMPT_MATCH,
+}
@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+const (
+	ReportMarkdown = "md"
+	ReportHTML     = "html"
+	ReportPDF      = "pdf"
+	ReportJSON     = "json"
+)
+
+// DebateExchange is a critique and the founder's defense against it.
+type DebateExchange struct {
+	EvaluationId int        `json:"evaluation_id"`
+	Critique     string     `json:"critique"`
+	Defense      string     `json:"defense"`
+	CreatedAt    *time.Time `json:"created_at"`
+}
+
+// IdeaReport is everything known about an idea, oldest entry first in
+// every list.
+type IdeaReport struct {
+	Idea SubmitIdeaRequest `json:"idea"`
+	// Critiques are the critic and panel evaluations, carrying the scores
+	Critiques    []Evaluation     `json:"critiques"`
+	Debates      []DebateExchange `json:"debates"`
+	Improvements []Evaluation     `json:"improvements"`
+	Transitions  []IdeaTransition `json:"transitions"`
+	GeneratedAt  time.Time        `json:"generated_at"`
+}
+
+// ReportFile is a rendered report ready to be downloaded.
+type ReportFile struct {
+	Name        string
+	ContentType string
+	Body        []byte
+}
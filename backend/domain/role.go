@@ -0,0 +1,106 @@
+package domain
+
+const (
+	RoleMember   = "member"
+	RoleReviewer = "reviewer"
+	RoleAdmin    = "admin"
+)
+
+const (
+	PermIdeaReadOwn  = "ideas:read:own"
+	PermIdeaWriteOwn = "ideas:write:own"
+	PermIdeaReadAny  = "ideas:read:any"
+	PermIdeaReview   = "ideas:review"
+	PermIdeaWriteAny = "ideas:write:any"
+	PermPromptManage = "prompts:manage"
+	PermUsageRead    = "usage:read"
+	PermUserManage   = "users:manage"
+	PermAuditRead    = "audit:read"
+	PermJobManage    = "jobs:manage"
+)
+
+// RolePermissions lists what every role may do. Roles are cumulative: a
+// reviewer can do everything a member can, an admin everything a reviewer can.
+var RolePermissions = map[string][]string{
+	RoleMember: {
+		PermIdeaReadOwn,
+		PermIdeaWriteOwn,
+	},
+	RoleReviewer: {
+		PermIdeaReadOwn,
+		PermIdeaWriteOwn,
+		PermIdeaReadAny,
+		PermIdeaReview,
+	},
+	RoleAdmin: {
+		PermIdeaReadOwn,
+		PermIdeaWriteOwn,
+		PermIdeaReadAny,
+		PermIdeaReview,
+		PermIdeaWriteAny,
+		PermPromptManage,
+		PermUsageRead,
+		PermUserManage,
+		PermAuditRead,
+		PermJobManage,
+	},
+}
+
+// WritePermissions change data; an API key needs one of them for any
+// request that is not a read.
+var WritePermissions = []string{
+	PermIdeaWriteOwn,
+	PermIdeaWriteAny,
+	PermIdeaReview,
+	PermPromptManage,
+	PermUserManage,
+	PermJobManage,
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role"`
+}
+
+var roleRank = map[string]int{
+	RoleMember:   1,
+	RoleReviewer: 2,
+	RoleAdmin:    3,
+}
+
+// HasRole reports whether the user's role is role or a more privileged one.
+func (u User) HasRole(role string) bool {
+	return roleRank[u.Role] >= roleRank[role] && roleRank[u.Role] > 0
+}
+
+func IsValidRole(role string) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+// Can reports whether the user's role grants permission and, for API key
+// requests, whether the key was issued with that scope.
+func (u User) Can(permission string) bool {
+	if u.Scopes != nil && !containsString(u.Scopes, permission) {
+		return false
+	}
+	return containsString(RolePermissions[u.Role], permission)
+}
+
+// CanWrite reports whether the user holds any of the WritePermissions.
+func (u User) CanWrite() bool {
+	for _, permission := range WritePermissions {
+		if u.Can(permission) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
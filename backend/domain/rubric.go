@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	// MaxRubricDimensions caps how many dimensions one critique is asked for
+	MaxRubricDimensions = 12
+	// MaxRubricScale is the highest score a dimension scale may go up to
+	MaxRubricScale = 100
+	// OverallScale is the scale of the weighted overall score
+	OverallScale = 10
+)
+
+// RubricDimension is one named criterion of a rubric. The critic scores it
+// from Min to Max; Weight is its share of the overall score relative to the
+// other dimensions.
+type RubricDimension struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Weight      float64 `json:"weight"`
+	Min         int     `json:"min"`
+	Max         int     `json:"max"`
+}
+
+// Rubric replaces the built-in originality, scalability and feasibility
+// dimensions for the critiques of a workspace. At most one rubric per
+// workspace is the default.
+type Rubric struct {
+	Id          int               `json:"id" gorm:"primaryKey"`
+	WorkspaceId int               `json:"workspace_id" gorm:"not null;index;index:idx_workspace_default_rubric,unique,where:is_default"`
+	Name        string            `json:"name" gorm:"type:varchar(128);not null"`
+	Description string            `json:"description" gorm:"type:text"`
+	Dimensions  []RubricDimension `json:"dimensions" gorm:"serializer:json"`
+	IsDefault   bool              `json:"is_default" gorm:"not null;default:false"`
+	CreatedAt   *time.Time        `json:"created_at"`
+	UpdatedAt   *time.Time        `json:"updated_at"`
+}
+
+type RubricRequest struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Dimensions  []RubricDimension `json:"dimensions"`
+	IsDefault   bool              `json:"is_default"`
+}
+
+// DimensionScore is the score a critique gave one rubric dimension.
+type DimensionScore struct {
+	Name  string `json:"name"`
+	Score int    `json:"score"`
+	Min   int    `json:"min"`
+	Max   int    `json:"max"`
+}
+
+type RubricHandler interface {
+	ListRubrics(w http.ResponseWriter, r *http.Request)
+	GetRubric(w http.ResponseWriter, r *http.Request)
+	CreateRubric(w http.ResponseWriter, r *http.Request)
+	UpdateRubric(w http.ResponseWriter, r *http.Request)
+	DeleteRubric(w http.ResponseWriter, r *http.Request)
+}
+
+type RubricUsecase interface {
+	ListRubrics(ctx context.Context) ([]Rubric, error)
+	GetRubric(ctx context.Context, id int) (Rubric, error)
+	CreateRubric(ctx context.Context, req RubricRequest) (Rubric, error)
+	UpdateRubric(ctx context.Context, id int, req RubricRequest) (Rubric, error)
+	DeleteRubric(ctx context.Context, id int) error
+}
+
+// RubricRepository works on the rubrics of the active workspace.
+type RubricRepository interface {
+	ListRubrics(ctx context.Context) ([]Rubric, error)
+	GetRubric(ctx context.Context, id int) (Rubric, error)
+	GetDefaultRubric(ctx context.Context) (Rubric, error)
+	CreateRubric(ctx context.Context, rubric Rubric) (Rubric, error)
+	UpdateRubric(ctx context.Context, rubric Rubric) error
+	DeleteRubric(ctx context.Context, id int) error
+	// ClearDefaultRubric unsets the current default of the workspace
+	ClearDefaultRubric(ctx context.Context) error
+}
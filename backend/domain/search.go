@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	SearchIdeas       = "idea"
+	SearchEvaluations = "evaluation"
+)
+
+// Snippet markers wrap the matched terms in SearchResult.Snippet, which is
+// HTML escaped otherwise.
+const (
+	SnippetStart = "<mark>"
+	SnippetStop  = "</mark>"
+)
+
+type SearchQuery struct {
+	Query string
+	// Type limits results to SearchIdeas or SearchEvaluations; empty
+	// searches both
+	Type    string
+	Kind    string
+	Status  string
+	OwnerId int
+	From    *time.Time
+	To      *time.Time
+	Limit   int
+	Offset  int
+}
+
+// SearchResult is a matching idea, or a matching evaluation of IdeaId.
+type SearchResult struct {
+	Type         string     `json:"type"`
+	IdeaId       int        `json:"idea_id"`
+	EvaluationId int        `json:"evaluation_id,omitempty"`
+	Kind         string     `json:"kind,omitempty"`
+	Rank         float64    `json:"rank"`
+	Snippet      string     `json:"snippet"`
+	CreatedAt    *time.Time `json:"created_at"`
+}
+
+type SearchHandler interface {
+	Search(w http.ResponseWriter, r *http.Request)
+}
+
+type SearchUsecase interface {
+	Search(ctx context.Context, query SearchQuery) ([]SearchResult, error)
+}
+
+type SearchRepository interface {
+	// Search looks in the active workspace only
+	Search(ctx context.Context, query SearchQuery) ([]SearchResult, error)
+	// EnsureSearchIndex adds the tsvector columns and GIN indexes on
+	// Postgres; other databases are searched without an index
+	EnsureSearchIndex(ctx context.Context) error
+}
@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	// ShareLinkPrefix marks share tokens in URLs and logs
+	ShareLinkPrefix = "sh_"
+	// MaxShareLinkDays bounds how long a share link may live
+	MaxShareLinkDays = 365
+)
+
+// SharedEvaluationKinds are the evaluations a share link shows; tagging and
+// comparison runs stay internal.
+var SharedEvaluationKinds = []string{PromptCritic, PromptPanel, PromptDefend, PromptImprove}
+
+// ShareLink lets anybody holding its token read an idea, or a single
+// evaluation of it, without an account. Only the hash of the signed token is
+// stored; the token is returned once on creation.
+type ShareLink struct {
+	Id          int `json:"id" gorm:"primaryKey"`
+	WorkspaceId int `json:"workspace_id" gorm:"index"`
+	// UserId is who created the link
+	UserId int `json:"user_id" gorm:"index;not null"`
+	IdeaId int `json:"idea_id" gorm:"index;not null"`
+	// EvaluationId limits the link to one evaluation, 0 shares the idea
+	// with all its evaluations
+	EvaluationId int        `json:"evaluation_id,omitempty"`
+	Prefix       string     `json:"prefix" gorm:"type:varchar(16);not null"`
+	TokenHash    string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	Views        int64      `json:"views" gorm:"not null;default:0"`
+	LastViewedAt *time.Time `json:"last_viewed_at"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	CreatedAt    *time.Time `json:"created_at"`
+}
+
+// Active reports whether the link can still be opened at now.
+func (l ShareLink) Active(now time.Time) bool {
+	return l.RevokedAt == nil && (l.ExpiresAt == nil || now.Before(*l.ExpiresAt))
+}
+
+type CreateShareLinkRequest struct {
+	IdeaId       int `json:"idea_id"`
+	EvaluationId int `json:"evaluation_id"`
+	// ExpiresInDays is how long the link works, forever when 0
+	ExpiresInDays int `json:"expires_in_days"`
+}
+
+type CreateShareLinkResponse struct {
+	ShareLink
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+// SharedIdea is an idea without its owner, workspace or internal fields.
+type SharedIdea struct {
+	Idea      string     `json:"idea"`
+	Status    string     `json:"status"`
+	Score     *float64   `json:"score"`
+	Language  string     `json:"language,omitempty"`
+	CreatedAt *time.Time `json:"created_at"`
+	IdeaFields
+}
+
+// SharedEvaluation is the model answer of an evaluation with its scores,
+// leaving out the prompt, model, usage and feedback.
+type SharedEvaluation struct {
+	Kind             string           `json:"kind"`
+	Persona          string           `json:"persona,omitempty"`
+	Output           string           `json:"output"`
+	ScoreOriginality int              `json:"score_originality"`
+	ScoreScalability int              `json:"score_scalability"`
+	ScoreFeasibility int              `json:"score_feasibility"`
+	DimensionScores  []DimensionScore `json:"dimension_scores,omitempty"`
+	OverallScore     *float64         `json:"overall_score,omitempty"`
+	Weaknesses       []Weakness       `json:"weaknesses,omitempty"`
+	CreatedAt        *time.Time       `json:"created_at"`
+}
+
+// SharedView is what the public share endpoint returns.
+type SharedView struct {
+	Idea        SharedIdea         `json:"idea"`
+	Evaluations []SharedEvaluation `json:"evaluations"`
+	Views       int64              `json:"views"`
+	ExpiresAt   *time.Time         `json:"expires_at"`
+}
+
+type ShareLinkFilter struct {
+	// UserId is ignored when it is 0, listing the links of the workspace
+	UserId int
+	IdeaId int
+}
+
+type ShareHandler interface {
+	ListShareLinks(w http.ResponseWriter, r *http.Request)
+	CreateShareLink(w http.ResponseWriter, r *http.Request)
+	RevokeShareLink(w http.ResponseWriter, r *http.Request)
+	GetSharedView(w http.ResponseWriter, r *http.Request)
+}
+
+type ShareUsecase interface {
+	// ListShareLinks lists the caller's links of the active workspace, or
+	// those of every member for a workspace admin
+	ListShareLinks(ctx context.Context, ideaId int) ([]ShareLink, error)
+	CreateShareLink(ctx context.Context, req CreateShareLinkRequest) (CreateShareLinkResponse, error)
+	RevokeShareLink(ctx context.Context, id int) error
+	// GetSharedView opens a link without authentication and counts the view
+	GetSharedView(ctx context.Context, token string) (SharedView, error)
+}
+
+type ShareRepository interface {
+	ListShareLinks(ctx context.Context, filter ShareLinkFilter) ([]ShareLink, error)
+	GetShareLink(ctx context.Context, id int) (ShareLink, error)
+	// FindShareLinkByHash is not limited to the active workspace
+	FindShareLinkByHash(ctx context.Context, hash string) (ShareLink, error)
+	CreateShareLink(ctx context.Context, link ShareLink) (ShareLink, error)
+	RevokeShareLink(ctx context.Context, id int) error
+	// RecordShareView counts a view of the link
+	RecordShareView(ctx context.Context, id int) error
+}
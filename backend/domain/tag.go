@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	TagSourceManual = "manual"
+	TagSourceAuto   = "auto"
+
+	// MaxTagLength is the longest tag name in characters
+	MaxTagLength = 64
+	// MaxIdeaTags caps the tags on a single idea
+	MaxIdeaTags = 10
+)
+
+// Tag is a label from the workspace vocabulary such as "fintech". Names are
+// stored normalised, see NormalizeTags.
+type Tag struct {
+	Id          int        `json:"id" gorm:"primaryKey"`
+	WorkspaceId int        `json:"workspace_id" gorm:"not null;uniqueIndex:idx_workspace_tag"`
+	Name        string     `json:"name" gorm:"type:varchar(64);not null;uniqueIndex:idx_workspace_tag"`
+	CreatedAt   *time.Time `json:"created_at"`
+	// Ideas counts the live ideas with the tag, filled when listing the
+	// vocabulary
+	Ideas int `json:"ideas,omitempty" gorm:"->;-:migration"`
+	// Source tells how the tag got on an idea, filled for the tags of an idea
+	Source string `json:"source,omitempty" gorm:"->;-:migration"`
+}
+
+type IdeaTag struct {
+	IdeaId    int        `json:"idea_id" gorm:"primaryKey"`
+	TagId     int        `json:"tag_id" gorm:"primaryKey;index"`
+	Source    string     `json:"source" gorm:"type:varchar(16);not null"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+type TagRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// NormalizeTags lower cases the names, collapses their whitespace and drops
+// duplicates. Empty or overlong names and control characters are rejected.
+func NormalizeTags(names []string) ([]string, error) {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, name := range names {
+		name = strings.ToLower(strings.Join(strings.Fields(name), " "))
+		if name == "" || len([]rune(name)) > MaxTagLength {
+			return nil, fmt.Errorf("%w: tags must be 1 to %d characters", ErrBadRequest, MaxTagLength)
+		}
+		for _, r := range name {
+			if unicode.IsControl(r) {
+				return nil, fmt.Errorf("%w: tag %q contains control characters", ErrBadRequest, name)
+			}
+		}
+		if !seen[name] {
+			seen[name] = true
+			normalized = append(normalized, name)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("%w: tags are required", ErrBadRequest)
+	}
+	return normalized, nil
+}
+
+type TagHandler interface {
+	ListTags(w http.ResponseWriter, r *http.Request)
+	CreateTag(w http.ResponseWriter, r *http.Request)
+	DeleteTag(w http.ResponseWriter, r *http.Request)
+}
+
+type TagUsecase interface {
+	ListTags(ctx context.Context) ([]Tag, error)
+	CreateTags(ctx context.Context, names []string) ([]Tag, error)
+	DeleteTag(ctx context.Context, id int) error
+}
+
+// TagRepository works on the vocabulary of the active workspace.
+type TagRepository interface {
+	ListTags(ctx context.Context) ([]Tag, error)
+	GetTag(ctx context.Context, id int) (Tag, error)
+	// CreateTags returns the tags named names, creating the missing ones
+	CreateTags(ctx context.Context, names []string) ([]Tag, error)
+	DeleteTag(ctx context.Context, id int) error
+	AddIdeaTags(ctx context.Context, ideaId int, tagIds []int, source string) error
+	RemoveIdeaTag(ctx context.Context, ideaId, tagId int) error
+	// ListIdeaTags returns the tags of each idea keyed by idea id
+	ListIdeaTags(ctx context.Context, ideaIds []int) (map[int][]Tag, error)
+}
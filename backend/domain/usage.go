@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	UsagePeriodDay   = "day"
+	UsagePeriodMonth = "month"
+
+	QuotaUnitEvaluations = "evaluations"
+	QuotaUnitTokens      = "tokens"
+)
+
+// RateLimitBucket is a token bucket persisted so limits survive restarts and
+// are shared between instances.
+type RateLimitBucket struct {
+	BucketKey string    `json:"bucket_key" gorm:"type:varchar(128);primaryKey"`
+	Tokens    float64   `json:"tokens" gorm:"not null"`
+	Allowed   bool      `json:"allowed" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null"`
+}
+
+// UsageCounter aggregates LLM usage of one user for a day or a month
+// (PeriodStart is the first instant of the period in UTC).
+type UsageCounter struct {
+	Id          int       `json:"-" gorm:"primaryKey"`
+	UserId      int       `json:"user_id" gorm:"not null;uniqueIndex:idx_usage_period"`
+	Period      string    `json:"period" gorm:"type:varchar(8);not null;uniqueIndex:idx_usage_period"`
+	PeriodStart time.Time `json:"period_start" gorm:"not null;uniqueIndex:idx_usage_period"`
+	Evaluations int64     `json:"evaluations" gorm:"not null;default:0"`
+	Tokens      int64     `json:"tokens" gorm:"not null;default:0"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// QuotaStatus describes the tightest quota window of a user, or the
+// exhausted one when Exceeded is set.
+type QuotaStatus struct {
+	Plan      string    `json:"plan"`
+	Period    string    `json:"period"`
+	Unit      string    `json:"unit"`
+	Limit     int64     `json:"limit"`
+	Used      int64     `json:"used"`
+	Remaining int64     `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+	Exceeded  bool      `json:"exceeded"`
+	// Unlimited is set when the plan has no quota at all
+	Unlimited bool `json:"unlimited"`
+}
+
+type UsageSummary struct {
+	Plan  Plan         `json:"plan"`
+	Day   UsageCounter `json:"day"`
+	Month UsageCounter `json:"month"`
+	Quota QuotaStatus  `json:"quota"`
+}
+
+type UsageHandler interface {
+	GetUsage(w http.ResponseWriter, r *http.Request)
+	GetUserUsage(w http.ResponseWriter, r *http.Request)
+}
+
+type UsageUsecase interface {
+	// Allow takes one token from the bucket of key
+	Allow(ctx context.Context, key string, plan Plan) (RateLimitResult, error)
+	CheckQuota(ctx context.Context, user User) (QuotaStatus, error)
+	// CheckQuotaFor checks the quota for a request making calls model calls:
+	// an evaluations window with fewer than calls left counts as exceeded.
+	// Tokens are not known up front, so token windows only need some left.
+	CheckQuotaFor(ctx context.Context, user User, calls int64) (QuotaStatus, error)
+	RecordUsage(ctx context.Context, userId int, tokens int64) error
+	GetUsage(ctx context.Context) (UsageSummary, error)
+	GetUserUsage(ctx context.Context, userId int) (UsageSummary, error)
+}
+
+type UsageRepository interface {
+	// TakeToken refills and decrements the bucket atomically and reports the
+	// tokens left and whether one could be taken.
+	TakeToken(ctx context.Context, key string, capacity, perSecond float64) (RateLimitBucket, error)
+	GetUsageCounters(ctx context.Context, userId int, dayStart, monthStart time.Time) (day, month UsageCounter, err error)
+	IncrementUsage(ctx context.Context, userId int, period string, periodStart time.Time, evaluations, tokens int64) error
+}
@@ -0,0 +1,177 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type User struct {
+	Id        int        `json:"id" gorm:"primaryKey"`
+	Username  string     `json:"username" gorm:"type:varchar(255);not null;uniqueIndex"`
+	Email     string     `json:"email" gorm:"type:varchar(255);not null;uniqueIndex"`
+	Password  string     `json:"-" gorm:"type:varchar(255);not null"`
+	Role      string     `json:"role" gorm:"type:varchar(32);not null;default:member"`
+	Plan      string     `json:"plan" gorm:"type:varchar(32);not null;default:free"`
+	CreatedAt *time.Time `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+	// EmailVerifiedAt is set once the user opened the verification link or
+	// signed in through a provider that verified the address.
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	// OIDCSubject links the account to the identity provider "sub" claim for
+	// single sign-on; nil for password-only accounts.
+	OIDCSubject *string `json:"-" gorm:"column:oidc_subject;type:varchar(255);uniqueIndex"`
+	// Scopes narrows the role permissions when the request was made with an
+	// API key; nil means a regular session.
+	Scopes []string `json:"scopes,omitempty" gorm:"-"`
+}
+
+// RefreshToken is stored hashed. Tokens rotated from the same login share a
+// FamilyId so a replayed token can revoke the whole chain.
+type RefreshToken struct {
+	Id           int        `json:"id" gorm:"primaryKey"`
+	UserId       int        `json:"user_id" gorm:"index;not null"`
+	FamilyId     string     `json:"family_id" gorm:"type:varchar(64);index;not null"`
+	TokenHash    string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	ReplacedById int        `json:"replaced_by_id"`
+	CreatedAt    *time.Time `json:"created_at"`
+}
+
+// RevokedToken blacklists an access token by jti until it expires.
+type RevokedToken struct {
+	Jti       string     `json:"jti" gorm:"type:varchar(64);primaryKey"`
+	UserId    int        `json:"user_id" gorm:"index"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"index;not null"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+const (
+	TokenVerifyEmail   = "verify_email"
+	TokenResetPassword = "reset_password"
+)
+
+// UserToken is a single-use, expiring token mailed to the user. Only the hash
+// is stored.
+type UserToken struct {
+	Id        int        `json:"id" gorm:"primaryKey"`
+	UserId    int        `json:"user_id" gorm:"index;not null"`
+	Purpose   string     `json:"purpose" gorm:"type:varchar(32);not null"`
+	TokenHash string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+type RegisterRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type AuthResponse struct {
+	AccessToken      string    `json:"access_token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	User             User      `json:"user"`
+}
+
+type UserHandler interface {
+	Register(w http.ResponseWriter, r *http.Request)
+	Login(w http.ResponseWriter, r *http.Request)
+	Me(w http.ResponseWriter, r *http.Request)
+	Refresh(w http.ResponseWriter, r *http.Request)
+	Logout(w http.ResponseWriter, r *http.Request)
+	ListUsers(w http.ResponseWriter, r *http.Request)
+	UpdateRole(w http.ResponseWriter, r *http.Request)
+	UpdatePlan(w http.ResponseWriter, r *http.Request)
+	OIDCLogin(w http.ResponseWriter, r *http.Request)
+	OIDCCallback(w http.ResponseWriter, r *http.Request)
+	VerifyEmail(w http.ResponseWriter, r *http.Request)
+	ResendVerification(w http.ResponseWriter, r *http.Request)
+	ForgotPassword(w http.ResponseWriter, r *http.Request)
+	ResetPassword(w http.ResponseWriter, r *http.Request)
+}
+
+type UserUsecase interface {
+	Register(ctx context.Context, req RegisterRequest) (User, error)
+	Login(ctx context.Context, req LoginRequest) (AuthResponse, error)
+	Refresh(ctx context.Context, refreshToken string) (AuthResponse, error)
+	Logout(ctx context.Context, accessToken, refreshToken string) error
+	ListUsers(ctx context.Context) ([]User, error)
+	UpdateRole(ctx context.Context, id int, role string) (User, error)
+	UpdatePlan(ctx context.Context, id int, plan string) (User, error)
+	// OIDCAuthURL starts a single sign-on login and returns the identity
+	// provider URL to redirect the browser to.
+	OIDCAuthURL(ctx context.Context, redirectTo string) (string, error)
+	// OIDCCallback completes the login, provisioning the user on first sign
+	// in, and returns the session plus the frontend URL to land on ("" when
+	// no frontend is configured).
+	OIDCCallback(ctx context.Context, code, state string) (AuthResponse, string, error)
+	VerifyEmail(ctx context.Context, token string) (User, error)
+	ResendVerification(ctx context.Context) error
+	// ForgotPassword never reveals whether the email is registered
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, req ResetPasswordRequest) error
+}
+
+type UserRepository interface {
+	CreateUser(ctx context.Context, user User) (User, error)
+	FindById(ctx context.Context, id int) (User, error)
+	FindByEmail(ctx context.Context, email string) (User, error)
+	FindByOIDCSubject(ctx context.Context, subject string) (User, error)
+	LinkOIDCSubject(ctx context.Context, id int, subject string) error
+	ExistsByEmailOrUsername(ctx context.Context, email, username string) (bool, error)
+	ListUsers(ctx context.Context) ([]User, error)
+	UpdateRole(ctx context.Context, id int, role string) error
+	UpdatePlan(ctx context.Context, id int, plan string) error
+	UpdatePassword(ctx context.Context, id int, hash string) error
+	MarkEmailVerified(ctx context.Context, id int) error
+	CreateRefreshToken(ctx context.Context, token RefreshToken) (RefreshToken, error)
+	// FindRefreshTokenByHash locks the row when called inside a transaction
+	FindRefreshTokenByHash(ctx context.Context, hash string) (RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, id, replacedById int) error
+	RevokeRefreshTokenFamily(ctx context.Context, familyId string) error
+	RevokeUserRefreshTokens(ctx context.Context, userId int) error
+	RevokeAccessToken(ctx context.Context, token RevokedToken) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	CreateOIDCState(ctx context.Context, state OIDCState) error
+	// TakeOIDCState deletes and returns the state so it cannot be replayed
+	TakeOIDCState(ctx context.Context, stateHash string) (OIDCState, error)
+	CreateUserToken(ctx context.Context, token UserToken) (UserToken, error)
+	// FindUserTokenByHash locks the row when called inside a transaction
+	FindUserTokenByHash(ctx context.Context, hash string) (UserToken, error)
+	MarkUserTokenUsed(ctx context.Context, id int) error
+	// InvalidateUserTokens marks every unused token of purpose as used
+	InvalidateUserTokens(ctx context.Context, userId int, purpose string) error
+}
+
+// UserFromContext returns the authenticated user stored by AuthMiddleware.
+func UserFromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(ContextUser).(User)
+	return user, ok
+}
@@ -0,0 +1,187 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	WorkspaceOwner  = "owner"
+	WorkspaceAdmin  = "admin"
+	WorkspaceMember = "member"
+)
+
+// Workspace is a team sharing ideas. Every user gets a personal workspace on
+// first use; requests pick another one with the X-Workspace-Id header.
+type Workspace struct {
+	Id       int    `json:"id" gorm:"primaryKey"`
+	Name     string `json:"name" gorm:"type:varchar(128);not null"`
+	OwnerId  int    `json:"owner_id" gorm:"not null;index;index:idx_workspace_personal,unique,where:personal"`
+	Personal bool   `json:"personal" gorm:"not null;default:false"`
+	// DefaultModel overrides OLLAMA_MODEL for evaluations in the workspace
+	DefaultModel string `json:"default_model" gorm:"type:varchar(128)"`
+	// PromptVersions pins a prompt version per prompt name; missing names use
+	// the active version
+	PromptVersions map[string]int `json:"prompt_versions" gorm:"serializer:json"`
+	CreatedAt      *time.Time     `json:"created_at"`
+	UpdatedAt      *time.Time     `json:"updated_at"`
+	// MemberRole is the caller's role in the workspace
+	MemberRole string `json:"member_role,omitempty" gorm:"-"`
+}
+
+type WorkspaceMembership struct {
+	Id          int        `json:"id" gorm:"primaryKey"`
+	WorkspaceId int        `json:"workspace_id" gorm:"not null;uniqueIndex:idx_workspace_member"`
+	UserId      int        `json:"user_id" gorm:"not null;uniqueIndex:idx_workspace_member;index"`
+	Role        string     `json:"role" gorm:"type:varchar(16);not null"`
+	CreatedAt   *time.Time `json:"created_at"`
+	User        *User      `json:"user,omitempty" gorm:"foreignKey:UserId"`
+}
+
+// WorkspaceInvitation is mailed to Email and accepted with the plain token by
+// a user signed in with that address.
+type WorkspaceInvitation struct {
+	Id          int        `json:"id" gorm:"primaryKey"`
+	WorkspaceId int        `json:"workspace_id" gorm:"not null;index"`
+	Email       string     `json:"email" gorm:"type:varchar(255);not null"`
+	Role        string     `json:"role" gorm:"type:varchar(16);not null"`
+	TokenHash   string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	InvitedById int        `json:"invited_by_id"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	AcceptedAt  *time.Time `json:"accepted_at"`
+	CreatedAt   *time.Time `json:"created_at"`
+}
+
+type CreateWorkspaceRequest struct {
+	Name string `json:"name"`
+}
+
+type UpdateWorkspaceRequest struct {
+	Name           *string        `json:"name"`
+	DefaultModel   *string        `json:"default_model"`
+	PromptVersions map[string]int `json:"prompt_versions"`
+}
+
+type InviteMemberRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type UpdateMemberRequest struct {
+	Role string `json:"role"`
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token"`
+}
+
+type WorkspaceHandler interface {
+	ListWorkspaces(w http.ResponseWriter, r *http.Request)
+	CreateWorkspace(w http.ResponseWriter, r *http.Request)
+	GetWorkspace(w http.ResponseWriter, r *http.Request)
+	UpdateWorkspace(w http.ResponseWriter, r *http.Request)
+	DeleteWorkspace(w http.ResponseWriter, r *http.Request)
+	ListMembers(w http.ResponseWriter, r *http.Request)
+	UpdateMember(w http.ResponseWriter, r *http.Request)
+	RemoveMember(w http.ResponseWriter, r *http.Request)
+	ListInvitations(w http.ResponseWriter, r *http.Request)
+	InviteMember(w http.ResponseWriter, r *http.Request)
+	RevokeInvitation(w http.ResponseWriter, r *http.Request)
+	AcceptInvitation(w http.ResponseWriter, r *http.Request)
+}
+
+type WorkspaceUsecase interface {
+	// Resolve returns the workspace a request acts in: workspaceId when the
+	// user is a member of it, otherwise (workspaceId 0) the personal one.
+	Resolve(ctx context.Context, user User, workspaceId int) (Workspace, error)
+	ListWorkspaces(ctx context.Context) ([]Workspace, error)
+	CreateWorkspace(ctx context.Context, req CreateWorkspaceRequest) (Workspace, error)
+	GetWorkspace(ctx context.Context, id int) (Workspace, error)
+	UpdateWorkspace(ctx context.Context, id int, req UpdateWorkspaceRequest) (Workspace, error)
+	DeleteWorkspace(ctx context.Context, id int) error
+	ListMembers(ctx context.Context, id int) ([]WorkspaceMembership, error)
+	UpdateMember(ctx context.Context, id, userId int, role string) (WorkspaceMembership, error)
+	RemoveMember(ctx context.Context, id, userId int) error
+	ListInvitations(ctx context.Context, id int) ([]WorkspaceInvitation, error)
+	InviteMember(ctx context.Context, id int, req InviteMemberRequest) (WorkspaceInvitation, error)
+	RevokeInvitation(ctx context.Context, id, invitationId int) error
+	AcceptInvitation(ctx context.Context, token string) (Workspace, error)
+}
+
+type WorkspaceRepository interface {
+	GetWorkspace(ctx context.Context, id int) (Workspace, error)
+	FindPersonalWorkspace(ctx context.Context, userId int) (Workspace, error)
+	ListWorkspacesForUser(ctx context.Context, userId int) ([]Workspace, error)
+	CreateWorkspace(ctx context.Context, workspace Workspace) (Workspace, error)
+	UpdateWorkspace(ctx context.Context, workspace Workspace) error
+	DeleteWorkspace(ctx context.Context, id int) error
+	CountIdeas(ctx context.Context, workspaceId int) (int64, error)
+	// AdoptUnscopedIdeas moves ideas created before workspaces existed into
+	// the owner's personal workspace
+	AdoptUnscopedIdeas(ctx context.Context, userId, workspaceId int) error
+	GetMembership(ctx context.Context, workspaceId, userId int) (WorkspaceMembership, error)
+	ListMembers(ctx context.Context, workspaceId int) ([]WorkspaceMembership, error)
+	CountOwners(ctx context.Context, workspaceId int) (int64, error)
+	AddMember(ctx context.Context, member WorkspaceMembership) (WorkspaceMembership, error)
+	UpdateMemberRole(ctx context.Context, workspaceId, userId int, role string) error
+	RemoveMember(ctx context.Context, workspaceId, userId int) error
+	ListInvitations(ctx context.Context, workspaceId int) ([]WorkspaceInvitation, error)
+	GetInvitation(ctx context.Context, id int) (WorkspaceInvitation, error)
+	// FindInvitationByHash locks the row when called inside a transaction
+	FindInvitationByHash(ctx context.Context, hash string) (WorkspaceInvitation, error)
+	CreateInvitation(ctx context.Context, invitation WorkspaceInvitation) (WorkspaceInvitation, error)
+	AcceptInvitation(ctx context.Context, id int) error
+	DeleteInvitation(ctx context.Context, id int) error
+}
+
+var workspaceRank = map[string]int{
+	WorkspaceMember: 1,
+	WorkspaceAdmin:  2,
+	WorkspaceOwner:  3,
+}
+
+// HasMemberRole reports whether the caller's role in the workspace is role or
+// a more privileged one.
+func (w Workspace) HasMemberRole(role string) bool {
+	return workspaceRank[w.MemberRole] >= workspaceRank[role] && workspaceRank[w.MemberRole] > 0
+}
+
+// ManageWorkspace allows changes to what the members of the active workspace
+// share, such as its tags and rubrics, to its admins; in a personal
+// workspace that is its owner.
+func ManageWorkspace(ctx context.Context) error {
+	user, ok := UserFromContext(ctx)
+	if !ok {
+		return ErrUnauthorized
+	}
+	workspace, ok := WorkspaceFromContext(ctx)
+	if !ok || !user.Can(PermIdeaWriteOwn) || !workspace.HasMemberRole(WorkspaceAdmin) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// WorkspaceManager reports whether user looks after what every member of the
+// active workspace created, such as comments and share links, rather than
+// only their own: with the any idea permission, or as an admin of a team
+// workspace.
+func WorkspaceManager(ctx context.Context, user User) bool {
+	if user.Can(PermIdeaWriteAny) {
+		return true
+	}
+	workspace, ok := WorkspaceFromContext(ctx)
+	return ok && !workspace.Personal && user.Can(PermIdeaWriteOwn) && workspace.HasMemberRole(WorkspaceAdmin)
+}
+
+func IsValidWorkspaceRole(role string) bool {
+	_, ok := workspaceRank[role]
+	return ok
+}
+
+// WorkspaceFromContext returns the active workspace stored by
+// WorkspaceMiddleware.
+func WorkspaceFromContext(ctx context.Context) (Workspace, bool) {
+	workspace, ok := ctx.Value(ContextWorkspace).(Workspace)
+	return workspace, ok
+}
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	item[V any] struct {
+		value     V
+		expiresAt time.Time
+	}
+
+	// Cache is a small in-memory map whose entries expire after their TTL.
+	Cache[V any] struct {
+		mu    sync.RWMutex
+		items map[string]item[V]
+	}
+)
+
+func New[V any]() *Cache[V] {
+	return &Cache[V]{
+		items: map[string]item[V]{},
+	}
+}
+
+func (c *Cache[V]) Get(key string) (V, bool) {
+	c.mu.RLock()
+	entry, ok := c.items[key]
+	c.mu.RUnlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+func (c *Cache[V]) Set(key string, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.items[key] = item[V]{value: value, expiresAt: now.Add(ttl)}
+
+	// drop expired entries opportunistically so the map does not grow forever
+	if len(c.items)%256 == 0 {
+		for k, v := range c.items {
+			if now.After(v.expiresAt) {
+				delete(c.items, k)
+			}
+		}
+	}
+}
+
+func (c *Cache[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+}
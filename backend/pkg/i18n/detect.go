@@ -0,0 +1,67 @@
+package i18n
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minDetectHits is how many function words must be seen before Detect
+// trusts its guess.
+const minDetectHits = 2
+
+// stopwords are short function words that are frequent in one language and
+// rare in the other, which is enough to tell English from Indonesian.
+var stopwords = map[string][]string{
+	English: {
+		"the", "and", "of", "to", "is", "are", "for", "with", "that", "this",
+		"it", "on", "be", "as", "by", "an", "we", "our", "will", "can",
+		"not", "or", "from", "which", "they", "their", "have", "has", "would",
+		"should", "who", "what", "how", "into", "more", "than", "you", "your",
+	},
+	Indonesian: {
+		"yang", "dan", "di", "ke", "dari", "untuk", "dengan", "ini", "itu",
+		"tidak", "ada", "akan", "dalam", "pada", "adalah", "bisa", "kami",
+		"kita", "saya", "mereka", "juga", "atau", "karena", "sudah", "belum",
+		"agar", "supaya", "tetapi", "namun", "sebuah", "seperti", "lebih",
+		"bagi", "oleh", "para", "hanya", "masih", "jika", "kalau", "bahwa",
+		"setiap", "banyak", "harus", "dapat", "secara", "tersebut", "sangat",
+		"ingin", "yg", "tdk", "dgn", "utk",
+	},
+}
+
+var stopwordIndex = func() map[string]string {
+	index := map[string]string{}
+	for locale, words := range stopwords {
+		for _, word := range words {
+			index[word] = locale
+		}
+	}
+	return index
+}()
+
+// Detect guesses whether text is English or Indonesian by counting function
+// words. It returns "" when the text is too short or too mixed to tell.
+func Detect(text string) string {
+	hits := map[string]int{}
+	total := 0
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		if locale, ok := stopwordIndex[word]; ok {
+			hits[locale]++
+			total++
+		}
+	}
+	if total < minDetectHits {
+		return ""
+	}
+	switch {
+	case hits[Indonesian] > hits[English]:
+		return Indonesian
+	case hits[English] > hits[Indonesian]:
+		return English
+	default:
+		return ""
+	}
+}
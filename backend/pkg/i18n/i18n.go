@@ -0,0 +1,80 @@
+// Package i18n negotiates the response language, detects the language of
+// free text and translates the fixed API messages.
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	English    = "en"
+	Indonesian = "id"
+	// Default is used when the caller did not ask for a supported language
+	Default = English
+)
+
+var names = map[string]string{
+	English:    "English",
+	Indonesian: "Indonesian (Bahasa Indonesia)",
+}
+
+// Normalize maps a language tag such as "id-ID" or "EN_us" to a supported
+// locale. ok is false when the language is not supported.
+func Normalize(tag string) (locale string, ok bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	// "in" is the withdrawn ISO 639 code some older clients still send
+	if tag == "in" {
+		tag = Indonesian
+	}
+	_, ok = names[tag]
+	return tag, ok
+}
+
+// Name returns the English name of a supported locale, used to tell the
+// model which language to answer in, or "" when it is not supported.
+func Name(locale string) string {
+	return names[locale]
+}
+
+// Negotiate picks the supported locale the Accept-Language header prefers
+// most, or "" when it names none.
+func Negotiate(header string) string {
+	type candidate struct {
+		locale string
+		q      float64
+	}
+	candidates := []candidate{}
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		if strings.TrimSpace(tag) == "*" {
+			candidates = append(candidates, candidate{Default, q})
+			continue
+		}
+		if locale, ok := Normalize(tag); ok {
+			candidates = append(candidates, candidate{locale, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].locale
+}
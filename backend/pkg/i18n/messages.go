@@ -0,0 +1,233 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// catalog maps the English API messages to their translation per locale.
+// English is the source language and has no entry.
+var catalog = map[string]map[string]string{
+	Indonesian: {
+		// authentication and users
+		"Unauthorized":                                           "Tidak terautentikasi",
+		"Forbidden":                                              "Akses ditolak",
+		"Too many requests":                                      "Terlalu banyak permintaan",
+		"Quota exceeded":                                         "Kuota terlampaui",
+		"Internal server error":                                  "Terjadi kesalahan pada server",
+		"Login successful":                                       "Berhasil masuk",
+		"Logout successful":                                      "Berhasil keluar",
+		"Token refreshed successfully":                           "Token berhasil diperbarui",
+		"User registered successfully":                           "Pengguna berhasil didaftarkan",
+		"User retrieved successfully":                            "Pengguna berhasil diambil",
+		"Users retrieved successfully":                           "Daftar pengguna berhasil diambil",
+		"Role updated successfully":                              "Peran berhasil diperbarui",
+		"Plan updated successfully":                              "Paket berhasil diperbarui",
+		"Email verified successfully":                            "Email berhasil diverifikasi",
+		"Verification email sent":                                "Email verifikasi telah dikirim",
+		"Password reset successfully":                            "Kata sandi berhasil diatur ulang",
+		"If the email is registered, a reset link has been sent": "Jika email terdaftar, tautan pengaturan ulang telah dikirim",
+		"Error requesting password reset":                        "Gagal meminta pengaturan ulang kata sandi",
+		"Error listing users":                                    "Gagal mengambil daftar pengguna",
+
+		// api keys and usage
+		"API key created successfully, store it now as it will not be shown again": "Kunci API berhasil dibuat, simpan sekarang karena tidak akan ditampilkan lagi",
+		"API key revoked successfully":                                             "Kunci API berhasil dicabut",
+		"API keys retrieved successfully":                                          "Daftar kunci API berhasil diambil",
+		"Error listing api keys":                                                   "Gagal mengambil daftar kunci API",
+		"Error revoking api key":                                                   "Gagal mencabut kunci API",
+		"Usage retrieved successfully":                                             "Pemakaian berhasil diambil",
+		"Audit logs retrieved successfully":                                        "Log audit berhasil diambil",
+
+		// request parsing
+		"Invalid ID format":             "Format ID tidak valid",
+		"Invalid X-Workspace-Id header": "Header X-Workspace-Id tidak valid",
+		"Invalid owner_id":              "owner_id tidak valid",
+		"Invalid actor_id":              "actor_id tidak valid",
+		"Invalid workspace_id":          "workspace_id tidak valid",
+		"Invalid limit":                 "limit tidak valid",
+		"Invalid offset":                "offset tidak valid",
+		"Invalid from":                  "from tidak valid",
+		"Invalid to":                    "to tidak valid",
+		"Invalid min_score":             "min_score tidak valid",
+		"Invalid max_score":             "max_score tidak valid",
+		"Invalid rubric_id":             "rubric_id tidak valid",
+		"Invalid concurrency":           "concurrency tidak valid",
+		"Invalid format":                "Format tidak valid",
+		"Error reading request body":    "Gagal membaca isi permintaan",
+		"Error parsing request body":    "Gagal mengurai isi permintaan",
+
+		// ideas
+		"Idea submitted successfully":          "Ide berhasil dikirim",
+		"Idea retrieved successfully":          "Ide berhasil diambil",
+		"Ideas retrieved successfully":         "Daftar ide berhasil diambil",
+		"Idea updated successfully":            "Ide berhasil diperbarui",
+		"Idea deleted successfully":            "Ide berhasil dihapus",
+		"Idea restored successfully":           "Ide berhasil dipulihkan",
+		"Idea status updated successfully":     "Status ide berhasil diperbarui",
+		"Idea workflow retrieved successfully": "Alur kerja ide berhasil diambil",
+		"Idea tagged successfully":             "Tag ide berhasil ditambahkan",
+		"Panel review completed successfully":  "Tinjauan panel berhasil diselesaikan",
+		"Evaluation rated successfully":        "Evaluasi berhasil dinilai",
+		"Search completed successfully":        "Pencarian berhasil diselesaikan",
+		"Error processing idea":                "Gagal memproses ide",
+		"Error retrieving idea":                "Gagal mengambil ide",
+		"Error listing ideas":                  "Gagal mengambil daftar ide",
+		"Error saving idea":                    "Gagal menyimpan ide",
+		"Error updating idea":                  "Gagal memperbarui ide",
+		"Error deleting idea":                  "Gagal menghapus ide",
+		"Error restoring idea":                 "Gagal memulihkan ide",
+		"Error changing idea status":           "Gagal mengubah status ide",
+		"Error getting idea workflow":          "Gagal mengambil alur kerja ide",
+		"Error tagging idea":                   "Gagal menambahkan tag ide",
+		"Error untagging idea":                 "Gagal menghapus tag ide",
+		"Error running panel review":           "Gagal menjalankan tinjauan panel",
+		"Error rating evaluation":              "Gagal menilai evaluasi",
+		"Ideas compared successfully":          "Ide berhasil dibandingkan",
+		"Comparison retrieved successfully":    "Perbandingan berhasil diambil",
+		"Error comparing ideas":                "Gagal membandingkan ide",
+		"Error retrieving comparison":          "Gagal mengambil perbandingan",
+		"Idea report generated successfully":   "Laporan ide berhasil dibuat",
+		"Error generating idea report":         "Gagal membuat laporan ide",
+
+		// model fallbacks
+		"I'm sorry, I couldn't process your idea at this time.":    "Maaf, saya belum bisa memproses ide Anda saat ini.",
+		"I'm sorry, I couldn't process your defense at this time.": "Maaf, saya belum bisa memproses pembelaan Anda saat ini.",
+		"I'm sorry, I couldn't review your idea at this time.":     "Maaf, saya belum bisa meninjau ide Anda saat ini.",
+		"I'm sorry, I couldn't merge the reviews at this time.":    "Maaf, saya belum bisa menggabungkan tinjauan saat ini.",
+		"I'm sorry, I couldn't compare the ideas at this time.":    "Maaf, saya belum bisa membandingkan ide-ide ini saat ini.",
+		"I'm sorry, I couldn't judge the ideas at this time.":      "Maaf, saya belum bisa menilai ide-ide ini saat ini.",
+
+		// tags, rubrics and personas
+		"Tags created successfully":       "Tag berhasil dibuat",
+		"Tags retrieved successfully":     "Daftar tag berhasil diambil",
+		"Tag deleted successfully":        "Tag berhasil dihapus",
+		"Tag removed successfully":        "Tag berhasil dilepas",
+		"Rubric created successfully":     "Rubrik berhasil dibuat",
+		"Rubric retrieved successfully":   "Rubrik berhasil diambil",
+		"Rubrics retrieved successfully":  "Daftar rubrik berhasil diambil",
+		"Rubric updated successfully":     "Rubrik berhasil diperbarui",
+		"Rubric deleted successfully":     "Rubrik berhasil dihapus",
+		"Persona created successfully":    "Persona berhasil dibuat",
+		"Personas retrieved successfully": "Daftar persona berhasil diambil",
+		"Persona updated successfully":    "Persona berhasil diperbarui",
+		"Persona deleted successfully":    "Persona berhasil dihapus",
+
+		// batch jobs
+		"Batch job created successfully":     "Tugas batch berhasil dibuat",
+		"Batch jobs retrieved successfully":  "Daftar tugas batch berhasil diambil",
+		"Batch job retrieved successfully":   "Tugas batch berhasil diambil",
+		"Batch items retrieved successfully": "Baris tugas batch berhasil diambil",
+		"Batch job cancelled successfully":   "Tugas batch berhasil dibatalkan",
+		"Error reading uploaded file":        "Gagal membaca berkas yang diunggah",
+		"Streaming is not supported":         "Streaming tidak didukung",
+
+		// background jobs
+		"Job queued successfully":               "Pekerjaan berhasil diantrekan",
+		"Jobs retrieved successfully":           "Daftar pekerjaan berhasil diambil",
+		"Job retrieved successfully":            "Pekerjaan berhasil diambil",
+		"Job cancelled successfully":            "Pekerjaan berhasil dibatalkan",
+		"Job queued again successfully":         "Pekerjaan berhasil diantrekan ulang",
+		"Job statistics retrieved successfully": "Statistik pekerjaan berhasil diambil",
+
+		// share links
+		"Share link created successfully, store it now as it will not be shown again": "Tautan berbagi berhasil dibuat, simpan sekarang karena tidak akan ditampilkan lagi",
+		"Share link revoked successfully":                                             "Tautan berbagi berhasil dicabut",
+		"Share links retrieved successfully":                                          "Daftar tautan berbagi berhasil diambil",
+		"Shared idea retrieved successfully":                                          "Ide yang dibagikan berhasil diambil",
+		"Share link not found":                                                        "Tautan berbagi tidak ditemukan",
+		"Invalid idea_id":                                                             "idea_id tidak valid",
+
+		// comments
+		"Comments retrieved successfully":        "Daftar komentar berhasil diambil",
+		"Comment created successfully":           "Komentar berhasil dibuat",
+		"Comment updated successfully":           "Komentar berhasil diperbarui",
+		"Comment deleted successfully":           "Komentar berhasil dihapus",
+		"Comment history retrieved successfully": "Riwayat komentar berhasil diambil",
+		"Invalid evaluation_id":                  "evaluation_id tidak valid",
+
+		// prompts and experiments
+		"Prompt created successfully":               "Prompt berhasil dibuat",
+		"Prompt retrieved successfully":             "Prompt berhasil diambil",
+		"Prompts retrieved successfully":            "Daftar prompt berhasil diambil",
+		"Prompt activated successfully":             "Prompt berhasil diaktifkan",
+		"Prompt deleted successfully":               "Prompt berhasil dihapus",
+		"Error listing prompts":                     "Gagal mengambil daftar prompt",
+		"Error retrieving prompt":                   "Gagal mengambil prompt",
+		"Error activating prompt":                   "Gagal mengaktifkan prompt",
+		"Experiment created successfully":           "Eksperimen berhasil dibuat",
+		"Experiment retrieved successfully":         "Eksperimen berhasil diambil",
+		"Experiments retrieved successfully":        "Daftar eksperimen berhasil diambil",
+		"Experiment updated successfully":           "Eksperimen berhasil diperbarui",
+		"Experiment metrics retrieved successfully": "Metrik eksperimen berhasil diambil",
+		"Error listing experiments":                 "Gagal mengambil daftar eksperimen",
+		"Error retrieving experiment":               "Gagal mengambil eksperimen",
+		"Error retrieving experiment metrics":       "Gagal mengambil metrik eksperimen",
+
+		// workspaces
+		"Workspace created successfully":     "Ruang kerja berhasil dibuat",
+		"Workspace retrieved successfully":   "Ruang kerja berhasil diambil",
+		"Workspaces retrieved successfully":  "Daftar ruang kerja berhasil diambil",
+		"Workspace updated successfully":     "Ruang kerja berhasil diperbarui",
+		"Workspace deleted successfully":     "Ruang kerja berhasil dihapus",
+		"Members retrieved successfully":     "Daftar anggota berhasil diambil",
+		"Member updated successfully":        "Anggota berhasil diperbarui",
+		"Member removed successfully":        "Anggota berhasil dikeluarkan",
+		"Invitation sent successfully":       "Undangan berhasil dikirim",
+		"Invitations retrieved successfully": "Daftar undangan berhasil diambil",
+		"Invitation accepted successfully":   "Undangan berhasil diterima",
+		"Invitation revoked successfully":    "Undangan berhasil dibatalkan",
+	},
+}
+
+// prefixes translates messages built from a fixed prefix and a variable
+// part, such as the wrapped domain errors. The variable part is translated
+// again, so "bad request: Invalid ID format" is translated as a whole.
+var prefixes = map[string][]struct {
+	prefix string
+	format string
+}{
+	Indonesian: {
+		{"bad request: ", "permintaan tidak valid: %s"},
+		{"conflict: ", "konflik: %s"},
+		{"resource not found: ", "data tidak ditemukan: %s"},
+		{"unauthorized: ", "tidak terautentikasi: %s"},
+		{"forbidden: ", "akses ditolak: %s"},
+		{"quota exceeded: ", "kuota terlampaui: %s"},
+		// the rest is the OAuth error code of the identity provider
+		{"Single sign-on failed: ", "Single sign-on gagal: %s"},
+	},
+}
+
+// sentinels are the bare domain errors, returned without any detail.
+var sentinels = map[string]map[string]string{
+	Indonesian: {
+		"bad request":        "permintaan tidak valid",
+		"conflict":           "konflik",
+		"resource not found": "data tidak ditemukan",
+		"unauthorized":       "tidak terautentikasi",
+		"forbidden":          "akses ditolak",
+		"quota exceeded":     "kuota terlampaui",
+	},
+}
+
+// Translate returns message in locale. Messages without a translation, and
+// any message for English or an unsupported locale, are returned unchanged.
+func Translate(locale, message string) string {
+	messages, ok := catalog[locale]
+	if !ok || message == "" {
+		return message
+	}
+	if translated, ok := messages[message]; ok {
+		return translated
+	}
+	if translated, ok := sentinels[locale][message]; ok {
+		return translated
+	}
+	for _, p := range prefixes[locale] {
+		if rest, found := strings.CutPrefix(message, p.prefix); found && rest != "" {
+			return fmt.Sprintf(p.format, Translate(locale, rest))
+		}
+	}
+	return message
+}
@@ -2,23 +2,35 @@ package jwt
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
 	"time"
 
+	"github.com/Kocannn/self-dunking-ai/domain"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/hammer-code/lms-be/domain"
 )
 
 // JwtCustomClaims represents the custom claims for JWT with durationTime in Minuates
 func (j *jwtConfig) GenerateAccessToken(c context.Context, user *domain.User, durationTokenInMinuates int) (*string, time.Time, error) {
 	expiredTime := time.Now().Local().Add(time.Duration(durationTokenInMinuates) * time.Minute)
 
+	jti, err := newTokenId()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
 	claims := JwtCustomClaims{
-		ID:       user.ID,
+		ID:       user.Id,
 		UserName: user.Username,
 		Email:    user.Email,
 		Role:     user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiredTime),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   strconv.Itoa(user.Id),
+			// jti lets a single access token be revoked on logout
+			ID: jti,
 		},
 	}
 
@@ -29,3 +41,11 @@ func (j *jwtConfig) GenerateAccessToken(c context.Context, user *domain.User, du
 		return &encodedToken, expiredTime, err
 	}
 }
+
+func newTokenId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -4,8 +4,8 @@ import (
 	"context"
 	"time"
 
+	"github.com/Kocannn/self-dunking-ai/domain"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/hammer-code/lms-be/domain"
 )
 
 type (
@@ -1,17 +1,25 @@
 package jwt
 
 import (
+	"errors"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// VerifyToken checks the HMAC signature and requires a non-expired exp claim.
 func (j *jwtConfig) VerifyToken(token string) (*JwtCustomClaims, error) {
 	tkn, err := jwt.ParseWithClaims(token, &JwtCustomClaims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(j.SecretKey), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
 
 	if err != nil {
 		return nil, err
 	}
 
-	return tkn.Claims.(*JwtCustomClaims), nil
+	claims, ok := tkn.Claims.(*JwtCustomClaims)
+	if !ok || !tkn.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	return claims, nil
 }
@@ -0,0 +1,174 @@
+package mailer
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/Kocannn/self-dunking-ai/pkg/mailer/mailertest"
+)
+
+func newTestMailer(t *testing.T) (*mailertest.Sink, *Mailer) {
+	t.Helper()
+	sink, err := mailertest.NewSink()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(sink.Close)
+	return sink, New(Config{Host: sink.Host, Port: sink.Port, From: "Inverta <noreply@inverta.test>"})
+}
+
+func TestSend(t *testing.T) {
+	sink, m := newTestMailer(t)
+
+	err := m.Send(context.Background(), Message{
+		To:      "Ada <ada@example.com>",
+		Subject: "Überprüfung",
+		Text:    "Hello Ada,\n.\nbye",
+		HTML:    "<p>Hello Ada</p>",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := sink.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("sink got %d messages, want 1", len(messages))
+	}
+	got := messages[0]
+	if got.From != "noreply@inverta.test" || len(got.To) != 1 || got.To[0] != "ada@example.com" {
+		t.Errorf("envelope from %s to %v", got.From, got.To)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(got.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Überprüfung" {
+		t.Errorf("subject = %q (%v)", subject, err)
+	}
+	if to := msg.Header.Get("To"); to != `"Ada" <ada@example.com>` {
+		t.Errorf("To = %s", to)
+	}
+
+	parts := readParts(t, msg)
+	// a lone dot in the text survives the DATA transfer
+	if parts["text/plain"] != "Hello Ada,\n.\nbye" {
+		t.Errorf("text part = %q", parts["text/plain"])
+	}
+	if parts["text/html"] != "<p>Hello Ada</p>" {
+		t.Errorf("html part = %q", parts["text/html"])
+	}
+}
+
+func TestSendFailsWhenRejected(t *testing.T) {
+	sink, m := newTestMailer(t)
+	sink.Reject(1)
+
+	err := m.Send(context.Background(), Message{To: "ada@example.com", Subject: "hi", Text: "hi"})
+	if err == nil || !strings.Contains(err.Error(), "451") {
+		t.Fatalf("Send = %v, want the temporary failure", err)
+	}
+	if err := m.Send(context.Background(), Message{To: "ada@example.com", Subject: "hi", Text: "hi"}); err != nil {
+		t.Fatalf("Send after the rejection = %v", err)
+	}
+	if len(sink.Messages()) != 1 || sink.Attempts() != 2 {
+		t.Errorf("%d messages after %d attempts, want 1 after 2", len(sink.Messages()), sink.Attempts())
+	}
+}
+
+func TestSendRejectsInvalidRecipient(t *testing.T) {
+	sink, m := newTestMailer(t)
+
+	if err := m.Send(context.Background(), Message{To: "not an address", Text: "hi"}); err == nil {
+		t.Fatal("Send accepted an invalid recipient")
+	}
+	if sink.Attempts() != 0 {
+		t.Error("the sink was contacted for an invalid recipient")
+	}
+}
+
+func TestRender(t *testing.T) {
+	msg, err := Render(TemplateVerifyEmail, map[string]interface{}{
+		"AppName":   "Inverta",
+		"Username":  "<ada>",
+		"Link":      "https://app.example.com/verify-email?token=abc&x=1",
+		"ExpiresIn": "24 hours",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Subject != "Verify your Inverta email address" {
+		t.Errorf("subject = %q", msg.Subject)
+	}
+	// the text part is sent as is
+	for _, want := range []string{"Hi <ada>,", "https://app.example.com/verify-email?token=abc&x=1", "24 hours"} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("text part lacks %q:\n%s", want, msg.Text)
+		}
+	}
+	// the HTML part is escaped and wrapped in the layout
+	for _, want := range []string{"Hi &lt;ada&gt;,", `href="https://app.example.com/verify-email?token=abc&amp;x=1"`, "<h2 style=\"margin-top:0;\">Inverta</h2>"} {
+		if !strings.Contains(msg.HTML, want) {
+			t.Errorf("html part lacks %q:\n%s", want, msg.HTML)
+		}
+	}
+}
+
+func TestRenderEveryTemplate(t *testing.T) {
+	data := map[string]interface{}{
+		"AppName":   "Inverta",
+		"Username":  "ada",
+		"Link":      "https://app.example.com",
+		"ExpiresIn": "1 hour",
+	}
+	for _, name := range []string{TemplateVerifyEmail, TemplateResetPassword, TemplateWorkspaceInvite, TemplateCommentMention} {
+		msg, err := Render(name, data)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if msg.Subject == "" || msg.Text == "" || msg.HTML == "" {
+			t.Errorf("%s rendered an empty part: %+v", name, msg)
+		}
+	}
+	if _, err := Render("missing", data); err == nil {
+		t.Error("Render of an unknown template succeeded")
+	}
+}
+
+// readParts decodes the parts of a multipart/alternative message by media
+// type.
+func readParts(t *testing.T, msg *mail.Message) map[string]string {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %s (%v)", msg.Header.Get("Content-Type"), err)
+	}
+	parts := map[string]string{}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			return parts
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if part.Header.Get("Content-Transfer-Encoding") != "quoted-printable" {
+			t.Errorf("part is %s encoded", part.Header.Get("Content-Transfer-Encoding"))
+		}
+		body, err := io.ReadAll(quotedprintable.NewReader(part))
+		if err != nil {
+			t.Fatal(err)
+		}
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[partType] = string(body)
+	}
+}
@@ -0,0 +1,155 @@
+// Package mailertest runs a plain SMTP sink for tests. It offers neither
+// STARTTLS nor AUTH, keeps every accepted message and can be told to reject
+// deliveries to play an unavailable relay.
+package mailertest
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+type (
+	// Sink is the SMTP server; Host and Port are where it listens.
+	Sink struct {
+		Host string
+		Port string
+
+		listener net.Listener
+		wg       sync.WaitGroup
+
+		mu       sync.Mutex
+		messages []Message
+		// rejects is how many of the next deliveries fail
+		rejects int
+		// attempts counts the deliveries that reached DATA
+		attempts int
+	}
+
+	// Message is one accepted delivery as it went over the wire.
+	Message struct {
+		From string
+		To   []string
+		// Data is the raw message with the dot-stuffing undone
+		Data string
+	}
+)
+
+// NewSink starts a sink on a free local port. Close it when done.
+func NewSink() (*Sink, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	s := &Sink{Host: host, Port: port, listener: listener}
+
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Close stops listening and waits for open sessions to end.
+func (s *Sink) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+// Reject makes the next n deliveries fail with a temporary error.
+func (s *Sink) Reject(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejects = n
+}
+
+// Messages returns the accepted deliveries in arrival order.
+func (s *Sink) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Attempts returns how many deliveries were tried, rejected ones included.
+func (s *Sink) Attempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts
+}
+
+func (s *Sink) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.session(textproto.NewConn(conn))
+		}()
+	}
+}
+
+// session speaks just enough SMTP for net/smtp.
+func (s *Sink) session(conn *textproto.Conn) {
+	conn.PrintfLine("220 mailertest ready")
+	msg := Message{}
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			conn.PrintfLine("250 mailertest")
+		case "MAIL":
+			msg = Message{From: address(arg)}
+			conn.PrintfLine("250 ok")
+		case "RCPT":
+			msg.To = append(msg.To, address(arg))
+			conn.PrintfLine("250 ok")
+		case "DATA":
+			conn.PrintfLine("354 end with <CRLF>.<CRLF>")
+			data, err := conn.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.Data = string(data)
+			conn.PrintfLine("%s", s.deliver(msg))
+		case "RSET":
+			msg = Message{}
+			conn.PrintfLine("250 ok")
+		case "NOOP":
+			conn.PrintfLine("250 ok")
+		case "QUIT":
+			conn.PrintfLine("221 bye")
+			return
+		default:
+			conn.PrintfLine("502 command not implemented")
+		}
+	}
+}
+
+// deliver keeps msg unless a rejection is pending and returns the reply.
+func (s *Sink) deliver(msg Message) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts++
+	if s.rejects > 0 {
+		s.rejects--
+		return "451 mailbox temporarily unavailable"
+	}
+	s.messages = append(s.messages, msg)
+	return "250 queued"
+}
+
+// address strips FROM:<...> or TO:<...> down to the address.
+func address(arg string) string {
+	_, addr, _ := strings.Cut(arg, ":")
+	addr, _, _ = strings.Cut(strings.TrimSpace(addr), " ")
+	return strings.Trim(addr, "<>")
+}
@@ -0,0 +1,63 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+const (
+	TemplateVerifyEmail     = "verify_email"
+	TemplateResetPassword   = "reset_password"
+	TemplateWorkspaceInvite = "workspace_invite"
+	TemplateCommentMention  = "comment_mention"
+)
+
+// Each template is a set of <name>.subject.tmpl, <name>.txt.tmpl and
+// <name>.html.tmpl files; the HTML one is escaped with html/template.
+//
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Render executes the named template set and returns a message without a
+// recipient.
+func Render(name string, data interface{}) (Message, error) {
+	subject, err := renderText(name+".subject.tmpl", data)
+	if err != nil {
+		return Message{}, err
+	}
+	text, err := renderText(name+".txt.tmpl", data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	tpl, err := htmltemplate.ParseFS(templateFS, "templates/layout.html.tmpl", "templates/"+name+".html.tmpl")
+	if err != nil {
+		return Message{}, fmt.Errorf("mailer: template %s: %w", name, err)
+	}
+	html := &bytes.Buffer{}
+	if err := tpl.ExecuteTemplate(html, "layout", data); err != nil {
+		return Message{}, fmt.Errorf("mailer: template %s: %w", name, err)
+	}
+
+	return Message{
+		Subject: strings.TrimSpace(subject),
+		Text:    text,
+		HTML:    html.String(),
+	}, nil
+}
+
+func renderText(file string, data interface{}) (string, error) {
+	tpl, err := texttemplate.ParseFS(templateFS, "templates/"+file)
+	if err != nil {
+		return "", fmt.Errorf("mailer: template %s: %w", file, err)
+	}
+	out := &bytes.Buffer{}
+	if err := tpl.Execute(out, data); err != nil {
+		return "", fmt.Errorf("mailer: template %s: %w", file, err)
+	}
+	return out.String(), nil
+}
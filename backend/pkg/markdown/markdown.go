@@ -0,0 +1,393 @@
+// Package markdown reads the subset of Markdown the models answer in:
+// headings, paragraphs, nested lists, fenced code, quotes, rules and pipe
+// tables, with bold, italic, code and link spans. Every report format
+// renders from the same parsed blocks so they look alike.
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Kind int
+
+const (
+	Paragraph Kind = iota
+	Heading
+	List
+	Code
+	Quote
+	Rule
+	Table
+)
+
+// Span is a run of text with one style.
+type Span struct {
+	Text   string
+	Bold   bool
+	Italic bool
+	Code   bool
+	// Link is the target of a link, empty for plain text
+	Link string
+}
+
+// Item is a list item. Level 0 is the outermost list.
+type Item struct {
+	Level   int
+	Ordered bool
+	Number  int
+	Spans   []Span
+}
+
+type Block struct {
+	Kind Kind
+	// Level is the level of a heading, 1 to 6
+	Level int
+	// Spans is the text of a heading or a paragraph
+	Spans []Span
+	Items []Item
+	// Text and Lang are the content and language of a code block
+	Text string
+	Lang string
+	// Blocks are the content of a quote
+	Blocks []Block
+	Header [][]Span
+	Rows   [][][]Span
+}
+
+var (
+	headingPattern = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	rulePattern    = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	itemPattern    = regexp.MustCompile(`^([ \t]*)([-*+]|\d{1,9}[.)])[ \t]+(.*)$`)
+	fencePattern   = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})[ \t]*([^`\\s]*)")
+	delimiterRow   = regexp.MustCompile(`^[ \t]*\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+)
+
+// Parse splits src into blocks.
+func Parse(src string) []Block {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\t", "    ")
+	return parseLines(strings.Split(src, "\n"))
+}
+
+func parseLines(lines []string) []Block {
+	blocks := []Block{}
+	paragraph := []string{}
+	flush := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, Block{Kind: Paragraph, Spans: ParseInline(strings.Join(paragraph, " "))})
+			paragraph = paragraph[:0]
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+
+		case fencePattern.MatchString(line):
+			flush()
+			match := fencePattern.FindStringSubmatch(line)
+			fence, code := match[1], []string{}
+			for i++; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), fence[:3]) && strings.Trim(strings.TrimSpace(lines[i]), fence[:1]) == "" {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			blocks = append(blocks, Block{Kind: Code, Lang: match[2], Text: strings.Join(code, "\n")})
+
+		case headingPattern.MatchString(line):
+			flush()
+			match := headingPattern.FindStringSubmatch(line)
+			blocks = append(blocks, Block{Kind: Heading, Level: len(match[1]), Spans: ParseInline(match[2])})
+
+		case len(paragraph) > 0 && (strings.Trim(trimmed, "=") == "" || strings.Trim(trimmed, "-") == ""):
+			// a setext underline turns the paragraph into a heading
+			level := 2
+			if trimmed[0] == '=' {
+				level = 1
+			}
+			blocks = append(blocks, Block{Kind: Heading, Level: level, Spans: ParseInline(strings.Join(paragraph, " "))})
+			paragraph = paragraph[:0]
+
+		case rulePattern.MatchString(line):
+			flush()
+			blocks = append(blocks, Block{Kind: Rule})
+
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			quoted := []string{}
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				text := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quoted = append(quoted, strings.TrimPrefix(text, " "))
+			}
+			i--
+			blocks = append(blocks, Block{Kind: Quote, Blocks: parseLines(quoted)})
+
+		case itemPattern.MatchString(line) && (len(paragraph) == 0 || isListStart(line)):
+			flush()
+			var items []Item
+			items, i = parseList(lines, i)
+			blocks = append(blocks, Block{Kind: List, Items: items})
+
+		case strings.Contains(line, "|") && i+1 < len(lines) && delimiterRow.MatchString(lines[i+1]) && strings.Contains(lines[i+1], "-"):
+			flush()
+			table := Block{Kind: Table, Header: parseRow(line)}
+			for i += 2; i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != ""; i++ {
+				table.Rows = append(table.Rows, parseRow(lines[i]))
+			}
+			i--
+			blocks = append(blocks, table)
+
+		default:
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	flush()
+	return blocks
+}
+
+// isListStart tells a list that interrupts a paragraph from a line that
+// merely starts with a number.
+func isListStart(line string) bool {
+	match := itemPattern.FindStringSubmatch(line)
+	return match[1] == "" && (!isDigit(match[2][0]) || match[2][:len(match[2])-1] == "1")
+}
+
+// parseList reads the items starting at lines[start] and returns them with
+// the index of the last line used. Indented lines continue the previous
+// item; a blank line only ends the list when no item follows it.
+func parseList(lines []string, start int) ([]Item, int) {
+	items := []Item{}
+	indents := []int{}
+	i := start
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			next := i + 1
+			for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+				next++
+			}
+			if next < len(lines) && (itemPattern.MatchString(lines[next]) || indentOf(lines[next]) > 0) {
+				continue
+			}
+			break
+		}
+
+		match := itemPattern.FindStringSubmatch(line)
+		if match == nil || rulePattern.MatchString(line) {
+			if indentOf(line) == 0 || len(items) == 0 {
+				break
+			}
+			last := &items[len(items)-1]
+			last.Spans = append(last.Spans, Span{Text: " "})
+			last.Spans = append(last.Spans, ParseInline(strings.TrimSpace(line))...)
+			continue
+		}
+
+		indent := len(match[1])
+		for len(indents) > 0 && indent < indents[len(indents)-1] {
+			indents = indents[:len(indents)-1]
+		}
+		if len(indents) == 0 || indent > indents[len(indents)-1]+1 {
+			indents = append(indents, indent)
+		}
+		item := Item{Level: len(indents) - 1, Spans: ParseInline(match[3])}
+		if isDigit(match[2][0]) {
+			item.Ordered = true
+			item.Number, _ = strconv.Atoi(match[2][:len(match[2])-1])
+		}
+		if item.Level == 0 && len(items) > 0 && item.Ordered != items[0].Ordered {
+			// switching between bullets and numbers starts another list
+			break
+		}
+		items = append(items, item)
+	}
+	return items, i - 1
+}
+
+func parseRow(line string) [][]Span {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	cells := [][]Span{}
+	cell := strings.Builder{}
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, ParseInline(strings.TrimSpace(cell.String())))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, ParseInline(strings.TrimSpace(cell.String())))
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// ParseInline splits text into styled spans. Markers without a closing
+// counterpart stay literal text.
+func ParseInline(text string) []Span {
+	spans := []Span{}
+	current := strings.Builder{}
+	bold, italic := false, false
+	emit := func() {
+		if current.Len() > 0 {
+			spans = append(spans, Span{Text: current.String(), Bold: bold, Italic: italic})
+			current.Reset()
+		}
+	}
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte("\\`*_[]()#+-.!|>~", text[i+1]) >= 0:
+			current.WriteByte(text[i+1])
+			i++
+
+		case c == '`':
+			ticks := countRun(text, i, '`')
+			end := strings.Index(text[i+ticks:], strings.Repeat("`", ticks))
+			if end < 0 {
+				current.WriteString(text[i : i+ticks])
+				i += ticks - 1
+				continue
+			}
+			emit()
+			spans = append(spans, Span{Text: strings.TrimSpace(text[i+ticks : i+ticks+end]), Code: true})
+			i += ticks + end + ticks - 1
+
+		case c == '[':
+			close := strings.Index(text[i:], "](")
+			if close < 0 {
+				current.WriteByte(c)
+				continue
+			}
+			end := strings.IndexByte(text[i+close:], ')')
+			if end < 0 || strings.ContainsAny(text[i+1:i+close], "[]") {
+				current.WriteByte(c)
+				continue
+			}
+			emit()
+			link := strings.TrimSpace(text[i+close+2 : i+close+end])
+			spans = append(spans, Span{Text: text[i+1 : i+close], Bold: bold, Italic: italic, Link: link})
+			i += close + end
+
+		case c == '*' || c == '_':
+			run := countRun(text, i, c)
+			if c == '_' && (isWordByte(text, i-1) || isWordByte(text, i+run)) {
+				// snake_case and the like are not emphasis
+				current.WriteString(text[i : i+run])
+				i += run - 1
+				continue
+			}
+			marker := text[i : i+run]
+			toggleBold, toggleItalic := run >= 2, run == 1 || run == 3
+			opening := (toggleBold && !bold) || (toggleItalic && !italic)
+			if opening && (i+run >= len(text) || text[i+run] == ' ' || !strings.Contains(text[i+run:], marker)) {
+				current.WriteString(marker)
+				i += run - 1
+				continue
+			}
+			emit()
+			if toggleBold {
+				bold = !bold
+			}
+			if toggleItalic {
+				italic = !italic
+			}
+			i += run - 1
+
+		default:
+			current.WriteByte(c)
+		}
+	}
+	emit()
+	return merge(spans)
+}
+
+func countRun(text string, i int, c byte) int {
+	n := 0
+	for i+n < len(text) && text[i+n] == c && n < 3 {
+		n++
+	}
+	return n
+}
+
+func isWordByte(text string, i int) bool {
+	if i < 0 || i >= len(text) {
+		return false
+	}
+	c := text[i]
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c)
+}
+
+// merge joins neighbouring spans of the same style.
+func merge(spans []Span) []Span {
+	merged := []Span{}
+	for _, span := range spans {
+		if n := len(merged); n > 0 && span.Link == "" && !span.Code {
+			last := &merged[n-1]
+			if last.Link == "" && !last.Code && last.Bold == span.Bold && last.Italic == span.Italic {
+				last.Text += span.Text
+				continue
+			}
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
+
+// PlainText is the text of spans without any styling.
+func PlainText(spans []Span) string {
+	text := strings.Builder{}
+	for _, span := range spans {
+		text.WriteString(span.Text)
+	}
+	return text.String()
+}
+
+// ShiftHeadings moves the headings of blocks so the largest one has level
+// top, keeping their relative levels. Model answers are nested below the
+// report's own headings this way.
+func ShiftHeadings(blocks []Block, top int) []Block {
+	largest := 0
+	for _, block := range blocks {
+		if block.Kind == Heading && (largest == 0 || block.Level < largest) {
+			largest = block.Level
+		}
+	}
+	if largest == 0 {
+		return blocks
+	}
+
+	shifted := make([]Block, len(blocks))
+	for i, block := range blocks {
+		if block.Kind == Heading {
+			block.Level += top - largest
+			if block.Level > 6 {
+				block.Level = 6
+			}
+		}
+		shifted[i] = block
+	}
+	return shifted
+}
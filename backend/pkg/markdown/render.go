@@ -0,0 +1,229 @@
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Markdown writes blocks back as normalized Markdown.
+func Markdown(blocks []Block) string {
+	out := strings.Builder{}
+	for i, block := range blocks {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		writeMarkdown(&out, block)
+	}
+	return out.String()
+}
+
+func writeMarkdown(out *strings.Builder, block Block) {
+	switch block.Kind {
+	case Heading:
+		fmt.Fprintf(out, "%s %s\n", strings.Repeat("#", block.Level), inlineMarkdown(block.Spans))
+	case Paragraph:
+		fmt.Fprintf(out, "%s\n", inlineMarkdown(block.Spans))
+	case List:
+		// a nested item is indented by the width of its parents' markers
+		widths := []int{}
+		for _, item := range block.Items {
+			marker := "-"
+			if item.Ordered {
+				marker = fmt.Sprintf("%d.", item.Number)
+			}
+			if item.Level < len(widths) {
+				widths = widths[:item.Level]
+			}
+			indent := 0
+			for _, width := range widths {
+				indent += width
+			}
+			widths = append(widths, len(marker)+1)
+			fmt.Fprintf(out, "%s%s %s\n", strings.Repeat(" ", indent), marker, inlineMarkdown(item.Spans))
+		}
+	case Code:
+		fence := "```"
+		for strings.Contains(block.Text, fence) {
+			fence += "`"
+		}
+		fmt.Fprintf(out, "%s%s\n%s\n%s\n", fence, block.Lang, block.Text, fence)
+	case Quote:
+		for _, line := range strings.Split(strings.TrimRight(Markdown(block.Blocks), "\n"), "\n") {
+			out.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+		}
+	case Rule:
+		out.WriteString("---\n")
+	case Table:
+		writeRow(out, block.Header)
+		out.WriteString("|" + strings.Repeat(" --- |", len(block.Header)) + "\n")
+		for _, row := range block.Rows {
+			writeRow(out, row)
+		}
+	}
+}
+
+func writeRow(out *strings.Builder, cells [][]Span) {
+	out.WriteString("|")
+	for _, cell := range cells {
+		out.WriteString(" " + strings.ReplaceAll(inlineMarkdown(cell), "|", `\|`) + " |")
+	}
+	out.WriteString("\n")
+}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "`", "\\`", "[", `\[`)
+
+func inlineMarkdown(spans []Span) string {
+	out := strings.Builder{}
+	for _, span := range spans {
+		if span.Code {
+			ticks := "`"
+			for strings.Contains(span.Text, ticks) {
+				ticks += "`"
+			}
+			out.WriteString(ticks + span.Text + ticks)
+			continue
+		}
+
+		text := markdownEscaper.Replace(span.Text)
+		if span.Link != "" {
+			text = fmt.Sprintf("[%s](%s)", text, span.Link)
+		}
+		// keep the spaces outside the markers, which do not close otherwise
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || (!span.Bold && !span.Italic) {
+			out.WriteString(text)
+			continue
+		}
+		lead := text[:strings.Index(text, trimmed)]
+		trail := text[len(lead)+len(trimmed):]
+		marker := "*"
+		if span.Bold && span.Italic {
+			marker = "***"
+		} else if span.Bold {
+			marker = "**"
+		}
+		out.WriteString(lead + marker + trimmed + marker + trail)
+	}
+	return out.String()
+}
+
+// HTML renders blocks as an HTML fragment. Links only keep web and mail
+// targets.
+func HTML(blocks []Block) string {
+	out := strings.Builder{}
+	for _, block := range blocks {
+		writeHTML(&out, block)
+	}
+	return out.String()
+}
+
+func writeHTML(out *strings.Builder, block Block) {
+	switch block.Kind {
+	case Heading:
+		fmt.Fprintf(out, "<h%d>%s</h%d>\n", block.Level, InlineHTML(block.Spans), block.Level)
+	case Paragraph:
+		fmt.Fprintf(out, "<p>%s</p>\n", InlineHTML(block.Spans))
+	case List:
+		writeListHTML(out, block.Items)
+	case Code:
+		class := ""
+		if block.Lang != "" {
+			class = fmt.Sprintf(` class="language-%s"`, html.EscapeString(block.Lang))
+		}
+		fmt.Fprintf(out, "<pre><code%s>%s</code></pre>\n", class, html.EscapeString(block.Text))
+	case Quote:
+		fmt.Fprintf(out, "<blockquote>\n%s</blockquote>\n", HTML(block.Blocks))
+	case Rule:
+		out.WriteString("<hr>\n")
+	case Table:
+		out.WriteString("<table>\n<thead><tr>")
+		for _, cell := range block.Header {
+			fmt.Fprintf(out, "<th>%s</th>", InlineHTML(cell))
+		}
+		out.WriteString("</tr></thead>\n<tbody>\n")
+		for _, row := range block.Rows {
+			out.WriteString("<tr>")
+			for i := range block.Header {
+				cell := []Span{}
+				if i < len(row) {
+					cell = row[i]
+				}
+				fmt.Fprintf(out, "<td>%s</td>", InlineHTML(cell))
+			}
+			out.WriteString("</tr>\n")
+		}
+		out.WriteString("</tbody>\n</table>\n")
+	}
+}
+
+// writeListHTML nests the flat items by their level.
+func writeListHTML(out *strings.Builder, items []Item) {
+	open := []string{}
+	pop := func() string {
+		tag := open[len(open)-1]
+		open = open[:len(open)-1]
+		return tag
+	}
+
+	for _, item := range items {
+		tag := "ul"
+		if item.Ordered {
+			tag = "ol"
+		}
+		for len(open) > item.Level+1 {
+			fmt.Fprintf(out, "</li></%s>\n", pop())
+		}
+		if len(open) == item.Level+1 {
+			out.WriteString("</li>\n")
+			if open[len(open)-1] != tag {
+				fmt.Fprintf(out, "</%s>\n", pop())
+			}
+		}
+		for len(open) < item.Level+1 {
+			start := ""
+			if item.Ordered && item.Number > 1 {
+				start = fmt.Sprintf(` start="%d"`, item.Number)
+			}
+			fmt.Fprintf(out, "<%s%s>\n", tag, start)
+			open = append(open, tag)
+			if len(open) < item.Level+1 {
+				// a list nested more than one level deeper needs a parent item
+				out.WriteString("<li>")
+			}
+		}
+		fmt.Fprintf(out, "<li>%s", InlineHTML(item.Spans))
+	}
+	for len(open) > 0 {
+		fmt.Fprintf(out, "</li></%s>\n", pop())
+	}
+}
+
+// InlineHTML renders spans with their text escaped.
+func InlineHTML(spans []Span) string {
+	out := strings.Builder{}
+	for _, span := range spans {
+		text := html.EscapeString(span.Text)
+		if span.Code {
+			out.WriteString("<code>" + text + "</code>")
+			continue
+		}
+		if span.Bold {
+			text = "<strong>" + text + "</strong>"
+		}
+		if span.Italic {
+			text = "<em>" + text + "</em>"
+		}
+		if SafeLink(span.Link) {
+			text = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(span.Link), text)
+		}
+		out.WriteString(text)
+	}
+	return out.String()
+}
+
+// SafeLink reports whether link may be followed from a report.
+func SafeLink(link string) bool {
+	lower := strings.ToLower(link)
+	return strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "mailto:")
+}
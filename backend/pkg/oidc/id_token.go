@@ -0,0 +1,88 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// clockSkew tolerates small clock differences with the provider.
+const clockSkew = time.Minute
+
+// Claims are the ID token claims the application cares about. Raw keeps the
+// full claim set so arbitrary role claims can be mapped.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Username      string
+	Raw           jwt.MapClaims
+}
+
+// VerifyIDToken checks the signature against the provider JWKS and validates
+// issuer, audience, expiry and nonce.
+func (p *Provider) VerifyIDToken(ctx context.Context, rawToken, nonce string) (Claims, error) {
+	discovery, err := p.Discover(ctx)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	raw := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(rawToken, raw, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.keys.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	)
+	if err != nil {
+		return Claims{}, fmt.Errorf("oidc: invalid id token: %w", err)
+	}
+
+	if tokenNonce, _ := raw["nonce"].(string); tokenNonce != nonce {
+		return Claims{}, errors.New("oidc: nonce mismatch")
+	}
+	// with several audiences the token must have been issued to us
+	if aud, ok := raw["aud"].([]interface{}); ok && len(aud) > 1 {
+		if azp, _ := raw["azp"].(string); azp != p.cfg.ClientID {
+			return Claims{}, errors.New("oidc: authorized party mismatch")
+		}
+	}
+
+	claims := Claims{Raw: raw}
+	claims.Subject, _ = raw["sub"].(string)
+	claims.Email, _ = raw["email"].(string)
+	claims.EmailVerified, _ = raw["email_verified"].(bool)
+	claims.Name, _ = raw["name"].(string)
+	claims.Username, _ = raw["preferred_username"].(string)
+	if claims.Subject == "" {
+		return Claims{}, errors.New("oidc: id token has no subject")
+	}
+	return claims, nil
+}
+
+// Strings returns the claim name as a list, accepting both a single string
+// and an array of strings (e.g. "groups" or "roles").
+func (c Claims) Strings(name string) []string {
+	switch v := c.Raw[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
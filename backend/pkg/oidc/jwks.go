@@ -0,0 +1,135 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+const (
+	jwksTTL = time.Hour
+	// jwksMinRefresh throttles refetches triggered by unknown key ids so a
+	// forged kid cannot be used to hammer the provider.
+	jwksMinRefresh = time.Minute
+)
+
+type (
+	jsonWebKey struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		Alg string `json:"alg"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	}
+
+	jsonWebKeySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	keySet struct {
+		uri      string
+		provider *Provider
+
+		mu        sync.Mutex
+		keys      map[string]*rsa.PublicKey
+		fetchedAt time.Time
+	}
+)
+
+func newKeySet(uri string, provider *Provider) *keySet {
+	return &keySet{
+		uri:      uri,
+		provider: provider,
+		keys:     map[string]*rsa.PublicKey{},
+	}
+}
+
+// key returns the signing key for kid, refreshing the cached set when it is
+// stale or the kid is unknown (key rotation).
+func (s *keySet) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.lookup(kid)
+	stale := time.Since(s.fetchedAt) > jwksTTL
+	if ok && !stale {
+		return key, nil
+	}
+
+	if stale || time.Since(s.fetchedAt) > jwksMinRefresh {
+		if err := s.refresh(ctx); err != nil {
+			if ok {
+				return key, nil
+			}
+			return nil, err
+		}
+		key, ok = s.lookup(kid)
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("oidc: unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+func (s *keySet) lookup(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+func (s *keySet) refresh(ctx context.Context) error {
+	set := jsonWebKeySet{}
+	if err := s.provider.getJSON(ctx, s.uri, &set); err != nil {
+		return fmt.Errorf("oidc jwks: %w", err)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		key, err := jwk.rsaPublicKey()
+		if err != nil {
+			return fmt.Errorf("oidc jwks: key %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	return nil
+}
+
+func (k jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("invalid RSA parameters")
+	}
+
+	exponent := 0
+	for _, b := range e {
+		exponent = exponent<<8 | int(b)
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: exponent,
+	}, nil
+}
@@ -0,0 +1,276 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/pkg/oidc/oidctest"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID    = "inverta"
+	testRedirectURL = "http://app.test/api/v1/auth/oidc/callback"
+)
+
+func newTestProvider(t *testing.T) (*oidctest.Server, *Provider) {
+	t.Helper()
+	idp, err := oidctest.NewServer(testClientID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(idp.Close)
+	provider := NewProvider(Config{
+		IssuerURL:   idp.URL,
+		ClientID:    testClientID,
+		RedirectURL: testRedirectURL,
+	}, idp.Client())
+	return idp, provider
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	idp, provider := newTestProvider(t)
+
+	raw, err := provider.AuthCodeURL(context.Background(), "the-state", "the-nonce", "the-verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := authURL.Scheme + "://" + authURL.Host + authURL.Path; got != idp.URL+"/authorize" {
+		t.Errorf("endpoint = %s, want the discovered authorization endpoint", got)
+	}
+
+	query := authURL.Query()
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"scope":                 "openid email profile",
+		"state":                 "the-state",
+		"nonce":                 "the-nonce",
+		"code_challenge":        CodeChallenge("the-verifier"),
+		"code_challenge_method": "S256",
+	}
+	for name, value := range want {
+		if got := query.Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// the example of RFC 7636 appendix B
+	got := CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	if want := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"; got != want {
+		t.Errorf("CodeChallenge = %s, want %s", got, want)
+	}
+}
+
+func TestExchangeAndVerify(t *testing.T) {
+	idp, provider := newTestProvider(t)
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce-1", "verifier-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state, err := idp.Login(authURL, jwt.MapClaims{
+		"sub":                "user-1",
+		"email":              "ada@example.com",
+		"email_verified":     true,
+		"name":               "Ada Lovelace",
+		"preferred_username": "ada",
+		"groups":             []string{"staff", "admins"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != "state" {
+		t.Errorf("state = %q, want it echoed back", state)
+	}
+
+	idToken, err := provider.Exchange(ctx, code, "verifier-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := provider.VerifyIDToken(ctx, idToken, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "user-1" || claims.Email != "ada@example.com" || !claims.EmailVerified ||
+		claims.Name != "Ada Lovelace" || claims.Username != "ada" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if groups := claims.Strings("groups"); len(groups) != 2 || groups[1] != "admins" {
+		t.Errorf("groups = %v", groups)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	idp, provider := newTestProvider(t)
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", "verifier-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _, err := idp.Login(authURL, jwt.MapClaims{"sub": "user-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Exchange(ctx, code, "verifier-2"); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("Exchange with another verifier = %v, want invalid_grant", err)
+	}
+}
+
+func TestExchangeRejectsReusedCode(t *testing.T) {
+	idp, provider := newTestProvider(t)
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", "verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _, err := idp.Login(authURL, jwt.MapClaims{"sub": "user-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Exchange(ctx, code, "verifier"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Exchange(ctx, code, "verifier"); err == nil {
+		t.Error("a code was exchanged twice")
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	idp, provider := newTestProvider(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	with := func(changes jwt.MapClaims) jwt.MapClaims {
+		claims := idp.Claims("user-1", "nonce")
+		for name, value := range changes {
+			if value == nil {
+				delete(claims, name)
+				continue
+			}
+			claims[name] = value
+		}
+		return claims
+	}
+	sign := func(claims jwt.MapClaims) string {
+		signed, err := idp.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		nonce   string
+		wantErr string
+	}{
+		{name: "valid", token: sign(with(nil)), nonce: "nonce"},
+		{name: "expired within the clock skew", token: sign(with(jwt.MapClaims{"exp": now.Add(-30 * time.Second).Unix()})), nonce: "nonce"},
+		{name: "several audiences issued to us", token: sign(with(jwt.MapClaims{"aud": []string{"other", testClientID}, "azp": testClientID})), nonce: "nonce"},
+		{
+			name: "signed by another key",
+			token: func() string {
+				signed, err := oidctest.Sign(otherKey, idp.KeyID, with(nil))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return signed
+			}(),
+			nonce:   "nonce",
+			wantErr: "signature",
+		},
+		{
+			name: "unknown key id",
+			token: func() string {
+				signed, err := oidctest.Sign(idp.Key, "rotated", with(nil))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return signed
+			}(),
+			nonce:   "nonce",
+			wantErr: "unknown signing key",
+		},
+		{
+			name: "symmetric algorithm",
+			token: func() string {
+				signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, with(nil)).SignedString([]byte("secret"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return signed
+			}(),
+			nonce:   "nonce",
+			wantErr: "signing method",
+		},
+		{name: "tampered payload", token: tamper(t, sign(with(nil))), nonce: "nonce", wantErr: "signature"},
+		{name: "other issuer", token: sign(with(jwt.MapClaims{"iss": "https://evil.example.com"})), nonce: "nonce", wantErr: "issuer"},
+		{name: "other audience", token: sign(with(jwt.MapClaims{"aud": "someone-else"})), nonce: "nonce", wantErr: "audience"},
+		{name: "several audiences without azp", token: sign(with(jwt.MapClaims{"aud": []string{"other", testClientID}})), nonce: "nonce", wantErr: "authorized party"},
+		{name: "expired", token: sign(with(jwt.MapClaims{"exp": now.Add(-2 * time.Minute).Unix()})), nonce: "nonce", wantErr: "expired"},
+		{name: "no expiry", token: sign(with(jwt.MapClaims{"exp": nil})), nonce: "nonce", wantErr: "exp"},
+		{name: "issued in the future", token: sign(with(jwt.MapClaims{"iat": now.Add(time.Hour).Unix()})), nonce: "nonce", wantErr: "issued"},
+		{name: "nonce mismatch", token: sign(with(nil)), nonce: "other-nonce", wantErr: "nonce mismatch"},
+		{name: "no subject", token: sign(with(jwt.MapClaims{"sub": nil})), nonce: "nonce", wantErr: "no subject"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.VerifyIDToken(context.Background(), tt.token, tt.nonce)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("VerifyIDToken = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("VerifyIDToken = %v, want an error about %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDiscoverRejectsOtherIssuer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Discovery{Issuer: "https://evil.example.com"})
+	}))
+	defer server.Close()
+
+	provider := NewProvider(Config{IssuerURL: server.URL, ClientID: testClientID}, server.Client())
+	if _, err := provider.Discover(context.Background()); err == nil || !strings.Contains(err.Error(), "issuer mismatch") {
+		t.Errorf("Discover = %v, want an issuer mismatch", err)
+	}
+}
+
+// tamper swaps the payload of a signed token for one with another subject.
+func tamper(t *testing.T, token string) string {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "admin"}).SigningString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parts[0] + "." + strings.Split(forged, ".")[1] + "." + parts[2]
+}
@@ -0,0 +1,255 @@
+// Package oidctest runs a minimal OpenID Connect provider for tests. It
+// serves discovery, the JWKS, an authorization endpoint that signs the user
+// in without a prompt and a token endpoint that checks PKCE.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type (
+	// Server is the mock provider; its URL is the issuer.
+	Server struct {
+		*httptest.Server
+		ClientID string
+		// Key signs the ID tokens and is published under KeyID
+		Key   *rsa.PrivateKey
+		KeyID string
+
+		mu     sync.Mutex
+		grants map[string]grant
+		// pending are the claims of the next Login
+		pending jwt.MapClaims
+		// TokenRequests counts the calls of the token endpoint
+		TokenRequests int
+	}
+
+	// grant is what the authorization endpoint remembers for a code.
+	grant struct {
+		challenge   string
+		redirectURI string
+		claims      jwt.MapClaims
+	}
+)
+
+// NewServer starts a provider for clientID. Close it when done.
+func NewServer(clientID string) (*Server, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		ClientID: clientID,
+		Key:      key,
+		KeyID:    "test-key",
+		grants:   map[string]grant{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/jwks", s.jwks)
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("/token", s.token)
+	s.Server = httptest.NewServer(mux)
+	return s, nil
+}
+
+// Claims returns the claims of a valid ID token for subject, issued now and
+// valid for an hour.
+func (s *Server) Claims(subject, nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":   s.URL,
+		"sub":   subject,
+		"aud":   s.ClientID,
+		"nonce": nonce,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}
+}
+
+// Sign signs claims with the provider key.
+func (s *Server) Sign(claims jwt.MapClaims) (string, error) {
+	return Sign(s.Key, s.KeyID, claims)
+}
+
+// Sign signs claims with key as an RS256 token carrying kid.
+func Sign(key *rsa.PrivateKey, kid string, claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	return token.SignedString(key)
+}
+
+// Login plays the user signing in at the authorization URL the relying
+// party built. The ID token issued for the code carries claims on top of
+// the defaults. It returns the code and state the provider redirects back
+// with.
+func (s *Server) Login(authURL string, claims jwt.MapClaims) (code, state string, err error) {
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	s.mu.Lock()
+	s.pending = claims
+	s.mu.Unlock()
+
+	resp, err := client.Get(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		return "", "", fmt.Errorf("oidctest: authorize answered %d", resp.StatusCode)
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return "", "", err
+	}
+	return location.Query().Get("code"), location.Query().Get("state"), nil
+}
+
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 s.URL,
+		"authorization_endpoint": s.URL + "/authorize",
+		"token_endpoint":         s.URL + "/token",
+		"jwks_uri":               s.URL + "/jwks",
+	})
+}
+
+func (s *Server) jwks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	key, kid := s.Key.PublicKey, s.KeyID
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kid": kid,
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if err := s.checkAuthorize(query); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	code := base64.RawURLEncoding.EncodeToString(randomBytes())
+	s.mu.Lock()
+	claims := jwt.MapClaims{}
+	for name, value := range s.pending {
+		claims[name] = value
+	}
+	if _, ok := claims["nonce"]; !ok {
+		claims["nonce"] = query.Get("nonce")
+	}
+	s.grants[code] = grant{
+		challenge:   query.Get("code_challenge"),
+		redirectURI: query.Get("redirect_uri"),
+		claims:      claims,
+	}
+	s.mu.Unlock()
+
+	redirect, _ := url.Parse(query.Get("redirect_uri"))
+	values := redirect.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	redirect.RawQuery = values.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *Server) checkAuthorize(query url.Values) error {
+	switch {
+	case query.Get("response_type") != "code":
+		return errors.New("unsupported response_type")
+	case query.Get("client_id") != s.ClientID:
+		return errors.New("unknown client")
+	case query.Get("redirect_uri") == "":
+		return errors.New("missing redirect_uri")
+	case query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "":
+		return errors.New("PKCE with S256 is required")
+	}
+	return nil
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.TokenRequests++
+	s.mu.Unlock()
+
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type")
+		return
+	}
+	if r.PostForm.Get("client_id") != s.ClientID {
+		tokenError(w, "invalid_client")
+		return
+	}
+
+	// codes are single use, a second exchange fails like a forged one
+	s.mu.Lock()
+	g, ok := s.grants[r.PostForm.Get("code")]
+	delete(s.grants, r.PostForm.Get("code"))
+	s.mu.Unlock()
+	if !ok || g.redirectURI != r.PostForm.Get("redirect_uri") {
+		tokenError(w, "invalid_grant")
+		return
+	}
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	claims := s.Claims("", "")
+	for name, value := range g.claims {
+		claims[name] = value
+	}
+	idToken, err := s.Sign(claims)
+	if err != nil {
+		tokenError(w, "server_error")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": "access-" + r.PostForm.Get("code"),
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+	})
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func randomBytes() []byte {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return b
+}
@@ -0,0 +1,71 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Kocannn/self-dunking-ai/config"
+	"github.com/sirupsen/logrus"
+)
+
+type embedRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+}
+
+type embedResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float64 `json:"embeddings"`
+}
+
+// Embed returns the vector of text and the model that computed it, taken
+// from OLLAMA_EMBED_MODEL or else OLLAMA_MODEL.
+func Embed(ctx context.Context, text string) ([]float64, string, error) {
+	cfg := config.GetConfig()
+
+	model := cfg.OLLAMA_EMBED_MODEL
+	if model == "" {
+		model = cfg.OLLAMA_MODEL
+	}
+	jsonData, err := json.Marshal(embedRequest{Model: model, Input: text})
+	if err != nil {
+		return nil, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/api/embed", cfg.OLLAMA_HOST), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		logrus.Errorf("Error sending request to Ollama: %v", err)
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("ollama answered %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+
+	embedResp := embedResponse{}
+	if err := json.Unmarshal(body, &embedResp); err != nil {
+		return nil, "", err
+	}
+	if len(embedResp.Embeddings) == 0 || len(embedResp.Embeddings[0]) == 0 {
+		return nil, "", fmt.Errorf("ollama returned no embedding for model %s", model)
+	}
+	if embedResp.Model != "" {
+		model = embedResp.Model
+	}
+	return embedResp.Embeddings[0], model, nil
+}
@@ -7,18 +7,22 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/Kocannn/self-dunking-ai/config"
 	"github.com/Kocannn/self-dunking-ai/domain"
 	"github.com/sirupsen/logrus"
 )
 
-func PostPrompt(messages []*domain.Message) (*domain.Ollama, error) {
+// PostPrompt sends messages to model, or to OLLAMA_MODEL when model is empty.
+func PostPrompt(model string, messages []*domain.Message) (*domain.Ollama, error) {
 	cfg := config.GetConfig()
 
-	// Use the model from config
+	if model == "" {
+		model = cfg.OLLAMA_MODEL
+	}
 	requestBody := domain.OllamaRequest{
-		Model:    cfg.OLLAMA_MODEL,
+		Model:    model,
 		Messages: messages,
 	}
 
@@ -47,7 +51,6 @@ func PostPrompt(messages []*domain.Message) (*domain.Ollama, error) {
 	// For Ollama streaming responses, we need to collect the content from multiple events
 	// Each line is a separate JSON object
 	var fullContent string
-	var model string
 
 	// Split the response by newlines and process each JSON object
 	parts := bytes.Split(body, []byte("\n"))
@@ -89,19 +92,23 @@ func PostPrompt(messages []*domain.Message) (*domain.Ollama, error) {
 	return ollamaResponse, nil
 }
 
-// New streaming function
-func StreamPrompt(w http.ResponseWriter, messages []*domain.Message) error {
+// StreamPrompt relays the model answer to w as server-sent events and returns
+// the concatenated content once the stream is done.
+func StreamPrompt(w http.ResponseWriter, model string, messages []*domain.Message) (string, error) {
 	cfg := config.GetConfig()
 
+	if model == "" {
+		model = cfg.OLLAMA_MODEL
+	}
 	requestBody := domain.OllamaRequest{
-		Model:    cfg.OLLAMA_MODEL,
+		Model:    model,
 		Messages: messages,
 	}
 
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
 		logrus.Errorf("Error marshaling request: %v", err)
-		return err
+		return "", err
 	}
 
 	// Set up SSE headers
@@ -114,7 +121,7 @@ func StreamPrompt(w http.ResponseWriter, messages []*domain.Message) error {
 	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/chat", cfg.OLLAMA_HOST), bytes.NewBuffer(jsonData))
 	if err != nil {
 		logrus.Errorf("Error creating request: %v", err)
-		return err
+		return "", err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
@@ -123,13 +130,15 @@ func StreamPrompt(w http.ResponseWriter, messages []*domain.Message) error {
 	resp, err := client.Do(req)
 	if err != nil {
 		logrus.Errorf("Error sending request to Ollama: %v", err)
-		return err
+		return "", err
 	}
 	defer resp.Body.Close()
 
 	// Create a scanner to read line by line from response
 	scanner := bufio.NewScanner(resp.Body)
 
+	var fullContent strings.Builder
+
 	// Stream each chunk as it arrives
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -151,6 +160,8 @@ func StreamPrompt(w http.ResponseWriter, messages []*domain.Message) error {
 			content = streamResp.Message.Content
 		}
 
+		fullContent.WriteString(content)
+
 		// Send each chunk to client
 		fmt.Fprintf(w, "data: %s\n\n", content)
 		w.(http.Flusher).Flush() // Flush to ensure data is sent immediately
@@ -160,5 +171,5 @@ func StreamPrompt(w http.ResponseWriter, messages []*domain.Message) error {
 	fmt.Fprintf(w, "data: [DONE]\n\n")
 	w.(http.Flusher).Flush()
 
-	return nil
+	return fullContent.String(), nil
 }
@@ -0,0 +1,97 @@
+package report
+
+type font int
+
+// The PDF uses the standard Type 1 fonts every reader ships, so nothing
+// has to be embedded.
+const (
+	regular font = iota
+	bold
+	italic
+	boldItalic
+	mono
+)
+
+var fontNames = []string{"Helvetica", "Helvetica-Bold", "Helvetica-Oblique", "Helvetica-BoldOblique", "Courier"}
+
+// Advance widths of the printable ASCII characters in thousandths of the
+// font size, from the Adobe font metrics. The oblique faces share the
+// widths of their upright ones and Courier is 600 throughout.
+var (
+	helveticaWidths = [95]float64{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBoldWidths = [95]float64{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+	// winAnsiWidths covers the typographic characters of winAnsi; other
+	// characters above ASCII are taken as wide as a digit
+	winAnsiWidths = map[byte]float64{
+		0x82: 222, 0x84: 333, 0x85: 1000, 0x91: 222, 0x92: 222, 0x93: 333,
+		0x94: 333, 0x95: 350, 0x96: 556, 0x97: 1000, 0x99: 1000,
+	}
+)
+
+// winAnsi maps the characters the models like to use outside Latin-1 onto
+// the WinAnsi code page of the standard fonts.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// encode converts text to WinAnsi. Characters the fonts lack become '?'.
+func encode(text string) string {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r == '\t':
+			out = append(out, ' ')
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			out = append(out, byte(r))
+		case r < 0x20:
+		default:
+			if b, ok := winAnsi[r]; ok {
+				out = append(out, b)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return string(out)
+}
+
+func charWidth(f font, c byte) float64 {
+	if f == mono {
+		return 600
+	}
+	widths := &helveticaWidths
+	if f == bold || f == boldItalic {
+		widths = &helveticaBoldWidths
+	}
+	if c >= 0x20 && c < 0x7f {
+		return widths[c-0x20]
+	}
+	if width, ok := winAnsiWidths[c]; ok {
+		return width
+	}
+	return 556
+}
+
+// textWidth measures WinAnsi encoded text in points.
+func textWidth(f font, size float64, text string) float64 {
+	width := 0.0
+	for i := 0; i < len(text); i++ {
+		width += charWidth(f, text[i])
+	}
+	return width * size / 1000
+}
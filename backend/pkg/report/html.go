@@ -0,0 +1,33 @@
+package report
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+
+	"github.com/Kocannn/self-dunking-ai/pkg/markdown"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var htmlTemplate = template.Must(template.ParseFS(templateFS, "templates/report.html.tmpl"))
+
+// HTML renders doc as a standalone page that prints well.
+func HTML(doc Document) ([]byte, error) {
+	out := &bytes.Buffer{}
+	err := htmlTemplate.ExecuteTemplate(out, "report", map[string]interface{}{
+		"Brand":    brand(),
+		"Accent":   template.CSS(accent),
+		"Title":    doc.Title,
+		"Subtitle": doc.Subtitle,
+		"Footer":   footer(doc),
+		// markdown.HTML escapes the text and drops unsafe links
+		"Body": template.HTML(markdown.HTML(doc.Blocks)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("report: html: %w", err)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,469 @@
+package report
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/Kocannn/self-dunking-ai/pkg/markdown"
+)
+
+// A4 in points, with the margins around the body.
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+	margin     = 56.0
+	bodyTop    = pageHeight - margin - 8
+	bodyBottom = margin
+	bodySize   = 10.0
+	codeSize   = 9.0
+	tableSize  = 9.0
+	listIndent = 16.0
+	quoteStep  = 14.0
+)
+
+var headingSizes = map[int]float64{1: 20, 2: 16, 3: 13, 4: 11.5, 5: 10.5, 6: 10}
+
+type color [3]float64
+
+var (
+	textColor   = color{0.13, 0.13, 0.13}
+	mutedColor  = color{0.45, 0.45, 0.45}
+	accentColor = color{0x43 / 255.0, 0x38 / 255.0, 0xca / 255.0}
+	ruleColor   = color{0.85, 0.85, 0.85}
+	fillColor   = color{0.94, 0.94, 0.94}
+)
+
+type (
+	pdfLink struct {
+		x1, y1, x2, y2 float64
+		uri            string
+	}
+
+	pdfPage struct {
+		content bytes.Buffer
+		links   []pdfLink
+	}
+
+	// pdfWriter lays blocks out top to bottom, opening pages as it goes. y
+	// is the top of the next line.
+	pdfWriter struct {
+		doc    Document
+		brand  string
+		pages  []*pdfPage
+		y      float64
+		indent float64
+		// quotes are the x positions of the bars of the open quotes
+		quotes []float64
+	}
+)
+
+// PDF renders doc as an A4 PDF with the brand on every page and numbered
+// footers.
+func PDF(doc Document) ([]byte, error) {
+	w := &pdfWriter{doc: doc, brand: brand()}
+	w.newPage()
+
+	for _, l := range wrap([]markdown.Span{{Text: doc.Title}}, bold, headingSizes[1], w.width()) {
+		w.line(l, headingSizes[1], headingSizes[1]*1.3, accentColor, "")
+	}
+	if doc.Subtitle != "" {
+		for _, l := range wrap([]markdown.Span{{Text: doc.Subtitle}}, italic, bodySize, w.width()) {
+			w.line(l, bodySize, bodySize*1.4, mutedColor, "")
+		}
+	}
+	w.space(8)
+	w.blocks(doc.Blocks)
+
+	return w.bytes()
+}
+
+func (w *pdfWriter) page() *pdfPage {
+	return w.pages[len(w.pages)-1]
+}
+
+func (w *pdfWriter) left() float64 {
+	return margin + w.indent
+}
+
+func (w *pdfWriter) width() float64 {
+	return pageWidth - margin - w.left()
+}
+
+// newPage starts a page below the brand header.
+func (w *pdfWriter) newPage() {
+	w.pages = append(w.pages, &pdfPage{})
+	w.text(margin, pageHeight-38, bold, 9, accentColor, encode(w.brand))
+	title := encode(w.doc.Title)
+	w.text(pageWidth-margin-textWidth(regular, 9, title), pageHeight-38, regular, 9, mutedColor, title)
+	w.rule(margin, pageWidth-margin, pageHeight-46, accentColor, 0.8)
+	w.y = bodyTop
+}
+
+// reserve opens a new page unless height still fits on this one.
+func (w *pdfWriter) reserve(height float64) {
+	if w.y-height < bodyBottom {
+		w.newPage()
+	}
+}
+
+// space leaves a gap, which is dropped at the top of a page.
+func (w *pdfWriter) space(height float64) {
+	if w.y < bodyTop {
+		w.y -= height
+	}
+	if w.y < bodyBottom {
+		w.newPage()
+	}
+}
+
+// line sets one wrapped line at the current indent, with marker in front
+// of it for list items.
+func (w *pdfWriter) line(l line, size, leading float64, c color, marker string) {
+	w.reserve(leading)
+	for _, x := range w.quotes {
+		w.fillRect(x, w.y-leading, 2.5, leading, ruleColor)
+	}
+	baseline := w.y - leading + (leading-size)/2 + size*0.22
+	if marker != "" {
+		marker = encode(marker)
+		w.text(w.left()-textWidth(regular, size, marker)-4, baseline, regular, size, c, marker)
+	}
+	w.runs(l, w.left(), baseline, c)
+	w.y -= leading
+}
+
+func (w *pdfWriter) runs(l line, x, baseline float64, c color) {
+	for _, r := range l.runs {
+		runColor := c
+		if r.link != "" {
+			runColor = accentColor
+			w.page().links = append(w.page().links, pdfLink{x, baseline - r.size*0.25, x + r.width, baseline + r.size*0.8, r.link})
+		}
+		w.text(x, baseline, r.font, r.size, runColor, r.text)
+		x += r.width
+	}
+}
+
+func (w *pdfWriter) blocks(blocks []markdown.Block) {
+	for _, block := range blocks {
+		w.block(block)
+	}
+}
+
+func (w *pdfWriter) block(block markdown.Block) {
+	switch block.Kind {
+	case markdown.Heading:
+		size := headingSizes[block.Level]
+		c := textColor
+		if block.Level <= 2 {
+			c = accentColor
+		}
+		w.space(size * 0.9)
+		// keep a heading together with the start of its section
+		w.reserve(size*1.3 + 3*bodySize*1.4)
+		for _, l := range wrap(block.Spans, bold, size, w.width()) {
+			w.line(l, size, size*1.3, c, "")
+		}
+		if block.Level <= 2 {
+			w.rule(w.left(), pageWidth-margin, w.y-2, ruleColor, 0.5)
+			w.y -= 4
+		}
+		w.space(3)
+
+	case markdown.Paragraph:
+		for _, l := range wrap(block.Spans, regular, bodySize, w.width()) {
+			w.line(l, bodySize, bodySize*1.4, textColor, "")
+		}
+		w.space(6)
+
+	case markdown.List:
+		indent := w.indent
+		for _, item := range block.Items {
+			w.indent = indent + listIndent*float64(item.Level+1)
+			marker := "•"
+			if item.Ordered {
+				marker = strconv.Itoa(item.Number) + "."
+			}
+			for i, l := range wrap(item.Spans, regular, bodySize, w.width()) {
+				if i > 0 {
+					marker = ""
+				}
+				w.line(l, bodySize, bodySize*1.4, textColor, marker)
+			}
+			w.space(2)
+		}
+		w.indent = indent
+		w.space(4)
+
+	case markdown.Code:
+		leading := codeSize * 1.35
+		columns := int((w.width() - 12) / (codeSize * 0.6))
+		w.space(2)
+		for _, text := range strings.Split(block.Text, "\n") {
+			text = encode(text)
+			for {
+				chunk := text
+				if len(chunk) > columns {
+					chunk = chunk[:columns]
+				}
+				w.reserve(leading)
+				w.fillRect(w.left(), w.y-leading, w.width(), leading, fillColor)
+				w.text(w.left()+6, w.y-leading+(leading-codeSize)/2+codeSize*0.22, mono, codeSize, textColor, chunk)
+				w.y -= leading
+				text = text[len(chunk):]
+				if text == "" {
+					break
+				}
+			}
+		}
+		w.space(8)
+
+	case markdown.Quote:
+		w.quotes = append(w.quotes, w.left())
+		w.indent += quoteStep
+		w.blocks(block.Blocks)
+		w.indent -= quoteStep
+		w.quotes = w.quotes[:len(w.quotes)-1]
+		w.space(2)
+
+	case markdown.Rule:
+		w.reserve(14)
+		w.rule(w.left(), pageWidth-margin, w.y-7, ruleColor, 0.8)
+		w.y -= 14
+
+	case markdown.Table:
+		w.table(block)
+		w.space(8)
+	}
+}
+
+// table sizes the columns after their content and repeats the header on
+// every page the table runs onto.
+func (w *pdfWriter) table(block markdown.Block) {
+	columns := len(block.Header)
+	if columns == 0 {
+		return
+	}
+	natural := make([]float64, columns)
+	total := 0.0
+	for i := range natural {
+		natural[i] = cellWidth(block.Header[i], bold)
+		for _, row := range block.Rows {
+			if i < len(row) && cellWidth(row[i], regular) > natural[i] {
+				natural[i] = cellWidth(row[i], regular)
+			}
+		}
+		natural[i] = clamp(natural[i]+8, 40, w.width())
+		total += natural[i]
+	}
+	widths := make([]float64, columns)
+	for i := range widths {
+		widths[i] = natural[i] * w.width() / total
+	}
+
+	header, headerHeight := wrapRow(block.Header, widths, bold)
+	w.reserve(headerHeight)
+	w.row(header, widths, headerHeight, true)
+	for _, cells := range block.Rows {
+		lines, height := wrapRow(cells, widths, regular)
+		if w.y-height < bodyBottom {
+			w.newPage()
+			w.row(header, widths, headerHeight, true)
+		}
+		w.row(lines, widths, height, false)
+	}
+}
+
+// wrapRow wraps each cell to its column and returns the row height. Rows
+// missing cells are padded with empty ones.
+func wrapRow(cells [][]markdown.Span, widths []float64, base font) ([][]line, float64) {
+	wrapped := make([][]line, len(widths))
+	height := 0.0
+	for i := range widths {
+		cell := []markdown.Span{}
+		if i < len(cells) {
+			cell = cells[i]
+		}
+		wrapped[i] = wrap(cell, base, tableSize, widths[i]-8)
+		if h := float64(len(wrapped[i]))*tableSize*1.35 + 6; h > height {
+			height = h
+		}
+	}
+	return wrapped, height
+}
+
+func (w *pdfWriter) row(cells [][]line, widths []float64, height float64, header bool) {
+	leading := tableSize * 1.35
+	x := w.left()
+	for i, lines := range cells {
+		if header {
+			w.fillRect(x, w.y-height, widths[i], height, fillColor)
+		}
+		w.strokeRect(x, w.y-height, widths[i], height)
+		for j, l := range lines {
+			baseline := w.y - 3 - float64(j+1)*leading + (leading-tableSize)/2 + tableSize*0.22
+			w.runs(l, x+4, baseline, textColor)
+		}
+		x += widths[i]
+	}
+	w.y -= height
+}
+
+func cellWidth(cell []markdown.Span, base font) float64 {
+	width := 0.0
+	for _, span := range cell {
+		width += textWidth(spanFont(span, base), tableSize, encode(span.Text))
+	}
+	return width
+}
+
+func clamp(value, low, high float64) float64 {
+	if value < low {
+		return low
+	}
+	if value > high {
+		return high
+	}
+	return value
+}
+
+func (w *pdfWriter) text(x, y float64, f font, size float64, c color, text string) {
+	if text == "" {
+		return
+	}
+	fmt.Fprintf(&w.page().content, "BT %s rg /F%d %s Tf %s %s Td (%s) Tj ET\n",
+		c, f+1, num(size), num(x), num(y), escape(text))
+}
+
+func (w *pdfWriter) rule(x1, x2, y float64, c color, thickness float64) {
+	fmt.Fprintf(&w.page().content, "%s RG %s w %s %s m %s %s l S\n",
+		c, num(thickness), num(x1), num(y), num(x2), num(y))
+}
+
+func (w *pdfWriter) fillRect(x, y, width, height float64, c color) {
+	fmt.Fprintf(&w.page().content, "%s rg %s %s %s %s re f\n", c, num(x), num(y), num(width), num(height))
+}
+
+func (w *pdfWriter) strokeRect(x, y, width, height float64) {
+	fmt.Fprintf(&w.page().content, "%s RG 0.5 w %s %s %s %s re S\n", ruleColor, num(x), num(y), num(width), num(height))
+}
+
+func (c color) String() string {
+	return num(c[0]) + " " + num(c[1]) + " " + num(c[2])
+}
+
+func num(value float64) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
+
+// escape makes text safe inside a PDF literal string.
+func escape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`, "\r", `\r`, "\n", `\n`).Replace(text)
+}
+
+// textString encodes metadata as UTF-16, which readers show in full
+// unlike the WinAnsi body text.
+func textString(text string) string {
+	out := strings.Builder{}
+	out.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&out, "%04X", unit)
+	}
+	out.WriteString(">")
+	return out.String()
+}
+
+// uriString percent-encodes what a URI action may not carry.
+func uriString(uri string) string {
+	out := strings.Builder{}
+	for i := 0; i < len(uri); i++ {
+		if c := uri[i]; c <= 0x20 || c >= 0x7f {
+			fmt.Fprintf(&out, "%%%02X", c)
+		} else {
+			out.WriteByte(c)
+		}
+	}
+	return "(" + escape(out.String()) + ")"
+}
+
+// bytes adds the footers and writes the file: catalog, page tree, info
+// and fonts first, then each page with its content and link annotations.
+func (w *pdfWriter) bytes() ([]byte, error) {
+	credit := encode(footer(w.doc))
+	for i, page := range w.pages {
+		number := fmt.Sprintf("Page %d of %d", i+1, len(w.pages))
+		fmt.Fprintf(&page.content, "%s RG 0.5 w %s 40 m %s 40 l S\n", ruleColor, num(margin), num(pageWidth-margin))
+		fmt.Fprintf(&page.content, "BT %s rg /F1 8 Tf %s 28 Td (%s) Tj ET\n", mutedColor, num(margin), escape(credit))
+		fmt.Fprintf(&page.content, "BT %s rg /F1 8 Tf %s 28 Td (%s) Tj ET\n",
+			mutedColor, num(pageWidth-margin-textWidth(regular, 8, number)), number)
+	}
+
+	out := &bytes.Buffer{}
+	offsets := []int{0}
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", len(offsets)-1, body)
+	}
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	const firstFont = 4
+	firstPage := firstFont + len(fontNames)
+	kids := []string{}
+	next := firstPage
+	for _, page := range w.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", next))
+		next += 2 + len(page.links)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(w.pages)))
+	object(fmt.Sprintf("<< /Title %s /Producer %s /CreationDate (D:%s) >>",
+		textString(w.doc.Title), textString(w.brand), w.doc.GeneratedAt.UTC().Format("20060102150405Z")))
+	fonts := []string{}
+	for i, name := range fontNames {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+		fonts = append(fonts, fmt.Sprintf("/F%d %d 0 R", i+1, firstFont+i))
+	}
+
+	for _, page := range w.pages {
+		id := len(offsets)
+		annots := ""
+		if len(page.links) > 0 {
+			refs := []string{}
+			for i := range page.links {
+				refs = append(refs, fmt.Sprintf("%d 0 R", id+2+i))
+			}
+			annots = " /Annots [" + strings.Join(refs, " ") + "]"
+		}
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << %s >> >> /Contents %d 0 R%s >>",
+			num(pageWidth), num(pageHeight), strings.Join(fonts, " "), id+1, annots))
+
+		stream := &bytes.Buffer{}
+		zw := zlib.NewWriter(stream)
+		if _, err := zw.Write(page.content.Bytes()); err != nil {
+			return nil, fmt.Errorf("report: pdf: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("report: pdf: %w", err)
+		}
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", stream.Len(), stream.Bytes()))
+
+		for _, link := range page.links {
+			object(fmt.Sprintf("<< /Type /Annot /Subtype /Link /Rect [%s %s %s %s] /Border [0 0 0] /A << /S /URI /URI %s >> >>",
+				num(link.x1), num(link.y1), num(link.x2), num(link.y2), uriString(link.uri)))
+		}
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets))
+	for _, offset := range offsets[1:] {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets), xref)
+	return out.Bytes(), nil
+}
@@ -0,0 +1,49 @@
+// Package report renders documents made of Markdown blocks as Markdown,
+// standalone HTML or PDF, branded with APP_NAME. Everything is drawn in
+// pure Go, so the server needs no browser or converter to export reports.
+package report
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Kocannn/self-dunking-ai/config"
+	"github.com/Kocannn/self-dunking-ai/pkg/markdown"
+)
+
+// accent is the brand colour of headings, rules and links.
+const accent = "#4338ca"
+
+type Document struct {
+	Title    string
+	Subtitle string
+	// Blocks are the body; their headings should start at level 2, the
+	// title being the only level 1 heading
+	Blocks      []markdown.Block
+	GeneratedAt time.Time
+}
+
+// Markdown renders doc as a Markdown file.
+func Markdown(doc Document) []byte {
+	blocks := []markdown.Block{{Kind: markdown.Heading, Level: 1, Spans: []markdown.Span{{Text: doc.Title}}}}
+	if doc.Subtitle != "" {
+		blocks = append(blocks, markdown.Block{Kind: markdown.Paragraph, Spans: []markdown.Span{{Text: doc.Subtitle, Italic: true}}})
+	}
+	blocks = append(blocks, doc.Blocks...)
+	blocks = append(blocks,
+		markdown.Block{Kind: markdown.Rule},
+		markdown.Block{Kind: markdown.Paragraph, Spans: []markdown.Span{{Text: footer(doc), Italic: true}}},
+	)
+	return []byte(markdown.Markdown(blocks))
+}
+
+func brand() string {
+	if name := strings.TrimSpace(config.GetConfig().APP_NAME); name != "" {
+		return name
+	}
+	return "Inverta Mind"
+}
+
+func footer(doc Document) string {
+	return "Generated by " + brand() + " on " + doc.GeneratedAt.Format("2 Jan 2006 15:04 MST")
+}
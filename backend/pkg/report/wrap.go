@@ -0,0 +1,116 @@
+package report
+
+import (
+	"strings"
+
+	"github.com/Kocannn/self-dunking-ai/pkg/markdown"
+)
+
+// run is WinAnsi text set in one font, the piece a line is made of.
+type run struct {
+	text  string
+	font  font
+	size  float64
+	link  string
+	width float64
+}
+
+type line struct {
+	runs  []run
+	width float64
+}
+
+func (l *line) add(r run) {
+	if n := len(l.runs); n > 0 {
+		last := &l.runs[n-1]
+		if last.font == r.font && last.size == r.size && last.link == r.link {
+			last.text += r.text
+			last.width += r.width
+			l.width += r.width
+			return
+		}
+	}
+	l.runs = append(l.runs, r)
+	l.width += r.width
+}
+
+func spanFont(span markdown.Span, base font) font {
+	if span.Code {
+		return mono
+	}
+	isBold := span.Bold || base == bold || base == boldItalic
+	isItalic := span.Italic || base == italic || base == boldItalic
+	switch {
+	case isBold && isItalic:
+		return boldItalic
+	case isBold:
+		return bold
+	case isItalic:
+		return italic
+	}
+	return regular
+}
+
+// wrap breaks spans into lines no wider than width, at spaces where
+// possible and inside words that do not fit on a line of their own.
+func wrap(spans []markdown.Span, base font, size, width float64) []line {
+	lines := []line{}
+	current := line{}
+	var space *run
+
+	for _, span := range spans {
+		f := spanFont(span, base)
+		link := ""
+		if markdown.SafeLink(span.Link) {
+			link = span.Link
+		}
+		text := encode(span.Text)
+		for len(text) > 0 {
+			if text[0] == ' ' {
+				text = strings.TrimLeft(text, " ")
+				if len(current.runs) > 0 {
+					space = &run{text: " ", font: f, size: size, link: link, width: textWidth(f, size, " ")}
+				}
+				continue
+			}
+
+			end := strings.IndexByte(text, ' ')
+			if end < 0 {
+				end = len(text)
+			}
+			word := run{text: text[:end], font: f, size: size, link: link}
+			word.width = textWidth(f, size, word.text)
+			text = text[end:]
+
+			gap := 0.0
+			if space != nil {
+				gap = space.width
+			}
+			if len(current.runs) > 0 && current.width+gap+word.width > width {
+				lines = append(lines, current)
+				current, space, gap = line{}, nil, 0
+			}
+			if space != nil {
+				current.add(*space)
+				space = nil
+			}
+			for current.width+word.width > width && len(word.text) > 1 {
+				// cut a word that is longer than the whole line
+				cut := 1
+				for cut < len(word.text) && current.width+textWidth(f, size, word.text[:cut+1]) <= width {
+					cut++
+				}
+				current.add(run{text: word.text[:cut], font: f, size: size, link: link, width: textWidth(f, size, word.text[:cut])})
+				lines = append(lines, current)
+				current = line{}
+				word.text = word.text[cut:]
+				word.width = textWidth(f, size, word.text)
+			}
+			current.add(word)
+		}
+	}
+	if len(current.runs) > 0 || len(lines) == 0 {
+		lines = append(lines, current)
+	}
+	return lines
+}
@@ -2,12 +2,21 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/Kocannn/self-dunking-ai/domain"
+	"github.com/Kocannn/self-dunking-ai/pkg/i18n"
+	"github.com/sirupsen/logrus"
 )
 
+// internalError is shown for any error that is not a domain sentinel.
+const internalError = "Internal server error"
+
 func Response(data domain.HttpResponse, w http.ResponseWriter) {
+	// Translate the message into the language LocaleMiddleware picked
+	data.Message = i18n.Translate(w.Header().Get("Content-Language"), data.Message)
+
 	// Marshal the data to JSON
 	response, err := json.Marshal(data)
 	if err != nil {
@@ -24,3 +33,49 @@ func Response(data domain.HttpResponse, w http.ResponseWriter) {
 	// Write the JSON data
 	w.Write(response)
 }
+
+// ErrorStatus maps the domain sentinel errors to an HTTP status code.
+func ErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrBadRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ErrorMessage is the message shown for err: the text of a domain sentinel
+// error, whose details are written for the caller, or fallback for anything
+// else, so database errors never reach the client.
+func ErrorMessage(err error, fallback string) string {
+	if ErrorStatus(err) == http.StatusInternalServerError {
+		return fallback
+	}
+	return err.Error()
+}
+
+// RespondError logs err under msg and answers with its status and
+// ErrorMessage.
+func RespondError(w http.ResponseWriter, msg string, err error) {
+	logrus.Errorf("%s: %v", msg, err)
+	Response(domain.HttpResponse{
+		Code:    ErrorStatus(err),
+		Message: ErrorMessage(err, internalError),
+		Data:    nil,
+	}, w)
+}
+
+// Error is http.Error with the message translated like Response does.
+func Error(w http.ResponseWriter, message string, code int) {
+	http.Error(w, i18n.Translate(w.Header().Get("Content-Language"), message), code)
+}